@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// EdgeMiddleware authenticates edge nodes reporting data with a shared token
+type EdgeMiddleware struct {
+	token string
+}
+
+func NewEdgeMiddleware(token string) *EdgeMiddleware {
+	return &EdgeMiddleware{
+		token: token,
+	}
+}
+
+func (m *EdgeMiddleware) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.token == "" {
+			http.Error(w, "Edge ingestion is not configured", http.StatusServiceUnavailable)
+			return
+		}
+
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(m.token)) != 1 {
+			http.Error(w, "Invalid edge token", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
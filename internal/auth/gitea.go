@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"egide-server/internal/config"
+	"egide-server/internal/models"
+)
+
+type giteaUser struct {
+	ID    int64  `json:"id"`
+	Login string `json:"login"`
+	Email string `json:"email"`
+}
+
+// GiteaProvider signs users in with a self-hosted Gitea (or Forgejo) instance
+type GiteaProvider struct {
+	client  *oauthClient
+	baseURL string
+}
+
+func NewGiteaProvider(cfg config.OAuthConfig) *GiteaProvider {
+	baseURL := strings.TrimSuffix(cfg.URL, "/")
+
+	return &GiteaProvider{
+		client: &oauthClient{
+			clientID:     cfg.ClientID,
+			clientSecret: cfg.ClientSecret,
+			redirectURL:  cfg.RedirectURL,
+			authURL:      baseURL + "/login/oauth/authorize",
+			tokenURL:     baseURL + "/login/oauth/access_token",
+			scopes:       []string{"read:user"},
+		},
+		baseURL: baseURL,
+	}
+}
+
+func (p *GiteaProvider) Name() string {
+	return ProviderGitea
+}
+
+func (p *GiteaProvider) AuthURL(ctx context.Context, state *OAuthState) (string, error) {
+	return p.client.authCodeURL(state, nil), nil
+}
+
+func (p *GiteaProvider) Identify(ctx context.Context, code string, state *OAuthState) (*models.Identity, error) {
+	token, err := p.client.exchange(ctx, code, state)
+	if err != nil {
+		return nil, err
+	}
+
+	var user giteaUser
+	if err := getJSON(ctx, p.baseURL+"/api/v1/user", token.AccessToken, &user); err != nil {
+		return nil, err
+	}
+
+	return &models.Identity{
+		Provider: ProviderGitea,
+		Subject:  strconv.FormatInt(user.ID, 10),
+		Username: user.Login,
+		Email:    user.Email,
+	}, nil
+}
@@ -2,15 +2,11 @@ package auth
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
-	"fmt"
-	"io"
-	"net/http"
-	"time"
+	"strconv"
 
-	"github.com/golang-jwt/jwt/v4"
 	"egide-server/internal/config"
+	"egide-server/internal/models"
 )
 
 type GitHubUser struct {
@@ -26,89 +22,61 @@ type GitHubEmail struct {
 	Verified bool   `json:"verified"`
 }
 
+// GitHubService signs users in with their GitHub account
 type GitHubService struct {
-	config *config.Config
+	client *oauthClient
 }
 
 func NewGitHubService(cfg *config.Config) *GitHubService {
 	return &GitHubService{
-		config: cfg,
+		client: &oauthClient{
+			clientID:     cfg.GitHubOAuth.ClientID,
+			clientSecret: cfg.GitHubOAuth.ClientSecret,
+			redirectURL:  cfg.GitHubOAuth.RedirectURL,
+			authURL:      "https://github.com/login/oauth/authorize",
+			tokenURL:     "https://github.com/login/oauth/access_token",
+			scopes:       []string{"read:user", "user:email"},
+		},
 	}
 }
 
-func (s *GitHubService) GetAuthURL() string {
-	return fmt.Sprintf(
-		"https://github.com/login/oauth/authorize?client_id=%s&redirect_uri=%s&scope=%s",
-		s.config.GitHubOAuth.ClientID,
-		s.config.GitHubOAuth.RedirectURL,
-		"read:user,user:email",
-	)
+func (s *GitHubService) Name() string {
+	return ProviderGitHub
 }
 
-func (s *GitHubService) Exchange(code string) (string, error) {
-	requestURL := fmt.Sprintf(
-		"https://github.com/login/oauth/access_token?client_id=%s&client_secret=%s&code=%s",
-		s.config.GitHubOAuth.ClientID,
-		s.config.GitHubOAuth.ClientSecret,
-		code,
-	)
-
-	req, err := http.NewRequest("POST", requestURL, nil)
-	if err != nil {
-		return "", err
-	}
-	req.Header.Set("Accept", "application/json")
-
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	var result struct {
-		AccessToken string `json:"access_token"`
-		Error       string `json:"error"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", err
-	}
-
-	if result.Error != "" {
-		return "", errors.New(result.Error)
-	}
-
-	return result.AccessToken, nil
+// AuthURL returns the authorization URL of a login bound to the given state
+func (s *GitHubService) AuthURL(ctx context.Context, state *OAuthState) (string, error) {
+	return s.client.authCodeURL(state, nil), nil
 }
 
-func (s *GitHubService) GetUser(accessToken string) (*GitHubUser, error) {
-	req, err := http.NewRequest("GET", "https://api.github.com/user", nil)
+func (s *GitHubService) Identify(ctx context.Context, code string, state *OAuthState) (*models.Identity, error) {
+	token, err := s.client.exchange(ctx, code, state)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Authorization", "token "+accessToken)
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	user, err := s.GetUser(ctx, token.AccessToken)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("GitHub API error: %s", body)
-	}
+	return &models.Identity{
+		Provider: ProviderGitHub,
+		Subject:  strconv.FormatInt(user.ID, 10),
+		Username: user.Login,
+		Email:    user.Email,
+	}, nil
+}
 
+func (s *GitHubService) GetUser(ctx context.Context, accessToken string) (*GitHubUser, error) {
 	var user GitHubUser
-	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+	if err := getJSON(ctx, "https://api.github.com/user", accessToken, &user); err != nil {
 		return nil, err
 	}
 
 	// If email is not public, fetch it separately
 	if user.Email == "" {
-		email, err := s.GetPrimaryEmail(accessToken)
+		email, err := s.GetPrimaryEmail(ctx, accessToken)
 		if err != nil {
 			return nil, err
 		}
@@ -118,27 +86,9 @@ func (s *GitHubService) GetUser(accessToken string) (*GitHubUser, error) {
 	return &user, nil
 }
 
-func (s *GitHubService) GetPrimaryEmail(accessToken string) (string, error) {
-	req, err := http.NewRequest("GET", "https://api.github.com/user/emails", nil)
-	if err != nil {
-		return "", err
-	}
-	req.Header.Set("Authorization", "token "+accessToken)
-
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("GitHub API error: %s", body)
-	}
-
+func (s *GitHubService) GetPrimaryEmail(ctx context.Context, accessToken string) (string, error) {
 	var emails []GitHubEmail
-	if err := json.NewDecoder(resp.Body).Decode(&emails); err != nil {
+	if err := getJSON(ctx, "https://api.github.com/user/emails", accessToken, &emails); err != nil {
 		return "", err
 	}
 
@@ -150,65 +100,3 @@ func (s *GitHubService) GetPrimaryEmail(accessToken string) (string, error) {
 
 	return "", errors.New("no primary email found")
 }
-
-type Claims struct {
-	UserID int64 `json:"user_id"`
-	jwt.RegisteredClaims
-}
-
-func (s *GitHubService) GenerateToken(userID int64) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour)
-	claims := &Claims{
-		UserID: userID,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(s.config.JWTSecret))
-	if err != nil {
-		return "", err
-	}
-
-	return tokenString, nil
-}
-
-type ContextKey string
-
-const UserIDKey ContextKey = "userID"
-
-func (s *GitHubService) ParseToken(tokenString string) (int64, error) {
-	claims := &Claims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(s.config.JWTSecret), nil
-	})
-
-	if err != nil {
-		return 0, err
-	}
-
-	if !token.Valid {
-		return 0, errors.New("invalid token")
-	}
-
-	return claims.UserID, nil
-}
-
-// adds the user ID to the request context
-func WithUserID(ctx context.Context, userID int64) context.Context {
-	return context.WithValue(ctx, UserIDKey, userID)
-}
-
-// gets the user ID from the request context
-func UserIDFromContext(ctx context.Context) (int64, error) {
-	userID, ok := ctx.Value(UserIDKey).(int64)
-	if !ok {
-		return 0, errors.New("user ID not found in context")
-	}
-	return userID, nil
-}
@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"egide-server/internal/config"
+	"egide-server/internal/models"
+)
+
+// DefaultGitLabURL is used when GITLAB_URL is not set
+const DefaultGitLabURL = "https://gitlab.com"
+
+type gitLabUser struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// GitLabProvider signs users in with gitlab.com or a self-managed GitLab
+type GitLabProvider struct {
+	client  *oauthClient
+	baseURL string
+}
+
+func NewGitLabProvider(cfg config.OAuthConfig) *GitLabProvider {
+	baseURL := strings.TrimSuffix(cfg.URL, "/")
+	if baseURL == "" {
+		baseURL = DefaultGitLabURL
+	}
+
+	return &GitLabProvider{
+		client: &oauthClient{
+			clientID:     cfg.ClientID,
+			clientSecret: cfg.ClientSecret,
+			redirectURL:  cfg.RedirectURL,
+			authURL:      baseURL + "/oauth/authorize",
+			tokenURL:     baseURL + "/oauth/token",
+			scopes:       []string{"read_user"},
+		},
+		baseURL: baseURL,
+	}
+}
+
+func (p *GitLabProvider) Name() string {
+	return ProviderGitLab
+}
+
+func (p *GitLabProvider) AuthURL(ctx context.Context, state *OAuthState) (string, error) {
+	return p.client.authCodeURL(state, nil), nil
+}
+
+func (p *GitLabProvider) Identify(ctx context.Context, code string, state *OAuthState) (*models.Identity, error) {
+	token, err := p.client.exchange(ctx, code, state)
+	if err != nil {
+		return nil, err
+	}
+
+	var user gitLabUser
+	if err := getJSON(ctx, p.baseURL+"/api/v4/user", token.AccessToken, &user); err != nil {
+		return nil, err
+	}
+
+	return &models.Identity{
+		Provider: ProviderGitLab,
+		Subject:  strconv.FormatInt(user.ID, 10),
+		Username: user.Username,
+		Email:    user.Email,
+	}, nil
+}
@@ -0,0 +1,177 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Algorithms of the keys signing access tokens
+const (
+	AlgorithmEdDSA = "EdDSA"
+	AlgorithmES256 = "ES256"
+)
+
+// SigningKey is a private key signing access tokens, identified by the kid
+// header of the tokens it signs
+type SigningKey struct {
+	ID         string
+	Algorithm  string
+	PrivateKey crypto.Signer
+}
+
+// KeyStore holds the keys of the token service: the current signing key, and
+// every key whose tokens are still accepted
+type KeyStore interface {
+	SigningKey() (*SigningKey, error)
+	VerificationKey(kid string) (crypto.PublicKey, error)
+}
+
+// signingMethod returns the JWT signing method of an algorithm
+func signingMethod(algorithm string) (jwt.SigningMethod, error) {
+	switch algorithm {
+	case AlgorithmEdDSA:
+		return jwt.SigningMethodEdDSA, nil
+	case AlgorithmES256:
+		return jwt.SigningMethodES256, nil
+	}
+	return nil, fmt.Errorf("unsupported signing algorithm %q", algorithm)
+}
+
+// GenerateSigningKey creates a new key pair for an algorithm, with a random key ID
+func GenerateSigningKey(algorithm string) (*SigningKey, error) {
+	var private crypto.Signer
+	var err error
+
+	switch algorithm {
+	case AlgorithmEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	case AlgorithmES256:
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := randomString(12)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SigningKey{ID: id, Algorithm: algorithm, PrivateKey: private}, nil
+}
+
+// NewJSONWebKey describes the public key of a signing key for a JWKS document
+func NewJSONWebKey(kid, algorithm string, public crypto.PublicKey) (JSONWebKey, error) {
+	jwk := JSONWebKey{Kid: kid, Use: "sig", Alg: algorithm}
+
+	switch key := public.(type) {
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(key)
+	case *ecdsa.PublicKey:
+		if key.Curve != elliptic.P256() {
+			return jwk, errors.New("unsupported curve")
+		}
+		jwk.Kty = "EC"
+		jwk.Crv = "P-256"
+		jwk.X = base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32)))
+		jwk.Y = base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32)))
+	default:
+		return jwk, fmt.Errorf("unsupported public key %T", public)
+	}
+
+	return jwk, nil
+}
+
+// Purposes of the keys encrypting secrets stored in the database
+const (
+	PurposeSigningKeys = "signing-keys"
+	PurposeTwoFactor   = "two-factor"
+)
+
+// KeyCipher encrypts the secrets stored in the database, such as private
+// signing keys, with a key derived from the JWT secret for their purpose
+type KeyCipher struct {
+	aead cipher.AEAD
+}
+
+func NewKeyCipher(jwtSecret, purpose string) (*KeyCipher, error) {
+	block, err := aes.NewCipher(deriveKey(jwtSecret, purpose))
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeyCipher{aead: aead}, nil
+}
+
+// Encrypt returns the nonce followed by the ciphertext of a secret
+func (c *KeyCipher) Encrypt(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return c.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Decrypt returns a secret encrypted by Encrypt
+func (c *KeyCipher) Decrypt(sealed []byte) ([]byte, error) {
+	if len(sealed) < c.aead.NonceSize() {
+		return nil, errors.New("invalid encrypted secret")
+	}
+
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("failed to decrypt secret, was JWT_SECRET changed?")
+	}
+	return plaintext, nil
+}
+
+// Seal encrypts a private key
+func (c *KeyCipher) Seal(key crypto.Signer) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Encrypt(der)
+}
+
+// Open decrypts a private key sealed by Seal
+func (c *KeyCipher) Open(sealed []byte) (crypto.Signer, error) {
+	der, err := c.Decrypt(sealed)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("invalid private key")
+	}
+	return signer, nil
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"errors"
+	"testing"
+)
+
+// staticKeys is a key store signing with its first key
+type staticKeys []*SigningKey
+
+func (k staticKeys) SigningKey() (*SigningKey, error) {
+	return k[0], nil
+}
+
+func (k staticKeys) VerificationKey(kid string) (crypto.PublicKey, error) {
+	for _, key := range k {
+		if key.ID == kid {
+			return key.PrivateKey.Public(), nil
+		}
+	}
+	return nil, errors.New("unknown signing key")
+}
+
+func newTestKeys(t *testing.T, algorithms ...string) staticKeys {
+	keys := staticKeys{}
+	for _, algorithm := range algorithms {
+		key, err := GenerateSigningKey(algorithm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func TestTokenKeyRotation(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmEdDSA, AlgorithmES256} {
+		keys := newTestKeys(t, algorithm, AlgorithmEdDSA)
+
+		token, err := NewTokenService(keys).GenerateToken(42, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Tokens of the previous key still verify once a new key signs
+		rotated := NewTokenService(staticKeys{keys[1], keys[0]})
+		claims, err := rotated.ParseToken(token)
+		if err != nil {
+			t.Fatalf("%s: ParseToken() error = %v", algorithm, err)
+		}
+		if claims.UserID != 42 || claims.SessionID != 1 {
+			t.Errorf("%s: claims = %+v", algorithm, claims)
+		}
+
+		// Until the key is dropped
+		if _, err := NewTokenService(keys[1:]).ParseToken(token); err == nil {
+			t.Errorf("%s: token of a removed key accepted", algorithm)
+		}
+	}
+}
+
+func TestKeyCipher(t *testing.T) {
+	key := newTestKeys(t, AlgorithmEdDSA)[0]
+
+	cipher, err := NewKeyCipher("secret", PurposeSigningKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := cipher.Seal(key.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opened, err := cipher.Open(sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !key.PrivateKey.(ed25519.PrivateKey).Equal(opened) {
+		t.Error("opened key differs from the sealed key")
+	}
+
+	other, err := NewKeyCipher("other secret", PurposeSigningKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.Open(sealed); err == nil {
+		t.Error("expected an error opening a key sealed with another secret")
+	}
+}
+
+func TestJSONWebKeyRoundTrip(t *testing.T) {
+	for _, key := range newTestKeys(t, AlgorithmEdDSA, AlgorithmES256) {
+		jwk, err := NewJSONWebKey(key.ID, key.Algorithm, key.PrivateKey.Public())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if jwk.Kid != key.ID || jwk.Alg != key.Algorithm || jwk.Use != "sig" {
+			t.Errorf("jwk = %+v", jwk)
+		}
+
+		public, err := jwk.PublicKey()
+		if err != nil {
+			t.Fatalf("%s: PublicKey() error = %v", key.Algorithm, err)
+		}
+		if !public.(interface{ Equal(crypto.PublicKey) bool }).Equal(key.PrivateKey.Public()) {
+			t.Errorf("%s: decoded key differs", key.Algorithm)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"egide-server/internal/models"
+)
+
+const (
+	// ProviderEmail identifies email addresses linked to a user, verified by
+	// a link sent to the address
+	ProviderEmail = models.EmailIdentity
+
+	// EmailLinkTTL is how long the verification link of an email address stays valid
+	EmailLinkTTL = 24 * time.Hour
+)
+
+// Link is a request of a signed-in user to attach an identity to their
+// account, from the session of SessionID
+type Link struct {
+	UserID    int64
+	SessionID int64
+	Provider  string
+	Email     string
+}
+
+type linkClaims struct {
+	Provider  string `json:"provider"`
+	Email     string `json:"email,omitempty"`
+	SessionID int64  `json:"sid"`
+	jwt.RegisteredClaims
+}
+
+// LinkTokens issues the short-lived tokens proving that a user started
+// linking an email address, since the browser following the link of the
+// email doesn't carry the access token of the API. OAuth accounts are linked
+// through the state cookie of the browser that started the link instead.
+type LinkTokens struct {
+	key []byte
+}
+
+func NewLinkTokens(jwtSecret string) *LinkTokens {
+	return &LinkTokens{
+		key: deriveKey(jwtSecret, "identity-link"),
+	}
+}
+
+// Issue returns a token attaching an identity of the provider to the user
+func (t *LinkTokens) Issue(link *Link, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := &linkClaims{
+		Provider:  link.Provider,
+		Email:     link.Email,
+		SessionID: link.SessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatInt(link.UserID, 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(t.key)
+}
+
+// Parse validates a link token issued for the provider
+func (t *LinkTokens) Parse(tokenString, provider string) (*Link, error) {
+	claims := &linkClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return t.key, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, errors.New("invalid or expired link")
+	}
+	if claims.Provider != provider {
+		return nil, errors.New("link issued for another provider")
+	}
+
+	userID, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil || userID <= 0 {
+		return nil, errors.New("invalid link")
+	}
+
+	return &Link{UserID: userID, SessionID: claims.SessionID, Provider: claims.Provider, Email: claims.Email}, nil
+}
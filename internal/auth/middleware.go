@@ -1,20 +1,27 @@
 package auth
 
 import (
-	"errors"
 	"net/http"
 	"strings"
-
-	"github.com/golang-jwt/jwt/v4"
 )
 
+// SessionValidator reports whether the session of an access token is still
+// active, so that revoking a session logs its tokens out immediately
+type SessionValidator interface {
+	IsActive(sessionID int64) (bool, error)
+}
+
 type Middleware struct {
-	jwtSecret string
+	tokenService *TokenService
+	sessions     SessionValidator
+	accessTokens AccessTokenValidator
 }
 
-func NewMiddleware(jwtSecret string) *Middleware {
+func NewMiddleware(tokenService *TokenService, sessions SessionValidator, accessTokens AccessTokenValidator) *Middleware {
 	return &Middleware{
-		jwtSecret: jwtSecret,
+		tokenService: tokenService,
+		sessions:     sessions,
+		accessTokens: accessTokens,
 	}
 }
 
@@ -34,21 +41,50 @@ func (m *Middleware) Authenticate(next http.Handler) http.Handler {
 
 		tokenString := parts[1]
 
-		claims := &Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, errors.New("unexpected signing method")
-			}
-			return []byte(m.jwtSecret), nil
-		})
+		if strings.HasPrefix(tokenString, AccessTokenPrefix) {
+			m.authenticateAccessToken(w, r, next, tokenString)
+			return
+		}
+
+		claims, err := m.tokenService.ParseToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+			return
+		}
 
-		if err != nil || !token.Valid {
+		// Tokens issued before sessions existed can't be revoked and are refused
+		if claims.SessionID == 0 {
 			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
 			return
 		}
 
-		// Add user ID to request context
+		active, err := m.sessions.IsActive(claims.SessionID)
+		if err != nil {
+			http.Error(w, "Failed to check session", http.StatusInternalServerError)
+			return
+		}
+		if !active {
+			http.Error(w, "Session revoked or expired", http.StatusUnauthorized)
+			return
+		}
+
+		// Add user and session IDs to request context
 		ctx := WithUserID(r.Context(), claims.UserID)
+		ctx = WithSessionID(ctx, claims.SessionID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// authenticateAccessToken serves requests made with a personal access token,
+// whose scopes are checked by the routes
+func (m *Middleware) authenticateAccessToken(w http.ResponseWriter, r *http.Request, next http.Handler, value string) {
+	token, err := m.accessTokens.Authenticate(value)
+	if err != nil {
+		http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+		return
+	}
+
+	ctx := WithUserID(r.Context(), token.UserID)
+	ctx = WithAccessToken(ctx, token)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
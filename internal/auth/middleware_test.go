@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"egide-server/internal/models"
+)
+
+type fakeSessions map[int64]bool
+
+func (s fakeSessions) IsActive(sessionID int64) (bool, error) {
+	return s[sessionID], nil
+}
+
+type fakeAccessTokens map[string]*models.AccessToken
+
+func (t fakeAccessTokens) Authenticate(value string) (*models.AccessToken, error) {
+	token, ok := t[value]
+	if !ok {
+		return nil, errors.New("access token not found")
+	}
+	return token, nil
+}
+
+func TestAuthenticate(t *testing.T) {
+	tokens := NewTokenService(newTestKeys(t, AlgorithmEdDSA))
+	middleware := NewMiddleware(tokens, fakeSessions{1: true, 2: false}, nil)
+
+	handler := middleware.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := UserIDFromContext(r.Context())
+		if userID != 42 || SessionIDFromContext(r.Context()) != 1 {
+			t.Errorf("context: user %d, session %d", userID, SessionIDFromContext(r.Context()))
+		}
+	}))
+
+	token := func(sessionID int64) string {
+		token, err := tokens.GenerateToken(42, sessionID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	tests := map[string]struct {
+		header string
+		want   int
+	}{
+		"active session":  {"Bearer " + token(1), http.StatusOK},
+		"revoked session": {"Bearer " + token(2), http.StatusUnauthorized},
+		"no session":      {"Bearer " + token(0), http.StatusUnauthorized},
+		"invalid token":   {"Bearer invalid", http.StatusUnauthorized},
+		"missing header":  {"", http.StatusUnauthorized},
+	}
+
+	for name, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/users/me", nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != tt.want {
+			t.Errorf("%s: status %d; want %d", name, rr.Code, tt.want)
+		}
+	}
+}
+
+func TestAccessTokenScopes(t *testing.T) {
+	tokens := NewTokenService(newTestKeys(t, AlgorithmEdDSA))
+	accessTokens := fakeAccessTokens{
+		AccessTokenPrefix + "reader": {ID: 1, UserID: 42, Scopes: []models.Scope{models.ScopeSitesRead}},
+	}
+	middleware := NewMiddleware(tokens, fakeSessions{1: true}, accessTokens)
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := UserIDFromContext(r.Context())
+		if userID != 42 {
+			t.Errorf("context: user %d; want 42", userID)
+		}
+	})
+	sites := middleware.Authenticate(RequireScopeByMethod(models.ScopeSitesRead, models.ScopeSitesWrite)(ok))
+	threats := middleware.Authenticate(RequireScope(models.ScopeThreatsRead)(ok))
+	users := middleware.Authenticate(RequireSession(ok))
+
+	session, err := tokens.GenerateToken(42, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		handler http.Handler
+		method  string
+		token   string
+		want    int
+	}{
+		"read with scope":        {sites, "GET", AccessTokenPrefix + "reader", http.StatusOK},
+		"write without scope":    {sites, "POST", AccessTokenPrefix + "reader", http.StatusForbidden},
+		"other scope":            {threats, "GET", AccessTokenPrefix + "reader", http.StatusForbidden},
+		"session only route":     {users, "GET", AccessTokenPrefix + "reader", http.StatusForbidden},
+		"unknown token":          {sites, "GET", AccessTokenPrefix + "unknown", http.StatusUnauthorized},
+		"session has all scopes": {threats, "GET", session, http.StatusOK},
+		"session write":          {sites, "POST", session, http.StatusOK},
+		"session on users":       {users, "GET", session, http.StatusOK},
+	}
+
+	for name, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/api/sites", nil)
+		req.Header.Set("Authorization", "Bearer "+tt.token)
+		rr := httptest.NewRecorder()
+		tt.handler.ServeHTTP(rr, req)
+
+		if rr.Code != tt.want {
+			t.Errorf("%s: status %d; want %d", name, rr.Code, tt.want)
+		}
+	}
+}
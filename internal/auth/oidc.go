@@ -0,0 +1,332 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"egide-server/internal/config"
+	"egide-server/internal/models"
+)
+
+// jwksRefreshInterval limits how often the keys are fetched again when an
+// ID token is signed with an unknown key
+const jwksRefreshInterval = time.Minute
+
+// Signing algorithms accepted for ID tokens
+var idTokenMethods = []string{"RS256", "RS384", "RS512", "PS256", "ES256", "ES384", "ES512"}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// JSONWebKey is a public key of a JSON Web Key Set (RFC 7517)
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JSONWebKeySet is the document served at a jwks_uri
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+type idTokenClaims struct {
+	Nonce             string      `json:"nonce"`
+	Email             string      `json:"email"`
+	EmailVerified     interface{} `json:"email_verified"`
+	PreferredUsername string      `json:"preferred_username"`
+	Name              string      `json:"name"`
+	jwt.RegisteredClaims
+}
+
+// OIDCProvider signs users in with any OpenID Connect provider, configured
+// from its discovery document. The identity comes from the ID token, whose
+// signature is checked against the keys published by the provider.
+type OIDCProvider struct {
+	cfg config.OAuthConfig
+
+	mu          sync.Mutex
+	discovery   *oidcDiscovery
+	keys        map[string]interface{}
+	keysFetched time.Time
+}
+
+func NewOIDCProvider(cfg config.OAuthConfig) *OIDCProvider {
+	cfg.URL = strings.TrimSuffix(cfg.URL, "/")
+
+	return &OIDCProvider{
+		cfg: cfg,
+	}
+}
+
+func (p *OIDCProvider) Name() string {
+	return ProviderOIDC
+}
+
+func (p *OIDCProvider) AuthURL(ctx context.Context, state *OAuthState) (string, error) {
+	client, err := p.client(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return client.authCodeURL(state, url.Values{"nonce": {state.Nonce}}), nil
+}
+
+func (p *OIDCProvider) Identify(ctx context.Context, code string, state *OAuthState) (*models.Identity, error) {
+	client, err := p.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := client.exchange(ctx, code, state)
+	if err != nil {
+		return nil, err
+	}
+	if token.IDToken == "" {
+		return nil, errors.New("no ID token in token response")
+	}
+
+	claims, err := p.verifyIDToken(ctx, token.IDToken, state.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %w", err)
+	}
+
+	identity := &models.Identity{
+		Provider: ProviderOIDC,
+		Subject:  claims.Subject,
+		Username: claims.PreferredUsername,
+	}
+	if claims.EmailVerified != false && claims.EmailVerified != "false" {
+		identity.Email = claims.Email
+	}
+	if identity.Username == "" {
+		identity.Username = claims.Name
+	}
+	if identity.Username == "" {
+		identity.Username = identity.Email
+	}
+	if identity.Username == "" {
+		identity.Username = claims.Subject
+	}
+
+	return identity, nil
+}
+
+// verifyIDToken checks the signature, issuer, audience, expiry and nonce of an ID token
+func (p *OIDCProvider) verifyIDToken(ctx context.Context, idToken, nonce string) (*idTokenClaims, error) {
+	discovery, err := p.loadDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &idTokenClaims{}
+	parser := jwt.NewParser(jwt.WithValidMethods(idTokenMethods))
+	token, err := parser.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.key(ctx, discovery, kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	if claims.Issuer != discovery.Issuer {
+		return nil, fmt.Errorf("unexpected issuer %q", claims.Issuer)
+	}
+	if !claims.VerifyAudience(p.cfg.ClientID, true) {
+		return nil, errors.New("token not issued for this client")
+	}
+	if claims.ExpiresAt == nil {
+		return nil, errors.New("missing expiry")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("missing subject")
+	}
+	if nonce == "" || claims.Nonce != nonce {
+		return nil, errors.New("nonce mismatch")
+	}
+
+	return claims, nil
+}
+
+func (p *OIDCProvider) client(ctx context.Context) (*oauthClient, error) {
+	discovery, err := p.loadDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &oauthClient{
+		clientID:     p.cfg.ClientID,
+		clientSecret: p.cfg.ClientSecret,
+		redirectURL:  p.cfg.RedirectURL,
+		authURL:      discovery.AuthorizationEndpoint,
+		tokenURL:     discovery.TokenEndpoint,
+		scopes:       []string{"openid", "profile", "email"},
+	}, nil
+}
+
+// loadDiscovery fetches the discovery document of the issuer once
+func (p *OIDCProvider) loadDiscovery(ctx context.Context) (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	var discovery oidcDiscovery
+	if err := getJSON(ctx, p.cfg.URL+"/.well-known/openid-configuration", "", &discovery); err != nil {
+		return nil, fmt.Errorf("OIDC discovery failed: %w", err)
+	}
+	if strings.TrimSuffix(discovery.Issuer, "/") != p.cfg.URL {
+		return nil, fmt.Errorf("OIDC discovery: issuer %q doesn't match %q", discovery.Issuer, p.cfg.URL)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, errors.New("OIDC discovery: incomplete provider metadata")
+	}
+
+	p.discovery = &discovery
+	return p.discovery, nil
+}
+
+// key returns the public key with the given ID, fetching the key set again
+// when the provider rotated its keys
+func (p *OIDCProvider) key(ctx context.Context, discovery *oidcDiscovery, kid string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := lookupKey(p.keys, kid); ok {
+		return key, nil
+	}
+	if time.Since(p.keysFetched) < jwksRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	var set JSONWebKeySet
+	if err := getJSON(ctx, discovery.JWKSURI, "", &set); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]interface{}{}
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	p.keys = keys
+	p.keysFetched = time.Now()
+
+	if key, ok := lookupKey(p.keys, kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// lookupKey finds a key by ID, or the only key of the set for tokens without kid
+func lookupKey(keys map[string]interface{}, kid string) (interface{}, bool) {
+	if key, ok := keys[kid]; ok {
+		return key, true
+	}
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+// PublicKey decodes an RSA, elliptic curve or Ed25519 public key
+func (k *JSONWebKey) PublicKey() (interface{}, error) {
+	switch k.Kty {
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"egide-server/internal/config"
+)
+
+// newTestIdP serves the discovery document, keys and token endpoint of an
+// OIDC provider whose token endpoint returns the ID token built by claims
+func newTestIdP(t *testing.T, claims func(issuer string) jwt.MapClaims) *httptest.Server {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 server.URL,
+			"authorization_endpoint": server.URL + "/authorize",
+			"token_endpoint":         server.URL + "/token",
+			"jwks_uri":               server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(JSONWebKeySet{Keys: []JSONWebKey{{
+			Kty: "RSA",
+			Kid: "key-1",
+			Use: "sig",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code") != "good-code" || r.FormValue("code_verifier") == "" {
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims(server.URL))
+		token.Header["kid"] = "key-1"
+		idToken, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "at", "id_token": idToken})
+	})
+
+	return server
+}
+
+func TestOIDCIdentify(t *testing.T) {
+	state := &OAuthState{Provider: ProviderOIDC, State: "s", Verifier: "v", Nonce: "n"}
+	validClaims := func(issuer string) jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":                issuer,
+			"sub":                "user-1",
+			"aud":                "egide",
+			"exp":                time.Now().Add(time.Minute).Unix(),
+			"nonce":              "n",
+			"email":              "jane@example.com",
+			"email_verified":     true,
+			"preferred_username": "jane",
+		}
+	}
+
+	server := newTestIdP(t, validClaims)
+	provider := NewOIDCProvider(config.OAuthConfig{ClientID: "egide", URL: server.URL, RedirectURL: "http://localhost/cb"})
+
+	authURL, err := provider.AuthURL(context.Background(), state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := server.URL + "/authorize?"; len(authURL) < len(want) || authURL[:len(want)] != want {
+		t.Errorf("AuthURL() = %q", authURL)
+	}
+
+	identity, err := provider.Identify(context.Background(), "good-code", state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.Subject != "user-1" || identity.Username != "jane" || identity.Email != "jane@example.com" {
+		t.Errorf("Identify() = %+v", identity)
+	}
+
+	if _, err := provider.Identify(context.Background(), "bad-code", state); err == nil {
+		t.Error("expected an error for a rejected code")
+	}
+
+	tests := map[string]func(jwt.MapClaims){
+		"nonce":    func(c jwt.MapClaims) { c["nonce"] = "other" },
+		"audience": func(c jwt.MapClaims) { c["aud"] = "other-client" },
+		"issuer":   func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+		"expiry":   func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() },
+	}
+	for name, tamper := range tests {
+		server := newTestIdP(t, func(issuer string) jwt.MapClaims {
+			claims := validClaims(issuer)
+			tamper(claims)
+			return claims
+		})
+		provider := NewOIDCProvider(config.OAuthConfig{ClientID: "egide", URL: server.URL})
+
+		if _, err := provider.Identify(context.Background(), "good-code", state); err == nil {
+			t.Errorf("%s: expected the ID token to be rejected", name)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"egide-server/internal/config"
+	"egide-server/internal/models"
+)
+
+// Provider names, used in the /auth/{provider} routes and stored with identities
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+	ProviderGitea  = "gitea"
+	ProviderOIDC   = "oidc"
+)
+
+// Provider signs users in with an identity provider using the OAuth 2.0
+// authorization code flow
+type Provider interface {
+	// Name returns the name of the provider in routes and identities
+	Name() string
+
+	// AuthURL returns the URL the browser is sent to for the given login
+	AuthURL(ctx context.Context, state *OAuthState) (string, error)
+
+	// Identify redeems the authorization code of a login and returns the
+	// identity of the user at the provider
+	Identify(ctx context.Context, code string, state *OAuthState) (*models.Identity, error)
+}
+
+// NewProviders returns the identity providers configured in cfg by name
+func NewProviders(cfg *config.Config) map[string]Provider {
+	providers := map[string]Provider{}
+
+	if cfg.GitHubOAuth.ClientID != "" {
+		providers[ProviderGitHub] = NewGitHubService(cfg)
+	}
+	if cfg.GitLabOAuth.ClientID != "" {
+		providers[ProviderGitLab] = NewGitLabProvider(cfg.GitLabOAuth)
+	}
+	if cfg.GiteaOAuth.ClientID != "" {
+		providers[ProviderGitea] = NewGiteaProvider(cfg.GiteaOAuth)
+	}
+	if cfg.OIDC.ClientID != "" {
+		providers[ProviderOIDC] = NewOIDCProvider(cfg.OIDC)
+	}
+
+	return providers
+}
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// oauthClient implements the authorization code flow with PKCE shared by providers
+type oauthClient struct {
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	authURL      string
+	tokenURL     string
+	scopes       []string
+}
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func (c *oauthClient) authCodeURL(state *OAuthState, extra url.Values) string {
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", c.clientID)
+	params.Set("redirect_uri", c.redirectURL)
+	if len(c.scopes) > 0 {
+		params.Set("scope", strings.Join(c.scopes, " "))
+	}
+	params.Set("state", state.State)
+	params.Set("code_challenge", state.CodeChallenge())
+	params.Set("code_challenge_method", "S256")
+	for key, values := range extra {
+		params[key] = values
+	}
+
+	separator := "?"
+	if strings.Contains(c.authURL, "?") {
+		separator = "&"
+	}
+	return c.authURL + separator + params.Encode()
+}
+
+// exchange redeems an authorization code with the PKCE verifier of its login
+func (c *oauthClient) exchange(ctx context.Context, code string, state *OAuthState) (*tokenResponse, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", c.redirectURL)
+	form.Set("client_id", c.clientID)
+	form.Set("client_secret", c.clientSecret)
+	form.Set("code_verifier", state.Verifier)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid token response (HTTP %d): %w", resp.StatusCode, err)
+	}
+
+	if result.Error != "" {
+		if result.ErrorDescription != "" {
+			return nil, fmt.Errorf("%s: %s", result.Error, result.ErrorDescription)
+		}
+		return nil, errors.New(result.Error)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint returned HTTP %d", resp.StatusCode)
+	}
+
+	return &result, nil
+}
+
+// getJSON decodes the JSON document at requestURL, authenticating with a
+// bearer token when one is given
+func getJSON(ctx context.Context, requestURL, accessToken string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("GET %s: HTTP %d: %s", requestURL, resp.StatusCode, body)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+
+	"egide-server/internal/models"
+)
+
+// AccessTokenPrefix starts every personal access token, telling them apart from JWTs
+const AccessTokenPrefix = "egide_pat_"
+
+// AccessTokenKey is the context key of the personal access token of a request
+const AccessTokenKey ContextKey = "accessToken"
+
+// AccessTokenValidator returns the personal access token matching a clear value
+type AccessTokenValidator interface {
+	Authenticate(value string) (*models.AccessToken, error)
+}
+
+// adds the personal access token authenticating a request to its context
+func WithAccessToken(ctx context.Context, token *models.AccessToken) context.Context {
+	return context.WithValue(ctx, AccessTokenKey, token)
+}
+
+// gets the personal access token from the request context, nil for sessions
+func AccessTokenFromContext(ctx context.Context) *models.AccessToken {
+	token, _ := ctx.Value(AccessTokenKey).(*models.AccessToken)
+	return token
+}
+
+// RequireScope refuses requests made with a personal access token lacking
+// scope. Signed-in users have every scope
+func RequireScope(scope models.Scope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := AccessTokenFromContext(r.Context())
+			if token != nil && !token.HasScope(scope) {
+				http.Error(w, "Access token lacks the "+string(scope)+" scope", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireScopeByMethod requires the read scope for GET and HEAD requests and
+// the write scope for every other method
+func RequireScopeByMethod(read, write models.Scope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		readNext := RequireScope(read)(next)
+		writeNext := RequireScope(write)(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				readNext.ServeHTTP(w, r)
+				return
+			}
+			writeNext.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireSession refuses personal access tokens, for routes no scope grants
+// such as managing the tokens themselves
+func RequireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if AccessTokenFromContext(r.Context()) != nil {
+			http.Error(w, "Not available to access tokens", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
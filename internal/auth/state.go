@@ -0,0 +1,207 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	// StateCookieName is the cookie carrying the OAuth state between the login and the callback
+	StateCookieName = "egide_oauth_state"
+
+	// StateTTL is how long a login attempt may take
+	StateTTL = 10 * time.Minute
+
+	// Longest return_to path kept across a login
+	maxReturnToLength = 512
+)
+
+// OAuthState binds an authorization request to the browser that started it:
+// State is echoed by the provider, Verifier is the PKCE secret proving that
+// the code is redeemed by the client that requested it and Nonce ties OIDC
+// ID tokens to the login. A state issued to link an identity carries the
+// user and the session that started the link.
+type OAuthState struct {
+	Provider      string
+	State         string
+	Verifier      string
+	Nonce         string
+	ReturnTo      string
+	LinkUserID    int64
+	LinkSessionID int64
+}
+
+type stateClaims struct {
+	Provider      string `json:"provider"`
+	Verifier      string `json:"verifier"`
+	Nonce         string `json:"nonce"`
+	ReturnTo      string `json:"return_to,omitempty"`
+	LinkUserID    int64  `json:"link_user_id,omitempty"`
+	LinkSessionID int64  `json:"link_session_id,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// CodeChallenge returns the S256 PKCE challenge of the verifier
+func (s *OAuthState) CodeChallenge() string {
+	sum := sha256.Sum256([]byte(s.Verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// StateCookie stores OAuth states in signed, short-lived cookies so that no
+// server-side storage is needed between the login and the callback
+type StateCookie struct {
+	key    []byte
+	secure bool
+}
+
+// NewStateCookie creates a state cookie signed with a key derived from the
+// JWT secret, so a state can never be mistaken for an access token
+func NewStateCookie(jwtSecret string, secure bool) *StateCookie {
+	return &StateCookie{
+		key:    deriveKey(jwtSecret, "oauth-state"),
+		secure: secure,
+	}
+}
+
+// Issue generates a new state for a login with a provider and sets it in a
+// cookie. A non-nil link attaches the identity to the user of the link instead.
+func (c *StateCookie) Issue(w http.ResponseWriter, provider, returnTo string, link *Link) (*OAuthState, error) {
+	state, err := randomString(32)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := randomString(32)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := randomString(16)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	claims := &stateClaims{
+		Provider: provider,
+		Verifier: verifier,
+		Nonce:    nonce,
+		ReturnTo: SafeReturnTo(returnTo),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        state,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(StateTTL)),
+		},
+	}
+	if link != nil {
+		claims.LinkUserID = link.UserID
+		claims.LinkSessionID = link.SessionID
+	}
+
+	value, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(c.key)
+	if err != nil {
+		return nil, err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     StateCookieName,
+		Value:    value,
+		Path:     "/auth",
+		MaxAge:   int(StateTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   c.secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return claims.oauthState(), nil
+}
+
+// Verify checks the state returned to the callback against the cookie of
+// the browser, which is cleared so a state can only be used once
+func (c *StateCookie) Verify(w http.ResponseWriter, r *http.Request) (*OAuthState, error) {
+	cookie, err := r.Cookie(StateCookieName)
+	if err != nil {
+		return nil, errors.New("missing state cookie")
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     StateCookieName,
+		Value:    "",
+		Path:     "/auth",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   c.secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	claims := &stateClaims{}
+	token, err := jwt.ParseWithClaims(cookie.Value, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return c.key, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, errors.New("invalid or expired state")
+	}
+
+	state := r.URL.Query().Get("state")
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(claims.ID)) != 1 {
+		return nil, errors.New("state mismatch")
+	}
+
+	return claims.oauthState(), nil
+}
+
+func (c *stateClaims) oauthState() *OAuthState {
+	return &OAuthState{
+		Provider:      c.Provider,
+		State:         c.ID,
+		Verifier:      c.Verifier,
+		Nonce:         c.Nonce,
+		ReturnTo:      c.ReturnTo,
+		LinkUserID:    c.LinkUserID,
+		LinkSessionID: c.LinkSessionID,
+	}
+}
+
+// SafeReturnTo returns the path if it is a local path of the frontend, or an
+// empty string so that logins can't be used to redirect to another site
+func SafeReturnTo(path string) string {
+	if path == "" || len(path) > maxReturnToLength {
+		return ""
+	}
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.ContainsAny(path, "\\\r\n\t") {
+		return ""
+	}
+
+	u, err := url.Parse(path)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.User != nil {
+		return ""
+	}
+	return path
+}
+
+// deriveKey derives the signing key of a kind of token from the JWT secret,
+// so that tokens issued for one purpose are never accepted for another
+func deriveKey(jwtSecret, purpose string) []byte {
+	mac := hmac.New(sha256.New, []byte(jwtSecret))
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+func randomString(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
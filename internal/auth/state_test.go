@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStateCookie(t *testing.T) {
+	cookies := NewStateCookie("secret", false)
+
+	rec := httptest.NewRecorder()
+	state, err := cookies.Issue(rec, ProviderGitHub, "/sites/1?tab=monitors", &Link{UserID: 7, SessionID: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.ReturnTo != "/sites/1?tab=monitors" {
+		t.Errorf("ReturnTo = %q", state.ReturnTo)
+	}
+	cookie := rec.Result().Cookies()[0]
+
+	callback := func(query string, cookie *http.Cookie) (*OAuthState, error) {
+		req := httptest.NewRequest("GET", "/auth/callback?"+query, nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		return cookies.Verify(httptest.NewRecorder(), req)
+	}
+
+	got, err := callback("code=abc&state="+state.State, cookie)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != *state {
+		t.Errorf("Verify() = %+v; want %+v", got, state)
+	}
+
+	if _, err := callback("code=abc&state=forged", cookie); err == nil {
+		t.Error("expected an error for a state that doesn't match the cookie")
+	}
+	if _, err := callback("code=abc&state="+state.State, nil); err == nil {
+		t.Error("expected an error without cookie")
+	}
+	if _, err := NewStateCookie("other", false).Verify(httptest.NewRecorder(), withCookie("state="+state.State, cookie)); err == nil {
+		t.Error("expected an error for a cookie signed with another key")
+	}
+}
+
+func TestCodeChallenge(t *testing.T) {
+	// BASE64URL(SHA256(verifier)) without padding
+	state := &OAuthState{Verifier: "egide-test-verifier"}
+	if got, want := state.CodeChallenge(), "-ND1Cml8HaeHRuMwigq08VcxAqVF2JuYliD65hmBdQM"; got != want {
+		t.Errorf("CodeChallenge() = %q; want %q", got, want)
+	}
+}
+
+func TestSafeReturnTo(t *testing.T) {
+	tests := map[string]string{
+		"/dashboard":            "/dashboard",
+		"/sites/1?tab=slos#top": "/sites/1?tab=slos#top",
+		"":                      "",
+		"dashboard":             "",
+		"//evil.com":            "",
+		"/\\evil.com":           "",
+		"https://evil.com/":     "",
+		"javascript:alert(1)":   "",
+		"/ok\r\nSet-Cookie: x":  "",
+	}
+
+	for path, want := range tests {
+		if got := SafeReturnTo(path); got != want {
+			t.Errorf("SafeReturnTo(%q) = %q; want %q", path, got, want)
+		}
+	}
+}
+
+func withCookie(query string, cookie *http.Cookie) *http.Request {
+	req := httptest.NewRequest("GET", "/auth/callback?"+query, nil)
+	req.AddCookie(cookie)
+	return req
+}
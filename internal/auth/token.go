@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// AccessTokenTTL is the lifetime of access tokens, renewed with the refresh
+// token of their session
+const AccessTokenTTL = 15 * time.Minute
+
+type Claims struct {
+	UserID    int64 `json:"user_id"`
+	SessionID int64 `json:"sid"`
+	jwt.RegisteredClaims
+}
+
+// TokenService issues the JWTs used by the frontend once a user signed in,
+// signed by the current key of the key store
+type TokenService struct {
+	keys KeyStore
+}
+
+func NewTokenService(keys KeyStore) *TokenService {
+	return &TokenService{
+		keys: keys,
+	}
+}
+
+// GenerateToken issues an access token for a session of the user
+func (s *TokenService) GenerateToken(userID, sessionID int64) (string, error) {
+	key, err := s.keys.SigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	method, err := signingMethod(key.Algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	expirationTime := time.Now().Add(AccessTokenTTL)
+	claims := &Claims{
+		UserID:    userID,
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expirationTime),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = key.ID
+	tokenString, err := token.SignedString(key.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+
+	return tokenString, nil
+}
+
+type ContextKey string
+
+const (
+	UserIDKey    ContextKey = "userID"
+	SessionIDKey ContextKey = "sessionID"
+)
+
+// ParseToken validates an access token against the key named by its kid header
+func (s *TokenService) ParseToken(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{AlgorithmEdDSA, AlgorithmES256}))
+	token, err := parser.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, errors.New("missing kid header")
+		}
+		return s.keys.VerificationKey(kid)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	return claims, nil
+}
+
+// adds the user ID to the request context
+func WithUserID(ctx context.Context, userID int64) context.Context {
+	return context.WithValue(ctx, UserIDKey, userID)
+}
+
+// gets the user ID from the request context
+func UserIDFromContext(ctx context.Context) (int64, error) {
+	userID, ok := ctx.Value(UserIDKey).(int64)
+	if !ok {
+		return 0, errors.New("user ID not found in context")
+	}
+	return userID, nil
+}
+
+// adds the session ID to the request context
+func WithSessionID(ctx context.Context, sessionID int64) context.Context {
+	return context.WithValue(ctx, SessionIDKey, sessionID)
+}
+
+// gets the session ID from the request context, 0 outside of a session
+func SessionIDFromContext(ctx context.Context) int64 {
+	sessionID, _ := ctx.Value(SessionIDKey).(int64)
+	return sessionID
+}
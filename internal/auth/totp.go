@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Parameters of the time-based one-time passwords (RFC 6238) used for
+// two-factor authentication, the defaults of authenticator apps
+const (
+	TOTPIssuer = "Egide"
+	TOTPDigits = 6
+	TOTPPeriod = 30 * time.Second
+
+	// totpSkew is how many periods before or after now a code is accepted,
+	// for clocks slightly out of sync
+	totpSkew = 1
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret returns a new random secret, base32 encoded as
+// authenticator apps expect it
+func GenerateTOTPSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// TOTPProvisioningURI returns the otpauth:// URI shown as a QR code to enroll
+// an authenticator app
+func TOTPProvisioningURI(account, secret string) string {
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", TOTPIssuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(TOTPDigits))
+	params.Set("period", fmt.Sprint(int(TOTPPeriod.Seconds())))
+
+	label := url.PathEscape(TOTPIssuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// TOTPCode returns the code of a secret for the period holding t
+func TOTPCode(secret string, t time.Time) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, totpCounter(t)), nil
+}
+
+// ValidateTOTP checks a code against a secret around now, and returns the
+// counter of the period it matched, so that callers can refuse codes of
+// periods already used
+func ValidateTOTP(secret, code string, now time.Time) (int64, bool) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil || len(code) != TOTPDigits {
+		return 0, false
+	}
+
+	counter := totpCounter(now)
+	for offset := int64(-totpSkew); offset <= totpSkew; offset++ {
+		expected := hotp(key, counter+offset)
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return counter + offset, true
+		}
+	}
+	return 0, false
+}
+
+func totpCounter(t time.Time) int64 {
+	return t.Unix() / int64(TOTPPeriod.Seconds())
+}
+
+// hotp computes an HMAC-based one-time password (RFC 4226)
+func hotp(key []byte, counter int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < TOTPDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", TOTPDigits, value%mod)
+}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTOTPCode(t *testing.T) {
+	// Test vectors of RFC 6238 for SHA-1, truncated to 6 digits
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	tests := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+
+	for unix, want := range tests {
+		got, err := TOTPCode(strings.TrimRight(secret, "="), time.Unix(unix, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("TOTPCode(%d) = %s; want %s", unix, got, want)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1700000000, 0)
+
+	code, _ := TOTPCode(secret, now.Add(-TOTPPeriod))
+	counter, ok := ValidateTOTP(secret, code, now)
+	if !ok || counter != totpCounter(now)-1 {
+		t.Errorf("code of the previous period: %d, %v", counter, ok)
+	}
+
+	code, _ = TOTPCode(secret, now.Add(-3*TOTPPeriod))
+	if _, ok := ValidateTOTP(secret, code, now); ok {
+		t.Error("code of an old period accepted")
+	}
+	if _, ok := ValidateTOTP(secret, "12345", now); ok {
+		t.Error("short code accepted")
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri := TOTPProvisioningURI("jane doe", "JBSWY3DPEHPK3PXP")
+
+	want := "otpauth://totp/Egide:jane%20doe?algorithm=SHA1&digits=6&issuer=Egide&period=30&secret=JBSWY3DPEHPK3PXP"
+	if uri != want {
+		t.Errorf("TOTPProvisioningURI() = %s; want %s", uri, want)
+	}
+}
@@ -0,0 +1,237 @@
+// Package authz decides what users may do on sites and organizations. Sites
+// are owned either by a user, who may do anything on them, or by an
+// organization, whose members act according to their role.
+package authz
+
+import (
+	"errors"
+
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+)
+
+// Action is an operation on a site
+type Action string
+
+const (
+	// ViewSite reads a site, its threats, metrics and settings
+	ViewSite Action = "view"
+
+	// EditSite changes the settings of a site: monitors, maintenance, SLOs...
+	EditSite Action = "edit"
+
+	// ManageSite deletes a site or switches its protection
+	ManageSite Action = "manage"
+
+	// TransferSite hands a site over to another user or organization
+	TransferSite Action = "transfer"
+)
+
+// OrganizationAction is an operation on an organization
+type OrganizationAction string
+
+const (
+	// ViewOrganization reads an organization and its members
+	ViewOrganization OrganizationAction = "view"
+
+	// ManageMembers adds and removes members and sites of an organization
+	ManageMembers OrganizationAction = "manage_members"
+
+	// ManageOrganization renames, configures or deletes an organization
+	ManageOrganization OrganizationAction = "manage"
+)
+
+var (
+	// ErrNotFound is returned for missing sites and organizations, and for
+	// organizations the user isn't a member of
+	ErrNotFound = errors.New("not found")
+
+	// ErrForbidden is returned when the user may not perform the action
+	ErrForbidden = errors.New("forbidden")
+
+	// ErrTwoFactorRequired is returned to members without two-factor
+	// authentication of organizations requiring it
+	ErrTwoFactorRequired = errors.New("organization requires two-factor authentication")
+)
+
+// roleRanks orders roles, each role being allowed what lower roles are
+var roleRanks = map[models.Role]int{
+	models.RoleViewer: 1,
+	models.RoleEditor: 2,
+	models.RoleAdmin:  3,
+	models.RoleOwner:  4,
+}
+
+// siteRoles is the lowest role allowed each action on the sites of an organization
+var siteRoles = map[Action]models.Role{
+	ViewSite:     models.RoleViewer,
+	EditSite:     models.RoleEditor,
+	ManageSite:   models.RoleAdmin,
+	TransferSite: models.RoleOwner,
+}
+
+// organizationRoles is the lowest role allowed each action on an organization
+var organizationRoles = map[OrganizationAction]models.Role{
+	ViewOrganization:   models.RoleViewer,
+	ManageMembers:      models.RoleAdmin,
+	ManageOrganization: models.RoleOwner,
+}
+
+// RoleAtLeast reports whether role is allowed what minimum is
+func RoleAtLeast(role, minimum models.Role) bool {
+	return roleRanks[role] >= roleRanks[minimum] && roleRanks[minimum] > 0
+}
+
+// Authorizer is the single place deciding access to sites and organizations
+type Authorizer struct {
+	siteRepo         *repository.SiteRepository
+	organizationRepo *repository.OrganizationRepository
+	twoFactorRepo    *repository.TwoFactorRepository
+}
+
+func NewAuthorizer(siteRepo *repository.SiteRepository, organizationRepo *repository.OrganizationRepository, twoFactorRepo *repository.TwoFactorRepository) *Authorizer {
+	return &Authorizer{
+		siteRepo:         siteRepo,
+		organizationRepo: organizationRepo,
+		twoFactorRepo:    twoFactorRepo,
+	}
+}
+
+// Site loads a site the user may perform action on
+func (a *Authorizer) Site(userID, siteID int64, action Action) (*models.Site, error) {
+	site, err := a.siteRepo.FindByID(siteID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	if err := a.CheckSite(userID, site, action); err != nil {
+		return nil, err
+	}
+	return site, nil
+}
+
+// CheckSite returns nil when the user may perform action on the site
+func (a *Authorizer) CheckSite(userID int64, site *models.Site, action Action) error {
+	if site.OrganizationID == nil {
+		if site.UserID != userID {
+			return ErrForbidden
+		}
+		return nil
+	}
+
+	member, err := a.organizationRepo.FindMember(*site.OrganizationID, userID)
+	if err != nil {
+		return ErrForbidden
+	}
+	if !RoleAtLeast(member.Role, siteRoles[action]) {
+		return ErrForbidden
+	}
+
+	org, err := a.organizationRepo.FindByID(*site.OrganizationID)
+	if err != nil {
+		return err
+	}
+	return a.checkTwoFactor(userID, org)
+}
+
+// Sites returns every site the user may view: their own sites and the sites
+// of their organizations
+func (a *Authorizer) Sites(userID int64) ([]*models.Site, error) {
+	sites, err := a.siteRepo.FindAccessibleByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	orgs, err := a.organizationRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Leave out the sites of organizations whose requirements aren't met
+	excluded := map[int64]bool{}
+	for _, org := range orgs {
+		if err := a.checkTwoFactor(userID, org); err == ErrTwoFactorRequired {
+			excluded[org.ID] = true
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	if len(excluded) == 0 {
+		return sites, nil
+	}
+
+	visible := []*models.Site{}
+	for _, site := range sites {
+		if site.OrganizationID == nil || !excluded[*site.OrganizationID] {
+			visible = append(visible, site)
+		}
+	}
+	return visible, nil
+}
+
+// Audience returns the users who may view a site, to whom its events and
+// notifications go: its owner, or the members of its organization
+func (a *Authorizer) Audience(site *models.Site) ([]int64, error) {
+	if site.OrganizationID == nil {
+		return []int64{site.UserID}, nil
+	}
+
+	org, err := a.organizationRepo.FindByID(*site.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := a.organizationRepo.FindMembers(org.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	users := []int64{}
+	for _, member := range members {
+		if err := a.checkTwoFactor(member.UserID, org); err == ErrTwoFactorRequired {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		users = append(users, member.UserID)
+	}
+	return users, nil
+}
+
+// Organization loads an organization the user may perform action on, with
+// the role of the user. Members without two-factor authentication of an
+// organization requiring it may only view it.
+func (a *Authorizer) Organization(userID, orgID int64, action OrganizationAction) (*models.Organization, error) {
+	org, err := a.organizationRepo.FindByID(orgID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	member, err := a.organizationRepo.FindMember(orgID, userID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	if !RoleAtLeast(member.Role, organizationRoles[action]) {
+		return nil, ErrForbidden
+	}
+	org.Role = member.Role
+
+	if action != ViewOrganization {
+		if err := a.checkTwoFactor(userID, org); err != nil {
+			return nil, err
+		}
+	}
+	return org, nil
+}
+
+func (a *Authorizer) checkTwoFactor(userID int64, org *models.Organization) error {
+	if !org.RequireTwoFactor {
+		return nil
+	}
+
+	twoFactor, err := a.twoFactorRepo.FindByUserID(userID)
+	if err != nil || twoFactor.EnabledAt == nil {
+		return ErrTwoFactorRequired
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package authz
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"egide-server/internal/migration"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestSiteAccess(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	if err := migration.RunMigrations(db, "../../migrations"); err != nil {
+		t.Fatal(err)
+	}
+
+	users := map[string]int64{}
+	for _, name := range []string{"owner", "admin", "editor", "viewer", "outsider"} {
+		result, err := db.Exec(`INSERT INTO users (username) VALUES (?)`, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		users[name], _ = result.LastInsertId()
+	}
+
+	siteRepo := repository.NewSiteRepository(db)
+	organizationRepo := repository.NewOrganizationRepository(db)
+	authorizer := NewAuthorizer(siteRepo, organizationRepo, repository.NewTwoFactorRepository(db))
+
+	orgID, err := organizationRepo.Create(&models.Organization{Name: "Team"}, users["owner"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, role := range []models.Role{models.RoleAdmin, models.RoleEditor, models.RoleViewer} {
+		member := &models.OrganizationMember{OrganizationID: orgID, UserID: users[string(role)], Role: role}
+		if err := organizationRepo.AddMember(member); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	personalID, err := siteRepo.Create(&models.Site{UserID: users["outsider"], Domain: "personal.com", ProtectionMode: models.SimpleProtection})
+	if err != nil {
+		t.Fatal(err)
+	}
+	teamID, err := siteRepo.Create(&models.Site{UserID: users["owner"], Domain: "team.com", ProtectionMode: models.SimpleProtection, OrganizationID: &orgID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		user   string
+		siteID int64
+		action Action
+		want   error
+	}{
+		{"outsider", personalID, ManageSite, nil},
+		{"owner", personalID, ViewSite, ErrForbidden},
+		{"owner", teamID, ManageSite, nil},
+		{"admin", teamID, ManageSite, nil},
+		{"editor", teamID, EditSite, nil},
+		{"editor", teamID, ManageSite, ErrForbidden},
+		{"viewer", teamID, ViewSite, nil},
+		{"viewer", teamID, EditSite, ErrForbidden},
+		{"outsider", teamID, ViewSite, ErrForbidden},
+		{"viewer", 999, ViewSite, ErrNotFound},
+	}
+	for _, tt := range tests {
+		if _, err := authorizer.Site(users[tt.user], tt.siteID, tt.action); err != tt.want {
+			t.Errorf("Site(%s, %d, %s) = %v; want %v", tt.user, tt.siteID, tt.action, err, tt.want)
+		}
+	}
+
+	sites, err := authorizer.Sites(users["viewer"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sites) != 1 || sites[0].ID != teamID || sites[0].OrganizationID == nil || *sites[0].OrganizationID != orgID {
+		t.Errorf("Sites(viewer) = %+v; want the team site", sites)
+	}
+
+	// Events of a site go to everyone who may view it
+	audience := func(siteID int64) []int64 {
+		site, err := siteRepo.FindByID(siteID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		userIDs, err := authorizer.Audience(site)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return userIDs
+	}
+	if got := audience(personalID); len(got) != 1 || got[0] != users["outsider"] {
+		t.Errorf("Audience(personal) = %v; want the outsider", got)
+	}
+	if got := audience(teamID); len(got) != 4 {
+		t.Errorf("Audience(team) = %v; want the 4 members", got)
+	}
+
+	// Requiring two-factor authentication locks out members without it
+	org, err := organizationRepo.FindByID(orgID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	org.RequireTwoFactor = true
+	if err := organizationRepo.Update(org); err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(
+		`INSERT INTO user_two_factor (user_id, secret, enabled_at, created_at) VALUES (?, ?, ?, ?)`,
+		users["editor"], []byte("secret"), time.Now().UTC(), time.Now().UTC(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := authorizer.Site(users["viewer"], teamID, ViewSite); err != ErrTwoFactorRequired {
+		t.Errorf("Site(viewer) without two-factor = %v; want %v", err, ErrTwoFactorRequired)
+	}
+	if sites, _ := authorizer.Sites(users["viewer"]); len(sites) != 0 {
+		t.Errorf("Sites(viewer) without two-factor = %d sites; want none", len(sites))
+	}
+	if _, err := authorizer.Site(users["editor"], teamID, EditSite); err != nil {
+		t.Errorf("Site(editor) with two-factor = %v", err)
+	}
+	if got := audience(teamID); len(got) != 1 || got[0] != users["editor"] {
+		t.Errorf("Audience(team) requiring two-factor = %v; want the editor", got)
+	}
+
+	// Members can still see the organization to enable two-factor authentication
+	if _, err := authorizer.Organization(users["viewer"], orgID, ViewOrganization); err != nil {
+		t.Errorf("Organization(viewer, view) = %v", err)
+	}
+	if _, err := authorizer.Organization(users["admin"], orgID, ManageMembers); err != ErrTwoFactorRequired {
+		t.Errorf("Organization(admin, manage members) = %v; want %v", err, ErrTwoFactorRequired)
+	}
+	if _, err := authorizer.Organization(users["outsider"], orgID, ViewOrganization); err != ErrNotFound {
+		t.Errorf("Organization(outsider) = %v; want %v", err, ErrNotFound)
+	}
+}
@@ -3,10 +3,22 @@ package config
 import (
 	"errors"
 	"os"
+	"sort"
 	"strconv"
+	"strings"
 	"log"
+	"time"
 )
 
+// OAuthConfig holds the client credentials of an identity provider
+type OAuthConfig struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	// Base URL of self-hosted providers, or issuer of OIDC providers
+	URL string
+}
+
 type Config struct {
 	ServerPort  int
 	DatabaseURL string
@@ -17,7 +29,25 @@ type Config struct {
 		RedirectURL  string
 		Scopes       []string
 	}
+	GitLabOAuth OAuthConfig
+	GiteaOAuth  OAuthConfig
+	OIDC        OAuthConfig
 	JWTSecret string
+	// Algorithm of the keys signing access tokens, EdDSA or ES256
+	JWTSigningAlgorithm string
+	// How long a signing key signs new tokens before being replaced
+	JWTKeyRotation time.Duration
+	EdgeToken string
+	MetricsToken string
+	// Days before expiry at which TLS certificate warnings are sent, largest first
+	TLSExpiryWarningDays []int
+	SMTP      struct {
+		Host     string
+		Port     int
+		Username string
+		Password string
+		From     string
+	}
 }
 
 func New() (*Config, error) {
@@ -32,29 +62,90 @@ func New() (*Config, error) {
 		return nil, errors.New("JWT_SECRET environment variable is required")
 	}
 
-	githubClientID := getEnv("GITHUB_CLIENT_ID", "")
-	githubClientSecret := getEnv("GITHUB_CLIENT_SECRET", "")
-	if githubClientID == "" || githubClientSecret == "" {
-		return nil, errors.New("GitHub OAuth credentials are required")
-	}
-
 	cfg := &Config{
 		ServerPort:  port,
 		DatabaseURL: dbURL,
         FrontendURL: getEnv("FRONTEND_URL", "http://localhost:3000"),
 		JWTSecret:   jwtSecret,
+		EdgeToken:   getEnv("EDGE_API_TOKEN", ""),
+		MetricsToken: getEnv("METRICS_TOKEN", ""),
+	}
+
+	cfg.JWTSigningAlgorithm = getEnv("JWT_SIGNING_ALGORITHM", "EdDSA")
+	if cfg.JWTSigningAlgorithm != "EdDSA" && cfg.JWTSigningAlgorithm != "ES256" {
+		return nil, errors.New("JWT_SIGNING_ALGORITHM must be EdDSA or ES256")
+	}
+
+	rotationDays, err := strconv.Atoi(getEnv("JWT_KEY_ROTATION_DAYS", "30"))
+	if err != nil || rotationDays <= 0 {
+		return nil, errors.New("invalid JWT_KEY_ROTATION_DAYS")
 	}
+	cfg.JWTKeyRotation = time.Duration(rotationDays) * 24 * time.Hour
 
-	cfg.GitHubOAuth.ClientID = githubClientID
-	cfg.GitHubOAuth.ClientSecret = githubClientSecret
-	cfg.GitHubOAuth.RedirectURL = getEnv("GITHUB_REDIRECT_URL", "http://localhost:8080/auth/callback")
+	cfg.GitHubOAuth.ClientID = getEnv("GITHUB_CLIENT_ID", "")
+	cfg.GitHubOAuth.ClientSecret = getEnv("GITHUB_CLIENT_SECRET", "")
+	cfg.GitHubOAuth.RedirectURL = getEnv("GITHUB_REDIRECT_URL", "http://localhost:8080/auth/github/callback")
 	cfg.GitHubOAuth.Scopes = []string{"user:email"}
 
+	cfg.GitLabOAuth = oauthConfig("GITLAB", "gitlab")
+	cfg.GiteaOAuth = oauthConfig("GITEA", "gitea")
+	cfg.OIDC = oauthConfig("OIDC", "oidc")
+
+	if cfg.GitHubOAuth.ClientID == "" && cfg.GitLabOAuth.ClientID == "" && cfg.GiteaOAuth.ClientID == "" && cfg.OIDC.ClientID == "" {
+		return nil, errors.New("at least one identity provider (GitHub, GitLab, Gitea or OIDC) is required")
+	}
+	if cfg.GitHubOAuth.ClientID != "" && cfg.GitHubOAuth.ClientSecret == "" {
+		return nil, errors.New("GITHUB_CLIENT_SECRET is required")
+	}
+	if cfg.GiteaOAuth.ClientID != "" && cfg.GiteaOAuth.URL == "" {
+		return nil, errors.New("GITEA_URL is required")
+	}
+	if cfg.OIDC.ClientID != "" && cfg.OIDC.URL == "" {
+		return nil, errors.New("OIDC_URL is required")
+	}
+
+	smtpPort, err := strconv.Atoi(getEnv("SMTP_PORT", "587"))
+	if err != nil {
+		return nil, errors.New("invalid SMTP_PORT")
+	}
+
+	cfg.SMTP.Host = getEnv("SMTP_HOST", "")
+	cfg.SMTP.Port = smtpPort
+	cfg.SMTP.Username = getEnv("SMTP_USERNAME", "")
+	cfg.SMTP.Password = getEnv("SMTP_PASSWORD", "")
+	cfg.SMTP.From = getEnv("SMTP_FROM", "egide@localhost")
+
+	cfg.TLSExpiryWarningDays, err = parseDays(getEnv("TLS_EXPIRY_WARNING_DAYS", "30,14,7"))
+	if err != nil {
+		return nil, errors.New("invalid TLS_EXPIRY_WARNING_DAYS")
+	}
+
 	log.Printf("GitHub RedirectURL: %s", cfg.GitHubOAuth.RedirectURL)
 
 	return cfg, nil
 }
 
+// oauthConfig reads the <PREFIX>_CLIENT_ID, _CLIENT_SECRET, _REDIRECT_URL
+// and _URL variables of an identity provider
+func oauthConfig(prefix, provider string) OAuthConfig {
+	return OAuthConfig{
+		ClientID:     getEnv(prefix+"_CLIENT_ID", ""),
+		ClientSecret: getEnv(prefix+"_CLIENT_SECRET", ""),
+		RedirectURL:  getEnv(prefix+"_REDIRECT_URL", "http://localhost:8080/auth/"+provider+"/callback"),
+		URL:          getEnv(prefix+"_URL", ""),
+	}
+}
+
+// SecureCallbacks reports whether OAuth callbacks are served over HTTPS
+func (c *Config) SecureCallbacks() bool {
+	for _, redirectURL := range []string{c.GitHubOAuth.RedirectURL, c.GitLabOAuth.RedirectURL, c.GiteaOAuth.RedirectURL, c.OIDC.RedirectURL} {
+		if strings.HasPrefix(redirectURL, "https://") {
+			return true
+		}
+	}
+	return false
+}
+
 func getEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
 	if value == "" {
@@ -62,3 +153,21 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+// parseDays parses a comma-separated list of positive day counts, sorted largest first
+func parseDays(value string) ([]int, error) {
+	var days []int
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil || n <= 0 {
+			return nil, errors.New("invalid number of days: " + part)
+		}
+		days = append(days, n)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(days)))
+	return days, nil
+}
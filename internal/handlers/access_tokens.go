@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"egide-server/internal/auth"
+	"egide-server/internal/models"
+	"egide-server/internal/service"
+)
+
+// AccessTokenHandler handles the personal access tokens of the current user
+type AccessTokenHandler struct {
+	accessTokenService *service.AccessTokenService
+	twoFactorService   *service.TwoFactorService
+	validator          *validator.Validate
+}
+
+// NewAccessTokenHandler creates a new access token handler
+func NewAccessTokenHandler(accessTokenService *service.AccessTokenService, twoFactorService *service.TwoFactorService) *AccessTokenHandler {
+	return &AccessTokenHandler{
+		accessTokenService: accessTokenService,
+		twoFactorService:   twoFactorService,
+		validator:          validator.New(),
+	}
+}
+
+// ListAccessTokens handles GET /api/users/me/tokens
+func (h *AccessTokenHandler) ListAccessTokens(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	tokens, err := h.accessTokenService.List(userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch access tokens", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// CreateAccessToken handles POST /api/users/me/tokens
+func (h *AccessTokenHandler) CreateAccessToken(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var input models.AccessTokenInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !requireStepUp(w, r, h.twoFactorService) {
+		return
+	}
+
+	token, err := h.accessTokenService.Create(userID, &input)
+	if err != nil {
+		http.Error(w, "Failed to create access token", http.StatusInternalServerError)
+		return
+	}
+
+	// The token is only ever shown in this response
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(token)
+}
+
+// RevokeAccessToken handles DELETE /api/users/me/tokens/{tokenID}
+func (h *AccessTokenHandler) RevokeAccessToken(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	tokenID, err := strconv.ParseInt(chi.URLParam(r, "tokenID"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid access token ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.accessTokenService.Revoke(userID, tokenID); err != nil {
+		http.Error(w, "Access token not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
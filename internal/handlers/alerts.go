@@ -0,0 +1,438 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"egide-server/internal/auth"
+	"egide-server/internal/authz"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+	"egide-server/internal/service"
+)
+
+// Number of incidents returned by ListIncidents
+const incidentListLimit = 100
+
+// AlertHandler handles alert rules, notification channels and incidents
+type AlertHandler struct {
+	alertRepo           *repository.AlertRepository
+	channelRepo         *repository.NotificationChannelRepository
+	authorizer          *authz.Authorizer
+	monitorRepo         *repository.MonitorRepository
+	notificationService *service.NotificationService
+	validator           *validator.Validate
+}
+
+// NewAlertHandler creates a new alert handler
+func NewAlertHandler(
+	alertRepo *repository.AlertRepository,
+	channelRepo *repository.NotificationChannelRepository,
+	authorizer *authz.Authorizer,
+	monitorRepo *repository.MonitorRepository,
+	notificationService *service.NotificationService,
+) *AlertHandler {
+	return &AlertHandler{
+		alertRepo:           alertRepo,
+		channelRepo:         channelRepo,
+		authorizer:          authorizer,
+		monitorRepo:         monitorRepo,
+		notificationService: notificationService,
+		validator:           validator.New(),
+	}
+}
+
+// ListRules handles GET /api/alerts/rules
+func (h *AlertHandler) ListRules(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	rules, err := h.alertRepo.FindByUserID(userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch alert rules", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rules)
+}
+
+// GetRule handles GET /api/alerts/rules/{id}
+func (h *AlertHandler) GetRule(w http.ResponseWriter, r *http.Request) {
+	rule, ok := h.ownedRule(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rule)
+}
+
+// CreateRule handles POST /api/alerts/rules
+func (h *AlertHandler) CreateRule(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	rule := &models.AlertRule{
+		UserID:  userID,
+		Enabled: true,
+	}
+	if !h.applyRuleInput(w, r, rule) {
+		return
+	}
+
+	ruleID, err := h.alertRepo.Create(rule)
+	if err != nil {
+		http.Error(w, "Failed to create alert rule: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rule, err = h.alertRepo.FindByID(ruleID)
+	if err != nil {
+		http.Error(w, "Alert rule created but failed to fetch", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rule)
+}
+
+// UpdateRule handles PUT /api/alerts/rules/{id}
+func (h *AlertHandler) UpdateRule(w http.ResponseWriter, r *http.Request) {
+	rule, ok := h.ownedRule(w, r)
+	if !ok {
+		return
+	}
+
+	if !h.applyRuleInput(w, r, rule) {
+		return
+	}
+
+	if err := h.alertRepo.Update(rule); err != nil {
+		http.Error(w, "Failed to update alert rule: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rule)
+}
+
+// DeleteRule handles DELETE /api/alerts/rules/{id}
+func (h *AlertHandler) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	rule, ok := h.ownedRule(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.alertRepo.Delete(rule.ID); err != nil {
+		http.Error(w, "Failed to delete alert rule: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListIncidents handles GET /api/alerts/incidents
+func (h *AlertHandler) ListIncidents(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	incidents, err := h.alertRepo.FindIncidentsByUserID(userID, incidentListLimit)
+	if err != nil {
+		http.Error(w, "Failed to fetch incidents", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(incidents)
+}
+
+// ListChannels handles GET /api/alerts/channels
+func (h *AlertHandler) ListChannels(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	channels, err := h.channelRepo.FindByUserID(userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch notification channels", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(channels)
+}
+
+// CreateChannel handles POST /api/alerts/channels
+func (h *AlertHandler) CreateChannel(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	channel := &models.NotificationChannel{
+		UserID:  userID,
+		Enabled: true,
+	}
+	if !h.applyChannelInput(w, r, channel) {
+		return
+	}
+
+	channelID, err := h.channelRepo.Create(channel)
+	if err != nil {
+		http.Error(w, "Failed to create notification channel: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	channel, err = h.channelRepo.FindByID(channelID)
+	if err != nil {
+		http.Error(w, "Notification channel created but failed to fetch", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(channel)
+}
+
+// UpdateChannel handles PUT /api/alerts/channels/{id}
+func (h *AlertHandler) UpdateChannel(w http.ResponseWriter, r *http.Request) {
+	channel, ok := h.ownedChannel(w, r)
+	if !ok {
+		return
+	}
+
+	if !h.applyChannelInput(w, r, channel) {
+		return
+	}
+
+	if err := h.channelRepo.Update(channel); err != nil {
+		http.Error(w, "Failed to update notification channel: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(channel)
+}
+
+// DeleteChannel handles DELETE /api/alerts/channels/{id}
+func (h *AlertHandler) DeleteChannel(w http.ResponseWriter, r *http.Request) {
+	channel, ok := h.ownedChannel(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.channelRepo.Delete(channel.ID); err != nil {
+		http.Error(w, "Failed to delete notification channel: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// TestChannel handles POST /api/alerts/channels/{id}/test
+func (h *AlertHandler) TestChannel(w http.ResponseWriter, r *http.Request) {
+	channel, ok := h.ownedChannel(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.notificationService.Send(channel, &service.Notification{
+		Title:   "Egide test notification",
+		Message: "This channel is correctly configured to receive Egide alerts.",
+		Status:  models.IncidentResolved,
+		Time:    time.Now(),
+	})
+	if err != nil {
+		http.Error(w, "Failed to send test notification: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// applyRuleInput decodes and validates a rule payload, checking that every
+// referenced site, monitor and channel belongs to the rule owner
+func (h *AlertHandler) applyRuleInput(w http.ResponseWriter, r *http.Request, rule *models.AlertRule) bool {
+	var input models.AlertRuleInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return false
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return false
+	}
+
+	rule.SiteID = nil
+	rule.MonitorID = nil
+
+	switch input.Type {
+	case models.MonitorDownRule, models.LatencyAboveRule:
+		if input.MonitorID == nil {
+			http.Error(w, "Validation error: monitor_id is required for "+string(input.Type)+" rules", http.StatusBadRequest)
+			return false
+		}
+
+		monitor, err := h.monitorRepo.FindByID(*input.MonitorID)
+		if err != nil {
+			http.Error(w, "Monitor not found", http.StatusBadRequest)
+			return false
+		}
+
+		site, err := h.authorizer.Site(rule.UserID, monitor.SiteID, authz.ViewSite)
+		if err != nil {
+			http.Error(w, "Monitor not found", http.StatusBadRequest)
+			return false
+		}
+
+		rule.SiteID = &site.ID
+		rule.MonitorID = &monitor.ID
+
+	case models.ThreatRateRule:
+		if input.SiteID == nil {
+			http.Error(w, "Validation error: site_id is required for threat_rate rules", http.StatusBadRequest)
+			return false
+		}
+
+		site, err := h.authorizer.Site(rule.UserID, *input.SiteID, authz.ViewSite)
+		if err != nil {
+			http.Error(w, "Site not found", http.StatusBadRequest)
+			return false
+		}
+
+		rule.SiteID = &site.ID
+	}
+
+	for _, channelID := range input.ChannelIDs {
+		channel, err := h.channelRepo.FindByID(channelID)
+		if err != nil || channel.UserID != rule.UserID {
+			http.Error(w, "Notification channel not found: "+strconv.FormatInt(channelID, 10), http.StatusBadRequest)
+			return false
+		}
+	}
+
+	rule.Name = input.Name
+	rule.Type = input.Type
+	rule.Threshold = input.Threshold
+	rule.WindowMinutes = input.WindowMinutes
+	rule.ChannelIDs = input.ChannelIDs
+	if rule.ChannelIDs == nil {
+		rule.ChannelIDs = []int64{}
+	}
+	if input.Enabled != nil {
+		rule.Enabled = *input.Enabled
+	}
+
+	return true
+}
+
+// applyChannelInput decodes and validates a notification channel payload
+func (h *AlertHandler) applyChannelInput(w http.ResponseWriter, r *http.Request, channel *models.NotificationChannel) bool {
+	var input models.NotificationChannelInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return false
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return false
+	}
+
+	targetTag := "url"
+	if input.Type == models.EmailChannel {
+		targetTag = "email"
+	}
+	if err := h.validator.Var(input.Target, targetTag); err != nil {
+		http.Error(w, "Validation error: target must be a valid "+targetTag, http.StatusBadRequest)
+		return false
+	}
+	if input.Type != models.EmailChannel {
+		if err := service.CheckPublicURL(r.Context(), input.Target); err != nil {
+			http.Error(w, "Validation error: target: "+err.Error(), http.StatusBadRequest)
+			return false
+		}
+	}
+
+	channel.Name = input.Name
+	channel.Type = input.Type
+	channel.Target = input.Target
+	if input.Enabled != nil {
+		channel.Enabled = *input.Enabled
+	}
+
+	return true
+}
+
+func (h *AlertHandler) ownedRule(w http.ResponseWriter, r *http.Request) (*models.AlertRule, bool) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	ruleID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid alert rule ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	rule, err := h.alertRepo.FindByID(ruleID)
+	if err != nil {
+		http.Error(w, "Alert rule not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	if rule.UserID != userID {
+		http.Error(w, "Unauthorized", http.StatusForbidden)
+		return nil, false
+	}
+
+	return rule, true
+}
+
+func (h *AlertHandler) ownedChannel(w http.ResponseWriter, r *http.Request) (*models.NotificationChannel, bool) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	channelID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid notification channel ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	channel, err := h.channelRepo.FindByID(channelID)
+	if err != nil {
+		http.Error(w, "Notification channel not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	if channel.UserID != userID {
+		http.Error(w, "Unauthorized", http.StatusForbidden)
+		return nil, false
+	}
+
+	return channel, true
+}
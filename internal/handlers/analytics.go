@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"egide-server/internal/authz"
+	"egide-server/internal/service"
+)
+
+const (
+	// defaultAnalyticsLimit is the number of top values returned per dimension
+	defaultAnalyticsLimit = 10
+
+	// maxAnalyticsLimit matches the number of values tracked per hour
+	maxAnalyticsLimit = service.AnalyticsTopKCapacity
+)
+
+// AnalyticsHandler handles traffic analytics requests
+type AnalyticsHandler struct {
+	authorizer       *authz.Authorizer
+	analyticsService *service.AnalyticsService
+}
+
+// NewAnalyticsHandler creates a new analytics handler
+func NewAnalyticsHandler(authorizer *authz.Authorizer, analyticsService *service.AnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		authorizer:       authorizer,
+		analyticsService: analyticsService,
+	}
+}
+
+// GetSiteAnalytics handles GET /api/sites/{id}/analytics
+func (h *AnalyticsHandler) GetSiteAnalytics(w http.ResponseWriter, r *http.Request) {
+	site, ok := loadSite(w, r, h.authorizer)
+	if !ok {
+		return
+	}
+
+	start, end, err := parseTimeRange(r, DefaultLatencyRange)
+	if err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limit := defaultAnalyticsLimit
+	if param := r.URL.Query().Get("limit"); param != "" {
+		limit, err = strconv.Atoi(param)
+		if err != nil || limit < 1 || limit > maxAnalyticsLimit {
+			http.Error(w, "Validation error: limit must be between 1 and "+strconv.Itoa(maxAnalyticsLimit), http.StatusBadRequest)
+			return
+		}
+	}
+
+	analytics, err := h.analyticsService.GetSiteAnalytics(site.ID, start, end, limit)
+	if err != nil {
+		http.Error(w, "Error fetching analytics: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(analytics)
+}
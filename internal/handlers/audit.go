@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"egide-server/internal/authz"
+	"egide-server/internal/repository"
+)
+
+// auditListLimit bounds the number of audit entries returned at once
+const auditListLimit = 100
+
+// AuditHandler exposes the audit log of sites
+type AuditHandler struct {
+	auditRepo  *repository.AuditRepository
+	authorizer *authz.Authorizer
+}
+
+// NewAuditHandler creates a new audit handler
+func NewAuditHandler(auditRepo *repository.AuditRepository, authorizer *authz.Authorizer) *AuditHandler {
+	return &AuditHandler{
+		auditRepo:  auditRepo,
+		authorizer: authorizer,
+	}
+}
+
+// ListSiteAudit handles GET /api/sites/{id}/audit
+func (h *AuditHandler) ListSiteAudit(w http.ResponseWriter, r *http.Request) {
+	site, ok := loadSiteFor(w, r, h.authorizer, authz.ManageSite)
+	if !ok {
+		return
+	}
+
+	entries, err := h.auditRepo.FindBySiteID(site.ID, auditListLimit)
+	if err != nil {
+		http.Error(w, "Failed to fetch audit log", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
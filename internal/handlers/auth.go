@@ -1,86 +1,284 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
-	"strconv"
+	"net/url"
 
+	"github.com/go-chi/chi/v5"
 	"egide-server/internal/auth"
 	"egide-server/internal/config"
 	"egide-server/internal/models"
 	"egide-server/internal/repository"
+	"egide-server/internal/service"
 )
 
 type AuthHandler struct {
-	authService *auth.GitHubService
-	userRepo    *repository.UserRepository
-	config      *config.Config
+	providers       map[string]auth.Provider
+	sessionService  *service.SessionService
+	stateCookie     *auth.StateCookie
+	linkTokens      *auth.LinkTokens
+	userRepo        *repository.UserRepository
+	identityRepo    *repository.IdentityRepository
+	identityService *service.IdentityService
+	config          *config.Config
 }
 
-func NewAuthHandler(authService *auth.GitHubService, userRepo *repository.UserRepository, config *config.Config) *AuthHandler {
+func NewAuthHandler(
+	providers map[string]auth.Provider,
+	sessionService *service.SessionService,
+	stateCookie *auth.StateCookie,
+	linkTokens *auth.LinkTokens,
+	userRepo *repository.UserRepository,
+	identityRepo *repository.IdentityRepository,
+	identityService *service.IdentityService,
+	config *config.Config,
+) *AuthHandler {
 	return &AuthHandler{
-		authService: authService,
-		userRepo:    userRepo,
-		config:      config,
+		providers:       providers,
+		sessionService:  sessionService,
+		stateCookie:     stateCookie,
+		linkTokens:      linkTokens,
+		userRepo:        userRepo,
+		identityRepo:    identityRepo,
+		identityService: identityService,
+		config:          config,
 	}
 }
 
-func (h *AuthHandler) GitHubLogin(w http.ResponseWriter, r *http.Request) {
-	url := h.authService.GetAuthURL()
-	http.Redirect(w, r, url, http.StatusTemporaryRedirect)
+// Login handles GET /auth/{provider}?return_to=/path
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	provider, ok := h.providers[chi.URLParam(r, "provider")]
+	if !ok {
+		http.Error(w, "Unknown identity provider", http.StatusNotFound)
+		return
+	}
+
+	state, err := h.stateCookie.Issue(w, provider.Name(), r.URL.Query().Get("return_to"), nil)
+	if err != nil {
+		http.Error(w, "Failed to start login", http.StatusInternalServerError)
+		return
+	}
+
+	authURL, err := provider.AuthURL(r.Context(), state)
+	if err != nil {
+		http.Error(w, "Identity provider unavailable: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	http.Redirect(w, r, authURL, http.StatusTemporaryRedirect)
 }
 
+// Callback handles GET /auth/{provider}/callback
+func (h *AuthHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	h.callback(w, r, chi.URLParam(r, "provider"))
+}
+
+// GitHubCallback handles GET /auth/callback, the callback URL of GitHub
+// OAuth apps registered before other providers were supported
 func (h *AuthHandler) GitHubCallback(w http.ResponseWriter, r *http.Request) {
+	h.callback(w, r, auth.ProviderGitHub)
+}
+
+func (h *AuthHandler) callback(w http.ResponseWriter, r *http.Request, name string) {
+    provider, ok := h.providers[name]
+    if !ok {
+        http.Error(w, "Unknown identity provider", http.StatusNotFound)
+        return
+    }
+
+    // The state must match the cookie set by Login in this browser, for this provider
+    state, err := h.stateCookie.Verify(w, r)
+    if err != nil {
+        http.Error(w, "Invalid OAuth state: "+err.Error(), http.StatusBadRequest)
+        return
+    }
+    if state.Provider != provider.Name() {
+        http.Error(w, "Invalid OAuth state: provider mismatch", http.StatusBadRequest)
+        return
+    }
+
+    if errorCode := r.URL.Query().Get("error"); errorCode != "" {
+        http.Error(w, "Authorization failed: "+errorCode, http.StatusBadRequest)
+        return
+    }
+
     code := r.URL.Query().Get("code")
     if code == "" {
         http.Error(w, "Missing authorization code", http.StatusBadRequest)
         return
     }
 
-    accessToken, err := h.authService.Exchange(code)
+    identity, err := provider.Identify(r.Context(), code, state)
     if err != nil {
-        http.Error(w, "Failed to exchange token: "+err.Error(), http.StatusInternalServerError)
+        http.Error(w, "Failed to get user info: "+err.Error(), http.StatusInternalServerError)
         return
     }
 
-    githubUser, err := h.authService.GetUser(accessToken)
-    if err != nil {
-        http.Error(w, "Failed to get user info: "+err.Error(), http.StatusInternalServerError)
+    if state.LinkUserID != 0 {
+        // The link dies with the session that started it
+        active, err := h.sessionService.IsActive(state.LinkSessionID)
+        if err != nil {
+            http.Error(w, "Failed to check session", http.StatusInternalServerError)
+            return
+        }
+        if !active {
+            http.Error(w, "Session revoked or expired", http.StatusUnauthorized)
+            return
+        }
+        if _, err := h.identityService.Link(state.LinkUserID, identity); err != nil {
+            if errors.Is(err, service.ErrIdentityInUse) {
+                http.Error(w, "This account is already linked to another user", http.StatusConflict)
+                return
+            }
+            http.Error(w, "Failed to link identity: "+err.Error(), http.StatusInternalServerError)
+            return
+        }
+        // The user is already signed in on the frontend, no new session is needed
+        redirectURL := fmt.Sprintf("%s/auth/callback#linked=%s", h.frontendURL(), url.QueryEscape(identity.Provider))
+        if state.ReturnTo != "" {
+            redirectURL += "&returnTo=" + url.QueryEscape(state.ReturnTo)
+        }
+        http.Redirect(w, r, redirectURL, http.StatusTemporaryRedirect)
         return
     }
 
-    user, err := h.userRepo.FindByGitHubID(strconv.FormatInt(githubUser.ID, 10))
+    user, err := h.userRepo.FindByIdentity(identity.Provider, identity.Subject)
     if err != nil {
         user = &models.User{
-            GitHubID: strconv.FormatInt(githubUser.ID, 10),
-            Username: githubUser.Login,
-            Email:    githubUser.Email,
+            Username: identity.Username,
+            Email:    identity.Email,
         }
 
-        userID, err := h.userRepo.Create(user)
+        userID, err := h.userRepo.CreateWithIdentity(user, identity)
         if err != nil {
             http.Error(w, "Failed to create user: "+err.Error(), http.StatusInternalServerError)
             return
         }
         user.ID = userID
+    } else if err := h.identityRepo.UpdateProfile(identity); err != nil {
+        http.Error(w, "Failed to update identity: "+err.Error(), http.StatusInternalServerError)
+        return
     }
 
-    token, err := h.authService.GenerateToken(user.ID)
+    tokens, err := h.sessionService.Start(user.ID, r.UserAgent(), clientIP(r))
     if err != nil {
-        http.Error(w, "Failed to generate token: "+err.Error(), http.StatusInternalServerError)
+        http.Error(w, "Failed to start session: "+err.Error(), http.StatusInternalServerError)
         return
     }
 
-    // Get the redirect URL (or use a default)
-    frontendURL := h.config.FrontendURL
-    if frontendURL == "" {
-        frontendURL = "http://localhost:3000" // Default frontend URL
+    // Redirect to frontend with the tokens
+    // Using hash fragment is more secure for tokens
+    redirectURL := fmt.Sprintf("%s/auth/callback#token=%s&expiry=%d&userId=%d&refreshToken=%s",
+        h.frontendURL(), tokens.AccessToken, tokens.ExpiresIn, user.ID, tokens.RefreshToken)
+    if state.ReturnTo != "" {
+        redirectURL += "&returnTo=" + url.QueryEscape(state.ReturnTo)
     }
 
-    // Redirect to frontend with the token
-    // Using hash fragment is more secure for tokens
-    redirectURL := fmt.Sprintf("%s/auth/callback#token=%s&expiry=%d&userId=%d", 
-        frontendURL, token, 86400, user.ID)
-    
     http.Redirect(w, r, redirectURL, http.StatusTemporaryRedirect)
 }
+
+// Refresh handles POST /auth/refresh, renewing the tokens of a session
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+    var input refreshInput
+    if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.RefreshToken == "" {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    tokens, err := h.sessionService.Refresh(input.RefreshToken, r.UserAgent(), clientIP(r))
+    if err != nil {
+        if errors.Is(err, service.ErrInvalidRefreshToken) || errors.Is(err, service.ErrRefreshTokenReused) {
+            http.Error(w, err.Error(), http.StatusUnauthorized)
+            return
+        }
+        http.Error(w, "Failed to refresh session: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.Header().Set("Cache-Control", "no-store")
+    json.NewEncoder(w).Encode(tokens)
+}
+
+// Logout handles POST /auth/logout, revoking the session of a refresh token
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+    var input refreshInput
+    if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.RefreshToken == "" {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    // Logging out of an unknown session has nothing left to do
+    if err := h.sessionService.Logout(input.RefreshToken); err != nil && !errors.Is(err, service.ErrInvalidRefreshToken) {
+        http.Error(w, "Failed to log out: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+}
+
+// VerifyEmail handles POST /auth/email/verify, following the link sent to
+// an email address a user asked to link
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+    var input struct {
+        Token string `json:"token"`
+    }
+    if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.Token == "" {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    link, err := h.linkTokens.Parse(input.Token, auth.ProviderEmail)
+    if err != nil || link.Email == "" {
+        http.Error(w, "Invalid or expired verification link", http.StatusBadRequest)
+        return
+    }
+
+    // Like OAuth links, the link dies with the session that started it
+    active, err := h.sessionService.IsActive(link.SessionID)
+    if err != nil {
+        http.Error(w, "Failed to check session", http.StatusInternalServerError)
+        return
+    }
+    if !active {
+        http.Error(w, "Session revoked or expired", http.StatusUnauthorized)
+        return
+    }
+
+    identity, err := h.identityService.LinkEmail(link.UserID, link.Email)
+    if err != nil {
+        if errors.Is(err, service.ErrIdentityInUse) {
+            http.Error(w, "This email address is already linked to another user", http.StatusConflict)
+            return
+        }
+        http.Error(w, "Failed to link email address: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(identity)
+}
+
+type refreshInput struct {
+    RefreshToken string `json:"refresh_token"`
+}
+
+func (h *AuthHandler) frontendURL() string {
+    if h.config.FrontendURL == "" {
+        return "http://localhost:3000" // Default frontend URL
+    }
+    return h.config.FrontendURL
+}
+
+// clientIP returns the address of the client of a request, without port
+func clientIP(r *http.Request) string {
+    host, _, err := net.SplitHostPort(r.RemoteAddr)
+    if err != nil {
+        return r.RemoteAddr
+    }
+    return host
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"egide-server/internal/authz"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+	"egide-server/internal/service"
+)
+
+// badgeCacheControl lets READMEs and CDNs cache badges for a few minutes
+const badgeCacheControl = "public, max-age=300"
+
+// BadgeHandler handles badge settings and the public SVG badges
+type BadgeHandler struct {
+	authorizer   *authz.Authorizer
+	siteRepo     *repository.SiteRepository
+	monitorRepo  *repository.MonitorRepository
+	badgeRepo    *repository.BadgeRepository
+	badgeService *service.BadgeService
+	validator    *validator.Validate
+}
+
+// NewBadgeHandler creates a new badge handler
+func NewBadgeHandler(
+	authorizer *authz.Authorizer,
+	siteRepo *repository.SiteRepository,
+	monitorRepo *repository.MonitorRepository,
+	badgeRepo *repository.BadgeRepository,
+	badgeService *service.BadgeService,
+) *BadgeHandler {
+	return &BadgeHandler{
+		authorizer:   authorizer,
+		siteRepo:     siteRepo,
+		monitorRepo:  monitorRepo,
+		badgeRepo:    badgeRepo,
+		badgeService: badgeService,
+		validator:    validator.New(),
+	}
+}
+
+// GetSettings handles GET /api/sites/{id}/badge
+func (h *BadgeHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	site, ok := loadSite(w, r, h.authorizer)
+	if !ok {
+		return
+	}
+
+	settings, err := h.badgeRepo.FindBySiteID(site.ID)
+	if err != nil {
+		http.Error(w, "Failed to fetch badge settings", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
+
+// UpdateSettings handles PUT /api/sites/{id}/badge
+func (h *BadgeHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	site, ok := loadSite(w, r, h.authorizer)
+	if !ok {
+		return
+	}
+
+	var input models.BadgeSettingsInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	settings := &models.BadgeSettings{SiteID: site.ID, Enabled: *input.Enabled}
+	if err := h.badgeRepo.Save(settings); err != nil {
+		http.Error(w, "Failed to update badge settings: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
+
+// SiteUptimeBadge handles GET /badges/sites/{id}/uptime.svg
+func (h *BadgeHandler) SiteUptimeBadge(w http.ResponseWriter, r *http.Request) {
+	period, ok := badgePeriod(w, r)
+	if !ok {
+		return
+	}
+
+	site, ok := h.publicSite(w, r, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+
+	monitors, err := h.monitorRepo.FindBySiteID(site.ID)
+	if err != nil {
+		http.Error(w, "Failed to fetch monitors", http.StatusInternalServerError)
+		return
+	}
+
+	monitorIDs := make([]int64, 0, len(monitors))
+	for _, monitor := range monitors {
+		monitorIDs = append(monitorIDs, monitor.ID)
+	}
+
+	badge, err := h.badgeService.UptimeBadge(monitorIDs, period)
+	if err != nil {
+		http.Error(w, "Failed to render badge", http.StatusInternalServerError)
+		return
+	}
+
+	writeBadge(w, badge)
+}
+
+// MonitorUptimeBadge handles GET /badges/monitors/{id}/uptime.svg
+func (h *BadgeHandler) MonitorUptimeBadge(w http.ResponseWriter, r *http.Request) {
+	period, ok := badgePeriod(w, r)
+	if !ok {
+		return
+	}
+
+	monitorID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid monitor ID", http.StatusBadRequest)
+		return
+	}
+
+	monitor, err := h.monitorRepo.FindByID(monitorID)
+	if err != nil {
+		http.Error(w, "Badge not found", http.StatusNotFound)
+		return
+	}
+
+	if _, ok := h.publicSite(w, r, strconv.FormatInt(monitor.SiteID, 10)); !ok {
+		return
+	}
+
+	badge, err := h.badgeService.UptimeBadge([]int64{monitor.ID}, period)
+	if err != nil {
+		http.Error(w, "Failed to render badge", http.StatusInternalServerError)
+		return
+	}
+
+	writeBadge(w, badge)
+}
+
+// ProtectionBadge handles GET /badges/sites/{id}/protected.svg
+func (h *BadgeHandler) ProtectionBadge(w http.ResponseWriter, r *http.Request) {
+	site, ok := h.publicSite(w, r, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+
+	writeBadge(w, h.badgeService.ProtectionBadge(site.Active))
+}
+
+// publicSite loads a site whose owner opted in to badges. Sites that don't
+// exist and sites without badges both answer 404 so that IDs can't be probed.
+func (h *BadgeHandler) publicSite(w http.ResponseWriter, r *http.Request, id string) (*models.Site, bool) {
+	siteID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid site ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	site, err := h.siteRepo.FindByID(siteID)
+	if err != nil {
+		http.Error(w, "Badge not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	settings, err := h.badgeRepo.FindBySiteID(site.ID)
+	if err != nil {
+		http.Error(w, "Failed to fetch badge settings", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	if !settings.Enabled {
+		http.Error(w, "Badge not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	return site, true
+}
+
+// badgePeriod reads the optional ?period= parameter (24h, 7d, 30d or 90d)
+func badgePeriod(w http.ResponseWriter, r *http.Request) (time.Duration, bool) {
+	name := r.URL.Query().Get("period")
+	if name == "" {
+		name = service.DefaultBadgePeriod
+	}
+
+	period, ok := service.BadgePeriods[name]
+	if !ok {
+		http.Error(w, "Invalid period: use 24h, 7d, 30d or 90d", http.StatusBadRequest)
+		return 0, false
+	}
+
+	return period, true
+}
+
+func writeBadge(w http.ResponseWriter, badge []byte) {
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", badgeCacheControl)
+	w.Write(badge)
+}
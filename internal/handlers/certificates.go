@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"egide-server/internal/authz"
+	"egide-server/internal/service"
+)
+
+// CertificateHandler exposes the TLS certificates seen by the monitors of a site
+type CertificateHandler struct {
+	authorizer         *authz.Authorizer
+	certificateService *service.CertificateService
+}
+
+// NewCertificateHandler creates a new certificate handler
+func NewCertificateHandler(authorizer *authz.Authorizer, certificateService *service.CertificateService) *CertificateHandler {
+	return &CertificateHandler{
+		authorizer:         authorizer,
+		certificateService: certificateService,
+	}
+}
+
+// ListCertificates handles GET /api/sites/{id}/certificates
+func (h *CertificateHandler) ListCertificates(w http.ResponseWriter, r *http.Request) {
+	site, ok := loadSite(w, r, h.authorizer)
+	if !ok {
+		return
+	}
+
+	certs, err := h.certificateService.GetSiteCertificates(site.ID)
+	if err != nil {
+		http.Error(w, "Failed to fetch certificates", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(certs)
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"egide-server/internal/authz"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+	"egide-server/internal/service"
+)
+
+const (
+	// defaultContentChangesLimit is the number of content changes returned by default
+	defaultContentChangesLimit = 50
+
+	// maxContentChangesLimit is the maximum number of content changes returned
+	maxContentChangesLimit = 500
+)
+
+// ContentHandler handles content change detection of the monitors of hardened sites
+type ContentHandler struct {
+	authorizer     *authz.Authorizer
+	monitorRepo    *repository.MonitorRepository
+	contentService *service.ContentService
+	validator      *validator.Validate
+}
+
+// NewContentHandler creates a new content handler
+func NewContentHandler(authorizer *authz.Authorizer, monitorRepo *repository.MonitorRepository, contentService *service.ContentService) *ContentHandler {
+	return &ContentHandler{
+		authorizer:     authorizer,
+		monitorRepo:    monitorRepo,
+		contentService: contentService,
+		validator:      validator.New(),
+	}
+}
+
+// GetContentCheck handles GET /api/sites/{id}/monitors/{monitorID}/content
+func (h *ContentHandler) GetContentCheck(w http.ResponseWriter, r *http.Request) {
+	monitor, ok := h.ownedMonitor(w, r)
+	if !ok {
+		return
+	}
+
+	check, err := h.contentService.GetCheck(monitor.ID)
+	if err != nil {
+		http.Error(w, "Failed to fetch content check", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(check)
+}
+
+// UpdateContentCheck handles PUT /api/sites/{id}/monitors/{monitorID}/content
+func (h *ContentHandler) UpdateContentCheck(w http.ResponseWriter, r *http.Request) {
+	monitor, ok := h.ownedMonitor(w, r)
+	if !ok {
+		return
+	}
+
+	var input models.ContentCheckInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	check, err := h.contentService.Configure(monitor.ID, &input)
+	if err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(check)
+}
+
+// ResetBaseline handles POST /api/sites/{id}/monitors/{monitorID}/content/baseline
+func (h *ContentHandler) ResetBaseline(w http.ResponseWriter, r *http.Request) {
+	monitor, ok := h.ownedMonitor(w, r)
+	if !ok {
+		return
+	}
+
+	check, err := h.contentService.ResetBaseline(monitor.ID)
+	if err != nil {
+		http.Error(w, "Failed to reset baseline: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(check)
+}
+
+// ListContentChanges handles GET /api/sites/{id}/content-changes
+func (h *ContentHandler) ListContentChanges(w http.ResponseWriter, r *http.Request) {
+	site, ok := loadSite(w, r, h.authorizer)
+	if !ok {
+		return
+	}
+
+	limit := defaultContentChangesLimit
+	if param := r.URL.Query().Get("limit"); param != "" {
+		var err error
+		limit, err = strconv.Atoi(param)
+		if err != nil || limit < 1 || limit > maxContentChangesLimit {
+			http.Error(w, "Validation error: limit must be between 1 and "+strconv.Itoa(maxContentChangesLimit), http.StatusBadRequest)
+			return
+		}
+	}
+
+	changes, err := h.contentService.GetSiteChanges(site.ID, limit)
+	if err != nil {
+		http.Error(w, "Failed to fetch content changes", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(changes)
+}
+
+// ownedMonitor loads the monitor from the URL, ensuring it belongs to a
+// hardened site of the user since content checks are part of that tier
+func (h *ContentHandler) ownedMonitor(w http.ResponseWriter, r *http.Request) (*models.Monitor, bool) {
+	site, ok := loadSite(w, r, h.authorizer)
+	if !ok {
+		return nil, false
+	}
+
+	monitorID, err := strconv.ParseInt(chi.URLParam(r, "monitorID"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid monitor ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	monitor, err := h.monitorRepo.FindByID(monitorID)
+	if err != nil || monitor.SiteID != site.ID {
+		http.Error(w, "Monitor not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	if site.ProtectionMode != models.HardenedProtection {
+		http.Error(w, "Content checks require the hardened protection mode", http.StatusBadRequest)
+		return nil, false
+	}
+
+	return monitor, true
+}
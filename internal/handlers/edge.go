@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+	"egide-server/internal/service"
+)
+
+// Maximum number of items accepted in a single edge report
+const maxEdgeBatchSize = 1000
+
+// EdgeHandler handles data reported by edge nodes
+type EdgeHandler struct {
+	siteRepo         *repository.SiteRepository
+	threatService    *service.ThreatService
+	trafficService   *service.TrafficService
+	analyticsService *service.AnalyticsService
+	validator        *validator.Validate
+}
+
+// NewEdgeHandler creates a new edge handler
+func NewEdgeHandler(
+	siteRepo *repository.SiteRepository,
+	threatService *service.ThreatService,
+	trafficService *service.TrafficService,
+	analyticsService *service.AnalyticsService,
+) *EdgeHandler {
+	return &EdgeHandler{
+		siteRepo:         siteRepo,
+		threatService:    threatService,
+		trafficService:   trafficService,
+		analyticsService: analyticsService,
+		validator:        validator.New(),
+	}
+}
+
+// edgeReportResult summarizes how many items of a report were stored
+type edgeReportResult struct {
+	Accepted int `json:"accepted"`
+	Skipped  int `json:"skipped"`
+}
+
+// IngestThreats handles POST /api/edge/threats
+func (h *EdgeHandler) IngestThreats(w http.ResponseWriter, r *http.Request) {
+	var inputs []models.ThreatInput
+	if err := json.NewDecoder(r.Body).Decode(&inputs); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if len(inputs) > maxEdgeBatchSize {
+		http.Error(w, "Too many threats in a single report", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	for _, input := range inputs {
+		if err := h.validator.Struct(input); err != nil {
+			http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	sites := make(map[string]*models.Site)
+	var result edgeReportResult
+
+	for _, input := range inputs {
+		site := h.activeSite(sites, input.Site)
+
+		// Threats for unknown or inactive sites are dropped
+		if site == nil {
+			result.Skipped++
+			continue
+		}
+
+		threat := &models.Threat{
+			Nature: input.Nature,
+			Status: input.Status,
+			Source: input.Source,
+			Time:   time.Now(),
+		}
+		if input.Time != nil {
+			threat.Time = *input.Time
+		}
+
+		if err := h.threatService.Ingest(site, threat); err != nil {
+			http.Error(w, "Failed to store threat: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		result.Accepted++
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(result)
+}
+
+// IngestTraffic handles POST /api/edge/traffic
+func (h *EdgeHandler) IngestTraffic(w http.ResponseWriter, r *http.Request) {
+	var inputs []models.TrafficInput
+	if err := json.NewDecoder(r.Body).Decode(&inputs); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if len(inputs) > maxEdgeBatchSize {
+		http.Error(w, "Too many counters in a single report", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	for _, input := range inputs {
+		if err := h.validator.Struct(input); err != nil {
+			http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	sites := make(map[string]*models.Site)
+	var result edgeReportResult
+
+	for i := range inputs {
+		site := h.activeSite(sites, inputs[i].Site)
+
+		// Counters for unknown or inactive sites are dropped
+		if site == nil {
+			result.Skipped++
+			continue
+		}
+
+		if err := h.trafficService.Record(site, &inputs[i]); err != nil {
+			http.Error(w, "Failed to store traffic counters: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		result.Accepted++
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(result)
+}
+
+// IngestRequests handles POST /api/edge/requests
+func (h *EdgeHandler) IngestRequests(w http.ResponseWriter, r *http.Request) {
+	var inputs []models.RequestSampleInput
+	if err := json.NewDecoder(r.Body).Decode(&inputs); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if len(inputs) > maxEdgeBatchSize {
+		http.Error(w, "Too many requests in a single report", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	for _, input := range inputs {
+		if err := h.validator.Struct(input); err != nil {
+			http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	sites := make(map[string]*models.Site)
+	var result edgeReportResult
+
+	for i := range inputs {
+		site := h.activeSite(sites, inputs[i].Site)
+
+		// Requests for unknown or inactive sites are dropped
+		if site == nil {
+			result.Skipped++
+			continue
+		}
+
+		if err := h.analyticsService.Record(site, &inputs[i]); err != nil {
+			http.Error(w, "Failed to record requests: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		result.Accepted++
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(result)
+}
+
+// activeSite looks up an active site by domain, caching lookups since
+// reports usually contain many items for the same domains
+func (h *EdgeHandler) activeSite(cache map[string]*models.Site, domain string) *models.Site {
+	site, seen := cache[domain]
+	if !seen {
+		site, _ = h.siteRepo.FindActiveByDomain(domain)
+		cache[domain] = site
+	}
+	return site
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"egide-server/internal/auth"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+	"egide-server/internal/service"
+)
+
+func TestIngestTraffic(t *testing.T) {
+	db := newTestDB(t)
+	siteRepo, threatService := seedThreats(t, db)
+	trafficRepo := repository.NewTrafficRepository(db)
+	handler := NewEdgeHandler(siteRepo, threatService, service.NewTrafficService(trafficRepo), nil)
+
+	period := time.Now().Add(-time.Hour).UTC().Truncate(time.Minute).Format(time.RFC3339)
+	report := func(body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("POST", "/api/edge/traffic", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		handler.IngestTraffic(rr, req)
+		return rr
+	}
+
+	body := `[
+		{"site": "example.com", "node": "edge-1", "period_start": "` + period + `", "requests": 100, "blocked": 7},
+		{"site": "unknown.com", "node": "edge-1", "period_start": "` + period + `", "requests": 50}
+	]`
+
+	// Sending the same report twice must not count it twice
+	for i := 0; i < 2; i++ {
+		rr := report(body)
+		if rr.Code != http.StatusAccepted {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusAccepted)
+		}
+
+		var result edgeReportResult
+		if err := json.Unmarshal(rr.Body.Bytes(), &result); err != nil {
+			t.Fatal(err)
+		}
+		if result.Accepted != 1 || result.Skipped != 1 {
+			t.Errorf("unexpected result: %+v", result)
+		}
+	}
+
+	site, err := siteRepo.FindActiveByDomain("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	totals, err := trafficRepo.GetTotals([]int64{site.ID}, time.Now().Add(-24*time.Hour), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *totals != (models.TrafficTotals{Requests: 100, Blocked: 7}) {
+		t.Errorf("unexpected totals: %+v", totals)
+	}
+
+	// More blocked than total requests is rejected
+	rr := report(`[{"site": "example.com", "node": "edge-1", "period_start": "` + period + `", "requests": 1, "blocked": 2}]`)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+	}
+}
+
+func TestIngestRequestsAndAnalytics(t *testing.T) {
+	db := newTestDB(t)
+	siteRepo, threatService := seedThreats(t, db)
+	analyticsService := service.NewAnalyticsService(repository.NewAnalyticsRepository(db))
+	edgeHandler := NewEdgeHandler(siteRepo, threatService, nil, analyticsService)
+
+	body := `[
+		{"site": "example.com", "path": "/login?next=/admin", "status_code": 401, "user_agent": "curl/8.0", "country": "fr", "asn": 13335, "sample_rate": 0.1},
+		{"site": "example.com", "path": "/login", "status_code": 200, "referrer": "https://Search.example.org/q?x=1"},
+		{"site": "example.com", "path": "/", "status_code": 200, "user_agent": "curl/8.0"}
+	]`
+	req, err := http.NewRequest("POST", "/api/edge/requests", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	edgeHandler.IngestRequests(rr, req)
+
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("handler returned wrong status code: got %v want %v: %s", rr.Code, http.StatusAccepted, rr.Body.String())
+	}
+
+	analyticsService.Flush(time.Now())
+
+	site, err := siteRepo.FindActiveByDomain("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := NewAnalyticsHandler(newTestAuthorizer(db), analyticsService)
+	req, err = http.NewRequest("GET", "/api/sites/1/analytics?limit=5", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr = httptest.NewRecorder()
+	handler.GetSiteAnalytics(rr, withSiteID(req, site))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v: %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+
+	var analytics models.SiteAnalytics
+	if err := json.Unmarshal(rr.Body.Bytes(), &analytics); err != nil {
+		t.Fatal(err)
+	}
+
+	// The sampled request counts ten times, query strings are dropped
+	want := map[models.AnalyticsDimension]models.TopValue{
+		models.PathDimension:       {Value: "/login", Count: 11},
+		models.StatusCodeDimension: {Value: "401", Count: 10},
+		models.UserAgentDimension:  {Value: "curl/8.0", Count: 11},
+		models.ReferrerDimension:   {Value: "search.example.org", Count: 1},
+		models.CountryDimension:    {Value: "FR", Count: 10},
+		models.ASNDimension:        {Value: "AS13335", Count: 10},
+	}
+	for dimension, top := range want {
+		values := analytics.Top[dimension]
+		if len(values) == 0 || *values[0] != top {
+			t.Errorf("unexpected top %s: %+v", dimension, values)
+		}
+	}
+}
+
+// withSiteID authenticates a request as the owner of site and sets the {id} URL parameter
+func withSiteID(req *http.Request, site *models.Site) *http.Request {
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add("id", strconv.FormatInt(site.ID, 10))
+
+	ctx := auth.WithUserID(req.Context(), site.UserID)
+	ctx = context.WithValue(ctx, chi.RouteCtxKey, routeCtx)
+	return req.WithContext(ctx)
+}
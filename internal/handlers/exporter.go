@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+
+	"egide-server/internal/auth"
+	"egide-server/internal/authz"
+	"egide-server/internal/service"
+	"egide-server/internal/telemetry"
+)
+
+// ExporterHandler exposes the data of an account's sites to Prometheus
+type ExporterHandler struct {
+	authorizer      *authz.Authorizer
+	exporterService *service.ExporterService
+}
+
+// NewExporterHandler creates a new exporter handler
+func NewExporterHandler(authorizer *authz.Authorizer, exporterService *service.ExporterService) *ExporterHandler {
+	return &ExporterHandler{
+		authorizer:      authorizer,
+		exporterService: exporterService,
+	}
+}
+
+// GetPrometheusMetrics handles GET /api/metrics/prometheus
+func (h *ExporterHandler) GetPrometheusMetrics(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	sites, err := h.authorizer.Sites(userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch sites", http.StatusInternalServerError)
+		return
+	}
+
+	registry, err := h.exporterService.Export(sites)
+	if err != nil {
+		http.Error(w, "Error exporting metrics: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var b bytes.Buffer
+	registry.WriteText(&b)
+
+	w.Header().Set("Content-Type", telemetry.ContentType)
+	w.Header().Set("Cache-Control", "no-store")
+	if _, err := w.Write(b.Bytes()); err != nil {
+		log.Printf("Failed to write metrics: %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"egide-server/internal/auth"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+	"egide-server/internal/service"
+)
+
+func TestGetPrometheusMetrics(t *testing.T) {
+	db := newTestDB(t)
+	siteRepo, _ := seedThreats(t, db)
+	monitorRepo := repository.NewMonitorRepository(db)
+	healthCheckRepo := repository.NewHealthCheckRepository(db)
+
+	site, err := siteRepo.FindActiveByDomain("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	monitor := &models.Monitor{SiteID: site.ID, Name: "Home", URL: "https://example.com", Enabled: true}
+	monitor.ID, err = monitorRepo.Create(monitor)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An old successful check followed by a recent failure
+	for _, check := range []*models.HealthCheck{
+		{MonitorID: &monitor.ID, Timestamp: time.Now().Add(-time.Hour), ResponseTimeMs: 80, Success: true},
+		{MonitorID: &monitor.ID, Timestamp: time.Now().Add(-time.Minute), ResponseTimeMs: 250, Success: false},
+	} {
+		if _, err := healthCheckRepo.Create(check); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	handler := NewExporterHandler(newTestAuthorizer(db), service.NewExporterService(
+		monitorRepo,
+		healthCheckRepo,
+		repository.NewThreatRepository(db),
+		service.NewMaintenanceService(repository.NewMaintenanceRepository(db)),
+	))
+
+	req, err := http.NewRequest("GET", "/api/metrics/prometheus", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = req.WithContext(auth.WithUserID(context.Background(), site.UserID))
+
+	rr := httptest.NewRecorder()
+	handler.GetPrometheusMetrics(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	if !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("unexpected content type %q", rr.Header().Get("Content-Type"))
+	}
+
+	body := rr.Body.String()
+	expected := []string{
+		`egide_site_up{site="example.com"} 0`,
+		`egide_monitor_up{site="example.com",monitor_id="1",monitor="Home"} 0`,
+		`egide_monitor_response_time_seconds{site="example.com",monitor_id="1",monitor="Home"} 0.25`,
+		`egide_site_maintenance{site="example.com"} 0`,
+		`egide_site_threats_total{site="example.com",nature="ai_crawler",status="blocked"} 2`,
+		`egide_site_threats_total{site="example.com",nature="sql_injection",status="blocked"} 1`,
+		`egide_site_threats_total{site="another-example.com",nature="ddos",status="blocked"} 1`,
+		`egide_site_config_version{site="example.com",protection_mode="simple"} `,
+	}
+	for _, line := range expected {
+		if !strings.Contains(body, line) {
+			t.Errorf("expected %q in:\n%s", line, body)
+		}
+	}
+
+	// Sites without monitoring data have no up gauge rather than a misleading value
+	if strings.Contains(body, `egide_site_up{site="another-example.com"}`) {
+		t.Errorf("unexpected up gauge for a site without checks:\n%s", body)
+	}
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"egide-server/internal/auth"
+	"egide-server/internal/config"
+	"egide-server/internal/models"
+	"egide-server/internal/service"
+)
+
+// IdentityHandler handles the identities linked to the current user
+type IdentityHandler struct {
+	providers           map[string]auth.Provider
+	stateCookie         *auth.StateCookie
+	linkTokens          *auth.LinkTokens
+	identityService     *service.IdentityService
+	notificationService *service.NotificationService
+	config              *config.Config
+	validator           *validator.Validate
+}
+
+// NewIdentityHandler creates a new identity handler
+func NewIdentityHandler(
+	providers map[string]auth.Provider,
+	stateCookie *auth.StateCookie,
+	linkTokens *auth.LinkTokens,
+	identityService *service.IdentityService,
+	notificationService *service.NotificationService,
+	config *config.Config,
+) *IdentityHandler {
+	return &IdentityHandler{
+		providers:           providers,
+		stateCookie:         stateCookie,
+		linkTokens:          linkTokens,
+		identityService:     identityService,
+		notificationService: notificationService,
+		config:              config,
+		validator:           validator.New(),
+	}
+}
+
+// ListIdentities handles GET /api/users/me/identities
+func (h *IdentityHandler) ListIdentities(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	identities, err := h.identityService.List(userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch identities", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(identities)
+}
+
+// LinkIdentity handles POST /api/users/me/identities/{provider}?return_to=/path
+//
+// For OAuth providers, it sets the OAuth state cookie and returns the
+// authorization URL of the provider, so the account is attached to the current
+// user only when the callback reaches the browser that started the link. For
+// email, it sends a verification link to the address of the body.
+func (h *IdentityHandler) LinkIdentity(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	name := chi.URLParam(r, "provider")
+	if name == auth.ProviderEmail {
+		h.linkEmail(w, r, userID)
+		return
+	}
+
+	provider, ok := h.providers[name]
+	if !ok {
+		http.Error(w, "Unknown identity provider", http.StatusNotFound)
+		return
+	}
+
+	link := &auth.Link{
+		UserID:    userID,
+		SessionID: auth.SessionIDFromContext(r.Context()),
+		Provider:  provider.Name(),
+	}
+	state, err := h.stateCookie.Issue(w, provider.Name(), r.URL.Query().Get("return_to"), link)
+	if err != nil {
+		http.Error(w, "Failed to start link", http.StatusInternalServerError)
+		return
+	}
+
+	authURL, err := provider.AuthURL(r.Context(), state)
+	if err != nil {
+		http.Error(w, "Identity provider unavailable: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]string{
+		"url": authURL,
+	})
+}
+
+func (h *IdentityHandler) linkEmail(w http.ResponseWriter, r *http.Request, userID int64) {
+	var input models.EmailIdentityInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	token, err := h.linkTokens.Issue(&auth.Link{
+		UserID:    userID,
+		SessionID: auth.SessionIDFromContext(r.Context()),
+		Provider:  auth.ProviderEmail,
+		Email:     input.Email,
+	}, auth.EmailLinkTTL)
+	if err != nil {
+		http.Error(w, "Failed to start link", http.StatusInternalServerError)
+		return
+	}
+
+	link := fmt.Sprintf("%s/auth/verify-email?token=%s", h.config.FrontendURL, url.QueryEscape(token))
+	err = h.notificationService.SendEmail(input.Email, &service.Notification{
+		Title: "Confirm your email address",
+		Message: fmt.Sprintf("Open the following link within %s to link %s to your Egide account:\n\n%s\n\nIf you didn't ask for it, ignore this email.",
+			auth.EmailLinkTTL, input.Email, link),
+		Time: time.Now(),
+	})
+	if err != nil {
+		log.Printf("Failed to send verification email to %s: %v", input.Email, err)
+		http.Error(w, "Failed to send verification email", http.StatusBadGateway)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// UnlinkIdentity handles DELETE /api/users/me/identities/{identityID}
+func (h *IdentityHandler) UnlinkIdentity(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	identityID, err := strconv.ParseInt(chi.URLParam(r, "identityID"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid identity ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.identityService.Unlink(userID, identityID); err != nil {
+		if errors.Is(err, service.ErrLastIdentity) {
+			http.Error(w, "Cannot unlink the last identity you can sign in with", http.StatusConflict)
+			return
+		}
+		http.Error(w, "Identity not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
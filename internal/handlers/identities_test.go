@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"egide-server/internal/auth"
+	"egide-server/internal/config"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+	"egide-server/internal/service"
+)
+
+func TestLinkAndUnlinkIdentities(t *testing.T) {
+	db := newTestDB(t)
+	cfg := &config.Config{FrontendURL: "http://localhost:3000"}
+
+	userRepo := repository.NewUserRepository(db)
+	identityRepo := repository.NewIdentityRepository(db)
+	identityService := service.NewIdentityService(identityRepo)
+	linkTokens := auth.NewLinkTokens("secret")
+	providers := map[string]auth.Provider{
+		auth.ProviderGitLab: &stubProvider{name: auth.ProviderGitLab, identity: &models.Identity{Provider: auth.ProviderGitLab, Subject: "10", Username: "jane"}},
+	}
+
+	userID, err := userRepo.CreateWithIdentity(&models.User{Username: "jane"}, &models.Identity{Provider: auth.ProviderGitHub, Subject: "1", Username: "jane"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherID, err := userRepo.CreateWithIdentity(&models.User{Username: "john"}, &models.Identity{Provider: auth.ProviderGitHub, Subject: "2", Username: "john"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyCipher, err := auth.NewKeyCipher("secret", auth.PurposeSigningKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signingKeyService := service.NewSigningKeyService(repository.NewSigningKeyRepository(db), keyCipher, auth.AlgorithmEdDSA, time.Hour)
+	sessionService := service.NewSessionService(repository.NewSessionRepository(db), auth.NewTokenService(signingKeyService))
+	stateCookie := auth.NewStateCookie("secret", false)
+	identityHandler := NewIdentityHandler(providers, stateCookie, linkTokens, identityService, service.NewNotificationService(cfg), cfg)
+	authHandler := NewAuthHandler(providers, sessionService, stateCookie, linkTokens, userRepo, identityRepo, identityService, cfg)
+
+	session, err := sessionService.Start(userID, "test", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := chi.NewRouter()
+	r.Route("/api", func(r chi.Router) {
+		r.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ctx := auth.WithSessionID(auth.WithUserID(r.Context(), userID), session.SessionID)
+				next.ServeHTTP(w, r.WithContext(ctx))
+			})
+		})
+		r.Get("/users/me/identities", identityHandler.ListIdentities)
+		r.Post("/users/me/identities/{provider}", identityHandler.LinkIdentity)
+		r.Delete("/users/me/identities/{identityID}", identityHandler.UnlinkIdentity)
+	})
+	r.Get("/auth/{provider}/callback", authHandler.Callback)
+	r.Post("/auth/email/verify", authHandler.VerifyEmail)
+
+	serve := func(method, target, body string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Linking an OAuth account returns the authorization URL of the provider
+	// and keeps the state in a cookie of the browser that asked for it
+	startLink := func() (string, *http.Cookie) {
+		rr := serve("POST", "/api/users/me/identities/gitlab?return_to=/settings", "")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("link: status %d: %s", rr.Code, rr.Body.String())
+		}
+		var link struct {
+			URL string `json:"url"`
+		}
+		json.NewDecoder(rr.Body).Decode(&link)
+		authURL, err := url.Parse(link.URL)
+		if err != nil || authURL.Host != "idp.test" || strings.Contains(link.URL, "token") {
+			t.Fatalf("link URL = %q", link.URL)
+		}
+		cookies := rr.Result().Cookies()
+		if len(cookies) != 1 || cookies[0].Name != auth.StateCookieName {
+			t.Fatalf("link cookies = %v", cookies)
+		}
+		return "/auth/gitlab/callback?code=abc&state=" + authURL.Query().Get("state"), cookies[0]
+	}
+
+	// Someone holding the callback URL without the cookie can't complete it
+	callback, cookie := startLink()
+	if rr := serve("GET", callback, ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("callback without state cookie: status %d", rr.Code)
+	}
+
+	// The callback attaches the account to the user who started the link
+	rr := serve("GET", callback, "", cookie)
+	if rr.Code != http.StatusTemporaryRedirect || !strings.Contains(rr.Header().Get("Location"), "#linked=gitlab") {
+		t.Fatalf("link callback: status %d, location %q", rr.Code, rr.Header().Get("Location"))
+	}
+	if serve("POST", "/api/users/me/identities/unknown", "").Code != http.StatusNotFound {
+		t.Error("expected 404 for an unknown provider")
+	}
+	if _, err := identityService.Link(otherID, &models.Identity{Provider: auth.ProviderGitLab, Subject: "10"}); err != service.ErrIdentityInUse {
+		t.Errorf("linking an identity of another user: err = %v", err)
+	}
+
+	// A link can't outlive the session that started it
+	callback, cookie = startLink()
+	if err := sessionService.Revoke(userID, session.SessionID); err != nil {
+		t.Fatal(err)
+	}
+	if rr := serve("GET", callback, "", cookie); rr.Code != http.StatusUnauthorized {
+		t.Errorf("callback after the session was revoked: status %d", rr.Code)
+	}
+
+	// Email addresses are verified by a link sent to them, which also dies with its session
+	emailLink := &auth.Link{UserID: userID, SessionID: session.SessionID, Provider: auth.ProviderEmail, Email: "Jane@Example.com"}
+	token, err := linkTokens.Issue(emailLink, auth.EmailLinkTTL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rr := serve("POST", "/auth/email/verify", `{"token": "`+token+`"}`); rr.Code != http.StatusUnauthorized {
+		t.Errorf("verify email after the session was revoked: status %d", rr.Code)
+	}
+
+	current, err := sessionService.Start(userID, "test", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	emailLink.SessionID = current.SessionID
+	token, err = linkTokens.Issue(emailLink, auth.EmailLinkTTL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rr := serve("POST", "/auth/email/verify", `{"token": "`+token+`"}`); rr.Code != http.StatusOK {
+		t.Fatalf("verify email: status %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := serve("POST", "/auth/email/verify", `{"token": "forged"}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("verify forged email token: status %d", rr.Code)
+	}
+
+	rr = serve("GET", "/api/users/me/identities", "")
+	var identities []*models.Identity
+	json.NewDecoder(rr.Body).Decode(&identities)
+	if len(identities) != 3 {
+		t.Fatalf("got %d identities; want 3", len(identities))
+	}
+	if identities[2].Provider != auth.ProviderEmail || identities[2].Subject != "jane@example.com" {
+		t.Errorf("email identity = %+v", identities[2])
+	}
+
+	unlink := func(identity *models.Identity) int {
+		return serve("DELETE", "/api/users/me/identities/"+strconv.FormatInt(identity.ID, 10), "").Code
+	}
+
+	// The email address alone can't sign in, so the last OAuth identity stays
+	if code := unlink(identities[0]); code != http.StatusNoContent {
+		t.Errorf("unlink github: status %d", code)
+	}
+	if code := unlink(identities[1]); code != http.StatusConflict {
+		t.Errorf("unlink last sign-in identity: status %d; want %d", code, http.StatusConflict)
+	}
+	if code := unlink(identities[2]); code != http.StatusNoContent {
+		t.Errorf("unlink email: status %d", code)
+	}
+
+	// Identities of other users can't be unlinked
+	others, _ := identityService.List(otherID)
+	if code := unlink(others[0]); code != http.StatusNotFound {
+		t.Errorf("unlink identity of another user: status %d", code)
+	}
+}
+
+// stubProvider identifies every login as the same account
+type stubProvider struct {
+	name     string
+	identity *models.Identity
+}
+
+func (p *stubProvider) Name() string {
+	return p.name
+}
+
+func (p *stubProvider) AuthURL(ctx context.Context, state *auth.OAuthState) (string, error) {
+	return "https://idp.test/authorize?state=" + url.QueryEscape(state.State), nil
+}
+
+func (p *stubProvider) Identify(ctx context.Context, code string, state *auth.OAuthState) (*models.Identity, error) {
+	identity := *p.identity
+	return &identity, nil
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"egide-server/internal/auth"
+	"egide-server/internal/authz"
+	"egide-server/internal/models"
+	"egide-server/internal/service"
+)
+
+// InvitationHandler handles the email invitations to join organizations
+type InvitationHandler struct {
+	invitationService *service.InvitationService
+	authorizer        *authz.Authorizer
+	validator         *validator.Validate
+}
+
+// NewInvitationHandler creates a new invitation handler
+func NewInvitationHandler(invitationService *service.InvitationService, authorizer *authz.Authorizer) *InvitationHandler {
+	return &InvitationHandler{
+		invitationService: invitationService,
+		authorizer:        authorizer,
+		validator:         validator.New(),
+	}
+}
+
+// ListInvitations handles GET /api/organizations/{orgID}/invitations
+func (h *InvitationHandler) ListInvitations(w http.ResponseWriter, r *http.Request) {
+	org, _, ok := loadOrganization(w, r, h.authorizer, authz.ManageMembers)
+	if !ok {
+		return
+	}
+
+	invitations, err := h.invitationService.List(org.ID)
+	if err != nil {
+		http.Error(w, "Failed to fetch invitations", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(invitations)
+}
+
+// CreateInvitation handles POST /api/organizations/{orgID}/invitations
+func (h *InvitationHandler) CreateInvitation(w http.ResponseWriter, r *http.Request) {
+	org, userID, ok := loadOrganization(w, r, h.authorizer, authz.ManageMembers)
+	if !ok {
+		return
+	}
+
+	var input models.InvitationInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	invitation, err := h.invitationService.Invite(org, userID, &input)
+	if err != nil {
+		writeOrganizationError(w, err, "Failed to create invitation")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(invitation)
+}
+
+// ResendInvitation handles POST /api/organizations/{orgID}/invitations/{invitationID}/resend
+func (h *InvitationHandler) ResendInvitation(w http.ResponseWriter, r *http.Request) {
+	org, _, ok := loadOrganization(w, r, h.authorizer, authz.ManageMembers)
+	if !ok {
+		return
+	}
+
+	invitationID, err := strconv.ParseInt(chi.URLParam(r, "invitationID"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid invitation ID", http.StatusBadRequest)
+		return
+	}
+
+	invitation, err := h.invitationService.Resend(org, invitationID)
+	if err != nil {
+		writeOrganizationError(w, err, "Failed to resend invitation")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(invitation)
+}
+
+// RevokeInvitation handles DELETE /api/organizations/{orgID}/invitations/{invitationID}
+func (h *InvitationHandler) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	org, _, ok := loadOrganization(w, r, h.authorizer, authz.ManageMembers)
+	if !ok {
+		return
+	}
+
+	invitationID, err := strconv.ParseInt(chi.URLParam(r, "invitationID"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid invitation ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.invitationService.Revoke(org, invitationID); err != nil {
+		writeOrganizationError(w, err, "Failed to revoke invitation")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AcceptInvitation handles POST /api/invitations/accept
+func (h *InvitationHandler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var input models.InvitationAcceptInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	org, err := h.invitationService.Accept(userID, input.Token)
+	if err != nil {
+		writeOrganizationError(w, err, "Failed to accept invitation")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(org)
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"egide-server/internal/service"
+)
+
+// JWKSHandler publishes the public keys of access tokens, so that other
+// services can verify them without sharing a secret
+type JWKSHandler struct {
+	signingKeyService *service.SigningKeyService
+}
+
+// NewJWKSHandler creates a new JWKS handler
+func NewJWKSHandler(signingKeyService *service.SigningKeyService) *JWKSHandler {
+	return &JWKSHandler{
+		signingKeyService: signingKeyService,
+	}
+}
+
+// GetJWKS handles GET /.well-known/jwks.json
+func (h *JWKSHandler) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	set, err := h.signingKeyService.JWKS()
+	if err != nil {
+		http.Error(w, "Failed to fetch signing keys", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(set)
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"egide-server/internal/authz"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+)
+
+// MaintenanceHandler handles the maintenance windows of a site
+type MaintenanceHandler struct {
+	authorizer      *authz.Authorizer
+	maintenanceRepo *repository.MaintenanceRepository
+	validator       *validator.Validate
+}
+
+// NewMaintenanceHandler creates a new maintenance handler
+func NewMaintenanceHandler(authorizer *authz.Authorizer, maintenanceRepo *repository.MaintenanceRepository) *MaintenanceHandler {
+	return &MaintenanceHandler{
+		authorizer:      authorizer,
+		maintenanceRepo: maintenanceRepo,
+		validator:       validator.New(),
+	}
+}
+
+// ListWindows handles GET /api/sites/{id}/maintenance
+func (h *MaintenanceHandler) ListWindows(w http.ResponseWriter, r *http.Request) {
+	site, ok := loadSite(w, r, h.authorizer)
+	if !ok {
+		return
+	}
+
+	windows, err := h.maintenanceRepo.FindBySiteID(site.ID)
+	if err != nil {
+		http.Error(w, "Failed to fetch maintenance windows", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(windows)
+}
+
+// CreateWindow handles POST /api/sites/{id}/maintenance
+func (h *MaintenanceHandler) CreateWindow(w http.ResponseWriter, r *http.Request) {
+	site, ok := loadSite(w, r, h.authorizer)
+	if !ok {
+		return
+	}
+
+	window := &models.MaintenanceWindow{SiteID: site.ID}
+	if !h.applyInput(w, r, window) {
+		return
+	}
+
+	windowID, err := h.maintenanceRepo.Create(window)
+	if err != nil {
+		http.Error(w, "Failed to create maintenance window: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	window, err = h.maintenanceRepo.FindByID(windowID)
+	if err != nil {
+		http.Error(w, "Maintenance window created but failed to fetch", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(window)
+}
+
+// UpdateWindow handles PUT /api/sites/{id}/maintenance/{windowID}
+func (h *MaintenanceHandler) UpdateWindow(w http.ResponseWriter, r *http.Request) {
+	window, ok := h.ownedWindow(w, r)
+	if !ok {
+		return
+	}
+
+	if !h.applyInput(w, r, window) {
+		return
+	}
+
+	if err := h.maintenanceRepo.Update(window); err != nil {
+		http.Error(w, "Failed to update maintenance window: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(window)
+}
+
+// DeleteWindow handles DELETE /api/sites/{id}/maintenance/{windowID}
+func (h *MaintenanceHandler) DeleteWindow(w http.ResponseWriter, r *http.Request) {
+	window, ok := h.ownedWindow(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.maintenanceRepo.Delete(window.ID); err != nil {
+		http.Error(w, "Failed to delete maintenance window: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// applyInput decodes and validates a maintenance window payload
+func (h *MaintenanceHandler) applyInput(w http.ResponseWriter, r *http.Request, window *models.MaintenanceWindow) bool {
+	var input models.MaintenanceWindowInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return false
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return false
+	}
+
+	if input.TimeZone == "" {
+		input.TimeZone = "UTC"
+	}
+	if _, err := time.LoadLocation(input.TimeZone); err != nil {
+		http.Error(w, "Validation error: unknown time zone "+input.TimeZone, http.StatusBadRequest)
+		return false
+	}
+
+	if input.Recurrence != models.WeeklyMaintenance {
+		input.Weekdays = nil
+	}
+
+	window.Name = input.Name
+	window.Recurrence = input.Recurrence
+	window.StartsAt = input.StartsAt
+	window.DurationMinutes = input.DurationMinutes
+	window.Weekdays = input.Weekdays
+	window.TimeZone = input.TimeZone
+
+	return true
+}
+
+func (h *MaintenanceHandler) ownedWindow(w http.ResponseWriter, r *http.Request) (*models.MaintenanceWindow, bool) {
+	site, ok := loadSite(w, r, h.authorizer)
+	if !ok {
+		return nil, false
+	}
+
+	windowID, err := strconv.ParseInt(chi.URLParam(r, "windowID"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid maintenance window ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	window, err := h.maintenanceRepo.FindByID(windowID)
+	if err != nil || window.SiteID != site.ID {
+		http.Error(w, "Maintenance window not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	return window, true
+}
@@ -2,21 +2,40 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"egide-server/internal/auth"
+	"egide-server/internal/authz"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
 	"egide-server/internal/service"
 )
 
+// DefaultLatencyRange is used by the latency endpoint when no range is given
+const DefaultLatencyRange = 24 * time.Hour
+
+// DefaultKpiRange is used by the KPI endpoint when no range is given
+const DefaultKpiRange = 30 * 24 * time.Hour
+
+// MaxMetricsRange bounds the time range of metrics queries
+const MaxMetricsRange = 366 * 24 * time.Hour
+
 // MetricsHandler handles metrics-related requests
 type MetricsHandler struct {
 	metricsService *service.MetricsService
+	authorizer     *authz.Authorizer
+	monitorRepo    *repository.MonitorRepository
 }
 
 // NewMetricsHandler creates a new metrics handler
-func NewMetricsHandler(metricsService *service.MetricsService) *MetricsHandler {
+func NewMetricsHandler(metricsService *service.MetricsService, authorizer *authz.Authorizer, monitorRepo *repository.MonitorRepository) *MetricsHandler {
 	return &MetricsHandler{
 		metricsService: metricsService,
+		authorizer:     authorizer,
+		monitorRepo:    monitorRepo,
 	}
 }
 
@@ -29,8 +48,50 @@ func (h *MetricsHandler) GetKpi(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	start, end, err := parseTimeRange(r, DefaultKpiRange)
+	if err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	compare := r.URL.Query().Get("compare")
+	switch compare {
+	case "":
+		compare = service.ComparePrevious
+	case service.ComparePrevious, service.CompareYearAgo, service.CompareNone:
+	default:
+		http.Error(w, "Validation error: compare must be previous, year_ago or none", http.StatusBadRequest)
+		return
+	}
+
+	sites, ok := h.selectedSites(w, r, userID)
+	if !ok {
+		return
+	}
+
+	siteIDs := make([]int64, 0, len(sites))
+	monitorIDs := []int64{}
+	for _, site := range sites {
+		siteIDs = append(siteIDs, site.ID)
+
+		monitors, err := h.monitorRepo.FindBySiteID(site.ID)
+		if err != nil {
+			http.Error(w, "Failed to fetch monitors", http.StatusInternalServerError)
+			return
+		}
+		for _, monitor := range monitors {
+			monitorIDs = append(monitorIDs, monitor.ID)
+		}
+	}
+
 	// Get KPI data for the user's sites
-	kpiData, err := h.metricsService.GetKpiData(userID)
+	kpiData, err := h.metricsService.GetKpiData(&service.KpiQuery{
+		From:       start,
+		To:         end,
+		Compare:    compare,
+		SiteIDs:    siteIDs,
+		MonitorIDs: monitorIDs,
+	})
 	if err != nil {
 		http.Error(w, "Error fetching KPI data: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -40,3 +101,162 @@ func (h *MetricsHandler) GetKpi(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(kpiData)
 }
+
+// selectedSites returns the site given by ?site_id=, or every site of the
+// user when it's missing
+func (h *MetricsHandler) selectedSites(w http.ResponseWriter, r *http.Request, userID int64) ([]*models.Site, bool) {
+	param := r.URL.Query().Get("site_id")
+	if param == "" {
+		sites, err := h.authorizer.Sites(userID)
+		if err != nil {
+			http.Error(w, "Failed to fetch sites", http.StatusInternalServerError)
+			return nil, false
+		}
+		return sites, true
+	}
+
+	siteID, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid site ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	site, err := h.authorizer.Site(userID, siteID, authz.ViewSite)
+	if err != nil {
+		http.Error(w, "Site not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	return []*models.Site{site}, true
+}
+
+// GetLatency handles GET /api/metrics/latency
+func (h *MetricsHandler) GetLatency(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	start, end, err := parseTimeRange(r, DefaultLatencyRange)
+	if err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var monitors []*models.Monitor
+	if param := r.URL.Query().Get("monitor_id"); param != "" {
+		monitorID, err := strconv.ParseInt(param, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid monitor ID", http.StatusBadRequest)
+			return
+		}
+
+		monitor, err := h.monitorRepo.FindByID(monitorID)
+		if err != nil {
+			http.Error(w, "Monitor not found", http.StatusNotFound)
+			return
+		}
+
+		if _, err := h.authorizer.Site(userID, monitor.SiteID, authz.ViewSite); err != nil {
+			http.Error(w, "Monitor not found", http.StatusNotFound)
+			return
+		}
+
+		monitors = []*models.Monitor{monitor}
+	} else {
+		sites, err := h.authorizer.Sites(userID)
+		if err != nil {
+			http.Error(w, "Failed to fetch sites", http.StatusInternalServerError)
+			return
+		}
+
+		for _, site := range sites {
+			siteMonitors, err := h.monitorRepo.FindBySiteID(site.ID)
+			if err != nil {
+				http.Error(w, "Failed to fetch monitors", http.StatusInternalServerError)
+				return
+			}
+			monitors = append(monitors, siteMonitors...)
+		}
+	}
+
+	latency := make([]*service.LatencyStats, 0, len(monitors))
+	for _, monitor := range monitors {
+		stats, err := h.metricsService.GetLatencyStats(monitor.ID, start, end)
+		if err != nil {
+			http.Error(w, "Error fetching latency data: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		latency = append(latency, stats)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(latency)
+}
+
+// parseTimeRange reads the optional from, to and tz query parameters. from
+// and to are RFC 3339 timestamps or YYYY-MM-DD dates in the tz time zone
+// (UTC by default), a date given as to includes the whole day. to defaults
+// to now and from to defaultRange before to.
+func parseTimeRange(r *http.Request, defaultRange time.Duration) (time.Time, time.Time, error) {
+	query := r.URL.Query()
+
+	loc := time.UTC
+	if tz := query.Get("tz"); tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			return time.Time{}, time.Time{}, errors.New("unknown time zone " + tz)
+		}
+	}
+
+	now := time.Now().In(loc)
+	end := now
+	if param := query.Get("to"); param != "" {
+		t, err := parseTimeParam(param, loc, true)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("to must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+		}
+		end = t
+	}
+
+	start := end.Add(-defaultRange)
+	if param := query.Get("from"); param != "" {
+		t, err := parseTimeParam(param, loc, false)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("from must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+		}
+		start = t
+	}
+
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, errors.New("from must be before to")
+	}
+
+	if start.After(now) {
+		return time.Time{}, time.Time{}, errors.New("from can't be in the future")
+	}
+
+	if end.Sub(start) > MaxMetricsRange {
+		return time.Time{}, time.Time{}, errors.New("time range can't exceed 366 days")
+	}
+
+	return start, end, nil
+}
+
+// parseTimeParam parses an RFC 3339 timestamp or a date in loc. Dates are
+// the start of the day, or the start of the next one with endOfDay.
+func parseTimeParam(value string, loc *time.Location, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.In(loc), nil
+	}
+
+	day, err := time.ParseInLocation("2006-01-02", value, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day, nil
+}
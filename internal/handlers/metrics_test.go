@@ -2,21 +2,108 @@ package handlers
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"egide-server/internal/auth"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
 	"egide-server/internal/service"
 )
 
+// seedHealthChecks creates a site with a monitor and a day of checks in the
+// current and previous KPI periods, every tenth check failing
+func seedHealthChecks(t *testing.T, db *sql.DB) *models.Monitor {
+	userRepo := repository.NewUserRepository(db)
+	siteRepo := repository.NewSiteRepository(db)
+	monitorRepo := repository.NewMonitorRepository(db)
+	healthCheckRepo := repository.NewHealthCheckRepository(db)
+
+	userID, err := userRepo.CreateWithIdentity(&models.User{Username: "test"}, &models.Identity{Provider: "github", Subject: "1", Username: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	siteID, err := siteRepo.Create(&models.Site{
+		UserID:         userID,
+		Domain:         "example.com",
+		ProtectionMode: models.SimpleProtection,
+		Active:         true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	monitor := &models.Monitor{SiteID: siteID, Name: "Home", URL: "https://example.com", Enabled: true}
+	monitor.ID, err = monitorRepo.Create(monitor)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	for _, start := range []time.Time{now.Add(-24 * time.Hour), now.AddDate(0, 0, -45)} {
+		for i := 0; i < 100; i++ {
+			check := &models.HealthCheck{
+				MonitorID:      &monitor.ID,
+				Timestamp:      start.Add(time.Duration(i) * time.Minute),
+				ResponseTimeMs: 100 + i,
+				Success:        i%10 != 0,
+			}
+			if !check.Success {
+				check.ResponseTimeMs = int(service.RequestTimeout / time.Millisecond)
+			}
+			if _, err := healthCheckRepo.Create(check); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	return monitor
+}
+
+// seedTraffic records edge counters for the seeded site in the current and previous KPI periods
+func seedTraffic(t *testing.T, db *sql.DB, monitor *models.Monitor) {
+	trafficService := service.NewTrafficService(repository.NewTrafficRepository(db))
+	site := &models.Site{ID: monitor.SiteID}
+
+	now := time.Now()
+	inputs := []*models.TrafficInput{
+		{Node: "edge-1", PeriodStart: now.Add(-2 * time.Hour), Requests: 1000, Blocked: 40, Cached: 300, Bytes: 1 << 20},
+		{Node: "edge-2", PeriodStart: now.Add(-2 * time.Hour), Requests: 500, Blocked: 10, Challenged: 5},
+		{Node: "edge-1", PeriodStart: now.AddDate(0, 0, -45), Requests: 1200, Blocked: 25},
+	}
+	for _, input := range inputs {
+		if err := trafficService.Record(site, input); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func newTestMetricsHandler(db *sql.DB) *MetricsHandler {
+	healthCheckRepo := repository.NewHealthCheckRepository(db)
+	rollupService := service.NewRollupService(healthCheckRepo, repository.NewRollupRepository(db))
+
+	trafficService := service.NewTrafficService(repository.NewTrafficRepository(db))
+
+	return NewMetricsHandler(
+		service.NewMetricsService(healthCheckRepo, rollupService, trafficService),
+		newTestAuthorizer(db),
+		repository.NewMonitorRepository(db),
+	)
+}
+
 func TestGetKpi(t *testing.T) {
-	// Create metrics service
-	metricsService := service.NewMetricsService()
+	db := newTestDB(t)
+	seedTraffic(t, db, seedHealthChecks(t, db))
 
 	// Create handler with service
-	handler := NewMetricsHandler(metricsService)
+	handler := newTestMetricsHandler(db)
 
 	// Create request
 	req, err := http.NewRequest("GET", "/api/metrics/kpi", nil)
@@ -25,7 +112,7 @@ func TestGetKpi(t *testing.T) {
 	}
 
 	// Add user ID to context
-	ctx := auth.WithUserID(context.Background(), 123)
+	ctx := auth.WithUserID(context.Background(), 1)
 	req = req.WithContext(ctx)
 
 	// Create response recorder
@@ -63,6 +150,19 @@ func TestGetKpi(t *testing.T) {
 		t.Errorf("blockedThreats.change should not be nil")
 	}
 
+	// Counters come from the edge reports: 1500 requests vs 1200, 50 blocked vs 25
+	if kpiData.TotalRequests.Value != float64(1500) || *kpiData.TotalRequests.Change != 25 {
+		t.Errorf("unexpected totalRequests: %v (%v%%)", kpiData.TotalRequests.Value, *kpiData.TotalRequests.Change)
+	}
+
+	if kpiData.BlockedThreats.Value != float64(50) || *kpiData.BlockedThreats.Change != 100 {
+		t.Errorf("unexpected blockedThreats: %v (%v%%)", kpiData.BlockedThreats.Value, *kpiData.BlockedThreats.Change)
+	}
+
+	if kpiData.Traffic == nil || kpiData.Traffic.Cached != 300 || kpiData.Traffic.Challenged != 5 {
+		t.Errorf("unexpected traffic totals: %+v", kpiData.Traffic)
+	}
+
 	if _, ok := kpiData.ResponseTime.Value.(string); !ok {
 		t.Errorf("responseTime.value should be a string")
 	}
@@ -71,6 +171,11 @@ func TestGetKpi(t *testing.T) {
 		t.Errorf("responseTime.change should not be nil")
 	}
 
+	// Failed checks must not inflate the average response time
+	if kpiData.ResponseTime.Value != "150ms" {
+		t.Errorf("unexpected responseTime.value: got %v, want 150ms", kpiData.ResponseTime.Value)
+	}
+
 	if _, ok := kpiData.Uptime.Value.(float64); !ok {
 		t.Errorf("uptime.value should be a number")
 	}
@@ -79,3 +184,187 @@ func TestGetKpi(t *testing.T) {
 		t.Errorf("uptime.subvalue should not be nil")
 	}
 }
+
+func TestGetLatency(t *testing.T) {
+	db := newTestDB(t)
+	monitor := seedHealthChecks(t, db)
+	handler := newTestMetricsHandler(db)
+
+	req, err := http.NewRequest("GET", "/api/metrics/latency?from="+url.QueryEscape(time.Now().Add(-30*time.Hour).Format(time.RFC3339)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = req.WithContext(auth.WithUserID(context.Background(), 1))
+
+	rr := httptest.NewRecorder()
+	handler.GetLatency(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var latency []*service.LatencyStats
+	if err := json.Unmarshal(rr.Body.Bytes(), &latency); err != nil {
+		t.Fatalf("could not parse response as JSON: %v", err)
+	}
+
+	if len(latency) != 1 || latency[0].MonitorID != monitor.ID {
+		t.Fatalf("unexpected latency stats: %+v", latency)
+	}
+
+	// 90 successful checks between 101ms and 199ms, the timeouts are ignored
+	stats := latency[0]
+	if stats.Count != 90 || stats.MinMs != 101 || stats.MaxMs != 199 {
+		t.Errorf("unexpected count/min/max: %d/%d/%d", stats.Count, stats.MinMs, stats.MaxMs)
+	}
+
+	if stats.P99Ms != 199 {
+		t.Errorf("unexpected p99: got %d, want 199", stats.P99Ms)
+	}
+}
+
+func TestGetLatencyInvalidRange(t *testing.T) {
+	db := newTestDB(t)
+	handler := newTestMetricsHandler(db)
+
+	req, err := http.NewRequest("GET", "/api/metrics/latency?from=2025-02-01T00:00:00Z&to=2025-01-01T00:00:00Z", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = req.WithContext(auth.WithUserID(context.Background(), 1))
+
+	rr := httptest.NewRecorder()
+	handler.GetLatency(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+	}
+}
+
+func TestGetKpiComparison(t *testing.T) {
+	db := newTestDB(t)
+	seedHealthChecks(t, db)
+	handler := newTestMetricsHandler(db)
+
+	tests := []struct {
+		query      string
+		wantChange bool
+	}{
+		{"compare=previous", true},
+		{"compare=none", false},
+		{"compare=year_ago", false}, // no data a year ago
+	}
+
+	for _, tt := range tests {
+		req, err := http.NewRequest("GET", "/api/metrics/kpi?"+tt.query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req = req.WithContext(auth.WithUserID(context.Background(), 1))
+
+		rr := httptest.NewRecorder()
+		handler.GetKpi(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("%s: handler returned wrong status code: got %v want %v", tt.query, status, http.StatusOK)
+		}
+
+		var kpiData service.KpiData
+		if err := json.Unmarshal(rr.Body.Bytes(), &kpiData); err != nil {
+			t.Fatalf("%s: could not parse response as JSON: %v", tt.query, err)
+		}
+
+		if got := kpiData.Uptime.Change != nil; got != tt.wantChange {
+			t.Errorf("%s: uptime change present = %v, want %v", tt.query, got, tt.wantChange)
+		}
+
+		if got := kpiData.Period.CompareFrom != nil; got != (tt.query != "compare=none") {
+			t.Errorf("%s: unexpected comparison window %v", tt.query, kpiData.Period.CompareFrom)
+		}
+	}
+}
+
+func TestGetKpiScopedToUser(t *testing.T) {
+	db := newTestDB(t)
+	seedHealthChecks(t, db)
+	handler := newTestMetricsHandler(db)
+
+	// Another user sees none of the checks and can't select the site
+	for query, want := range map[string]int{"": http.StatusOK, "site_id=1": http.StatusNotFound} {
+		req, err := http.NewRequest("GET", "/api/metrics/kpi?"+query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req = req.WithContext(auth.WithUserID(context.Background(), 2))
+
+		rr := httptest.NewRecorder()
+		handler.GetKpi(rr, req)
+
+		if rr.Code != want {
+			t.Errorf("%q: handler returned wrong status code: got %v want %v", query, rr.Code, want)
+		}
+
+		if rr.Code == http.StatusOK && !strings.Contains(rr.Body.String(), "No monitoring data available") {
+			t.Errorf("%q: expected no monitoring data, got %s", query, rr.Body.String())
+		}
+	}
+
+	// Nor does a query without monitors
+	now := time.Now()
+	kpiData, err := handler.metricsService.GetKpiData(&service.KpiQuery{From: now.AddDate(0, 0, -30), To: now, Compare: service.CompareNone})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kpiData.ResponseTime.Value != "0ms" {
+		t.Errorf("KPIs without monitors: responseTime = %v; want 0ms", kpiData.ResponseTime.Value)
+	}
+}
+
+func TestGetKpiValidation(t *testing.T) {
+	db := newTestDB(t)
+	handler := newTestMetricsHandler(db)
+
+	queries := []string{
+		"compare=last_week",
+		"from=2025-02-01&to=2025-01-01",
+		"from=2020-01-01&to=2025-01-01",
+		"from=yesterday",
+		"tz=Mars/Olympus_Mons",
+		"from=" + time.Now().AddDate(0, 0, 2).Format("2006-01-02") + "&to=" + time.Now().AddDate(0, 0, 3).Format("2006-01-02"),
+	}
+
+	for _, query := range queries {
+		req, err := http.NewRequest("GET", "/api/metrics/kpi?"+query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req = req.WithContext(auth.WithUserID(context.Background(), 1))
+
+		rr := httptest.NewRecorder()
+		handler.GetKpi(rr, req)
+
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("%s: handler returned wrong status code: got %v want %v", query, status, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestParseTimeRangeDates(t *testing.T) {
+	req, err := http.NewRequest("GET", "/api/metrics/kpi?from=2025-03-01&to=2025-03-31&tz=Europe/Paris", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start, end, err := parseTimeRange(req, DefaultKpiRange)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Paris is UTC+1 on March 1st and UTC+2 after the switch to summer time
+	if want := time.Date(2025, 2, 28, 23, 0, 0, 0, time.UTC); !start.Equal(want) {
+		t.Errorf("unexpected start: got %s, want %s", start.UTC(), want)
+	}
+	if want := time.Date(2025, 3, 31, 22, 0, 0, 0, time.UTC); !end.Equal(want) {
+		t.Errorf("unexpected end: got %s, want %s", end.UTC(), want)
+	}
+}
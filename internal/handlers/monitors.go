@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"egide-server/internal/authz"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+	"egide-server/internal/service"
+)
+
+type MonitorHandler struct {
+	authorizer  *authz.Authorizer
+	monitorRepo *repository.MonitorRepository
+	validator   *validator.Validate
+}
+
+func NewMonitorHandler(authorizer *authz.Authorizer, monitorRepo *repository.MonitorRepository) *MonitorHandler {
+	return &MonitorHandler{
+		authorizer:  authorizer,
+		monitorRepo: monitorRepo,
+		validator:   validator.New(),
+	}
+}
+
+// ListMonitors handles GET /api/sites/{id}/monitors
+func (h *MonitorHandler) ListMonitors(w http.ResponseWriter, r *http.Request) {
+	site, ok := loadSite(w, r, h.authorizer)
+	if !ok {
+		return
+	}
+
+	monitors, err := h.monitorRepo.FindBySiteID(site.ID)
+	if err != nil {
+		http.Error(w, "Failed to fetch monitors", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(monitors)
+}
+
+// CreateMonitor handles POST /api/sites/{id}/monitors
+func (h *MonitorHandler) CreateMonitor(w http.ResponseWriter, r *http.Request) {
+	site, ok := loadSite(w, r, h.authorizer)
+	if !ok {
+		return
+	}
+
+	var input models.MonitorInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !checkMonitorURL(w, r, site, input.URL) {
+		return
+	}
+
+	monitor := &models.Monitor{
+		SiteID:  site.ID,
+		Name:    input.Name,
+		URL:     input.URL,
+		Enabled: true,
+	}
+	if input.Enabled != nil {
+		monitor.Enabled = *input.Enabled
+	}
+
+	monitorID, err := h.monitorRepo.Create(monitor)
+	if err != nil {
+		http.Error(w, "Failed to create monitor: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	monitor, err = h.monitorRepo.FindByID(monitorID)
+	if err != nil {
+		http.Error(w, "Monitor created but failed to fetch", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(monitor)
+}
+
+// UpdateMonitor handles PUT /api/sites/{id}/monitors/{monitorID}
+func (h *MonitorHandler) UpdateMonitor(w http.ResponseWriter, r *http.Request) {
+	site, monitor, ok := h.ownedMonitor(w, r)
+	if !ok {
+		return
+	}
+
+	var input models.MonitorInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !checkMonitorURL(w, r, site, input.URL) {
+		return
+	}
+
+	monitor.Name = input.Name
+	monitor.URL = input.URL
+	if input.Enabled != nil {
+		monitor.Enabled = *input.Enabled
+	}
+
+	if err := h.monitorRepo.Update(monitor); err != nil {
+		http.Error(w, "Failed to update monitor: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(monitor)
+}
+
+// DeleteMonitor handles DELETE /api/sites/{id}/monitors/{monitorID}
+func (h *MonitorHandler) DeleteMonitor(w http.ResponseWriter, r *http.Request) {
+	_, monitor, ok := h.ownedMonitor(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.monitorRepo.Delete(monitor.ID); err != nil {
+		http.Error(w, "Failed to delete monitor: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ownedMonitor loads the site and monitor from the URL and ensures the monitor belongs to the site
+func (h *MonitorHandler) ownedMonitor(w http.ResponseWriter, r *http.Request) (*models.Site, *models.Monitor, bool) {
+	site, ok := loadSite(w, r, h.authorizer)
+	if !ok {
+		return nil, nil, false
+	}
+
+	monitorID, err := strconv.ParseInt(chi.URLParam(r, "monitorID"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid monitor ID", http.StatusBadRequest)
+		return nil, nil, false
+	}
+
+	monitor, err := h.monitorRepo.FindByID(monitorID)
+	if err != nil || monitor.SiteID != site.ID {
+		http.Error(w, "Monitor not found", http.StatusNotFound)
+		return nil, nil, false
+	}
+
+	return site, monitor, true
+}
+
+// checkMonitorURL ensures a monitor checks the site itself, or one of its
+// subdomains, over HTTP(S) and on public addresses only
+func checkMonitorURL(w http.ResponseWriter, r *http.Request, site *models.Site, rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		http.Error(w, "Validation error: url must be an HTTP or HTTPS URL", http.StatusBadRequest)
+		return false
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	domain := strings.ToLower(site.Domain)
+	if host != domain && !strings.HasSuffix(host, "."+domain) {
+		http.Error(w, "Validation error: url must be on "+site.Domain+" or one of its subdomains", http.StatusBadRequest)
+		return false
+	}
+
+	if err := service.CheckPublicURL(r.Context(), rawURL); err != nil {
+		http.Error(w, "Validation error: url: "+err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"egide-server/internal/auth"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+)
+
+func TestCreateMonitorURL(t *testing.T) {
+	db := newTestDB(t)
+
+	userID, err := repository.NewUserRepository(db).CreateWithIdentity(&models.User{Username: "test"}, &models.Identity{Provider: "github", Subject: "1", Username: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Sites are domains; IP addresses keep the test away from DNS
+	siteRepo := repository.NewSiteRepository(db)
+	sites := map[string]int64{}
+	for _, domain := range []string{"93.184.216.34", "localhost"} {
+		sites[domain], err = siteRepo.Create(&models.Site{UserID: userID, Domain: domain, ProtectionMode: models.SimpleProtection})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	handler := NewMonitorHandler(newTestAuthorizer(db), repository.NewMonitorRepository(db))
+	r := chi.NewRouter()
+	r.Post("/api/sites/{id}/monitors", handler.CreateMonitor)
+
+	tests := []struct {
+		domain string
+		url    string
+		want   int
+	}{
+		{"93.184.216.34", "https://93.184.216.34/health", http.StatusCreated},
+		{"93.184.216.34", "http://169.254.169.254/latest/meta-data/", http.StatusBadRequest},
+		{"93.184.216.34", "https://other.example.com/", http.StatusBadRequest},
+		{"93.184.216.34", "ftp://93.184.216.34/", http.StatusBadRequest},
+		{"localhost", "http://localhost:8080/admin", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		body := `{"name": "Check", "url": "` + tt.url + `"}`
+		req := httptest.NewRequest("POST", "/api/sites/"+strconv.FormatInt(sites[tt.domain], 10)+"/monitors", strings.NewReader(body))
+		req = req.WithContext(auth.WithUserID(req.Context(), userID))
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		if rr.Code != tt.want {
+			t.Errorf("monitor %s of %s: status %d; want %d: %s", tt.url, tt.domain, rr.Code, tt.want, rr.Body.String())
+		}
+	}
+}
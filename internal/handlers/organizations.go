@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"egide-server/internal/auth"
+	"egide-server/internal/authz"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+	"egide-server/internal/service"
+)
+
+// OrganizationHandler handles organizations and their members
+type OrganizationHandler struct {
+	organizationRepo    *repository.OrganizationRepository
+	organizationService *service.OrganizationService
+	authorizer          *authz.Authorizer
+	twoFactorService    *service.TwoFactorService
+	validator           *validator.Validate
+}
+
+// NewOrganizationHandler creates a new organization handler
+func NewOrganizationHandler(
+	organizationRepo *repository.OrganizationRepository,
+	organizationService *service.OrganizationService,
+	authorizer *authz.Authorizer,
+	twoFactorService *service.TwoFactorService,
+) *OrganizationHandler {
+	return &OrganizationHandler{
+		organizationRepo:    organizationRepo,
+		organizationService: organizationService,
+		authorizer:          authorizer,
+		twoFactorService:    twoFactorService,
+		validator:           validator.New(),
+	}
+}
+
+// ListOrganizations handles GET /api/organizations
+func (h *OrganizationHandler) ListOrganizations(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	orgs, err := h.organizationRepo.FindByUserID(userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch organizations", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(orgs)
+}
+
+// CreateOrganization handles POST /api/organizations
+func (h *OrganizationHandler) CreateOrganization(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var input models.OrganizationInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	org, err := h.organizationService.Create(userID, &input)
+	if err != nil {
+		writeOrganizationError(w, err, "Failed to create organization")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(org)
+}
+
+// GetOrganization handles GET /api/organizations/{orgID}
+func (h *OrganizationHandler) GetOrganization(w http.ResponseWriter, r *http.Request) {
+	org, _, ok := loadOrganization(w, r, h.authorizer, authz.ViewOrganization)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(org)
+}
+
+// UpdateOrganization handles PUT /api/organizations/{orgID}
+func (h *OrganizationHandler) UpdateOrganization(w http.ResponseWriter, r *http.Request) {
+	org, userID, ok := loadOrganization(w, r, h.authorizer, authz.ManageOrganization)
+	if !ok {
+		return
+	}
+
+	var input models.OrganizationInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.organizationService.Update(userID, org, &input); err != nil {
+		writeOrganizationError(w, err, "Failed to update organization")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(org)
+}
+
+// DeleteOrganization handles DELETE /api/organizations/{orgID}
+func (h *OrganizationHandler) DeleteOrganization(w http.ResponseWriter, r *http.Request) {
+	org, _, ok := loadOrganization(w, r, h.authorizer, authz.ManageOrganization)
+	if !ok {
+		return
+	}
+
+	if !requireStepUp(w, r, h.twoFactorService) {
+		return
+	}
+
+	if err := h.organizationService.Delete(org); err != nil {
+		writeOrganizationError(w, err, "Failed to delete organization")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListMembers handles GET /api/organizations/{orgID}/members
+func (h *OrganizationHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	org, _, ok := loadOrganization(w, r, h.authorizer, authz.ViewOrganization)
+	if !ok {
+		return
+	}
+
+	members, err := h.organizationRepo.FindMembers(org.ID)
+	if err != nil {
+		http.Error(w, "Failed to fetch members", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(members)
+}
+
+// UpdateMember handles PUT /api/organizations/{orgID}/members/{userID}
+func (h *OrganizationHandler) UpdateMember(w http.ResponseWriter, r *http.Request) {
+	org, _, ok := loadOrganization(w, r, h.authorizer, authz.ManageMembers)
+	if !ok {
+		return
+	}
+
+	memberID, err := strconv.ParseInt(chi.URLParam(r, "userID"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	var input models.OrganizationRoleInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	member, err := h.organizationService.UpdateMemberRole(org, memberID, input.Role)
+	if err != nil {
+		writeOrganizationError(w, err, "Failed to update member")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(member)
+}
+
+// RemoveMember handles DELETE /api/organizations/{orgID}/members/{userID}.
+// Any member can remove themselves to leave the organization.
+func (h *OrganizationHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	memberID, err := strconv.ParseInt(chi.URLParam(r, "userID"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	action := authz.ManageMembers
+	if memberID == userID {
+		action = authz.ViewOrganization
+	}
+
+	org, _, ok := loadOrganization(w, r, h.authorizer, action)
+	if !ok {
+		return
+	}
+
+	if err := h.organizationService.RemoveMember(org, memberID); err != nil {
+		writeOrganizationError(w, err, "Failed to remove member")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// loadOrganization loads the organization identified by the {orgID} URL
+// parameter if the authenticated user may perform action on it. It writes the
+// error response and returns false otherwise.
+func loadOrganization(w http.ResponseWriter, r *http.Request, authorizer *authz.Authorizer, action authz.OrganizationAction) (*models.Organization, int64, bool) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, 0, false
+	}
+
+	orgID, err := strconv.ParseInt(chi.URLParam(r, "orgID"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid organization ID", http.StatusBadRequest)
+		return nil, 0, false
+	}
+
+	org, err := authorizer.Organization(userID, orgID, action)
+	if err != nil {
+		writeAuthzError(w, err, "Organization not found")
+		return nil, 0, false
+	}
+
+	return org, userID, true
+}
+
+// writeOrganizationError writes the response for an organization service error
+func writeOrganizationError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrMemberNotFound),
+		errors.Is(err, service.ErrInvitationNotFound), errors.Is(err, service.ErrInvalidInvitation):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrOwnerRequired):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, service.ErrAlreadyMember), errors.Is(err, service.ErrLastOwner), errors.Is(err, service.ErrOrganizationHasSites),
+		errors.Is(err, service.ErrAlreadyInvited):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrInvitationNotSent):
+		http.Error(w, err.Error(), http.StatusBadGateway)
+	case errors.Is(err, service.ErrTwoFactorNotEnabled):
+		http.Error(w, "Enable two-factor authentication before requiring it", http.StatusBadRequest)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
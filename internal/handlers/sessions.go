@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"egide-server/internal/auth"
+	"egide-server/internal/service"
+)
+
+// SessionHandler handles the sessions of the current user
+type SessionHandler struct {
+	sessionService *service.SessionService
+}
+
+// NewSessionHandler creates a new session handler
+func NewSessionHandler(sessionService *service.SessionService) *SessionHandler {
+	return &SessionHandler{
+		sessionService: sessionService,
+	}
+}
+
+// ListSessions handles GET /api/users/me/sessions
+func (h *SessionHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	sessions, err := h.sessionService.List(userID, auth.SessionIDFromContext(r.Context()))
+	if err != nil {
+		http.Error(w, "Failed to fetch sessions", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sessions)
+}
+
+// RevokeSession handles DELETE /api/users/me/sessions/{sessionID}
+func (h *SessionHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	sessionID, err := strconv.ParseInt(chi.URLParam(r, "sessionID"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.sessionService.Revoke(userID, sessionID); err != nil {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
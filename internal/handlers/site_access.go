@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"egide-server/internal/auth"
+	"egide-server/internal/authz"
+	"egide-server/internal/models"
+)
+
+// loadSite loads the site identified by the {id} URL parameter and ensures the
+// authenticated user may access it: reading requests need to view the site,
+// others to edit it. It writes the error response and returns false when the
+// site can't be used.
+func loadSite(w http.ResponseWriter, r *http.Request, authorizer *authz.Authorizer) (*models.Site, bool) {
+	action := authz.EditSite
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		action = authz.ViewSite
+	}
+	return loadSiteFor(w, r, authorizer, action)
+}
+
+// loadSiteFor is loadSite for an explicit action
+func loadSiteFor(w http.ResponseWriter, r *http.Request, authorizer *authz.Authorizer, action authz.Action) (*models.Site, bool) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	siteID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid site ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	site, err := authorizer.Site(userID, siteID, action)
+	if err != nil {
+		writeAuthzError(w, err, "Site not found")
+		return nil, false
+	}
+
+	return site, true
+}
+
+// writeAuthzError writes the response for an authorization failure
+func writeAuthzError(w http.ResponseWriter, err error, notFound string) {
+	switch {
+	case errors.Is(err, authz.ErrNotFound):
+		http.Error(w, notFound, http.StatusNotFound)
+	case errors.Is(err, authz.ErrForbidden):
+		http.Error(w, "Unauthorized", http.StatusForbidden)
+	case errors.Is(err, authz.ErrTwoFactorRequired):
+		http.Error(w, "Organization requires two-factor authentication", http.StatusForbidden)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
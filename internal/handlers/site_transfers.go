@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"egide-server/internal/auth"
+	"egide-server/internal/authz"
+	"egide-server/internal/models"
+	"egide-server/internal/service"
+)
+
+// SiteTransferHandler handles the transfers of sites between users and organizations
+type SiteTransferHandler struct {
+	transferService  *service.SiteTransferService
+	authorizer       *authz.Authorizer
+	twoFactorService *service.TwoFactorService
+	validator        *validator.Validate
+}
+
+// NewSiteTransferHandler creates a new site transfer handler
+func NewSiteTransferHandler(transferService *service.SiteTransferService, authorizer *authz.Authorizer, twoFactorService *service.TwoFactorService) *SiteTransferHandler {
+	return &SiteTransferHandler{
+		transferService:  transferService,
+		authorizer:       authorizer,
+		twoFactorService: twoFactorService,
+		validator:        validator.New(),
+	}
+}
+
+// GetTransfer handles GET /api/sites/{id}/transfer
+func (h *SiteTransferHandler) GetTransfer(w http.ResponseWriter, r *http.Request) {
+	site, ok := loadSiteFor(w, r, h.authorizer, authz.ViewSite)
+	if !ok {
+		return
+	}
+
+	transfer, err := h.transferService.Pending(site.ID)
+	if err != nil {
+		writeTransferError(w, err, "Failed to fetch site transfer")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transfer)
+}
+
+// RequestTransfer handles POST /api/sites/{id}/transfer
+func (h *SiteTransferHandler) RequestTransfer(w http.ResponseWriter, r *http.Request) {
+	site, ok := loadSiteFor(w, r, h.authorizer, authz.TransferSite)
+	if !ok {
+		return
+	}
+	userID, _ := auth.UserIDFromContext(r.Context())
+
+	var input models.SiteTransferInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !requireStepUp(w, r, h.twoFactorService) {
+		return
+	}
+
+	transfer, err := h.transferService.Request(userID, site, &input)
+	if err != nil {
+		writeTransferError(w, err, "Failed to request site transfer")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(transfer)
+}
+
+// CancelTransfer handles DELETE /api/sites/{id}/transfer
+func (h *SiteTransferHandler) CancelTransfer(w http.ResponseWriter, r *http.Request) {
+	site, ok := loadSiteFor(w, r, h.authorizer, authz.TransferSite)
+	if !ok {
+		return
+	}
+	userID, _ := auth.UserIDFromContext(r.Context())
+
+	if err := h.transferService.Cancel(userID, site); err != nil {
+		writeTransferError(w, err, "Failed to cancel site transfer")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListIncomingTransfers handles GET /api/transfers
+func (h *SiteTransferHandler) ListIncomingTransfers(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	transfers, err := h.transferService.Incoming(userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch site transfers", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transfers)
+}
+
+// AcceptTransfer handles POST /api/transfers/{transferID}/accept
+func (h *SiteTransferHandler) AcceptTransfer(w http.ResponseWriter, r *http.Request) {
+	userID, transferID, ok := transferParams(w, r)
+	if !ok {
+		return
+	}
+
+	site, err := h.transferService.Accept(userID, transferID)
+	if err != nil {
+		writeTransferError(w, err, "Failed to accept site transfer")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(site)
+}
+
+// DeclineTransfer handles POST /api/transfers/{transferID}/decline
+func (h *SiteTransferHandler) DeclineTransfer(w http.ResponseWriter, r *http.Request) {
+	userID, transferID, ok := transferParams(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.transferService.Decline(userID, transferID); err != nil {
+		writeTransferError(w, err, "Failed to decline site transfer")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func transferParams(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+
+	transferID, err := strconv.ParseInt(chi.URLParam(r, "transferID"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid transfer ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+
+	return userID, transferID, true
+}
+
+// writeTransferError writes the response for a site transfer service error
+func writeTransferError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrTransferNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrInvalidRecipient):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, service.ErrTransferPending), errors.Is(err, service.ErrDomainTaken):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, authz.ErrTwoFactorRequired):
+		writeAuthzError(w, err, "")
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
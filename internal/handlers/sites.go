@@ -3,24 +3,31 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
-	"strconv"
+	"strings"
 
-	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 	"egide-server/internal/auth"
+	"egide-server/internal/authz"
 	"egide-server/internal/models"
 	"egide-server/internal/repository"
+	"egide-server/internal/service"
 )
 
 type SiteHandler struct {
-	siteRepo  *repository.SiteRepository
-	validator *validator.Validate
+	siteRepo         *repository.SiteRepository
+	authorizer       *authz.Authorizer
+	webhookService   *service.WebhookService
+	twoFactorService *service.TwoFactorService
+	validator        *validator.Validate
 }
 
-func NewSiteHandler(siteRepo *repository.SiteRepository) *SiteHandler {
+func NewSiteHandler(siteRepo *repository.SiteRepository, authorizer *authz.Authorizer, webhookService *service.WebhookService, twoFactorService *service.TwoFactorService) *SiteHandler {
 	return &SiteHandler{
-		siteRepo:  siteRepo,
-		validator: validator.New(),
+		siteRepo:         siteRepo,
+		authorizer:       authorizer,
+		webhookService:   webhookService,
+		twoFactorService: twoFactorService,
+		validator:        validator.New(),
 	}
 }
 
@@ -31,7 +38,7 @@ func (h *SiteHandler) ListSites(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	sites, err := h.siteRepo.FindByUserID(userID)
+	sites, err := h.authorizer.Sites(userID)
 	if err != nil {
 		http.Error(w, "Failed to fetch sites", http.StatusInternalServerError)
 		return
@@ -42,27 +49,8 @@ func (h *SiteHandler) ListSites(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *SiteHandler) GetSite(w http.ResponseWriter, r *http.Request) {
-	userID, err := auth.UserIDFromContext(r.Context())
-	if err != nil {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
-	}
-
-	siteID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
-	if err != nil {
-		http.Error(w, "Invalid site ID", http.StatusBadRequest)
-		return
-	}
-
-	site, err := h.siteRepo.FindByID(siteID)
-	if err != nil {
-		http.Error(w, "Site not found", http.StatusNotFound)
-		return
-	}
-
-	// Ensure the site belongs to the authenticated user
-	if site.UserID != userID {
-		http.Error(w, "Unauthorized", http.StatusForbidden)
+	site, ok := loadSiteFor(w, r, h.authorizer, authz.ViewSite)
+	if !ok {
 		return
 	}
 
@@ -88,6 +76,14 @@ func (h *SiteHandler) CreateSite(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Sites can be created in an organization by its admins
+	if input.OrganizationID != nil {
+		if _, err := h.authorizer.Organization(userID, *input.OrganizationID, authz.ManageMembers); err != nil {
+			writeAuthzError(w, err, "Organization not found")
+			return
+		}
+	}
+
 	// Always set active=false and verified=false for new sites
 	// Ignoring any provided active value as it doesn't make sense for unverified sites
 	active := false
@@ -99,6 +95,7 @@ func (h *SiteHandler) CreateSite(w http.ResponseWriter, r *http.Request) {
 		ProtectionMode: input.ProtectionMode,
 		Active:         active,
 		Verified:       verified,
+		OrganizationID: input.OrganizationID,
 	}
 
 	siteID, err := h.siteRepo.Create(site)
@@ -113,6 +110,8 @@ func (h *SiteHandler) CreateSite(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	service.PublishSiteEvent(h.authorizer, h.webhookService, site, models.EventSiteCreated, site)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(site)
@@ -125,20 +124,8 @@ func (h *SiteHandler) UpdateSite(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	siteID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
-	if err != nil {
-		http.Error(w, "Invalid site ID", http.StatusBadRequest)
-		return
-	}
-
-	existingSite, err := h.siteRepo.FindByID(siteID)
-	if err != nil {
-		http.Error(w, "Site not found", http.StatusNotFound)
-		return
-	}
-
-	if existingSite.UserID != userID {
-		http.Error(w, "Unauthorized", http.StatusForbidden)
+	existingSite, ok := loadSiteFor(w, r, h.authorizer, authz.EditSite)
+	if !ok {
 		return
 	}
 
@@ -153,6 +140,14 @@ func (h *SiteHandler) UpdateSite(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	wasActive, wasVerified := existingSite.Active, existingSite.Verified
+
+	// Verification was for the former domain: the new one has to be verified
+	// again before its protection can be activated
+	if !strings.EqualFold(input.Domain, existingSite.Domain) {
+		existingSite.Verified = false
+		existingSite.Active = false
+	}
 	existingSite.Domain = input.Domain
 	existingSite.ProtectionMode = input.ProtectionMode
 	
@@ -176,40 +171,40 @@ func (h *SiteHandler) UpdateSite(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Like ToggleSiteActivation, switching the protection or the verification takes managing the site
+	if existingSite.Active != wasActive || existingSite.Verified != wasVerified {
+		if err := h.authorizer.CheckSite(userID, existingSite, authz.ManageSite); err != nil {
+			writeAuthzError(w, err, "Site not found")
+			return
+		}
+	}
+
+	if !h.allowDeactivation(w, r, userID, existingSite, wasActive) {
+		return
+	}
+
 	if err := h.siteRepo.Update(existingSite); err != nil {
 		http.Error(w, "Failed to update site: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	service.PublishSiteEvent(h.authorizer, h.webhookService, existingSite, models.EventSiteUpdated, existingSite)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(existingSite)
 }
 
 func (h *SiteHandler) DeleteSite(w http.ResponseWriter, r *http.Request) {
-	userID, err := auth.UserIDFromContext(r.Context())
-	if err != nil {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	existingSite, ok := loadSiteFor(w, r, h.authorizer, authz.ManageSite)
+	if !ok {
 		return
 	}
 
-	siteID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
-	if err != nil {
-		http.Error(w, "Invalid site ID", http.StatusBadRequest)
+	if !requireStepUp(w, r, h.twoFactorService) {
 		return
 	}
 
-	existingSite, err := h.siteRepo.FindByID(siteID)
-	if err != nil {
-		http.Error(w, "Site not found", http.StatusNotFound)
-		return
-	}
-
-	if existingSite.UserID != userID {
-		http.Error(w, "Unauthorized", http.StatusForbidden)
-		return
-	}
-
-	if err := h.siteRepo.Delete(siteID); err != nil {
+	if err := h.siteRepo.Delete(existingSite.ID); err != nil {
 		http.Error(w, "Failed to delete site: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -224,20 +219,8 @@ func (h *SiteHandler) VerifySite(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	siteID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
-	if err != nil {
-		http.Error(w, "Invalid site ID", http.StatusBadRequest)
-		return
-	}
-
-	existingSite, err := h.siteRepo.FindByID(siteID)
-	if err != nil {
-		http.Error(w, "Site not found", http.StatusNotFound)
-		return
-	}
-
-	if existingSite.UserID != userID {
-		http.Error(w, "Unauthorized", http.StatusForbidden)
+	existingSite, ok := loadSiteFor(w, r, h.authorizer, authz.EditSite)
+	if !ok {
 		return
 	}
 
@@ -254,49 +237,40 @@ func (h *SiteHandler) VerifySite(w http.ResponseWriter, r *http.Request) {
 	if !input.Verified && existingSite.Active {
 		// First update active status to false
 		existingSite.Active = false
+		if !h.allowDeactivation(w, r, userID, existingSite, true) {
+			return
+		}
 		if err := h.siteRepo.Update(existingSite); err != nil {
 			http.Error(w, "Failed to deactivate site during unverification: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
 	}
 
-	if err := h.siteRepo.UpdateVerificationStatus(siteID, input.Verified); err != nil {
+	if err := h.siteRepo.UpdateVerificationStatus(existingSite.ID, input.Verified); err != nil {
 		http.Error(w, "Failed to update verification status: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	// Get the updated site
-	updatedSite, err := h.siteRepo.FindByID(siteID)
+	updatedSite, err := h.siteRepo.FindByID(existingSite.ID)
 	if err != nil {
 		http.Error(w, "Site updated but failed to fetch", http.StatusInternalServerError)
 		return
 	}
 
+	if updatedSite.Verified {
+		service.PublishSiteEvent(h.authorizer, h.webhookService, updatedSite, models.EventSiteVerified, updatedSite)
+	} else {
+		service.PublishSiteEvent(h.authorizer, h.webhookService, updatedSite, models.EventSiteUpdated, updatedSite)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(updatedSite)
 }
 
 func (h *SiteHandler) ToggleSiteActivation(w http.ResponseWriter, r *http.Request) {
-	userID, err := auth.UserIDFromContext(r.Context())
-	if err != nil {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
-	}
-
-	siteID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
-	if err != nil {
-		http.Error(w, "Invalid site ID", http.StatusBadRequest)
-		return
-	}
-
-	existingSite, err := h.siteRepo.FindByID(siteID)
-	if err != nil {
-		http.Error(w, "Site not found", http.StatusNotFound)
-		return
-	}
-
-	if existingSite.UserID != userID {
-		http.Error(w, "Unauthorized", http.StatusForbidden)
+	existingSite, ok := loadSiteFor(w, r, h.authorizer, authz.ManageSite)
+	if !ok {
 		return
 	}
 
@@ -314,6 +288,11 @@ func (h *SiteHandler) ToggleSiteActivation(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	// Disabling protection is sensitive
+	if !input.Active && existingSite.Active && !requireStepUp(w, r, h.twoFactorService) {
+		return
+	}
+
 	// Update active status
 	existingSite.Active = input.Active
 	if err := h.siteRepo.Update(existingSite); err != nil {
@@ -321,6 +300,28 @@ func (h *SiteHandler) ToggleSiteActivation(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	if existingSite.Active {
+		service.PublishSiteEvent(h.authorizer, h.webhookService, existingSite, models.EventSiteActivated, existingSite)
+	} else {
+		service.PublishSiteEvent(h.authorizer, h.webhookService, existingSite, models.EventSiteDeactivated, existingSite)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(existingSite)
 }
+
+// allowDeactivation checks that the user may switch off the protection of a
+// site that was active: like ToggleSiteActivation, it takes managing the site
+// and a recent two-factor verification. It writes the error response and
+// returns false otherwise.
+func (h *SiteHandler) allowDeactivation(w http.ResponseWriter, r *http.Request, userID int64, site *models.Site, wasActive bool) bool {
+	if !wasActive || site.Active {
+		return true
+	}
+
+	if err := h.authorizer.CheckSite(userID, site, authz.ManageSite); err != nil {
+		writeAuthzError(w, err, "Site not found")
+		return false
+	}
+	return requireStepUp(w, r, h.twoFactorService)
+}
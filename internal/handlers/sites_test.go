@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"egide-server/internal/auth"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+	"egide-server/internal/service"
+)
+
+func TestUpdateSiteVerification(t *testing.T) {
+	db := newTestDB(t)
+
+	userRepo := repository.NewUserRepository(db)
+	ownerID, err := userRepo.CreateWithIdentity(&models.User{Username: "owner"}, &models.Identity{Provider: "github", Subject: "1", Username: "owner"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	editorID, err := userRepo.CreateWithIdentity(&models.User{Username: "editor"}, &models.Identity{Provider: "github", Subject: "2", Username: "editor"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	organizationRepo := repository.NewOrganizationRepository(db)
+	orgID, err := organizationRepo.Create(&models.Organization{Name: "Team"}, ownerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := organizationRepo.AddMember(&models.OrganizationMember{OrganizationID: orgID, UserID: editorID, Role: models.RoleEditor}); err != nil {
+		t.Fatal(err)
+	}
+
+	siteRepo := repository.NewSiteRepository(db)
+	siteID, err := siteRepo.Create(&models.Site{UserID: ownerID, OrganizationID: &orgID, Domain: "example.com", ProtectionMode: models.SimpleProtection})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := siteRepo.UpdateVerificationStatus(siteID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := NewSiteHandler(siteRepo, newTestAuthorizer(db), service.NewWebhookService(repository.NewWebhookRepository(db)), nil)
+	r := chi.NewRouter()
+	r.Put("/api/sites/{id}", handler.UpdateSite)
+
+	update := func(userID int64, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PUT", "/api/sites/"+strconv.FormatInt(siteID, 10), strings.NewReader(body))
+		req = req.WithContext(auth.WithUserID(req.Context(), userID))
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Editors change the settings of the site, not its verification
+	if rr := update(editorID, `{"domain": "example.com", "protection_mode": "hardened"}`); rr.Code != http.StatusOK {
+		t.Errorf("editor changing the protection mode: status %d; want %d: %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	if rr := update(editorID, `{"domain": "example.com", "protection_mode": "hardened", "verified": false}`); rr.Code != http.StatusForbidden {
+		t.Errorf("editor unverifying: status %d; want %d", rr.Code, http.StatusForbidden)
+	}
+	if rr := update(editorID, `{"domain": "evil.example.net", "protection_mode": "hardened"}`); rr.Code != http.StatusForbidden {
+		t.Errorf("editor changing the domain of a verified site: status %d; want %d", rr.Code, http.StatusForbidden)
+	}
+
+	// A new domain has to be verified again
+	rr := update(ownerID, `{"domain": "www.example.com", "protection_mode": "hardened"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("owner changing the domain: status %d: %s", rr.Code, rr.Body.String())
+	}
+	var site models.Site
+	if err := json.NewDecoder(rr.Body).Decode(&site); err != nil {
+		t.Fatal(err)
+	}
+	if site.Domain != "www.example.com" || site.Verified || site.Active {
+		t.Errorf("site after changing its domain = %q verified=%v active=%v; want www.example.com unverified inactive", site.Domain, site.Verified, site.Active)
+	}
+
+	if rr := update(editorID, `{"domain": "www.example.com", "protection_mode": "hardened", "verified": true}`); rr.Code != http.StatusForbidden {
+		t.Errorf("editor verifying: status %d; want %d", rr.Code, http.StatusForbidden)
+	}
+}
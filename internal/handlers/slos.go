@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"egide-server/internal/auth"
+	"egide-server/internal/authz"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+	"egide-server/internal/service"
+)
+
+// SloHandler handles the SLOs of a site
+type SloHandler struct {
+	authorizer  *authz.Authorizer
+	monitorRepo *repository.MonitorRepository
+	channelRepo *repository.NotificationChannelRepository
+	sloRepo     *repository.SloRepository
+	sloService  *service.SloService
+	validator   *validator.Validate
+}
+
+// NewSloHandler creates a new SLO handler
+func NewSloHandler(
+	authorizer *authz.Authorizer,
+	monitorRepo *repository.MonitorRepository,
+	channelRepo *repository.NotificationChannelRepository,
+	sloRepo *repository.SloRepository,
+	sloService *service.SloService,
+) *SloHandler {
+	return &SloHandler{
+		authorizer:  authorizer,
+		monitorRepo: monitorRepo,
+		channelRepo: channelRepo,
+		sloRepo:     sloRepo,
+		sloService:  sloService,
+		validator:   validator.New(),
+	}
+}
+
+// ListSlos handles GET /api/sites/{id}/slos
+func (h *SloHandler) ListSlos(w http.ResponseWriter, r *http.Request) {
+	site, ok := loadSite(w, r, h.authorizer)
+	if !ok {
+		return
+	}
+
+	slos, err := h.sloRepo.FindBySiteID(site.ID)
+	if err != nil {
+		http.Error(w, "Failed to fetch SLOs", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	statuses := make([]*service.SloStatus, 0, len(slos))
+	for _, slo := range slos {
+		status, err := h.sloService.Status(slo, now)
+		if err != nil {
+			http.Error(w, "Error computing SLO status: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		statuses = append(statuses, status)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(statuses)
+}
+
+// GetSlo handles GET /api/sites/{id}/slos/{sloID}
+func (h *SloHandler) GetSlo(w http.ResponseWriter, r *http.Request) {
+	_, slo, ok := h.ownedSlo(w, r)
+	if !ok {
+		return
+	}
+
+	status, err := h.sloService.Status(slo, time.Now())
+	if err != nil {
+		http.Error(w, "Error computing SLO status: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// CreateSlo handles POST /api/sites/{id}/slos
+func (h *SloHandler) CreateSlo(w http.ResponseWriter, r *http.Request) {
+	site, ok := loadSite(w, r, h.authorizer)
+	if !ok {
+		return
+	}
+
+	slo := &models.Slo{SiteID: site.ID}
+	if !h.applyInput(w, r, site, slo) {
+		return
+	}
+
+	sloID, err := h.sloRepo.Create(slo)
+	if err != nil {
+		http.Error(w, "Failed to create SLO: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	slo, err = h.sloRepo.FindByID(sloID)
+	if err != nil {
+		http.Error(w, "SLO created but failed to fetch", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(slo)
+}
+
+// UpdateSlo handles PUT /api/sites/{id}/slos/{sloID}
+func (h *SloHandler) UpdateSlo(w http.ResponseWriter, r *http.Request) {
+	site, slo, ok := h.ownedSlo(w, r)
+	if !ok {
+		return
+	}
+
+	if !h.applyInput(w, r, site, slo) {
+		return
+	}
+
+	if err := h.sloRepo.Update(slo); err != nil {
+		http.Error(w, "Failed to update SLO: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(slo)
+}
+
+// DeleteSlo handles DELETE /api/sites/{id}/slos/{sloID}
+func (h *SloHandler) DeleteSlo(w http.ResponseWriter, r *http.Request) {
+	_, slo, ok := h.ownedSlo(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.sloRepo.Delete(slo.ID); err != nil {
+		http.Error(w, "Failed to delete SLO: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// applyInput decodes and validates an SLO payload
+func (h *SloHandler) applyInput(w http.ResponseWriter, r *http.Request, site *models.Site, slo *models.Slo) bool {
+	var input models.SloInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return false
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return false
+	}
+
+	if input.MonitorID != nil {
+		monitor, err := h.monitorRepo.FindByID(*input.MonitorID)
+		if err != nil || monitor.SiteID != site.ID {
+			http.Error(w, "Monitor not found", http.StatusBadRequest)
+			return false
+		}
+	}
+
+	// Channels belong to whoever configures the SLO, which on the sites of an
+	// organization isn't necessarily the creator of the site
+	userID, _ := auth.UserIDFromContext(r.Context())
+	for _, channelID := range input.ChannelIDs {
+		channel, err := h.channelRepo.FindByID(channelID)
+		if err != nil || channel.UserID != userID {
+			http.Error(w, "Notification channel not found: "+strconv.FormatInt(channelID, 10), http.StatusBadRequest)
+			return false
+		}
+	}
+
+	if input.WindowDays == 0 {
+		input.WindowDays = service.DefaultSloWindowDays
+	}
+	if input.BurnRateThreshold == 0 {
+		input.BurnRateThreshold = service.DefaultBurnRateThreshold
+	}
+
+	slo.Name = input.Name
+	slo.MonitorID = input.MonitorID
+	slo.Target = input.Target
+	slo.LatencyThresholdMs = input.LatencyThresholdMs
+	slo.WindowDays = input.WindowDays
+	slo.BurnRateThreshold = input.BurnRateThreshold
+	slo.ChannelIDs = input.ChannelIDs
+	if slo.ChannelIDs == nil {
+		slo.ChannelIDs = []int64{}
+	}
+	if input.AlertEnabled != nil {
+		slo.AlertEnabled = *input.AlertEnabled
+	}
+
+	return true
+}
+
+func (h *SloHandler) ownedSlo(w http.ResponseWriter, r *http.Request) (*models.Site, *models.Slo, bool) {
+	site, ok := loadSite(w, r, h.authorizer)
+	if !ok {
+		return nil, nil, false
+	}
+
+	sloID, err := strconv.ParseInt(chi.URLParam(r, "sloID"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid SLO ID", http.StatusBadRequest)
+		return nil, nil, false
+	}
+
+	slo, err := h.sloRepo.FindByID(sloID)
+	if err != nil || slo.SiteID != site.ID {
+		http.Error(w, "SLO not found", http.StatusNotFound)
+		return nil, nil, false
+	}
+
+	return site, slo, true
+}
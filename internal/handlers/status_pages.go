@@ -0,0 +1,438 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"egide-server/internal/auth"
+	"egide-server/internal/authz"
+	"egide-server/internal/config"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+	"egide-server/internal/service"
+)
+
+//go:embed templates/status_page.html
+var statusPageFS embed.FS
+
+var statusPageTemplate = template.Must(template.New("status_page.html").Funcs(template.FuncMap{
+	"formatUptime": func(uptime *float64) string {
+		if uptime == nil {
+			return "No data"
+		}
+		return fmt.Sprintf("%.2f%%", *uptime)
+	},
+	"barClass": func(uptime *float64) string {
+		switch {
+		case uptime == nil:
+			return ""
+		case *uptime >= 99.9:
+			return "good"
+		case *uptime >= 95:
+			return "warn"
+		default:
+			return "bad"
+		}
+	},
+}).ParseFS(statusPageFS, "templates/status_page.html"))
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9](?:[a-z0-9-]*[a-z0-9])?$`)
+
+const (
+	// domainChallengePrefix is prepended to a custom domain to find the TXT
+	// record proving its control
+	domainChallengePrefix = "_egide-challenge."
+
+	// domainTokenPrefix starts the value of the TXT record, followed by the
+	// token of the status page
+	domainTokenPrefix = "egide-verification="
+)
+
+// StatusPageHandler handles status page management and the public pages themselves
+type StatusPageHandler struct {
+	statusPageRepo    *repository.StatusPageRepository
+	authorizer        *authz.Authorizer
+	monitorRepo       *repository.MonitorRepository
+	statusPageService *service.StatusPageService
+	config            *config.Config
+	validator         *validator.Validate
+
+	// lookupTXT resolves the TXT records of a name, replaced in tests
+	lookupTXT func(ctx context.Context, name string) ([]string, error)
+}
+
+// NewStatusPageHandler creates a new status page handler
+func NewStatusPageHandler(
+	statusPageRepo *repository.StatusPageRepository,
+	authorizer *authz.Authorizer,
+	monitorRepo *repository.MonitorRepository,
+	statusPageService *service.StatusPageService,
+	config *config.Config,
+) *StatusPageHandler {
+	return &StatusPageHandler{
+		statusPageRepo:    statusPageRepo,
+		authorizer:        authorizer,
+		monitorRepo:       monitorRepo,
+		statusPageService: statusPageService,
+		config:            config,
+		validator:         validator.New(),
+		lookupTXT:         net.DefaultResolver.LookupTXT,
+	}
+}
+
+// ListStatusPages handles GET /api/status-pages
+func (h *StatusPageHandler) ListStatusPages(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	pages, err := h.statusPageRepo.FindByUserID(userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch status pages", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pages)
+}
+
+// GetStatusPage handles GET /api/status-pages/{id}
+func (h *StatusPageHandler) GetStatusPage(w http.ResponseWriter, r *http.Request) {
+	page, ok := h.ownedStatusPage(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// CreateStatusPage handles POST /api/status-pages
+func (h *StatusPageHandler) CreateStatusPage(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	page := &models.StatusPage{UserID: userID}
+	if !h.applyInput(w, r, page) {
+		return
+	}
+
+	pageID, err := h.statusPageRepo.Create(page)
+	if err != nil {
+		http.Error(w, "Failed to create status page: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	page, err = h.statusPageRepo.FindByID(pageID)
+	if err != nil {
+		http.Error(w, "Status page created but failed to fetch", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(page)
+}
+
+// UpdateStatusPage handles PUT /api/status-pages/{id}
+func (h *StatusPageHandler) UpdateStatusPage(w http.ResponseWriter, r *http.Request) {
+	page, ok := h.ownedStatusPage(w, r)
+	if !ok {
+		return
+	}
+
+	if !h.applyInput(w, r, page) {
+		return
+	}
+
+	if err := h.statusPageRepo.Update(page); err != nil {
+		http.Error(w, "Failed to update status page: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// DeleteStatusPage handles DELETE /api/status-pages/{id}
+func (h *StatusPageHandler) DeleteStatusPage(w http.ResponseWriter, r *http.Request) {
+	page, ok := h.ownedStatusPage(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.statusPageRepo.Delete(page.ID); err != nil {
+		http.Error(w, "Failed to delete status page: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// VerifyStatusPageDomain handles POST /api/status-pages/{id}/domain/verify,
+// making the pending domain live once its TXT record holds the page token
+func (h *StatusPageHandler) VerifyStatusPageDomain(w http.ResponseWriter, r *http.Request) {
+	page, ok := h.ownedStatusPage(w, r)
+	if !ok {
+		return
+	}
+
+	if page.PendingDomain == nil || page.DomainToken == nil {
+		http.Error(w, "No custom domain waiting for verification", http.StatusBadRequest)
+		return
+	}
+	domain := *page.PendingDomain
+	if h.reservedDomain(r, domain) {
+		http.Error(w, "Custom domain not available", http.StatusBadRequest)
+		return
+	}
+
+	records, err := h.lookupTXT(r.Context(), domainChallengePrefix+domain)
+	verified := false
+	for _, record := range records {
+		if record == domainTokenPrefix+*page.DomainToken {
+			verified = true
+			break
+		}
+	}
+	if err != nil || !verified {
+		http.Error(w, fmt.Sprintf("Verification failed: no TXT record %q found at %s", domainTokenPrefix+*page.DomainToken, domainChallengePrefix+domain), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.statusPageRepo.VerifyDomain(page.ID, domain); err != nil {
+		http.Error(w, "Failed to verify custom domain: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	page, err = h.statusPageRepo.FindByID(page.ID)
+	if err != nil {
+		http.Error(w, "Custom domain verified but failed to fetch status page", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// PublicStatusJSON handles GET /api/status/{slug}
+func (h *StatusPageHandler) PublicStatusJSON(w http.ResponseWriter, r *http.Request) {
+	page, err := h.statusPageRepo.FindBySlug(chi.URLParam(r, "slug"))
+	if err != nil {
+		http.Error(w, "Status page not found", http.StatusNotFound)
+		return
+	}
+
+	view, err := h.statusPageService.BuildView(page)
+	if err != nil {
+		http.Error(w, "Failed to build status page", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	json.NewEncoder(w).Encode(view)
+}
+
+// PublicStatusPage handles GET /status/{slug}
+func (h *StatusPageHandler) PublicStatusPage(w http.ResponseWriter, r *http.Request) {
+	page, err := h.statusPageRepo.FindBySlug(chi.URLParam(r, "slug"))
+	if err != nil {
+		http.Error(w, "Status page not found", http.StatusNotFound)
+		return
+	}
+
+	h.renderPage(w, page)
+}
+
+// CustomDomainStatusPage handles GET / for status pages served on a custom domain
+func (h *StatusPageHandler) CustomDomainStatusPage(w http.ResponseWriter, r *http.Request) {
+	host := r.Host
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+
+	page, err := h.statusPageRepo.FindByCustomDomain(strings.ToLower(host))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	h.renderPage(w, page)
+}
+
+func (h *StatusPageHandler) renderPage(w http.ResponseWriter, page *models.StatusPage) {
+	view, err := h.statusPageService.BuildView(page)
+	if err != nil {
+		http.Error(w, "Failed to build status page", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	if err := statusPageTemplate.Execute(w, view); err != nil {
+		http.Error(w, "Failed to render status page", http.StatusInternalServerError)
+	}
+}
+
+// applyInput decodes and validates a status page payload, checking that the
+// slug and domain are free and that the page owner may edit the site of every
+// monitor, as publishing its uptime is up to editors
+func (h *StatusPageHandler) applyInput(w http.ResponseWriter, r *http.Request, page *models.StatusPage) bool {
+	var input models.StatusPageInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return false
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return false
+	}
+
+	if !slugPattern.MatchString(input.Slug) {
+		http.Error(w, "Validation error: slug may only contain lowercase letters, digits and hyphens", http.StatusBadRequest)
+		return false
+	}
+
+	if existing, err := h.statusPageRepo.FindBySlug(input.Slug); err == nil && existing.ID != page.ID {
+		http.Error(w, "Slug already taken", http.StatusConflict)
+		return false
+	}
+
+	if !h.applyCustomDomain(w, r, page, input.CustomDomain) {
+		return false
+	}
+
+	for _, monitorID := range input.MonitorIDs {
+		monitor, err := h.monitorRepo.FindByID(monitorID)
+		if err != nil {
+			http.Error(w, "Monitor not found: "+strconv.FormatInt(monitorID, 10), http.StatusBadRequest)
+			return false
+		}
+
+		if _, err := h.authorizer.Site(page.UserID, monitor.SiteID, authz.EditSite); err != nil {
+			http.Error(w, "Monitor not found: "+strconv.FormatInt(monitorID, 10), http.StatusBadRequest)
+			return false
+		}
+	}
+
+	page.Slug = input.Slug
+	page.Title = input.Title
+	page.Description = input.Description
+	page.MonitorIDs = input.MonitorIDs
+	if page.MonitorIDs == nil {
+		page.MonitorIDs = []int64{}
+	}
+
+	return true
+}
+
+// applyCustomDomain sets the domain asked for a status page. A new domain is
+// pending, with a new token, until verified; the current one keeps serving
+// the page meanwhile.
+func (h *StatusPageHandler) applyCustomDomain(w http.ResponseWriter, r *http.Request, page *models.StatusPage, customDomain *string) bool {
+	if customDomain == nil {
+		page.CustomDomain = nil
+		page.PendingDomain = nil
+		page.DomainToken = nil
+		return true
+	}
+
+	domain := strings.TrimSuffix(strings.ToLower(*customDomain), ".")
+	switch {
+	case page.CustomDomain != nil && *page.CustomDomain == domain:
+		page.PendingDomain = nil
+		page.DomainToken = nil
+		return true
+	case page.PendingDomain != nil && *page.PendingDomain == domain:
+		return true
+	}
+
+	if h.reservedDomain(r, domain) {
+		http.Error(w, "Custom domain not available", http.StatusBadRequest)
+		return false
+	}
+	if existing, err := h.statusPageRepo.FindByCustomDomain(domain); err == nil && existing.ID != page.ID {
+		http.Error(w, "Custom domain already in use", http.StatusConflict)
+		return false
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		http.Error(w, "Failed to generate domain token", http.StatusInternalServerError)
+		return false
+	}
+	token := hex.EncodeToString(b)
+
+	page.PendingDomain = &domain
+	page.DomainToken = &token
+	return true
+}
+
+// reservedDomain reports whether a domain is a host of Egide itself, which
+// status pages can't take over
+func (h *StatusPageHandler) reservedDomain(r *http.Request, domain string) bool {
+	hosts := []string{r.Host, h.config.FrontendURL, h.config.GitHubOAuth.RedirectURL}
+	for _, provider := range []config.OAuthConfig{h.config.GitLabOAuth, h.config.GiteaOAuth, h.config.OIDC} {
+		hosts = append(hosts, provider.RedirectURL)
+	}
+
+	for _, host := range hosts {
+		if u, err := url.Parse(host); err == nil && u.Host != "" {
+			host = u.Host
+		}
+		if hostname, _, err := net.SplitHostPort(host); err == nil {
+			host = hostname
+		}
+		if host != "" && strings.EqualFold(host, domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *StatusPageHandler) ownedStatusPage(w http.ResponseWriter, r *http.Request) (*models.StatusPage, bool) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	pageID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid status page ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	page, err := h.statusPageRepo.FindByID(pageID)
+	if err != nil {
+		http.Error(w, "Status page not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	if page.UserID != userID {
+		http.Error(w, "Unauthorized", http.StatusForbidden)
+		return nil, false
+	}
+
+	return page, true
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"egide-server/internal/auth"
+	"egide-server/internal/config"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+	"egide-server/internal/service"
+)
+
+func TestStatusPageCustomDomain(t *testing.T) {
+	db := newTestDB(t)
+
+	userRepo := repository.NewUserRepository(db)
+	userID, err := userRepo.CreateWithIdentity(&models.User{Username: "test"}, &models.Identity{Provider: "github", Subject: "1", Username: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	monitorRepo := repository.NewMonitorRepository(db)
+	healthCheckRepo := repository.NewHealthCheckRepository(db)
+	statusPageService := service.NewStatusPageService(
+		monitorRepo,
+		newTestAuthorizer(db),
+		healthCheckRepo,
+		service.NewRollupService(healthCheckRepo, repository.NewRollupRepository(db)),
+		service.NewMaintenanceService(repository.NewMaintenanceRepository(db)),
+	)
+	handler := NewStatusPageHandler(repository.NewStatusPageRepository(db), newTestAuthorizer(db), monitorRepo, statusPageService, &config.Config{FrontendURL: "https://app.egide.test"})
+
+	records := map[string][]string{}
+	handler.lookupTXT = func(ctx context.Context, name string) ([]string, error) {
+		if txt, ok := records[name]; ok {
+			return txt, nil
+		}
+		return nil, errors.New("no such host")
+	}
+
+	r := chi.NewRouter()
+	r.Get("/", handler.CustomDomainStatusPage)
+	r.Route("/api/status-pages", func(r chi.Router) {
+		r.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				next.ServeHTTP(w, r.WithContext(auth.WithUserID(r.Context(), userID)))
+			})
+		})
+		r.Post("/", handler.CreateStatusPage)
+		r.Post("/{id}/domain/verify", handler.VerifyStatusPageDomain)
+	})
+
+	serve := func(method, target, host, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Host = host
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Hosts of Egide itself can't be claimed
+	for _, domain := range []string{"api.egide.test", "app.egide.test"} {
+		rr := serve("POST", "/api/status-pages", "api.egide.test:8080", `{"slug": "own", "title": "Own", "custom_domain": "`+domain+`"}`)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("claiming %s: status %d; want %d", domain, rr.Code, http.StatusBadRequest)
+		}
+	}
+
+	// A new domain waits for verification
+	rr := serve("POST", "/api/status-pages", "api.egide.test", `{"slug": "acme", "title": "Acme", "custom_domain": "Status.Example.com"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create: status %d: %s", rr.Code, rr.Body.String())
+	}
+	var page models.StatusPage
+	json.NewDecoder(rr.Body).Decode(&page)
+	if page.CustomDomain != nil || page.PendingDomain == nil || *page.PendingDomain != "status.example.com" || page.DomainToken == nil {
+		t.Fatalf("created page = %+v; want status.example.com pending", page)
+	}
+	if rr := serve("GET", "/", "status.example.com", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("unverified domain: status %d; want %d", rr.Code, http.StatusNotFound)
+	}
+
+	verify := "/api/status-pages/" + strconv.FormatInt(page.ID, 10) + "/domain/verify"
+	records["_egide-challenge.status.example.com"] = []string{"egide-verification=wrong"}
+	if rr := serve("POST", verify, "api.egide.test", ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("verify without the token: status %d; want %d", rr.Code, http.StatusBadRequest)
+	}
+
+	records["_egide-challenge.status.example.com"] = []string{"v=spf1 -all", "egide-verification=" + *page.DomainToken}
+	rr = serve("POST", verify, "api.egide.test", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("verify: status %d: %s", rr.Code, rr.Body.String())
+	}
+	var verified models.StatusPage
+	json.NewDecoder(rr.Body).Decode(&verified)
+	if verified.CustomDomain == nil || *verified.CustomDomain != "status.example.com" || verified.PendingDomain != nil {
+		t.Errorf("verified page = %+v; want status.example.com live", verified)
+	}
+	if rr := serve("GET", "/", "status.example.com:443", ""); rr.Code != http.StatusOK {
+		t.Errorf("verified domain: status %d; want %d", rr.Code, http.StatusOK)
+	}
+
+	// Viewers of an organization can't publish the monitors of its sites
+	ownerID, err := userRepo.CreateWithIdentity(&models.User{Username: "owner"}, &models.Identity{Provider: "github", Subject: "2", Username: "owner"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	organizationRepo := repository.NewOrganizationRepository(db)
+	orgID, err := organizationRepo.Create(&models.Organization{Name: "Team"}, ownerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := organizationRepo.AddMember(&models.OrganizationMember{OrganizationID: orgID, UserID: userID, Role: models.RoleViewer}); err != nil {
+		t.Fatal(err)
+	}
+	siteID, err := repository.NewSiteRepository(db).Create(&models.Site{UserID: ownerID, OrganizationID: &orgID, Domain: "team.example.com", ProtectionMode: models.SimpleProtection})
+	if err != nil {
+		t.Fatal(err)
+	}
+	monitorID, err := monitorRepo.Create(&models.Monitor{SiteID: siteID, Name: "Home", URL: "https://team.example.com", Enabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := `{"slug": "team", "title": "Team", "monitor_ids": [` + strconv.FormatInt(monitorID, 10) + `]}`
+	if rr := serve("POST", "/api/status-pages", "api.egide.test", body); rr.Code != http.StatusBadRequest {
+		t.Errorf("viewer publishing a monitor: status %d; want %d", rr.Code, http.StatusBadRequest)
+	}
+	if err := organizationRepo.UpdateMemberRole(orgID, userID, models.RoleEditor); err != nil {
+		t.Fatal(err)
+	}
+	if rr := serve("POST", "/api/status-pages", "api.egide.test", body); rr.Code != http.StatusCreated {
+		t.Errorf("editor publishing a monitor: status %d: %s", rr.Code, rr.Body.String())
+	}
+}
@@ -5,18 +5,18 @@ import (
 	"net/http"
 
 	"egide-server/internal/auth"
-	"egide-server/internal/repository"
+	"egide-server/internal/authz"
 	"egide-server/internal/service"
 )
 
 type ThreatHandler struct {
-	siteRepo      *repository.SiteRepository
+	authorizer    *authz.Authorizer
 	threatService *service.ThreatService
 }
 
-func NewThreatHandler(siteRepo *repository.SiteRepository, threatService *service.ThreatService) *ThreatHandler {
+func NewThreatHandler(authorizer *authz.Authorizer, threatService *service.ThreatService) *ThreatHandler {
 	return &ThreatHandler{
-		siteRepo:      siteRepo,
+		authorizer:    authorizer,
 		threatService: threatService,
 	}
 }
@@ -31,7 +31,7 @@ func (h *ThreatHandler) GetRecentThreats(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Get all sites for the user
-	sites, err := h.siteRepo.FindByUserID(userID)
+	sites, err := h.authorizer.Sites(userID)
 	if err != nil {
 		http.Error(w, "Error fetching sites", http.StatusInternalServerError)
 		return
@@ -59,7 +59,7 @@ func (h *ThreatHandler) GetThreatDistribution(w http.ResponseWriter, r *http.Req
 	}
 
 	// Get all sites for the user
-	sites, err := h.siteRepo.FindByUserID(userID)
+	sites, err := h.authorizer.Sites(userID)
 	if err != nil {
 		http.Error(w, "Error fetching sites", http.StatusInternalServerError)
 		return
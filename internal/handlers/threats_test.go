@@ -2,59 +2,104 @@ package handlers
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"egide-server/internal/auth"
+	"egide-server/internal/authz"
+	"egide-server/internal/migration"
 	"egide-server/internal/models"
 	"egide-server/internal/repository"
 	"egide-server/internal/service"
+
+	_ "github.com/mattn/go-sqlite3"
 )
 
-// MockSiteRepository is a mock implementation of SiteRepository
-type MockSiteRepository struct {
-	sites []*models.Site
+// newTestDB returns an in-memory database with all migrations applied
+func newTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every connection to :memory: is a different database
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	if err := migration.RunMigrations(db, "../../migrations"); err != nil {
+		t.Fatal(err)
+	}
+
+	return db
 }
 
-func (m *MockSiteRepository) FindByUserID(userID int64) ([]*models.Site, error) {
-	return m.sites, nil
+// newTestAuthorizer returns an authorizer over the test database
+func newTestAuthorizer(db *sql.DB) *authz.Authorizer {
+	return authz.NewAuthorizer(
+		repository.NewSiteRepository(db),
+		repository.NewOrganizationRepository(db),
+		repository.NewTwoFactorRepository(db),
+	)
 }
 
-// Other methods needed to implement the repository interface
-func (m *MockSiteRepository) Create(site *models.Site) (int64, error) { return 0, nil }
-func (m *MockSiteRepository) FindByID(id int64) (*models.Site, error) { return nil, nil }
-func (m *MockSiteRepository) FindByDomain(userID int64, domain string) (*models.Site, error) { return nil, nil }
-func (m *MockSiteRepository) Update(site *models.Site) error { return nil }
-func (m *MockSiteRepository) Delete(id int64) error { return nil }
+// seedThreats creates a user with two active sites and ingests a few threats for them
+func seedThreats(t *testing.T, db *sql.DB) (*repository.SiteRepository, *service.ThreatService) {
+	userRepo := repository.NewUserRepository(db)
+	siteRepo := repository.NewSiteRepository(db)
+	threatService := service.NewThreatService(
+		repository.NewThreatRepository(db),
+		newTestAuthorizer(db),
+		service.NewWebhookService(repository.NewWebhookRepository(db)),
+	)
+
+	userID, err := userRepo.CreateWithIdentity(&models.User{Username: "test"}, &models.Identity{Provider: "github", Subject: "1", Username: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	threats := map[string][]models.ThreatNature{
+		"example.com":         {models.AICrawler, models.SQLInjection, models.AICrawler},
+		"another-example.com": {models.DDoS, models.XSS},
+	}
+
+	for domain, natures := range threats {
+		site := &models.Site{
+			UserID:         userID,
+			Domain:         domain,
+			ProtectionMode: models.SimpleProtection,
+			Active:         true,
+			Verified:       true,
+		}
+		site.ID, err = siteRepo.Create(site)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, nature := range natures {
+			err := threatService.Ingest(site, &models.Threat{
+				Nature: nature,
+				Source: []string{"192.168.1.1", "10.0.0.1"},
+				Time:   time.Now().Add(-time.Hour),
+				Status: models.Blocked,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	return siteRepo, threatService
+}
 
 func TestGetRecentThreats(t *testing.T) {
-	// Create mock site repository with test data
-	mockSiteRepo := &MockSiteRepository{
-		sites: []*models.Site{
-			{
-				ID:             1,
-				UserID:         123,
-				Domain:         "example.com",
-				ProtectionMode: models.SimpleProtection,
-				Active:         true,
-			},
-			{
-				ID:             2,
-				UserID:         123,
-				Domain:         "another-example.com",
-				ProtectionMode: models.HardenedProtection,
-				Active:         true,
-			},
-		},
-	}
-
-	// Create threat service
-	threatService := service.NewThreatService()
-
-	// Create handler with mock repo and service
-	handler := NewThreatHandler(mockSiteRepo, threatService)
+	db := newTestDB(t)
+	_, threatService := seedThreats(t, db)
+
+	// Create handler with repo and service
+	handler := NewThreatHandler(newTestAuthorizer(db), threatService)
 
 	// Create request
 	req, err := http.NewRequest("GET", "/api/threats", nil)
@@ -63,7 +108,7 @@ func TestGetRecentThreats(t *testing.T) {
 	}
 
 	// Add user ID to context
-	ctx := auth.WithUserID(context.Background(), 123)
+	ctx := auth.WithUserID(context.Background(), 1)
 	req = req.WithContext(ctx)
 
 	// Create response recorder
@@ -84,9 +129,9 @@ func TestGetRecentThreats(t *testing.T) {
 		t.Errorf("could not parse response as JSON: %v", err)
 	}
 
-	// We should have between 4 and 10 threats (2-5 per site, 2 sites)
-	if len(threats) < 4 || len(threats) > 10 {
-		t.Errorf("unexpected number of threats: got %d, want between 4 and 10", len(threats))
+	// We should get every ingested threat (3 + 2)
+	if len(threats) != 5 {
+		t.Errorf("unexpected number of threats: got %d, want 5", len(threats))
 	}
 
 	// Check that all threats have valid fields
@@ -95,7 +140,7 @@ func TestGetRecentThreats(t *testing.T) {
 			t.Errorf("threat has unexpected site: %s", threat.Site)
 		}
 
-		if threat.Nature < 1 || threat.Nature > 6 {
+		if threat.Nature < 1 || threat.Nature > 5 {
 			t.Errorf("threat has invalid nature: %d", threat.Nature)
 		}
 
@@ -103,38 +148,18 @@ func TestGetRecentThreats(t *testing.T) {
 			t.Errorf("threat has invalid status: %d", threat.Status)
 		}
 
-		if len(threat.Source) < 1 {
-			t.Errorf("threat has no sources")
+		if len(threat.Source) != 2 {
+			t.Errorf("threat has unexpected sources: %v", threat.Source)
 		}
 	}
 }
 
 func TestGetThreatDistribution(t *testing.T) {
-	// Create mock site repository with test data
-	mockSiteRepo := &MockSiteRepository{
-		sites: []*models.Site{
-			{
-				ID:             1,
-				UserID:         123,
-				Domain:         "example.com",
-				ProtectionMode: models.SimpleProtection,
-				Active:         true,
-			},
-			{
-				ID:             2,
-				UserID:         123,
-				Domain:         "another-example.com",
-				ProtectionMode: models.HardenedProtection,
-				Active:         true,
-			},
-		},
-	}
-
-	// Create threat service
-	threatService := service.NewThreatService()
-
-	// Create handler with mock repo and service
-	handler := NewThreatHandler(mockSiteRepo, threatService)
+	db := newTestDB(t)
+	_, threatService := seedThreats(t, db)
+
+	// Create handler with repo and service
+	handler := NewThreatHandler(newTestAuthorizer(db), threatService)
 
 	// Create request
 	req, err := http.NewRequest("GET", "/api/threats/distribution", nil)
@@ -143,7 +168,7 @@ func TestGetThreatDistribution(t *testing.T) {
 	}
 
 	// Add user ID to context
-	ctx := auth.WithUserID(context.Background(), 123)
+	ctx := auth.WithUserID(context.Background(), 1)
 	req = req.WithContext(ctx)
 
 	// Create response recorder
@@ -169,26 +194,18 @@ func TestGetThreatDistribution(t *testing.T) {
 		t.Errorf("unexpected number of threat types: got %d, want 6", len(distribution))
 	}
 
-	// Check that all threat types are represented
-	natureMap := make(map[models.ThreatNature]bool)
-	for _, dist := range distribution {
-		natureMap[dist.Nature] = true
-		
-		// Check that the count is positive
-		if dist.Count <= 0 {
-			t.Errorf("threat count should be positive, got %d for nature %d", dist.Count, dist.Nature)
-		}
-		
-		// Verify that the nature is valid
-		if dist.Nature < 1 || dist.Nature > 6 {
-			t.Errorf("invalid threat nature: %d", dist.Nature)
-		}
+	// Check the counts of the ingested threats
+	want := map[models.ThreatNature]int{
+		models.AICrawler:    2,
+		models.DDoS:         1,
+		models.BruteForce:   0,
+		models.XSS:          1,
+		models.SQLInjection: 1,
+		models.Defacement:   0,
 	}
-	
-	// Verify all 6 threat types are included
-	for i := 1; i <= 6; i++ {
-		if !natureMap[models.ThreatNature(i)] {
-			t.Errorf("missing threat nature: %d", i)
+	for _, dist := range distribution {
+		if dist.Count != want[dist.Nature] {
+			t.Errorf("unexpected count for nature %d: got %d, want %d", dist.Nature, dist.Count, want[dist.Nature])
 		}
 	}
 }
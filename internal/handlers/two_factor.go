@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"egide-server/internal/auth"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+	"egide-server/internal/service"
+)
+
+// TwoFactorHandler handles the two-factor authentication of the current user
+type TwoFactorHandler struct {
+	twoFactorService *service.TwoFactorService
+	userRepo         *repository.UserRepository
+	validator        *validator.Validate
+}
+
+// NewTwoFactorHandler creates a new two-factor handler
+func NewTwoFactorHandler(twoFactorService *service.TwoFactorService, userRepo *repository.UserRepository) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactorService: twoFactorService,
+		userRepo:         userRepo,
+		validator:        validator.New(),
+	}
+}
+
+// GetTwoFactor handles GET /api/users/me/two-factor
+func (h *TwoFactorHandler) GetTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	status, err := h.twoFactorService.Status(userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// EnrollTwoFactor handles POST /api/users/me/two-factor
+func (h *TwoFactorHandler) EnrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := h.userRepo.FindByID(userID)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	enrollment, err := h.twoFactorService.Enroll(userID, user.Username)
+	if err != nil {
+		if errors.Is(err, service.ErrTwoFactorEnabled) {
+			http.Error(w, "Two-factor authentication is already enabled", http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to enroll two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(enrollment)
+}
+
+// ConfirmTwoFactor handles POST /api/users/me/two-factor/confirm
+func (h *TwoFactorHandler) ConfirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	input, ok := h.decodeCode(w, r)
+	if !ok {
+		return
+	}
+
+	codes, err := h.twoFactorService.Confirm(userID, auth.SessionIDFromContext(r.Context()), input.Code)
+	if err != nil {
+		writeTwoFactorError(w, err)
+		return
+	}
+
+	writeRecoveryCodes(w, codes)
+}
+
+// VerifyTwoFactor handles POST /api/users/me/two-factor/verify
+func (h *TwoFactorHandler) VerifyTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	input, ok := h.decodeCode(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.twoFactorService.Verify(userID, auth.SessionIDFromContext(r.Context()), input.Code); err != nil {
+		writeTwoFactorError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes handles POST /api/users/me/two-factor/recovery-codes
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !requireStepUp(w, r, h.twoFactorService) {
+		return
+	}
+
+	codes, err := h.twoFactorService.RegenerateRecoveryCodes(userID)
+	if err != nil {
+		writeTwoFactorError(w, err)
+		return
+	}
+
+	writeRecoveryCodes(w, codes)
+}
+
+// DisableTwoFactor handles DELETE /api/users/me/two-factor
+func (h *TwoFactorHandler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !requireStepUp(w, r, h.twoFactorService) {
+		return
+	}
+
+	if err := h.twoFactorService.Disable(userID); err != nil {
+		http.Error(w, "Failed to disable two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *TwoFactorHandler) decodeCode(w http.ResponseWriter, r *http.Request) (*models.TwoFactorCodeInput, bool) {
+	var input models.TwoFactorCodeInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return nil, false
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	return &input, true
+}
+
+// requireStepUp refuses sensitive actions until users who enabled two-factor
+// authentication verified a code in their session. It writes the error
+// response and returns false when the action can't proceed.
+func requireStepUp(w http.ResponseWriter, r *http.Request, twoFactorService *service.TwoFactorService) bool {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return false
+	}
+
+	err = twoFactorService.CheckStepUp(userID, auth.SessionIDFromContext(r.Context()))
+	if errors.Is(err, service.ErrStepUpRequired) {
+		// Tells the frontend to ask for a code, then retry
+		w.Header().Set("X-Two-Factor", "required")
+		http.Error(w, "Two-factor verification required", http.StatusForbidden)
+		return false
+	}
+	if err != nil {
+		http.Error(w, "Failed to check two-factor verification", http.StatusInternalServerError)
+		return false
+	}
+
+	return true
+}
+
+func writeTwoFactorError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidTwoFactorCode):
+		http.Error(w, "Invalid two-factor code", http.StatusForbidden)
+	case errors.Is(err, service.ErrTwoFactorLocked):
+		http.Error(w, "Too many failed attempts, try again later", http.StatusTooManyRequests)
+	case errors.Is(err, service.ErrTwoFactorEnabled):
+		http.Error(w, "Two-factor authentication is already enabled", http.StatusConflict)
+	case errors.Is(err, service.ErrTwoFactorNotEnabled):
+		http.Error(w, "Two-factor authentication is not enabled", http.StatusBadRequest)
+	default:
+		http.Error(w, "Failed to verify two-factor code", http.StatusInternalServerError)
+	}
+}
+
+func writeRecoveryCodes(w http.ResponseWriter, codes []string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string][]string{"recovery_codes": codes})
+}
@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"egide-server/internal/auth"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+	"egide-server/internal/service"
+)
+
+// Number of deliveries returned by ListDeliveries
+const deliveryListLimit = 100
+
+// WebhookHandler handles webhook subscriptions and their delivery log
+type WebhookHandler struct {
+	webhookRepo    *repository.WebhookRepository
+	webhookService *service.WebhookService
+	validator      *validator.Validate
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(webhookRepo *repository.WebhookRepository, webhookService *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookRepo:    webhookRepo,
+		webhookService: webhookService,
+		validator:      validator.New(),
+	}
+}
+
+// ListSubscriptions handles GET /api/webhooks
+func (h *WebhookHandler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	subs, err := h.webhookRepo.FindSubscriptionsByUserID(userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch webhook subscriptions", http.StatusInternalServerError)
+		return
+	}
+
+	// Secrets are only shown once, when the subscription is created
+	for _, sub := range subs {
+		sub.Secret = ""
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(subs)
+}
+
+// GetSubscription handles GET /api/webhooks/{id}
+func (h *WebhookHandler) GetSubscription(w http.ResponseWriter, r *http.Request) {
+	sub, ok := h.ownedSubscription(w, r)
+	if !ok {
+		return
+	}
+
+	sub.Secret = ""
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sub)
+}
+
+// CreateSubscription handles POST /api/webhooks
+func (h *WebhookHandler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var input models.WebhookSubscriptionInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := service.CheckPublicURL(r.Context(), input.URL); err != nil {
+		http.Error(w, "Validation error: url: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	secret, err := service.GenerateSecret()
+	if err != nil {
+		http.Error(w, "Failed to generate webhook secret", http.StatusInternalServerError)
+		return
+	}
+
+	sub := &models.WebhookSubscription{
+		UserID: userID,
+		URL:    input.URL,
+		Secret: secret,
+		Events: input.Events,
+		Active: true,
+	}
+	if input.Active != nil {
+		sub.Active = *input.Active
+	}
+
+	subID, err := h.webhookRepo.CreateSubscription(sub)
+	if err != nil {
+		http.Error(w, "Failed to create webhook subscription: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sub, err = h.webhookRepo.FindSubscriptionByID(subID)
+	if err != nil {
+		http.Error(w, "Webhook subscription created but failed to fetch", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(sub)
+}
+
+// UpdateSubscription handles PUT /api/webhooks/{id}
+func (h *WebhookHandler) UpdateSubscription(w http.ResponseWriter, r *http.Request) {
+	sub, ok := h.ownedSubscription(w, r)
+	if !ok {
+		return
+	}
+
+	var input models.WebhookSubscriptionInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := service.CheckPublicURL(r.Context(), input.URL); err != nil {
+		http.Error(w, "Validation error: url: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sub.URL = input.URL
+	sub.Events = input.Events
+	if input.Active != nil {
+		sub.Active = *input.Active
+	}
+
+	if err := h.webhookRepo.UpdateSubscription(sub); err != nil {
+		http.Error(w, "Failed to update webhook subscription: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sub.Secret = ""
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sub)
+}
+
+// DeleteSubscription handles DELETE /api/webhooks/{id}
+func (h *WebhookHandler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	sub, ok := h.ownedSubscription(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.webhookRepo.DeleteSubscription(sub.ID); err != nil {
+		http.Error(w, "Failed to delete webhook subscription: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListDeliveries handles GET /api/webhooks/{id}/deliveries
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	sub, ok := h.ownedSubscription(w, r)
+	if !ok {
+		return
+	}
+
+	deliveries, err := h.webhookRepo.FindDeliveriesBySubscriptionID(sub.ID, deliveryListLimit)
+	if err != nil {
+		http.Error(w, "Failed to fetch webhook deliveries", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// Redeliver handles POST /api/webhooks/{id}/deliveries/{deliveryID}/redeliver
+func (h *WebhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	sub, ok := h.ownedSubscription(w, r)
+	if !ok {
+		return
+	}
+
+	deliveryID, err := strconv.ParseInt(chi.URLParam(r, "deliveryID"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid delivery ID", http.StatusBadRequest)
+		return
+	}
+
+	delivery, err := h.webhookRepo.FindDeliveryByID(deliveryID)
+	if err != nil || delivery.SubscriptionID != sub.ID {
+		http.Error(w, "Delivery not found", http.StatusNotFound)
+		return
+	}
+
+	redelivery, err := h.webhookService.Redeliver(delivery)
+	if err != nil {
+		http.Error(w, "Failed to queue redelivery: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(redelivery)
+}
+
+func (h *WebhookHandler) ownedSubscription(w http.ResponseWriter, r *http.Request) (*models.WebhookSubscription, bool) {
+	userID, err := auth.UserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	subID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid webhook subscription ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	sub, err := h.webhookRepo.FindSubscriptionByID(subID)
+	if err != nil {
+		http.Error(w, "Webhook subscription not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	if sub.UserID != userID {
+		http.Error(w, "Unauthorized", http.StatusForbidden)
+		return nil, false
+	}
+
+	return sub, true
+}
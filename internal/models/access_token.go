@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Scope grants a personal access token access to a group of API routes
+type Scope string
+
+const (
+	ScopeSitesRead   Scope = "sites:read"
+	ScopeSitesWrite  Scope = "sites:write"
+	ScopeThreatsRead Scope = "threats:read"
+	ScopeMetricsRead Scope = "metrics:read"
+)
+
+// AccessToken is a named token used by automation to call the API on behalf of a user
+type AccessToken struct {
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"user_id"`
+	Name       string     `json:"name"`
+	TokenHash  string     `json:"-"`
+	Prefix     string     `json:"prefix"`          // first characters of the token, to recognize it
+	Token      string     `json:"token,omitempty"` // only returned when the token is created
+	Scopes     []Scope    `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// HasScope reports whether the token was granted a scope
+func (t *AccessToken) HasScope(scope Scope) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Data required to create a personal access token
+type AccessTokenInput struct {
+	Name          string  `json:"name" validate:"required,max=100"`
+	Scopes        []Scope `json:"scopes" validate:"required,min=1,dive,oneof=sites:read sites:write threats:read metrics:read"`
+	ExpiresInDays *int    `json:"expires_in_days,omitempty" validate:"omitempty,min=1,max=365"`
+}
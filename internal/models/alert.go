@@ -0,0 +1,96 @@
+package models
+
+import "time"
+
+// AlertRuleType represents the condition evaluated by an alert rule
+type AlertRuleType string
+
+const (
+	// MonitorDownRule fires when the last `threshold` checks of a monitor failed
+	MonitorDownRule AlertRuleType = "monitor_down"
+
+	// LatencyAboveRule fires when every successful check of a monitor in the
+	// window took longer than `threshold` milliseconds
+	LatencyAboveRule AlertRuleType = "latency_above"
+
+	// ThreatRateRule fires when a site receives more than `threshold` threats
+	// per minute over the window
+	ThreatRateRule AlertRuleType = "threat_rate"
+)
+
+// IncidentStatus represents the state of an alert incident
+type IncidentStatus string
+
+const (
+	IncidentFiring   IncidentStatus = "firing"
+	IncidentResolved IncidentStatus = "resolved"
+)
+
+// ChannelType represents how a notification is delivered
+type ChannelType string
+
+const (
+	WebhookChannel ChannelType = "webhook"
+	EmailChannel   ChannelType = "email"
+	SlackChannel   ChannelType = "slack"
+	DiscordChannel ChannelType = "discord"
+	MatrixChannel  ChannelType = "matrix"
+)
+
+// AlertRule is a user-defined condition evaluated in the background
+type AlertRule struct {
+	ID            int64         `json:"id"`
+	UserID        int64         `json:"user_id"`
+	Name          string        `json:"name"`
+	Type          AlertRuleType `json:"type"`
+	SiteID        *int64        `json:"site_id,omitempty"`
+	MonitorID     *int64        `json:"monitor_id,omitempty"`
+	Threshold     float64       `json:"threshold"`
+	WindowMinutes int           `json:"window_minutes"`
+	Enabled       bool          `json:"enabled"`
+	ChannelIDs    []int64       `json:"channel_ids"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+}
+
+// Data required to create or update an alert rule
+type AlertRuleInput struct {
+	Name          string        `json:"name" validate:"required,max=100"`
+	Type          AlertRuleType `json:"type" validate:"required,oneof=monitor_down latency_above threat_rate"`
+	SiteID        *int64        `json:"site_id,omitempty"`
+	MonitorID     *int64        `json:"monitor_id,omitempty"`
+	Threshold     float64       `json:"threshold" validate:"gte=0"`
+	WindowMinutes int           `json:"window_minutes" validate:"gte=0,lte=1440"`
+	Enabled       *bool         `json:"enabled,omitempty"`
+	ChannelIDs    []int64       `json:"channel_ids"`
+}
+
+// AlertIncident records one firing period of an alert rule
+type AlertIncident struct {
+	ID         int64          `json:"id"`
+	RuleID     int64          `json:"rule_id"`
+	Status     IncidentStatus `json:"status"`
+	Message    string         `json:"message"`
+	StartedAt  time.Time      `json:"started_at"`
+	ResolvedAt *time.Time     `json:"resolved_at,omitempty"`
+}
+
+// NotificationChannel is a destination for alert notifications
+type NotificationChannel struct {
+	ID        int64       `json:"id"`
+	UserID    int64       `json:"user_id"`
+	Name      string      `json:"name"`
+	Type      ChannelType `json:"type"`
+	Target    string      `json:"target"` // URL for webhooks, address for email
+	Enabled   bool        `json:"enabled"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// Data required to create or update a notification channel
+type NotificationChannelInput struct {
+	Name    string      `json:"name" validate:"required,max=100"`
+	Type    ChannelType `json:"type" validate:"required,oneof=webhook email slack discord matrix"`
+	Target  string      `json:"target" validate:"required"`
+	Enabled *bool       `json:"enabled,omitempty"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// AnalyticsDimension is an attribute of requests that top values are tracked for
+type AnalyticsDimension string
+
+const (
+	PathDimension       AnalyticsDimension = "path"
+	StatusCodeDimension AnalyticsDimension = "status_code"
+	UserAgentDimension  AnalyticsDimension = "user_agent"
+	ReferrerDimension   AnalyticsDimension = "referrer"
+	CountryDimension    AnalyticsDimension = "country"
+	ASNDimension        AnalyticsDimension = "asn"
+)
+
+// AnalyticsDimensions lists every tracked dimension
+var AnalyticsDimensions = []AnalyticsDimension{
+	PathDimension,
+	StatusCodeDimension,
+	UserAgentDimension,
+	ReferrerDimension,
+	CountryDimension,
+	ASNDimension,
+}
+
+// Sampled request reported by edge nodes. SampleRate is the fraction of
+// requests the node samples, each sample counts for 1/SampleRate requests.
+type RequestSampleInput struct {
+	Site       string     `json:"site" validate:"required,fqdn"`
+	Time       *time.Time `json:"time,omitempty"`
+	Path       string     `json:"path" validate:"required"`
+	StatusCode int        `json:"status_code" validate:"required,min=100,max=599"`
+	UserAgent  string     `json:"user_agent"`
+	Referrer   string     `json:"referrer"`
+	Country    string     `json:"country" validate:"omitempty,len=2,alpha"` // ISO 3166-1 alpha-2
+	ASN        int64      `json:"asn" validate:"min=0"`
+	SampleRate float64    `json:"sample_rate" validate:"omitempty,gt=0,lte=1"`
+}
+
+// TopValue is a value of a dimension and the estimated number of requests
+// having it. Error bounds how much Count may overestimate.
+type TopValue struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+	Error int64  `json:"error"`
+}
+
+// SiteAnalytics holds the top values of each dimension for a site over a range
+type SiteAnalytics struct {
+	SiteID int64                               `json:"site_id"`
+	From   time.Time                           `json:"from"`
+	To     time.Time                           `json:"to"`
+	Top    map[AnalyticsDimension][]*TopValue `json:"top"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditAction is a recorded change of ownership or access
+type AuditAction string
+
+const (
+	AuditSiteTransferRequested AuditAction = "site.transfer_requested"
+	AuditSiteTransferCancelled AuditAction = "site.transfer_cancelled"
+	AuditSiteTransferDeclined  AuditAction = "site.transfer_declined"
+	AuditSiteTransferred       AuditAction = "site.transferred"
+)
+
+// AuditEntry records who did what on a site or an organization
+type AuditEntry struct {
+	ID             int64           `json:"id"`
+	ActorID        *int64          `json:"actor_id"` // nil once the user is deleted
+	Action         AuditAction     `json:"action"`
+	SiteID         *int64          `json:"site_id,omitempty"`
+	OrganizationID *int64          `json:"organization_id,omitempty"`
+	Details        json.RawMessage `json:"details"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
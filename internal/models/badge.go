@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// BadgeSettings controls whether the public badges of a site can be embedded.
+// Badges are disabled until the owner opts in.
+type BadgeSettings struct {
+	SiteID    int64     `json:"site_id"`
+	Enabled   bool      `json:"enabled"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Data required to update the badge settings of a site
+type BadgeSettingsInput struct {
+	Enabled *bool `json:"enabled" validate:"required"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// TLSCertificate is the certificate a monitor's HTTPS endpoint presented on
+// its last check
+type TLSCertificate struct {
+	MonitorID         int64                `json:"monitor_id"`
+	Subject           string               `json:"subject"`
+	Issuer            string               `json:"issuer"`
+	SANs              []string             `json:"sans"`
+	SerialNumber      string               `json:"serial_number"`
+	Fingerprint       string               `json:"fingerprint"` // hex SHA-256 of the DER certificate
+	NotBefore         time.Time            `json:"not_before"`
+	NotAfter          time.Time            `json:"not_after"`
+	Chain             []*CertificateIssuer `json:"chain"`                        // intermediates, leaf issuer first
+	WarnedDays        *int                 `json:"-"`                            // smallest expiry threshold already notified
+	VerificationError *string              `json:"verification_error,omitempty"` // why the chain isn't trusted for the host, nil when it is
+	CheckedAt         time.Time            `json:"checked_at"`
+}
+
+// CertificateIssuer describes an intermediate certificate of a chain
+type CertificateIssuer struct {
+	Subject  string    `json:"subject"`
+	Issuer   string    `json:"issuer"`
+	NotAfter time.Time `json:"not_after"`
+}
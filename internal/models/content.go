@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// ContentCheck configures defacement detection on a monitor: the response
+// body is hashed on every successful check and compared to a baseline, after
+// removing the regions matched by IgnorePatterns (timestamps, CSRF tokens...)
+type ContentCheck struct {
+	MonitorID      int64      `json:"monitor_id"`
+	Enabled        bool       `json:"enabled"`
+	IgnorePatterns []string   `json:"ignore_patterns"`
+	BaselineHash   string     `json:"baseline_hash,omitempty"`
+	BaselineBody   string     `json:"-"`
+	BaselineAt     *time.Time `json:"baseline_at,omitempty"`
+	LastHash       string     `json:"last_hash,omitempty"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// Data required to configure content change detection
+type ContentCheckInput struct {
+	Enabled        *bool    `json:"enabled" validate:"required"`
+	IgnorePatterns []string `json:"ignore_patterns" validate:"max=20,dive,required,max=500"`
+}
+
+// ContentChange records a deviation of a monitored page from its baseline
+type ContentChange struct {
+	ID           int64     `json:"id"`
+	MonitorID    int64     `json:"monitor_id"`
+	SiteID       int64     `json:"site_id"`
+	BaselineHash string    `json:"baseline_hash"`
+	Hash         string    `json:"hash"`
+	Summary      string    `json:"summary"`
+	ThreatID     *int64    `json:"threat_id,omitempty"`
+	DetectedAt   time.Time `json:"detected_at"`
+}
@@ -4,10 +4,50 @@ import "time"
 
 type HealthCheck struct {
 	ID             int64     `json:"id"`
+	MonitorID      *int64    `json:"monitor_id,omitempty"` // nil for checks recorded before monitors existed
 	Timestamp      time.Time `json:"timestamp"`
 	ResponseTimeMs int       `json:"response_time_ms"`
 	StatusCode     *int      `json:"status_code,omitempty"` // nil if request failed completely
 	Success        bool      `json:"success"`
 	Error          *string   `json:"error,omitempty"`
+	Maintenance    bool      `json:"maintenance"` // performed during a maintenance window of the site
 	CreatedAt      time.Time `json:"created_at"`
 }
+
+// DailyCheckStats aggregates the checks of a monitor over one UTC day
+type DailyCheckStats struct {
+	Day       string `json:"day"` // YYYY-MM-DD
+	Total     int    `json:"total"`
+	Successes int    `json:"successes"`
+}
+
+// RollupResolution is the size of the buckets health checks are aggregated into
+type RollupResolution string
+
+const (
+	// HourlyRollup aggregates the checks of one hour
+	HourlyRollup RollupResolution = "hour"
+
+	// DailyRollup aggregates the hourly rollups of one UTC day
+	DailyRollup RollupResolution = "day"
+)
+
+// HealthCheckRollup aggregates the checks of a monitor over one bucket.
+// Total and Successes leave out checks made during maintenance; latency
+// figures only cover successful checks.
+type HealthCheckRollup struct {
+	MonitorID    int64            `json:"monitor_id"`
+	Resolution   RollupResolution `json:"resolution"`
+	BucketStart  time.Time        `json:"bucket_start"`
+	Total        int              `json:"total"`
+	Successes    int              `json:"successes"`
+	Maintenance  int              `json:"maintenance"`
+	LatencyCount int              `json:"latency_count"`
+	MinMs        int              `json:"min_ms"`
+	AvgMs        float64          `json:"avg_ms"`
+	MaxMs        int              `json:"max_ms"`
+	P50Ms        int              `json:"p50_ms"`
+	P95Ms        int              `json:"p95_ms"`
+	P99Ms        int              `json:"p99_ms"`
+	Histogram    []int            `json:"histogram"` // counts per latency bucket, see service.LatencyBuckets
+}
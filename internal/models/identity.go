@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// EmailIdentity is the provider of verified email addresses, which can be
+// linked to a user but not used to sign in
+const EmailIdentity = "email"
+
+// Identity is an account of a user at an identity provider
+type Identity struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CanSignIn reports whether the user can sign in with this identity
+func (i *Identity) CanSignIn() bool {
+	return i.Provider != EmailIdentity
+}
+
+// Data required to link an email address
+type EmailIdentityInput struct {
+	Email string `json:"email" validate:"required,email,max=254"`
+}
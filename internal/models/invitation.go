@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Invitation asks someone by email to join an organization with a role
+type Invitation struct {
+	ID             int64      `json:"id"`
+	OrganizationID int64      `json:"organization_id"`
+	Email          string     `json:"email"`
+	Role           Role       `json:"role"`
+	TokenHash      string     `json:"-"`
+	InvitedBy      int64      `json:"invited_by"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	SentAt         time.Time  `json:"sent_at"`
+	AcceptedBy     *int64     `json:"accepted_by,omitempty"`
+	AcceptedAt     *time.Time `json:"accepted_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// Data required to invite someone to an organization
+type InvitationInput struct {
+	Email         string `json:"email" validate:"required,email,max=254"`
+	Role          Role   `json:"role" validate:"required,oneof=owner admin editor viewer"`
+	ExpiresInDays *int   `json:"expires_in_days,omitempty" validate:"omitempty,min=1,max=30"`
+}
+
+// Data required to accept an invitation
+type InvitationAcceptInput struct {
+	Token string `json:"token" validate:"required"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// MaintenanceRecurrence represents how often a maintenance window repeats
+type MaintenanceRecurrence string
+
+const (
+	// OneOffMaintenance happens once, from StartsAt for DurationMinutes
+	OneOffMaintenance MaintenanceRecurrence = "none"
+
+	// DailyMaintenance repeats every day at the wall-clock time of StartsAt
+	DailyMaintenance MaintenanceRecurrence = "daily"
+
+	// WeeklyMaintenance repeats on Weekdays at the wall-clock time of StartsAt
+	WeeklyMaintenance MaintenanceRecurrence = "weekly"
+)
+
+// MaintenanceWindow is a period during which a site is intentionally down.
+// Checks performed during a window are tagged as maintenance, alerts are
+// suppressed and the period is excluded from uptime.
+type MaintenanceWindow struct {
+	ID              int64                 `json:"id"`
+	SiteID          int64                 `json:"site_id"`
+	Name            string                `json:"name"`
+	Recurrence      MaintenanceRecurrence `json:"recurrence"`
+	StartsAt        time.Time             `json:"starts_at"`
+	DurationMinutes int                   `json:"duration_minutes"`
+	Weekdays        []time.Weekday        `json:"weekdays,omitempty"` // 0 = Sunday, weekly windows only
+	TimeZone        string                `json:"time_zone"`
+	CreatedAt       time.Time             `json:"created_at"`
+	UpdatedAt       time.Time             `json:"updated_at"`
+}
+
+// Data required to create or update a maintenance window
+type MaintenanceWindowInput struct {
+	Name            string                `json:"name" validate:"required,max=100"`
+	Recurrence      MaintenanceRecurrence `json:"recurrence" validate:"required,oneof=none daily weekly"`
+	StartsAt        time.Time             `json:"starts_at" validate:"required"`
+	DurationMinutes int                   `json:"duration_minutes" validate:"required,min=1,max=10080"`
+	Weekdays        []time.Weekday        `json:"weekdays" validate:"dive,min=0,max=6"`
+	TimeZone        string                `json:"time_zone"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Monitor is an HTTP endpoint belonging to a site that is health-checked periodically
+type Monitor struct {
+	ID        int64     `json:"id"`
+	SiteID    int64     `json:"site_id"`
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Data required to create or update a monitor
+type MonitorInput struct {
+	Name    string `json:"name" validate:"required,max=100"`
+	URL     string `json:"url" validate:"required,url"`
+	Enabled *bool  `json:"enabled,omitempty"`
+}
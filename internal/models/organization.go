@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Role is the role of a member in an organization
+type Role string
+
+const (
+	// RoleOwner manages the organization itself, including its owners
+	RoleOwner Role = "owner"
+
+	// RoleAdmin manages the members and sites of the organization
+	RoleAdmin Role = "admin"
+
+	// RoleEditor changes the settings of the sites
+	RoleEditor Role = "editor"
+
+	// RoleViewer only reads the sites, their threats and metrics
+	RoleViewer Role = "viewer"
+)
+
+// Organization is a team sharing sites
+type Organization struct {
+	ID               int64     `json:"id"`
+	Name             string    `json:"name"`
+	RequireTwoFactor bool      `json:"require_two_factor"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+
+	// Role of the user listing organizations
+	Role Role `json:"role,omitempty"`
+}
+
+// OrganizationMember is a user with a role in an organization
+type OrganizationMember struct {
+	OrganizationID int64     `json:"organization_id"`
+	UserID         int64     `json:"user_id"`
+	Username       string    `json:"username"`
+	Role           Role      `json:"role"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Data required to create or update an organization
+type OrganizationInput struct {
+	Name             string `json:"name" validate:"required,max=100"`
+	RequireTwoFactor *bool  `json:"require_two_factor,omitempty"`
+}
+
+// Data required to change the role of a member
+type OrganizationRoleInput struct {
+	Role Role `json:"role" validate:"required,oneof=owner admin editor viewer"`
+}
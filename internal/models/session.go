@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Session is a sign-in of a user on a device, kept alive by rotating refresh tokens
+type Session struct {
+	ID                int64      `json:"id"`
+	UserID            int64      `json:"user_id"`
+	RefreshTokenHash  string     `json:"-"`
+	PreviousTokenHash string     `json:"-"`
+	UserAgent         string     `json:"user_agent"`
+	IPAddress         string     `json:"ip_address"`
+	CreatedAt         time.Time  `json:"created_at"`
+	LastUsedAt        time.Time  `json:"last_used_at"`
+	ExpiresAt         time.Time  `json:"expires_at"`
+	RevokedAt         *time.Time `json:"revoked_at,omitempty"`
+
+	// Last two-factor verification, allowing sensitive actions for a while
+	TwoFactorVerifiedAt *time.Time `json:"two_factor_verified_at,omitempty"`
+
+	// Whether the session is the one of the request listing sessions
+	Current bool `json:"current"`
+}
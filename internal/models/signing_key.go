@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// SigningKey is a key pair signing access tokens. It signs new tokens until
+// it rotates, and its tokens are accepted until it expires
+type SigningKey struct {
+	ID         int64
+	KeyID      string
+	Algorithm  string
+	PrivateKey []byte // encrypted PKCS #8 key
+	PublicKey  []byte // PKIX public key
+	CreatedAt  time.Time
+	RotatesAt  time.Time
+	ExpiresAt  time.Time
+}
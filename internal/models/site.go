@@ -15,6 +15,7 @@ const (
 type Site struct {
 	ID             int64          `json:"id"`
 	UserID         int64          `json:"user_id"`
+	OrganizationID *int64         `json:"organization_id,omitempty"` // set for sites owned by an organization
 	Domain         string         `json:"domain"`
 	ProtectionMode ProtectionMode `json:"protection_mode"`
 	Active         bool           `json:"active"`
@@ -29,4 +30,5 @@ type SiteInput struct {
 	ProtectionMode ProtectionMode `json:"protection_mode" validate:"required,oneof=simple hardened"`
 	Active         *bool          `json:"active,omitempty"`
 	Verified       *bool          `json:"verified,omitempty"`
+	OrganizationID *int64         `json:"organization_id,omitempty"` // only read on creation
 }
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// TransferStatus is the state of a site transfer
+type TransferStatus string
+
+const (
+	TransferPending   TransferStatus = "pending"
+	TransferAccepted  TransferStatus = "accepted"
+	TransferDeclined  TransferStatus = "declined"
+	TransferCancelled TransferStatus = "cancelled"
+)
+
+// SiteTransfer hands a site over to another user or organization, once
+// the recipient accepts it
+type SiteTransfer struct {
+	ID                 int64          `json:"id"`
+	SiteID             int64          `json:"site_id"`
+	Domain             string         `json:"domain"`
+	FromUserID         int64          `json:"from_user_id"`
+	FromOrganizationID *int64         `json:"from_organization_id,omitempty"`
+	ToUserID           *int64         `json:"to_user_id,omitempty"`
+	ToOrganizationID   *int64         `json:"to_organization_id,omitempty"`
+	Status             TransferStatus `json:"status"`
+	InitiatedBy        int64          `json:"initiated_by"`
+	CompletedBy        *int64         `json:"completed_by,omitempty"`
+	CreatedAt          time.Time      `json:"created_at"`
+	CompletedAt        *time.Time     `json:"completed_at,omitempty"`
+}
+
+// Data required to transfer a site: exactly one recipient
+type SiteTransferInput struct {
+	ToUserID         *int64 `json:"to_user_id,omitempty" validate:"required_without=ToOrganizationID,excluded_with=ToOrganizationID,omitempty,min=1"`
+	ToOrganizationID *int64 `json:"to_organization_id,omitempty" validate:"required_without=ToUserID,excluded_with=ToUserID,omitempty,min=1"`
+}
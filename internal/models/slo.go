@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Slo is a service level objective on the health checks of a site: the
+// percentage of checks that must be good over a rolling window. A check is
+// good when it succeeded and, if a latency threshold is set, was fast enough.
+// Checks performed during maintenance don't count.
+type Slo struct {
+	ID                 int64      `json:"id"`
+	SiteID             int64      `json:"site_id"`
+	MonitorID          *int64     `json:"monitor_id,omitempty"` // every monitor of the site when nil
+	Name               string     `json:"name"`
+	Target             float64    `json:"target"` // e.g. 99.9
+	LatencyThresholdMs *int       `json:"latency_threshold_ms,omitempty"`
+	WindowDays         int        `json:"window_days"`
+	AlertEnabled       bool       `json:"alert_enabled"`
+	BurnRateThreshold  float64    `json:"burn_rate_threshold"`
+	ChannelIDs         []int64    `json:"channel_ids"`
+	BurningSince       *time.Time `json:"burning_since,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+// Data required to create or update an SLO
+type SloInput struct {
+	Name               string  `json:"name" validate:"required,max=100"`
+	MonitorID          *int64  `json:"monitor_id,omitempty"`
+	Target             float64 `json:"target" validate:"required,gt=0,lt=100"`
+	LatencyThresholdMs *int    `json:"latency_threshold_ms,omitempty" validate:"omitempty,min=1,max=60000"`
+	WindowDays         int     `json:"window_days" validate:"omitempty,min=1,max=30"`
+	AlertEnabled       *bool   `json:"alert_enabled,omitempty"`
+	BurnRateThreshold  float64 `json:"burn_rate_threshold" validate:"omitempty,gt=1"`
+	ChannelIDs         []int64 `json:"channel_ids"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// StatusPage is a public page publishing the uptime of selected monitors.
+// CustomDomain serves the page once verified; a new domain stays in
+// PendingDomain until a TXT record holding DomainToken proves its control.
+type StatusPage struct {
+	ID            int64     `json:"id"`
+	UserID        int64     `json:"user_id"`
+	Slug          string    `json:"slug"`
+	Title         string    `json:"title"`
+	Description   string    `json:"description"`
+	CustomDomain  *string   `json:"custom_domain,omitempty"`
+	PendingDomain *string   `json:"pending_domain,omitempty"`
+	DomainToken   *string   `json:"domain_token,omitempty"`
+	MonitorIDs    []int64   `json:"monitor_ids"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Data required to create or update a status page
+type StatusPageInput struct {
+	Slug         string  `json:"slug" validate:"required,min=3,max=64"`
+	Title        string  `json:"title" validate:"required,max=100"`
+	Description  string  `json:"description" validate:"max=500"`
+	CustomDomain *string `json:"custom_domain,omitempty" validate:"omitempty,fqdn"`
+	MonitorIDs   []int64 `json:"monitor_ids" validate:"max=50"`
+}
@@ -13,6 +13,9 @@ const (
 	BruteForce   ThreatNature = 3
 	XSS          ThreatNature = 4
 	SQLInjection ThreatNature = 5
+
+	// Defacement is detected by content monitoring rather than reported by edge nodes
+	Defacement ThreatNature = 6
 )
 
 // ThreatStatus represents the status of the threat
@@ -26,6 +29,8 @@ const (
 
 // Threat represents a security threat detected for a site
 type Threat struct {
+	ID     int64        `json:"id,omitempty"`
+	SiteID int64        `json:"site_id,omitempty"`
 	Nature ThreatNature `json:"nature"`
 	Source []string     `json:"source"`
 	Time   time.Time    `json:"time"`
@@ -33,6 +38,15 @@ type Threat struct {
 	Status ThreatStatus `json:"status"`
 }
 
+// ThreatInput is a threat reported by an edge node
+type ThreatInput struct {
+	Site   string       `json:"site" validate:"required,fqdn"`
+	Nature ThreatNature `json:"nature" validate:"required,min=1,max=5"`
+	Status ThreatStatus `json:"status" validate:"required,min=1,max=3"`
+	Source []string     `json:"source" validate:"required,min=1,dive,ip"`
+	Time   *time.Time   `json:"time,omitempty"`
+}
+
 // GetNatureName returns the string representation of the threat nature
 func (t *Threat) GetNatureName() string {
 	switch t.Nature {
@@ -46,6 +60,8 @@ func (t *Threat) GetNatureName() string {
 		return "XSS"
 	case SQLInjection:
 		return "SQL Injection"
+	case Defacement:
+		return "Defacement"
 	default:
 		return "Unknown"
 	}
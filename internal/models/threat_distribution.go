@@ -5,3 +5,11 @@ type ThreatDistribution struct {
 	Nature ThreatNature `json:"nature"`
 	Count  int          `json:"count"`
 }
+
+// ThreatCount is the number of threats of a site with a given nature and status
+type ThreatCount struct {
+	SiteID int64        `json:"site_id"`
+	Nature ThreatNature `json:"nature"`
+	Status ThreatStatus `json:"status"`
+	Count  int          `json:"count"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// TrafficCounter holds the requests an edge node served for a site during
+// one reporting period
+type TrafficCounter struct {
+	SiteID      int64     `json:"site_id"`
+	Node        string    `json:"node"`
+	PeriodStart time.Time `json:"period_start"`
+	Requests    int64     `json:"requests"`
+	Blocked     int64     `json:"blocked"`
+	Challenged  int64     `json:"challenged"`
+	Cached      int64     `json:"cached"`
+	Bytes       int64     `json:"bytes"`
+}
+
+// Data reported by edge nodes for one site and period. Reporting the same
+// site, node and period again replaces the previous counters.
+type TrafficInput struct {
+	Site        string    `json:"site" validate:"required,fqdn"`
+	Node        string    `json:"node" validate:"required,max=64"`
+	PeriodStart time.Time `json:"period_start" validate:"required"`
+	Requests    int64     `json:"requests" validate:"min=0"`
+	Blocked     int64     `json:"blocked" validate:"min=0,ltefield=Requests"`
+	Challenged  int64     `json:"challenged" validate:"min=0,ltefield=Requests"`
+	Cached      int64     `json:"cached" validate:"min=0,ltefield=Requests"`
+	Bytes       int64     `json:"bytes" validate:"min=0"`
+}
+
+// TrafficTotals sums traffic counters over a range
+type TrafficTotals struct {
+	Requests   int64 `json:"requests"`
+	Blocked    int64 `json:"blocked"`
+	Challenged int64 `json:"challenged"`
+	Cached     int64 `json:"cached"`
+	Bytes      int64 `json:"bytes"`
+}
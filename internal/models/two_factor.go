@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// TwoFactor is the TOTP authenticator of a user, pending until a first code
+// confirms its enrollment
+type TwoFactor struct {
+	UserID      int64
+	Secret      []byte // encrypted base32 secret
+	EnabledAt   *time.Time
+	LastCounter int64 // period of the last accepted code, which can't be used again
+	CreatedAt   time.Time
+}
+
+// TwoFactorStatus describes the two-factor authentication of a user
+type TwoFactorStatus struct {
+	Enabled           bool       `json:"enabled"`
+	EnabledAt         *time.Time `json:"enabled_at,omitempty"`
+	RecoveryCodesLeft int        `json:"recovery_codes_left"`
+}
+
+// TwoFactorEnrollment is the secret to add to an authenticator app, shown as
+// a QR code of the provisioning URI
+type TwoFactorEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// Data required to verify a TOTP or recovery code
+type TwoFactorCodeInput struct {
+	Code string `json:"code" validate:"required,max=32"`
+}
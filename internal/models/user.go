@@ -4,7 +4,6 @@ import "time"
 
 type User struct {
 	ID        int64     `json:"id"`
-	GitHubID  string    `json:"github_id"`
 	Username  string    `json:"username"`
 	Email     string    `json:"email,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// EventType identifies an event that can be delivered to webhook subscriptions
+type EventType string
+
+const (
+	EventSiteCreated     EventType = "site.created"
+	EventSiteUpdated     EventType = "site.updated"
+	EventSiteVerified    EventType = "site.verified"
+	EventSiteActivated   EventType = "site.activated"
+	EventSiteDeactivated EventType = "site.deactivated"
+	EventThreatIngested  EventType = "threat.ingested"
+	EventIncidentOpened  EventType = "incident.opened"
+	EventIncidentClosed  EventType = "incident.closed"
+	EventSloBurning      EventType = "slo.burning"
+	EventSloRecovered    EventType = "slo.recovered"
+	EventCertExpiring    EventType = "certificate.expiring"
+	EventCertInvalid     EventType = "certificate.invalid"
+)
+
+// DeliveryStatus represents the state of a webhook delivery
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// WebhookSubscription is an HTTP endpoint subscribed to a set of events
+type WebhookSubscription struct {
+	ID        int64       `json:"id"`
+	UserID    int64       `json:"user_id"`
+	URL       string      `json:"url"`
+	Secret    string      `json:"secret,omitempty"` // only returned when the subscription is created
+	Events    []EventType `json:"events"`
+	Active    bool        `json:"active"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// Data required to create or update a webhook subscription
+type WebhookSubscriptionInput struct {
+	URL    string      `json:"url" validate:"required,url"`
+	Events []EventType `json:"events" validate:"required,min=1,dive,oneof=site.created site.updated site.verified site.activated site.deactivated threat.ingested incident.opened incident.closed slo.burning slo.recovered certificate.expiring certificate.invalid"`
+	Active *bool       `json:"active,omitempty"`
+}
+
+// WebhookDelivery is one attempt chain to deliver an event to a subscription
+type WebhookDelivery struct {
+	ID             int64          `json:"id"`
+	SubscriptionID int64          `json:"subscription_id"`
+	EventID        string         `json:"event_id"`
+	EventType      EventType      `json:"event_type"`
+	Payload        string         `json:"payload"`
+	Status         DeliveryStatus `json:"status"`
+	Attempts       int            `json:"attempts"`
+	NextAttemptAt  *time.Time     `json:"next_attempt_at,omitempty"`
+	LastStatusCode *int           `json:"last_status_code,omitempty"`
+	LastError      *string        `json:"last_error,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	DeliveredAt    *time.Time     `json:"delivered_at,omitempty"`
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"egide-server/internal/models"
+	"egide-server/internal/telemetry"
+)
+
+const accessTokenColumns = `id, user_id, name, token_hash, token_prefix, scopes, expires_at, last_used_at, created_at`
+
+type AccessTokenRepository struct {
+	db *sql.DB
+}
+
+func NewAccessTokenRepository(db *sql.DB) *AccessTokenRepository {
+	return &AccessTokenRepository{
+		db: db,
+	}
+}
+
+func (r *AccessTokenRepository) Create(token *models.AccessToken) (int64, error) {
+	defer telemetry.ObserveQuery("access_token", "Create")()
+
+	query := `
+		INSERT INTO access_tokens (user_id, name, token_hash, token_prefix, scopes, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	var expiresAt *time.Time
+	if token.ExpiresAt != nil {
+		utc := token.ExpiresAt.UTC()
+		expiresAt = &utc
+	}
+
+	result, err := r.db.Exec(
+		query,
+		token.UserID,
+		token.Name,
+		token.TokenHash,
+		token.Prefix,
+		joinScopes(token.Scopes),
+		expiresAt,
+		token.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+func (r *AccessTokenRepository) FindByID(id int64) (*models.AccessToken, error) {
+	defer telemetry.ObserveQuery("access_token", "FindByID")()
+
+	return r.findAccessToken(`SELECT `+accessTokenColumns+` FROM access_tokens WHERE id = ?`, id)
+}
+
+func (r *AccessTokenRepository) FindByHash(hash string) (*models.AccessToken, error) {
+	defer telemetry.ObserveQuery("access_token", "FindByHash")()
+
+	return r.findAccessToken(`SELECT `+accessTokenColumns+` FROM access_tokens WHERE token_hash = ?`, hash)
+}
+
+func (r *AccessTokenRepository) FindByUserID(userID int64) ([]*models.AccessToken, error) {
+	defer telemetry.ObserveQuery("access_token", "FindByUserID")()
+
+	query := `SELECT ` + accessTokenColumns + ` FROM access_tokens WHERE user_id = ? ORDER BY created_at DESC, id DESC`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []*models.AccessToken{}
+	for rows.Next() {
+		token, err := scanAccessToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, rows.Err()
+}
+
+func (r *AccessTokenRepository) UpdateLastUsed(id int64, at time.Time) error {
+	defer telemetry.ObserveQuery("access_token", "UpdateLastUsed")()
+
+	_, err := r.db.Exec(`UPDATE access_tokens SET last_used_at = ? WHERE id = ?`, at.UTC(), id)
+	return err
+}
+
+func (r *AccessTokenRepository) Delete(id int64) error {
+	defer telemetry.ObserveQuery("access_token", "Delete")()
+
+	_, err := r.db.Exec(`DELETE FROM access_tokens WHERE id = ?`, id)
+	return err
+}
+
+func (r *AccessTokenRepository) findAccessToken(query string, args ...interface{}) (*models.AccessToken, error) {
+	token, err := scanAccessToken(r.db.QueryRow(query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("access token not found")
+		}
+		return nil, err
+	}
+
+	return token, nil
+}
+
+func scanAccessToken(row rowScanner) (*models.AccessToken, error) {
+	var token models.AccessToken
+	var scopes string
+
+	err := row.Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Name,
+		&token.TokenHash,
+		&token.Prefix,
+		&scopes,
+		&token.ExpiresAt,
+		&token.LastUsedAt,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, scope := range strings.Split(scopes, ",") {
+		if scope != "" {
+			token.Scopes = append(token.Scopes, models.Scope(scope))
+		}
+	}
+
+	return &token, nil
+}
+
+func joinScopes(scopes []models.Scope) string {
+	values := make([]string, len(scopes))
+	for i, scope := range scopes {
+		values[i] = string(scope)
+	}
+	return strings.Join(values, ",")
+}
@@ -0,0 +1,396 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"egide-server/internal/models"
+	"egide-server/internal/telemetry"
+)
+
+type AlertRepository struct {
+	db *sql.DB
+}
+
+func NewAlertRepository(db *sql.DB) *AlertRepository {
+	return &AlertRepository{
+		db: db,
+	}
+}
+
+// Create stores a new alert rule together with its notification channels
+func (r *AlertRepository) Create(rule *models.AlertRule) (int64, error) {
+	defer telemetry.ObserveQuery("alert", "Create")()
+
+	query := `
+		INSERT INTO alert_rules (user_id, name, type, site_id, monitor_id, threshold, window_minutes, enabled, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	result, err := tx.Exec(
+		query,
+		rule.UserID,
+		rule.Name,
+		rule.Type,
+		rule.SiteID,
+		rule.MonitorID,
+		rule.Threshold,
+		rule.WindowMinutes,
+		rule.Enabled,
+		now,
+		now,
+	)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	ruleID, err := result.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if err := replaceRuleChannels(tx, ruleID, rule.ChannelIDs); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	return ruleID, tx.Commit()
+}
+
+func (r *AlertRepository) FindByID(id int64) (*models.AlertRule, error) {
+	defer telemetry.ObserveQuery("alert", "FindByID")()
+
+	query := `
+		SELECT id, user_id, name, type, site_id, monitor_id, threshold, window_minutes, enabled, created_at, updated_at
+		FROM alert_rules
+		WHERE id = ?
+	`
+
+	var rule models.AlertRule
+	var ruleType string
+
+	err := r.db.QueryRow(query, id).Scan(
+		&rule.ID,
+		&rule.UserID,
+		&rule.Name,
+		&ruleType,
+		&rule.SiteID,
+		&rule.MonitorID,
+		&rule.Threshold,
+		&rule.WindowMinutes,
+		&rule.Enabled,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("alert rule not found")
+		}
+		return nil, err
+	}
+
+	rule.Type = models.AlertRuleType(ruleType)
+	rule.ChannelIDs, err = r.findRuleChannelIDs(rule.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rule, nil
+}
+
+func (r *AlertRepository) FindByUserID(userID int64) ([]*models.AlertRule, error) {
+	defer telemetry.ObserveQuery("alert", "FindByUserID")()
+
+	query := `
+		SELECT id, user_id, name, type, site_id, monitor_id, threshold, window_minutes, enabled, created_at, updated_at
+		FROM alert_rules
+		WHERE user_id = ?
+		ORDER BY created_at ASC
+	`
+
+	return r.findRules(query, userID)
+}
+
+// FindEnabled returns every enabled alert rule, used by the background evaluator
+func (r *AlertRepository) FindEnabled() ([]*models.AlertRule, error) {
+	defer telemetry.ObserveQuery("alert", "FindEnabled")()
+
+	query := `
+		SELECT id, user_id, name, type, site_id, monitor_id, threshold, window_minutes, enabled, created_at, updated_at
+		FROM alert_rules
+		WHERE enabled = TRUE
+		ORDER BY id ASC
+	`
+
+	return r.findRules(query)
+}
+
+func (r *AlertRepository) Update(rule *models.AlertRule) error {
+	defer telemetry.ObserveQuery("alert", "Update")()
+
+	query := `
+		UPDATE alert_rules
+		SET name = ?, type = ?, site_id = ?, monitor_id = ?, threshold = ?, window_minutes = ?, enabled = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		query,
+		rule.Name,
+		rule.Type,
+		rule.SiteID,
+		rule.MonitorID,
+		rule.Threshold,
+		rule.WindowMinutes,
+		rule.Enabled,
+		time.Now(),
+		rule.ID,
+	)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := replaceRuleChannels(tx, rule.ID, rule.ChannelIDs); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *AlertRepository) Delete(id int64) error {
+	defer telemetry.ObserveQuery("alert", "Delete")()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, query := range []string{
+		`DELETE FROM alert_rule_channels WHERE rule_id = ?`,
+		`DELETE FROM alert_incidents WHERE rule_id = ?`,
+		`DELETE FROM alert_rules WHERE id = ?`,
+	} {
+		if _, err := tx.Exec(query, id); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// FindOpenIncident returns the firing incident of a rule, or nil if there is none
+func (r *AlertRepository) FindOpenIncident(ruleID int64) (*models.AlertIncident, error) {
+	defer telemetry.ObserveQuery("alert", "FindOpenIncident")()
+
+	query := `
+		SELECT id, rule_id, status, message, started_at, resolved_at
+		FROM alert_incidents
+		WHERE rule_id = ? AND status = 'firing'
+		ORDER BY started_at DESC
+		LIMIT 1
+	`
+
+	var incident models.AlertIncident
+	var status string
+
+	err := r.db.QueryRow(query, ruleID).Scan(
+		&incident.ID,
+		&incident.RuleID,
+		&status,
+		&incident.Message,
+		&incident.StartedAt,
+		&incident.ResolvedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	incident.Status = models.IncidentStatus(status)
+	return &incident, nil
+}
+
+func (r *AlertRepository) CreateIncident(incident *models.AlertIncident) (int64, error) {
+	defer telemetry.ObserveQuery("alert", "CreateIncident")()
+
+	query := `
+		INSERT INTO alert_incidents (rule_id, status, message, started_at)
+		VALUES (?, ?, ?, ?)
+	`
+
+	result, err := r.db.Exec(
+		query,
+		incident.RuleID,
+		incident.Status,
+		incident.Message,
+		incident.StartedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+func (r *AlertRepository) ResolveIncident(id int64, resolvedAt time.Time) error {
+	defer telemetry.ObserveQuery("alert", "ResolveIncident")()
+
+	query := `
+		UPDATE alert_incidents
+		SET status = 'resolved', resolved_at = ?
+		WHERE id = ?
+	`
+
+	_, err := r.db.Exec(query, resolvedAt, id)
+	return err
+}
+
+// FindIncidentsByUserID returns the most recent incidents of all rules owned by a user
+func (r *AlertRepository) FindIncidentsByUserID(userID int64, limit int) ([]*models.AlertIncident, error) {
+	defer telemetry.ObserveQuery("alert", "FindIncidentsByUserID")()
+
+	query := `
+		SELECT i.id, i.rule_id, i.status, i.message, i.started_at, i.resolved_at
+		FROM alert_incidents i
+		JOIN alert_rules r ON r.id = i.rule_id
+		WHERE r.user_id = ?
+		ORDER BY i.started_at DESC
+		LIMIT ?
+	`
+
+	rows, err := r.db.Query(query, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var incidents []*models.AlertIncident
+	for rows.Next() {
+		var incident models.AlertIncident
+		var status string
+
+		err := rows.Scan(
+			&incident.ID,
+			&incident.RuleID,
+			&status,
+			&incident.Message,
+			&incident.StartedAt,
+			&incident.ResolvedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		incident.Status = models.IncidentStatus(status)
+		incidents = append(incidents, &incident)
+	}
+
+	return incidents, rows.Err()
+}
+
+func (r *AlertRepository) findRules(query string, args ...interface{}) ([]*models.AlertRule, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []*models.AlertRule
+	for rows.Next() {
+		var rule models.AlertRule
+		var ruleType string
+
+		err := rows.Scan(
+			&rule.ID,
+			&rule.UserID,
+			&rule.Name,
+			&ruleType,
+			&rule.SiteID,
+			&rule.MonitorID,
+			&rule.Threshold,
+			&rule.WindowMinutes,
+			&rule.Enabled,
+			&rule.CreatedAt,
+			&rule.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		rule.Type = models.AlertRuleType(ruleType)
+		rules = append(rules, &rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for _, rule := range rules {
+		rule.ChannelIDs, err = r.findRuleChannelIDs(rule.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return rules, nil
+}
+
+func (r *AlertRepository) findRuleChannelIDs(ruleID int64) ([]int64, error) {
+	rows, err := r.db.Query(`SELECT channel_id FROM alert_rule_channels WHERE rule_id = ?`, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	channelIDs := []int64{}
+	for rows.Next() {
+		var channelID int64
+		if err := rows.Scan(&channelID); err != nil {
+			return nil, err
+		}
+		channelIDs = append(channelIDs, channelID)
+	}
+
+	return channelIDs, rows.Err()
+}
+
+func replaceRuleChannels(tx *sql.Tx, ruleID int64, channelIDs []int64) error {
+	if _, err := tx.Exec(`DELETE FROM alert_rule_channels WHERE rule_id = ?`, ruleID); err != nil {
+		return err
+	}
+
+	for _, channelID := range channelIDs {
+		_, err := tx.Exec(
+			`INSERT OR IGNORE INTO alert_rule_channels (rule_id, channel_id) VALUES (?, ?)`,
+			ruleID,
+			channelID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"egide-server/internal/models"
+	"egide-server/internal/telemetry"
+)
+
+// AnalyticsRepository stores the hourly top values of each site and
+// dimension. Buckets are always stored and queried in UTC.
+type AnalyticsRepository struct {
+	db *sql.DB
+}
+
+func NewAnalyticsRepository(db *sql.DB) *AnalyticsRepository {
+	return &AnalyticsRepository{
+		db: db,
+	}
+}
+
+// ReplaceBucket replaces the top values of a site, dimension and bucket
+func (r *AnalyticsRepository) ReplaceBucket(siteID int64, dimension models.AnalyticsDimension, bucket time.Time, values []*models.TopValue) error {
+	defer telemetry.ObserveQuery("analytics", "ReplaceBucket")()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		`DELETE FROM analytics_top_values WHERE site_id = ? AND dimension = ? AND bucket_start = ?`,
+		siteID, dimension, bucket.UTC(),
+	)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO analytics_top_values (site_id, dimension, bucket_start, value, count, error)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, value := range values {
+		if _, err := stmt.Exec(siteID, dimension, bucket.UTC(), value.Value, value.Count, value.Error); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// FindBucket returns the stored top values of a site, dimension and bucket
+func (r *AnalyticsRepository) FindBucket(siteID int64, dimension models.AnalyticsDimension, bucket time.Time) ([]*models.TopValue, error) {
+	defer telemetry.ObserveQuery("analytics", "FindBucket")()
+
+	query := `
+		SELECT value, count, error
+		FROM analytics_top_values
+		WHERE site_id = ? AND dimension = ? AND bucket_start = ?
+	`
+
+	rows, err := r.db.Query(query, siteID, dimension, bucket.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTopValues(rows)
+}
+
+// FindTopValues sums the top values of the buckets starting within [start, end)
+// and returns the limit largest ones
+func (r *AnalyticsRepository) FindTopValues(siteID int64, dimension models.AnalyticsDimension, start, end time.Time, limit int) ([]*models.TopValue, error) {
+	defer telemetry.ObserveQuery("analytics", "FindTopValues")()
+
+	query := `
+		SELECT value, SUM(count) AS total, SUM(error)
+		FROM analytics_top_values
+		WHERE site_id = ? AND dimension = ? AND bucket_start >= ? AND bucket_start < ?
+		GROUP BY value
+		ORDER BY total DESC, value ASC
+		LIMIT ?
+	`
+
+	rows, err := r.db.Query(query, siteID, dimension, start.UTC(), end.UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTopValues(rows)
+}
+
+// DeleteBefore removes the buckets starting before cutoff
+func (r *AnalyticsRepository) DeleteBefore(cutoff time.Time) error {
+	defer telemetry.ObserveQuery("analytics", "DeleteBefore")()
+
+	query := `DELETE FROM analytics_top_values WHERE bucket_start < ?`
+	_, err := r.db.Exec(query, cutoff.UTC())
+	return err
+}
+
+func scanTopValues(rows *sql.Rows) ([]*models.TopValue, error) {
+	values := []*models.TopValue{}
+	for rows.Next() {
+		var value models.TopValue
+		if err := rows.Scan(&value.Value, &value.Count, &value.Error); err != nil {
+			return nil, err
+		}
+		values = append(values, &value)
+	}
+
+	return values, rows.Err()
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"egide-server/internal/models"
+	"egide-server/internal/telemetry"
+)
+
+// execer is implemented by both *sql.DB and *sql.Tx, so that entries can be
+// recorded in the transaction of the change they are about
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+type AuditRepository struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{
+		db: db,
+	}
+}
+
+func (r *AuditRepository) Create(entry *models.AuditEntry) error {
+	defer telemetry.ObserveQuery("audit", "Create")()
+
+	return insertAuditEntry(r.db, entry)
+}
+
+// FindBySiteID returns the latest entries about a site, newest first
+func (r *AuditRepository) FindBySiteID(siteID int64, limit int) ([]*models.AuditEntry, error) {
+	defer telemetry.ObserveQuery("audit", "FindBySiteID")()
+
+	query := `
+		SELECT id, actor_id, action, site_id, organization_id, details, created_at
+		FROM audit_log WHERE site_id = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?
+	`
+
+	rows, err := r.db.Query(query, siteID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*models.AuditEntry{}
+	for rows.Next() {
+		var entry models.AuditEntry
+		var details string
+		err := rows.Scan(
+			&entry.ID,
+			&entry.ActorID,
+			&entry.Action,
+			&entry.SiteID,
+			&entry.OrganizationID,
+			&details,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entry.Details = []byte(details)
+		entries = append(entries, &entry)
+	}
+
+	return entries, rows.Err()
+}
+
+func insertAuditEntry(exec execer, entry *models.AuditEntry) error {
+	details := string(entry.Details)
+	if details == "" {
+		details = "{}"
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	_, err := exec.Exec(
+		`INSERT INTO audit_log (actor_id, action, site_id, organization_id, details, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		entry.ActorID, entry.Action, entry.SiteID, entry.OrganizationID, details, entry.CreatedAt.UTC(),
+	)
+	return err
+}
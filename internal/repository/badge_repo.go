@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"egide-server/internal/models"
+	"egide-server/internal/telemetry"
+)
+
+type BadgeRepository struct {
+	db *sql.DB
+}
+
+func NewBadgeRepository(db *sql.DB) *BadgeRepository {
+	return &BadgeRepository{
+		db: db,
+	}
+}
+
+// FindBySiteID returns the badge settings of a site, disabled when the owner never opted in
+func (r *BadgeRepository) FindBySiteID(siteID int64) (*models.BadgeSettings, error) {
+	defer telemetry.ObserveQuery("badge", "FindBySiteID")()
+
+	query := `
+		SELECT site_id, enabled, updated_at
+		FROM badge_settings
+		WHERE site_id = ?
+	`
+
+	var settings models.BadgeSettings
+	err := r.db.QueryRow(query, siteID).Scan(&settings.SiteID, &settings.Enabled, &settings.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &models.BadgeSettings{SiteID: siteID}, nil
+		}
+		return nil, err
+	}
+
+	return &settings, nil
+}
+
+func (r *BadgeRepository) Save(settings *models.BadgeSettings) error {
+	defer telemetry.ObserveQuery("badge", "Save")()
+
+	query := `
+		INSERT INTO badge_settings (site_id, enabled, updated_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT(site_id) DO UPDATE SET enabled = excluded.enabled, updated_at = excluded.updated_at
+	`
+
+	settings.UpdatedAt = time.Now()
+	_, err := r.db.Exec(query, settings.SiteID, settings.Enabled, settings.UpdatedAt)
+	return err
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"egide-server/internal/models"
+	"egide-server/internal/telemetry"
+)
+
+type CertificateRepository struct {
+	db *sql.DB
+}
+
+func NewCertificateRepository(db *sql.DB) *CertificateRepository {
+	return &CertificateRepository{
+		db: db,
+	}
+}
+
+// Save stores the certificate of a monitor, replacing the previous one
+func (r *CertificateRepository) Save(cert *models.TLSCertificate) error {
+	defer telemetry.ObserveQuery("certificate", "Save")()
+
+	chain, err := json.Marshal(cert.Chain)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO tls_certificates (monitor_id, subject, issuer, sans, serial_number, fingerprint, not_before, not_after, chain, warned_days, verification_error, checked_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (monitor_id) DO UPDATE SET
+			subject = excluded.subject,
+			issuer = excluded.issuer,
+			sans = excluded.sans,
+			serial_number = excluded.serial_number,
+			fingerprint = excluded.fingerprint,
+			not_before = excluded.not_before,
+			not_after = excluded.not_after,
+			chain = excluded.chain,
+			warned_days = excluded.warned_days,
+			verification_error = excluded.verification_error,
+			checked_at = excluded.checked_at
+	`
+
+	_, err = r.db.Exec(
+		query,
+		cert.MonitorID,
+		cert.Subject,
+		cert.Issuer,
+		strings.Join(cert.SANs, ","),
+		cert.SerialNumber,
+		cert.Fingerprint,
+		cert.NotBefore.UTC(),
+		cert.NotAfter.UTC(),
+		string(chain),
+		cert.WarnedDays,
+		cert.VerificationError,
+		cert.CheckedAt.UTC(),
+	)
+
+	return err
+}
+
+func (r *CertificateRepository) FindByMonitorID(monitorID int64) (*models.TLSCertificate, error) {
+	defer telemetry.ObserveQuery("certificate", "FindByMonitorID")()
+
+	query := `
+		SELECT monitor_id, subject, issuer, sans, serial_number, fingerprint, not_before, not_after, chain, warned_days, verification_error, checked_at
+		FROM tls_certificates
+		WHERE monitor_id = ?
+	`
+
+	certs, err := r.findCertificates(query, monitorID)
+	if err != nil {
+		return nil, err
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("certificate not found")
+	}
+
+	return certs[0], nil
+}
+
+// FindBySiteID returns the certificates of every monitor of a site
+func (r *CertificateRepository) FindBySiteID(siteID int64) ([]*models.TLSCertificate, error) {
+	defer telemetry.ObserveQuery("certificate", "FindBySiteID")()
+
+	query := `
+		SELECT c.monitor_id, c.subject, c.issuer, c.sans, c.serial_number, c.fingerprint, c.not_before, c.not_after, c.chain, c.warned_days, c.verification_error, c.checked_at
+		FROM tls_certificates c
+		JOIN monitors m ON m.id = c.monitor_id
+		WHERE m.site_id = ?
+		ORDER BY c.not_after ASC
+	`
+
+	return r.findCertificates(query, siteID)
+}
+
+func (r *CertificateRepository) findCertificates(query string, args ...interface{}) ([]*models.TLSCertificate, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	certs := []*models.TLSCertificate{}
+	for rows.Next() {
+		var cert models.TLSCertificate
+		var sans, chain string
+
+		err := rows.Scan(
+			&cert.MonitorID,
+			&cert.Subject,
+			&cert.Issuer,
+			&sans,
+			&cert.SerialNumber,
+			&cert.Fingerprint,
+			&cert.NotBefore,
+			&cert.NotAfter,
+			&chain,
+			&cert.WarnedDays,
+			&cert.VerificationError,
+			&cert.CheckedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		cert.SANs = []string{}
+		if sans != "" {
+			cert.SANs = strings.Split(sans, ",")
+		}
+		if err := json.Unmarshal([]byte(chain), &cert.Chain); err != nil {
+			return nil, err
+		}
+
+		certs = append(certs, &cert)
+	}
+
+	return certs, rows.Err()
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"egide-server/internal/models"
+	"egide-server/internal/telemetry"
+)
+
+type ContentRepository struct {
+	db *sql.DB
+}
+
+func NewContentRepository(db *sql.DB) *ContentRepository {
+	return &ContentRepository{
+		db: db,
+	}
+}
+
+// FindCheck returns the content check of a monitor, disabled when it was never configured
+func (r *ContentRepository) FindCheck(monitorID int64) (*models.ContentCheck, error) {
+	defer telemetry.ObserveQuery("content", "FindCheck")()
+
+	query := `
+		SELECT monitor_id, enabled, ignore_patterns, baseline_hash, baseline_body, baseline_at, last_hash, updated_at
+		FROM content_checks
+		WHERE monitor_id = ?
+	`
+
+	var check models.ContentCheck
+	var patterns string
+
+	err := r.db.QueryRow(query, monitorID).Scan(
+		&check.MonitorID,
+		&check.Enabled,
+		&patterns,
+		&check.BaselineHash,
+		&check.BaselineBody,
+		&check.BaselineAt,
+		&check.LastHash,
+		&check.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &models.ContentCheck{MonitorID: monitorID, IgnorePatterns: []string{}}, nil
+		}
+		return nil, err
+	}
+
+	check.IgnorePatterns = []string{}
+	if patterns != "" {
+		check.IgnorePatterns = strings.Split(patterns, "\n")
+	}
+
+	return &check, nil
+}
+
+// SaveCheck creates or replaces the content check of a monitor
+func (r *ContentRepository) SaveCheck(check *models.ContentCheck) error {
+	defer telemetry.ObserveQuery("content", "SaveCheck")()
+
+	query := `
+		INSERT INTO content_checks (monitor_id, enabled, ignore_patterns, baseline_hash, baseline_body, baseline_at, last_hash, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (monitor_id) DO UPDATE SET
+			enabled = excluded.enabled,
+			ignore_patterns = excluded.ignore_patterns,
+			baseline_hash = excluded.baseline_hash,
+			baseline_body = excluded.baseline_body,
+			baseline_at = excluded.baseline_at,
+			last_hash = excluded.last_hash,
+			updated_at = excluded.updated_at
+	`
+
+	check.UpdatedAt = time.Now()
+	_, err := r.db.Exec(
+		query,
+		check.MonitorID,
+		check.Enabled,
+		strings.Join(check.IgnorePatterns, "\n"),
+		check.BaselineHash,
+		check.BaselineBody,
+		check.BaselineAt,
+		check.LastHash,
+		check.UpdatedAt,
+	)
+
+	return err
+}
+
+func (r *ContentRepository) CreateChange(change *models.ContentChange) (int64, error) {
+	defer telemetry.ObserveQuery("content", "CreateChange")()
+
+	query := `
+		INSERT INTO content_changes (monitor_id, site_id, baseline_hash, hash, summary, threat_id, detected_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.Exec(
+		query,
+		change.MonitorID,
+		change.SiteID,
+		change.BaselineHash,
+		change.Hash,
+		change.Summary,
+		change.ThreatID,
+		change.DetectedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+// FindChangesBySiteID returns the latest content changes of a site, newest first
+func (r *ContentRepository) FindChangesBySiteID(siteID int64, limit int) ([]*models.ContentChange, error) {
+	defer telemetry.ObserveQuery("content", "FindChangesBySiteID")()
+
+	query := `
+		SELECT id, monitor_id, site_id, baseline_hash, hash, summary, threat_id, detected_at
+		FROM content_changes
+		WHERE site_id = ?
+		ORDER BY detected_at DESC
+		LIMIT ?
+	`
+
+	rows, err := r.db.Query(query, siteID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []*models.ContentChange{}
+	for rows.Next() {
+		var change models.ContentChange
+
+		err := rows.Scan(
+			&change.ID,
+			&change.MonitorID,
+			&change.SiteID,
+			&change.BaselineHash,
+			&change.Hash,
+			&change.Summary,
+			&change.ThreatID,
+			&change.DetectedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		changes = append(changes, &change)
+	}
+
+	return changes, rows.Err()
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"egide-server/internal/models"
+	"egide-server/internal/telemetry"
 )
 
 type HealthCheckRepository struct {
@@ -18,19 +19,23 @@ func NewHealthCheckRepository(db *sql.DB) *HealthCheckRepository {
 }
 
 func (r *HealthCheckRepository) Create(check *models.HealthCheck) (int64, error) {
+	defer telemetry.ObserveQuery("health_check", "Create")()
+
 	query := `
-		INSERT INTO health_checks (timestamp, response_time_ms, status_code, success, error, created_at)
-		VALUES (?, ?, ?, ?, ?, ?)
+		INSERT INTO health_checks (monitor_id, timestamp, response_time_ms, status_code, success, error, maintenance, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	now := time.Now()
 	result, err := r.db.Exec(
 		query,
+		check.MonitorID,
 		check.Timestamp,
 		check.ResponseTimeMs,
 		check.StatusCode,
 		check.Success,
 		check.Error,
+		check.Maintenance,
 		now,
 	)
 	if err != nil {
@@ -40,31 +45,214 @@ func (r *HealthCheckRepository) Create(check *models.HealthCheck) (int64, error)
 	return result.LastInsertId()
 }
 
-// GetChecksInRange returns health checks within a time range
-func (r *HealthCheckRepository) GetChecksInRange(start, end time.Time) ([]*models.HealthCheck, error) {
+// GetChecksInRange returns the health checks of the given monitors made within [start, end)
+func (r *HealthCheckRepository) GetChecksInRange(monitorIDs []int64, start, end time.Time) ([]*models.HealthCheck, error) {
+	defer telemetry.ObserveQuery("health_check", "GetChecksInRange")()
+
+	if len(monitorIDs) == 0 {
+		return nil, nil
+	}
+
+	placeholders, ids := int64Args(monitorIDs)
+	args := append([]interface{}{start.Local(), end.Local()}, ids...)
+	return r.findChecksInRange(`AND monitor_id IN (`+placeholders+`)`, args...)
+}
+
+// GetAllChecksInRange returns the health checks of every monitor made within
+// [start, end), for rolling them up
+func (r *HealthCheckRepository) GetAllChecksInRange(start, end time.Time) ([]*models.HealthCheck, error) {
+	defer telemetry.ObserveQuery("health_check", "GetAllChecksInRange")()
+
+	return r.findChecksInRange(``, start.Local(), end.Local())
+}
+
+func (r *HealthCheckRepository) findChecksInRange(filter string, args ...interface{}) ([]*models.HealthCheck, error) {
+	// Checks are stored in local time and compared as text
+	query := `
+		SELECT id, monitor_id, timestamp, response_time_ms, status_code, success, error, maintenance, created_at
+		FROM health_checks
+		WHERE timestamp >= ? AND timestamp < ? ` + filter + `
+		ORDER BY timestamp ASC
+	`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanHealthChecks(rows)
+}
+
+// GetMonitorChecksInRange returns the health checks of a single monitor within a time range
+func (r *HealthCheckRepository) GetMonitorChecksInRange(monitorID int64, start, end time.Time) ([]*models.HealthCheck, error) {
+	defer telemetry.ObserveQuery("health_check", "GetMonitorChecksInRange")()
+
 	query := `
-		SELECT id, timestamp, response_time_ms, status_code, success, error, created_at
+		SELECT id, monitor_id, timestamp, response_time_ms, status_code, success, error, maintenance, created_at
 		FROM health_checks
-		WHERE timestamp BETWEEN ? AND ?
+		WHERE monitor_id = ? AND timestamp BETWEEN ? AND ?
 		ORDER BY timestamp ASC
 	`
 
-	rows, err := r.db.Query(query, start, end)
+	rows, err := r.db.Query(query, monitorID, start, end)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	return scanHealthChecks(rows)
+}
+
+// GetResponseTimes returns the response times of the successful checks of a
+// monitor within a time range, sorted from fastest to slowest
+func (r *HealthCheckRepository) GetResponseTimes(monitorID int64, start, end time.Time) ([]int, error) {
+	defer telemetry.ObserveQuery("health_check", "GetResponseTimes")()
+
+	query := `
+		SELECT response_time_ms
+		FROM health_checks
+		WHERE monitor_id = ? AND success = TRUE AND timestamp BETWEEN ? AND ?
+		ORDER BY response_time_ms ASC
+	`
+
+	rows, err := r.db.Query(query, monitorID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var times []int
+	for rows.Next() {
+		var ms int
+		if err := rows.Scan(&ms); err != nil {
+			return nil, err
+		}
+		times = append(times, ms)
+	}
+
+	return times, rows.Err()
+}
+
+// CountGoodChecks returns the number of checks of the given monitors within
+// [start, end) and how many of them succeeded within latencyThresholdMs
+// (any latency when negative). Checks made during maintenance are ignored.
+func (r *HealthCheckRepository) CountGoodChecks(monitorIDs []int64, start, end time.Time, latencyThresholdMs int) (int, int, error) {
+	defer telemetry.ObserveQuery("health_check", "CountGoodChecks")()
+
+	if len(monitorIDs) == 0 {
+		return 0, 0, nil
+	}
+
+	placeholders, args := int64Args(monitorIDs)
+	query := `
+		SELECT COUNT(*), COALESCE(SUM(CASE WHEN success AND (? < 0 OR response_time_ms <= ?) THEN 1 ELSE 0 END), 0)
+		FROM health_checks
+		WHERE monitor_id IN (` + placeholders + `) AND maintenance = FALSE AND timestamp >= ? AND timestamp < ?
+	`
+	args = append([]interface{}{latencyThresholdMs, latencyThresholdMs}, args...)
+	args = append(args, start.Local(), end.Local())
+
+	var total, good int
+	err := r.db.QueryRow(query, args...).Scan(&total, &good)
+	return total, good, err
+}
+
+// GetLatestChecks returns the most recent health checks of a monitor, newest first
+func (r *HealthCheckRepository) GetLatestChecks(monitorID int64, limit int) ([]*models.HealthCheck, error) {
+	defer telemetry.ObserveQuery("health_check", "GetLatestChecks")()
+
+	query := `
+		SELECT id, monitor_id, timestamp, response_time_ms, status_code, success, error, maintenance, created_at
+		FROM health_checks
+		WHERE monitor_id = ?
+		ORDER BY timestamp DESC
+		LIMIT ?
+	`
+
+	rows, err := r.db.Query(query, monitorID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanHealthChecks(rows)
+}
+
+// GetDailyStats returns per-day check counts of a monitor since the given time,
+// ignoring checks performed during maintenance
+func (r *HealthCheckRepository) GetDailyStats(monitorID int64, since time.Time) ([]*models.DailyCheckStats, error) {
+	defer telemetry.ObserveQuery("health_check", "GetDailyStats")()
+
+	query := `
+		SELECT date(timestamp) AS day, COUNT(*), SUM(CASE WHEN success THEN 1 ELSE 0 END)
+		FROM health_checks
+		WHERE monitor_id = ? AND maintenance = FALSE AND timestamp >= ?
+		GROUP BY day
+		ORDER BY day ASC
+	`
+
+	rows, err := r.db.Query(query, monitorID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []*models.DailyCheckStats
+	for rows.Next() {
+		var day models.DailyCheckStats
+		if err := rows.Scan(&day.Day, &day.Total, &day.Successes); err != nil {
+			return nil, err
+		}
+		stats = append(stats, &day)
+	}
+
+	return stats, rows.Err()
+}
+
+// GetFailedChecks returns the failed checks of a monitor since the given time,
+// ignoring checks performed during maintenance
+func (r *HealthCheckRepository) GetFailedChecks(monitorID int64, since time.Time) ([]*models.HealthCheck, error) {
+	defer telemetry.ObserveQuery("health_check", "GetFailedChecks")()
+
+	query := `
+		SELECT id, monitor_id, timestamp, response_time_ms, status_code, success, error, maintenance, created_at
+		FROM health_checks
+		WHERE monitor_id = ? AND success = FALSE AND maintenance = FALSE AND timestamp >= ?
+		ORDER BY timestamp ASC
+	`
+
+	rows, err := r.db.Query(query, monitorID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanHealthChecks(rows)
+}
+
+// DeleteChecksBefore removes the raw checks made before cutoff
+func (r *HealthCheckRepository) DeleteChecksBefore(cutoff time.Time) error {
+	defer telemetry.ObserveQuery("health_check", "DeleteChecksBefore")()
+
+	query := `DELETE FROM health_checks WHERE timestamp < ?`
+	_, err := r.db.Exec(query, cutoff.Local())
+	return err
+}
+
+func scanHealthChecks(rows *sql.Rows) ([]*models.HealthCheck, error) {
 	var checks []*models.HealthCheck
 	for rows.Next() {
 		var check models.HealthCheck
 		err := rows.Scan(
 			&check.ID,
+			&check.MonitorID,
 			&check.Timestamp,
 			&check.ResponseTimeMs,
 			&check.StatusCode,
 			&check.Success,
 			&check.Error,
+			&check.Maintenance,
 			&check.CreatedAt,
 		)
 		if err != nil {
@@ -75,11 +263,3 @@ func (r *HealthCheckRepository) GetChecksInRange(start, end time.Time) ([]*model
 
 	return checks, rows.Err()
 }
-
-// DeleteOldChecks removes health checks older than the specified duration
-func (r *HealthCheckRepository) DeleteOldChecks(olderThan time.Duration) error {
-	cutoff := time.Now().Add(-olderThan)
-	query := `DELETE FROM health_checks WHERE timestamp < ?`
-	_, err := r.db.Exec(query, cutoff)
-	return err
-}
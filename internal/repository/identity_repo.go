@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"egide-server/internal/models"
+	"egide-server/internal/telemetry"
+)
+
+type IdentityRepository struct {
+	db *sql.DB
+}
+
+func NewIdentityRepository(db *sql.DB) *IdentityRepository {
+	return &IdentityRepository{
+		db: db,
+	}
+}
+
+// Create links an identity to its user
+func (r *IdentityRepository) Create(identity *models.Identity) (int64, error) {
+	defer telemetry.ObserveQuery("identity", "Create")()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := createIdentity(tx, identity)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+func (r *IdentityRepository) FindByID(id int64) (*models.Identity, error) {
+	defer telemetry.ObserveQuery("identity", "FindByID")()
+
+	query := `
+		SELECT id, user_id, provider, subject, username, email, created_at
+		FROM user_identities
+		WHERE id = ?
+	`
+
+	return r.findIdentity(query, id)
+}
+
+// FindByProviderSubject finds the identity of an account at a provider
+func (r *IdentityRepository) FindByProviderSubject(provider, subject string) (*models.Identity, error) {
+	defer telemetry.ObserveQuery("identity", "FindByProviderSubject")()
+
+	query := `
+		SELECT id, user_id, provider, subject, username, email, created_at
+		FROM user_identities
+		WHERE provider = ? AND subject = ?
+	`
+
+	return r.findIdentity(query, provider, subject)
+}
+
+// FindByUserID returns the identities of a user, oldest first
+func (r *IdentityRepository) FindByUserID(userID int64) ([]*models.Identity, error) {
+	defer telemetry.ObserveQuery("identity", "FindByUserID")()
+
+	query := `
+		SELECT id, user_id, provider, subject, username, email, created_at
+		FROM user_identities
+		WHERE user_id = ?
+		ORDER BY created_at ASC, id ASC
+	`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	identities := []*models.Identity{}
+	for rows.Next() {
+		var identity models.Identity
+
+		err := rows.Scan(
+			&identity.ID,
+			&identity.UserID,
+			&identity.Provider,
+			&identity.Subject,
+			&identity.Username,
+			&identity.Email,
+			&identity.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		identities = append(identities, &identity)
+	}
+
+	return identities, rows.Err()
+}
+
+// UpdateProfile refreshes the username and email of an identity after a login
+func (r *IdentityRepository) UpdateProfile(identity *models.Identity) error {
+	defer telemetry.ObserveQuery("identity", "UpdateProfile")()
+
+	query := `
+		UPDATE user_identities
+		SET username = ?, email = ?
+		WHERE provider = ? AND subject = ?
+	`
+
+	_, err := r.db.Exec(query, identity.Username, identity.Email, identity.Provider, identity.Subject)
+	return err
+}
+
+// DeleteUnlessLastSignIn removes an identity of a user unless it is the last
+// one the user can sign in with, in a single statement so that concurrent
+// deletions can't both pass the check. It reports whether it was removed.
+func (r *IdentityRepository) DeleteUnlessLastSignIn(userID, id int64) (bool, error) {
+	defer telemetry.ObserveQuery("identity", "DeleteUnlessLastSignIn")()
+
+	query := `
+		DELETE FROM user_identities
+		WHERE id = ? AND user_id = ?
+		AND (
+			provider = ?
+			OR (SELECT COUNT(*) FROM user_identities WHERE user_id = ? AND provider != ?) > 1
+		)
+	`
+
+	result, err := r.db.Exec(query, id, userID, models.EmailIdentity, userID, models.EmailIdentity)
+	if err != nil {
+		return false, err
+	}
+
+	deleted, err := result.RowsAffected()
+	return deleted > 0, err
+}
+
+func (r *IdentityRepository) findIdentity(query string, args ...interface{}) (*models.Identity, error) {
+	var identity models.Identity
+
+	err := r.db.QueryRow(query, args...).Scan(
+		&identity.ID,
+		&identity.UserID,
+		&identity.Provider,
+		&identity.Subject,
+		&identity.Username,
+		&identity.Email,
+		&identity.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("identity not found")
+		}
+		return nil, err
+	}
+
+	return &identity, nil
+}
+
+// createIdentity stores an identity within a transaction
+func createIdentity(tx *sql.Tx, identity *models.Identity) (int64, error) {
+	query := `
+		INSERT INTO user_identities (user_id, provider, subject, username, email, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	identity.CreatedAt = time.Now()
+	result, err := tx.Exec(
+		query,
+		identity.UserID,
+		identity.Provider,
+		identity.Subject,
+		identity.Username,
+		identity.Email,
+		identity.CreatedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"egide-server/internal/models"
+	"egide-server/internal/telemetry"
+)
+
+const invitationColumns = `id, organization_id, email, role, token_hash, invited_by, expires_at, sent_at, accepted_by, accepted_at, created_at`
+
+type InvitationRepository struct {
+	db *sql.DB
+}
+
+func NewInvitationRepository(db *sql.DB) *InvitationRepository {
+	return &InvitationRepository{
+		db: db,
+	}
+}
+
+func (r *InvitationRepository) Create(invitation *models.Invitation) (int64, error) {
+	defer telemetry.ObserveQuery("invitation", "Create")()
+
+	query := `
+		INSERT INTO organization_invitations (organization_id, email, role, token_hash, invited_by, expires_at, sent_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.Exec(
+		query,
+		invitation.OrganizationID,
+		invitation.Email,
+		invitation.Role,
+		invitation.TokenHash,
+		invitation.InvitedBy,
+		invitation.ExpiresAt.UTC(),
+		invitation.SentAt.UTC(),
+		invitation.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+func (r *InvitationRepository) FindByID(id int64) (*models.Invitation, error) {
+	defer telemetry.ObserveQuery("invitation", "FindByID")()
+
+	return r.findInvitation(`SELECT `+invitationColumns+` FROM organization_invitations WHERE id = ?`, id)
+}
+
+func (r *InvitationRepository) FindByHash(hash string) (*models.Invitation, error) {
+	defer telemetry.ObserveQuery("invitation", "FindByHash")()
+
+	return r.findInvitation(`SELECT `+invitationColumns+` FROM organization_invitations WHERE token_hash = ?`, hash)
+}
+
+// FindPending returns the invitations of an organization not accepted yet,
+// expired ones included so they can be resent
+func (r *InvitationRepository) FindPending(orgID int64) ([]*models.Invitation, error) {
+	defer telemetry.ObserveQuery("invitation", "FindPending")()
+
+	query := `
+		SELECT ` + invitationColumns + ` FROM organization_invitations
+		WHERE organization_id = ? AND accepted_at IS NULL
+		ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := r.db.Query(query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invitations := []*models.Invitation{}
+	for rows.Next() {
+		invitation, err := scanInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, invitation)
+	}
+
+	return invitations, rows.Err()
+}
+
+// UpdateToken replaces the token of an invitation being sent again
+func (r *InvitationRepository) UpdateToken(invitation *models.Invitation) error {
+	defer telemetry.ObserveQuery("invitation", "UpdateToken")()
+
+	_, err := r.db.Exec(
+		`UPDATE organization_invitations SET token_hash = ?, expires_at = ?, sent_at = ? WHERE id = ?`,
+		invitation.TokenHash, invitation.ExpiresAt.UTC(), invitation.SentAt.UTC(), invitation.ID,
+	)
+	return err
+}
+
+// Accept marks an invitation accepted by a user and makes them a member of
+// the organization with the invited role. It returns false when the
+// invitation was accepted in the meantime.
+func (r *InvitationRepository) Accept(invitation *models.Invitation, userID int64, at time.Time) (bool, error) {
+	defer telemetry.ObserveQuery("invitation", "Accept")()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+
+	result, err := tx.Exec(
+		`UPDATE organization_invitations SET accepted_by = ?, accepted_at = ? WHERE id = ? AND accepted_at IS NULL`,
+		userID, at.UTC(), invitation.ID,
+	)
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil || count == 0 {
+		tx.Rollback()
+		return false, err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO organization_members (organization_id, user_id, role, created_at) VALUES (?, ?, ?, ?)`,
+		invitation.OrganizationID, userID, invitation.Role, at.UTC(),
+	)
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func (r *InvitationRepository) Delete(id int64) error {
+	defer telemetry.ObserveQuery("invitation", "Delete")()
+
+	_, err := r.db.Exec(`DELETE FROM organization_invitations WHERE id = ?`, id)
+	return err
+}
+
+func (r *InvitationRepository) findInvitation(query string, args ...interface{}) (*models.Invitation, error) {
+	invitation, err := scanInvitation(r.db.QueryRow(query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("invitation not found")
+		}
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+func scanInvitation(row rowScanner) (*models.Invitation, error) {
+	var invitation models.Invitation
+
+	err := row.Scan(
+		&invitation.ID,
+		&invitation.OrganizationID,
+		&invitation.Email,
+		&invitation.Role,
+		&invitation.TokenHash,
+		&invitation.InvitedBy,
+		&invitation.ExpiresAt,
+		&invitation.SentAt,
+		&invitation.AcceptedBy,
+		&invitation.AcceptedAt,
+		&invitation.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &invitation, nil
+}
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"egide-server/internal/models"
+	"egide-server/internal/telemetry"
+)
+
+type MaintenanceRepository struct {
+	db *sql.DB
+}
+
+func NewMaintenanceRepository(db *sql.DB) *MaintenanceRepository {
+	return &MaintenanceRepository{
+		db: db,
+	}
+}
+
+func (r *MaintenanceRepository) Create(window *models.MaintenanceWindow) (int64, error) {
+	defer telemetry.ObserveQuery("maintenance", "Create")()
+
+	query := `
+		INSERT INTO maintenance_windows (site_id, name, recurrence, starts_at, duration_minutes, weekdays, time_zone, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	now := time.Now()
+	result, err := r.db.Exec(
+		query,
+		window.SiteID,
+		window.Name,
+		window.Recurrence,
+		window.StartsAt,
+		window.DurationMinutes,
+		joinWeekdays(window.Weekdays),
+		window.TimeZone,
+		now,
+		now,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+func (r *MaintenanceRepository) FindByID(id int64) (*models.MaintenanceWindow, error) {
+	defer telemetry.ObserveQuery("maintenance", "FindByID")()
+
+	query := `
+		SELECT id, site_id, name, recurrence, starts_at, duration_minutes, weekdays, time_zone, created_at, updated_at
+		FROM maintenance_windows
+		WHERE id = ?
+	`
+
+	window, err := scanMaintenanceWindow(r.db.QueryRow(query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("maintenance window not found")
+		}
+		return nil, err
+	}
+
+	return window, nil
+}
+
+func (r *MaintenanceRepository) FindBySiteID(siteID int64) ([]*models.MaintenanceWindow, error) {
+	defer telemetry.ObserveQuery("maintenance", "FindBySiteID")()
+
+	query := `
+		SELECT id, site_id, name, recurrence, starts_at, duration_minutes, weekdays, time_zone, created_at, updated_at
+		FROM maintenance_windows
+		WHERE site_id = ?
+		ORDER BY starts_at ASC
+	`
+
+	return r.findWindows(query, siteID)
+}
+
+// FindAll returns the maintenance windows of every site
+func (r *MaintenanceRepository) FindAll() ([]*models.MaintenanceWindow, error) {
+	defer telemetry.ObserveQuery("maintenance", "FindAll")()
+
+	query := `
+		SELECT id, site_id, name, recurrence, starts_at, duration_minutes, weekdays, time_zone, created_at, updated_at
+		FROM maintenance_windows
+	`
+
+	return r.findWindows(query)
+}
+
+func (r *MaintenanceRepository) Update(window *models.MaintenanceWindow) error {
+	defer telemetry.ObserveQuery("maintenance", "Update")()
+
+	query := `
+		UPDATE maintenance_windows
+		SET name = ?, recurrence = ?, starts_at = ?, duration_minutes = ?, weekdays = ?, time_zone = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	_, err := r.db.Exec(
+		query,
+		window.Name,
+		window.Recurrence,
+		window.StartsAt,
+		window.DurationMinutes,
+		joinWeekdays(window.Weekdays),
+		window.TimeZone,
+		time.Now(),
+		window.ID,
+	)
+
+	return err
+}
+
+func (r *MaintenanceRepository) Delete(id int64) error {
+	defer telemetry.ObserveQuery("maintenance", "Delete")()
+
+	query := `DELETE FROM maintenance_windows WHERE id = ?`
+	_, err := r.db.Exec(query, id)
+	return err
+}
+
+func (r *MaintenanceRepository) findWindows(query string, args ...interface{}) ([]*models.MaintenanceWindow, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	windows := []*models.MaintenanceWindow{}
+	for rows.Next() {
+		window, err := scanMaintenanceWindow(rows)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, window)
+	}
+
+	return windows, rows.Err()
+}
+
+func scanMaintenanceWindow(row rowScanner) (*models.MaintenanceWindow, error) {
+	var window models.MaintenanceWindow
+	var recurrence, weekdays string
+
+	err := row.Scan(
+		&window.ID,
+		&window.SiteID,
+		&window.Name,
+		&recurrence,
+		&window.StartsAt,
+		&window.DurationMinutes,
+		&weekdays,
+		&window.TimeZone,
+		&window.CreatedAt,
+		&window.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	window.Recurrence = models.MaintenanceRecurrence(recurrence)
+	for _, day := range strings.Split(weekdays, ",") {
+		if n, err := strconv.Atoi(day); err == nil {
+			window.Weekdays = append(window.Weekdays, time.Weekday(n))
+		}
+	}
+
+	return &window, nil
+}
+
+func joinWeekdays(weekdays []time.Weekday) string {
+	days := make([]string, len(weekdays))
+	for i, day := range weekdays {
+		days[i] = strconv.Itoa(int(day))
+	}
+	return strings.Join(days, ",")
+}
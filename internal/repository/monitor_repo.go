@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"egide-server/internal/models"
+	"egide-server/internal/telemetry"
+)
+
+type MonitorRepository struct {
+	db *sql.DB
+}
+
+func NewMonitorRepository(db *sql.DB) *MonitorRepository {
+	return &MonitorRepository{
+		db: db,
+	}
+}
+
+func (r *MonitorRepository) Create(monitor *models.Monitor) (int64, error) {
+	defer telemetry.ObserveQuery("monitor", "Create")()
+
+	query := `
+		INSERT INTO monitors (site_id, name, url, enabled, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	now := time.Now()
+	result, err := r.db.Exec(
+		query,
+		monitor.SiteID,
+		monitor.Name,
+		monitor.URL,
+		monitor.Enabled,
+		now,
+		now,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+func (r *MonitorRepository) FindByID(id int64) (*models.Monitor, error) {
+	defer telemetry.ObserveQuery("monitor", "FindByID")()
+
+	query := `
+		SELECT id, site_id, name, url, enabled, created_at, updated_at
+		FROM monitors
+		WHERE id = ?
+	`
+
+	var monitor models.Monitor
+	err := r.db.QueryRow(query, id).Scan(
+		&monitor.ID,
+		&monitor.SiteID,
+		&monitor.Name,
+		&monitor.URL,
+		&monitor.Enabled,
+		&monitor.CreatedAt,
+		&monitor.UpdatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("monitor not found")
+		}
+		return nil, err
+	}
+
+	return &monitor, nil
+}
+
+func (r *MonitorRepository) FindBySiteID(siteID int64) ([]*models.Monitor, error) {
+	defer telemetry.ObserveQuery("monitor", "FindBySiteID")()
+
+	query := `
+		SELECT id, site_id, name, url, enabled, created_at, updated_at
+		FROM monitors
+		WHERE site_id = ?
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.Query(query, siteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanMonitors(rows)
+}
+
+// FindEnabled returns every enabled monitor whose site still exists
+func (r *MonitorRepository) FindEnabled() ([]*models.Monitor, error) {
+	defer telemetry.ObserveQuery("monitor", "FindEnabled")()
+
+	query := `
+		SELECT m.id, m.site_id, m.name, m.url, m.enabled, m.created_at, m.updated_at
+		FROM monitors m
+		JOIN sites s ON s.id = m.site_id
+		WHERE m.enabled = TRUE
+		ORDER BY m.id ASC
+	`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanMonitors(rows)
+}
+
+func (r *MonitorRepository) Update(monitor *models.Monitor) error {
+	defer telemetry.ObserveQuery("monitor", "Update")()
+
+	query := `
+		UPDATE monitors
+		SET name = ?, url = ?, enabled = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	_, err := r.db.Exec(
+		query,
+		monitor.Name,
+		monitor.URL,
+		monitor.Enabled,
+		time.Now(),
+		monitor.ID,
+	)
+
+	return err
+}
+
+func (r *MonitorRepository) Delete(id int64) error {
+	defer telemetry.ObserveQuery("monitor", "Delete")()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := deleteMonitorData(tx, `SELECT ?`, id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM monitors WHERE id = ?`, id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// deleteMonitorData deletes what refers to the monitors selected by a query
+// taking a single argument. Foreign keys aren't enforced on the connection,
+// so their ON DELETE CASCADE clauses never apply.
+func deleteMonitorData(tx *sql.Tx, monitors string, arg interface{}) error {
+	rules := `SELECT id FROM alert_rules WHERE monitor_id IN (` + monitors + `)`
+	slos := `SELECT id FROM slos WHERE monitor_id IN (` + monitors + `)`
+
+	queries := []string{
+		`DELETE FROM alert_rule_channels WHERE rule_id IN (` + rules + `)`,
+		`DELETE FROM alert_incidents WHERE rule_id IN (` + rules + `)`,
+		`DELETE FROM alert_rules WHERE monitor_id IN (` + monitors + `)`,
+		`DELETE FROM slo_channels WHERE slo_id IN (` + slos + `)`,
+		`DELETE FROM slos WHERE monitor_id IN (` + monitors + `)`,
+		`DELETE FROM health_checks WHERE monitor_id IN (` + monitors + `)`,
+		`DELETE FROM health_check_rollups WHERE monitor_id IN (` + monitors + `)`,
+		`DELETE FROM status_page_monitors WHERE monitor_id IN (` + monitors + `)`,
+		`DELETE FROM tls_certificates WHERE monitor_id IN (` + monitors + `)`,
+		`DELETE FROM content_checks WHERE monitor_id IN (` + monitors + `)`,
+		`DELETE FROM content_changes WHERE monitor_id IN (` + monitors + `)`,
+	}
+	for _, query := range queries {
+		if _, err := tx.Exec(query, arg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scanMonitors(rows *sql.Rows) ([]*models.Monitor, error) {
+	var monitors []*models.Monitor
+	for rows.Next() {
+		var monitor models.Monitor
+		err := rows.Scan(
+			&monitor.ID,
+			&monitor.SiteID,
+			&monitor.Name,
+			&monitor.URL,
+			&monitor.Enabled,
+			&monitor.CreatedAt,
+			&monitor.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		monitors = append(monitors, &monitor)
+	}
+
+	return monitors, rows.Err()
+}
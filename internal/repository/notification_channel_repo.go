@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"egide-server/internal/models"
+	"egide-server/internal/telemetry"
+)
+
+type NotificationChannelRepository struct {
+	db *sql.DB
+}
+
+func NewNotificationChannelRepository(db *sql.DB) *NotificationChannelRepository {
+	return &NotificationChannelRepository{
+		db: db,
+	}
+}
+
+func (r *NotificationChannelRepository) Create(channel *models.NotificationChannel) (int64, error) {
+	defer telemetry.ObserveQuery("notification_channel", "Create")()
+
+	query := `
+		INSERT INTO notification_channels (user_id, name, type, target, enabled, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	now := time.Now()
+	result, err := r.db.Exec(
+		query,
+		channel.UserID,
+		channel.Name,
+		channel.Type,
+		channel.Target,
+		channel.Enabled,
+		now,
+		now,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+func (r *NotificationChannelRepository) FindByID(id int64) (*models.NotificationChannel, error) {
+	defer telemetry.ObserveQuery("notification_channel", "FindByID")()
+
+	query := `
+		SELECT id, user_id, name, type, target, enabled, created_at, updated_at
+		FROM notification_channels
+		WHERE id = ?
+	`
+
+	var channel models.NotificationChannel
+	var channelType string
+
+	err := r.db.QueryRow(query, id).Scan(
+		&channel.ID,
+		&channel.UserID,
+		&channel.Name,
+		&channelType,
+		&channel.Target,
+		&channel.Enabled,
+		&channel.CreatedAt,
+		&channel.UpdatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("notification channel not found")
+		}
+		return nil, err
+	}
+
+	channel.Type = models.ChannelType(channelType)
+	return &channel, nil
+}
+
+func (r *NotificationChannelRepository) FindByUserID(userID int64) ([]*models.NotificationChannel, error) {
+	defer telemetry.ObserveQuery("notification_channel", "FindByUserID")()
+
+	query := `
+		SELECT id, user_id, name, type, target, enabled, created_at, updated_at
+		FROM notification_channels
+		WHERE user_id = ?
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanNotificationChannels(rows)
+}
+
+// FindByRuleID returns the enabled channels attached to an alert rule
+func (r *NotificationChannelRepository) FindByRuleID(ruleID int64) ([]*models.NotificationChannel, error) {
+	defer telemetry.ObserveQuery("notification_channel", "FindByRuleID")()
+
+	query := `
+		SELECT c.id, c.user_id, c.name, c.type, c.target, c.enabled, c.created_at, c.updated_at
+		FROM notification_channels c
+		JOIN alert_rule_channels rc ON rc.channel_id = c.id
+		WHERE rc.rule_id = ? AND c.enabled = TRUE
+	`
+
+	rows, err := r.db.Query(query, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanNotificationChannels(rows)
+}
+
+// FindBySloID returns the enabled channels notified when an SLO burns too fast
+func (r *NotificationChannelRepository) FindBySloID(sloID int64) ([]*models.NotificationChannel, error) {
+	defer telemetry.ObserveQuery("notification_channel", "FindBySloID")()
+
+	query := `
+		SELECT c.id, c.user_id, c.name, c.type, c.target, c.enabled, c.created_at, c.updated_at
+		FROM notification_channels c
+		JOIN slo_channels sc ON sc.channel_id = c.id
+		WHERE sc.slo_id = ? AND c.enabled = TRUE
+	`
+
+	rows, err := r.db.Query(query, sloID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanNotificationChannels(rows)
+}
+
+func (r *NotificationChannelRepository) Update(channel *models.NotificationChannel) error {
+	defer telemetry.ObserveQuery("notification_channel", "Update")()
+
+	query := `
+		UPDATE notification_channels
+		SET name = ?, type = ?, target = ?, enabled = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	_, err := r.db.Exec(
+		query,
+		channel.Name,
+		channel.Type,
+		channel.Target,
+		channel.Enabled,
+		time.Now(),
+		channel.ID,
+	)
+
+	return err
+}
+
+func (r *NotificationChannelRepository) Delete(id int64) error {
+	defer telemetry.ObserveQuery("notification_channel", "Delete")()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM alert_rule_channels WHERE channel_id = ?`, id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM notification_channels WHERE id = ?`, id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func scanNotificationChannels(rows *sql.Rows) ([]*models.NotificationChannel, error) {
+	var channels []*models.NotificationChannel
+	for rows.Next() {
+		var channel models.NotificationChannel
+		var channelType string
+
+		err := rows.Scan(
+			&channel.ID,
+			&channel.UserID,
+			&channel.Name,
+			&channelType,
+			&channel.Target,
+			&channel.Enabled,
+			&channel.CreatedAt,
+			&channel.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		channel.Type = models.ChannelType(channelType)
+		channels = append(channels, &channel)
+	}
+
+	return channels, rows.Err()
+}
@@ -0,0 +1,260 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"egide-server/internal/models"
+	"egide-server/internal/telemetry"
+)
+
+type OrganizationRepository struct {
+	db *sql.DB
+}
+
+func NewOrganizationRepository(db *sql.DB) *OrganizationRepository {
+	return &OrganizationRepository{
+		db: db,
+	}
+}
+
+// Create stores an organization with its first owner
+func (r *OrganizationRepository) Create(org *models.Organization, ownerID int64) (int64, error) {
+	defer telemetry.ObserveQuery("organization", "Create")()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UTC()
+	result, err := tx.Exec(
+		`INSERT INTO organizations (name, require_two_factor, created_at, updated_at) VALUES (?, ?, ?, ?)`,
+		org.Name, org.RequireTwoFactor, now, now,
+	)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO organization_members (organization_id, user_id, role, created_at) VALUES (?, ?, ?, ?)`,
+		id, ownerID, models.RoleOwner, now,
+	)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+func (r *OrganizationRepository) FindByID(id int64) (*models.Organization, error) {
+	defer telemetry.ObserveQuery("organization", "FindByID")()
+
+	query := `SELECT id, name, require_two_factor, created_at, updated_at FROM organizations WHERE id = ?`
+
+	var org models.Organization
+	err := r.db.QueryRow(query, id).Scan(
+		&org.ID,
+		&org.Name,
+		&org.RequireTwoFactor,
+		&org.CreatedAt,
+		&org.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("organization not found")
+		}
+		return nil, err
+	}
+
+	return &org, nil
+}
+
+// FindByUserID returns the organizations a user is a member of, with their role
+func (r *OrganizationRepository) FindByUserID(userID int64) ([]*models.Organization, error) {
+	defer telemetry.ObserveQuery("organization", "FindByUserID")()
+
+	query := `
+		SELECT o.id, o.name, o.require_two_factor, o.created_at, o.updated_at, m.role
+		FROM organizations o
+		JOIN organization_members m ON m.organization_id = o.id
+		WHERE m.user_id = ?
+		ORDER BY o.name
+	`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orgs := []*models.Organization{}
+	for rows.Next() {
+		var org models.Organization
+		err := rows.Scan(
+			&org.ID,
+			&org.Name,
+			&org.RequireTwoFactor,
+			&org.CreatedAt,
+			&org.UpdatedAt,
+			&org.Role,
+		)
+		if err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, &org)
+	}
+
+	return orgs, rows.Err()
+}
+
+func (r *OrganizationRepository) Update(org *models.Organization) error {
+	defer telemetry.ObserveQuery("organization", "Update")()
+
+	_, err := r.db.Exec(
+		`UPDATE organizations SET name = ?, require_two_factor = ?, updated_at = ? WHERE id = ?`,
+		org.Name, org.RequireTwoFactor, time.Now().UTC(), org.ID,
+	)
+	return err
+}
+
+// Delete removes an organization and its memberships
+func (r *OrganizationRepository) Delete(id int64) error {
+	defer telemetry.ObserveQuery("organization", "Delete")()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM organization_members WHERE organization_id = ?`, id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM organizations WHERE id = ?`, id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *OrganizationRepository) FindMember(orgID, userID int64) (*models.OrganizationMember, error) {
+	defer telemetry.ObserveQuery("organization", "FindMember")()
+
+	query := `
+		SELECT m.organization_id, m.user_id, u.username, m.role, m.created_at
+		FROM organization_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.organization_id = ? AND m.user_id = ?
+	`
+
+	var member models.OrganizationMember
+	err := r.db.QueryRow(query, orgID, userID).Scan(
+		&member.OrganizationID,
+		&member.UserID,
+		&member.Username,
+		&member.Role,
+		&member.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("member not found")
+		}
+		return nil, err
+	}
+
+	return &member, nil
+}
+
+func (r *OrganizationRepository) FindMembers(orgID int64) ([]*models.OrganizationMember, error) {
+	defer telemetry.ObserveQuery("organization", "FindMembers")()
+
+	query := `
+		SELECT m.organization_id, m.user_id, u.username, m.role, m.created_at
+		FROM organization_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.organization_id = ?
+		ORDER BY m.created_at, m.user_id
+	`
+
+	rows, err := r.db.Query(query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []*models.OrganizationMember{}
+	for rows.Next() {
+		var member models.OrganizationMember
+		err := rows.Scan(
+			&member.OrganizationID,
+			&member.UserID,
+			&member.Username,
+			&member.Role,
+			&member.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, &member)
+	}
+
+	return members, rows.Err()
+}
+
+func (r *OrganizationRepository) AddMember(member *models.OrganizationMember) error {
+	defer telemetry.ObserveQuery("organization", "AddMember")()
+
+	_, err := r.db.Exec(
+		`INSERT INTO organization_members (organization_id, user_id, role, created_at) VALUES (?, ?, ?, ?)`,
+		member.OrganizationID, member.UserID, member.Role, time.Now().UTC(),
+	)
+	return err
+}
+
+func (r *OrganizationRepository) UpdateMemberRole(orgID, userID int64, role models.Role) error {
+	defer telemetry.ObserveQuery("organization", "UpdateMemberRole")()
+
+	_, err := r.db.Exec(
+		`UPDATE organization_members SET role = ? WHERE organization_id = ? AND user_id = ?`,
+		role, orgID, userID,
+	)
+	return err
+}
+
+func (r *OrganizationRepository) RemoveMember(orgID, userID int64) error {
+	defer telemetry.ObserveQuery("organization", "RemoveMember")()
+
+	_, err := r.db.Exec(`DELETE FROM organization_members WHERE organization_id = ? AND user_id = ?`, orgID, userID)
+	return err
+}
+
+func (r *OrganizationRepository) CountOwners(orgID int64) (int, error) {
+	defer telemetry.ObserveQuery("organization", "CountOwners")()
+
+	var count int
+	err := r.db.QueryRow(
+		`SELECT COUNT(*) FROM organization_members WHERE organization_id = ? AND role = ?`,
+		orgID, models.RoleOwner,
+	).Scan(&count)
+	return count, err
+}
+
+func (r *OrganizationRepository) CountSites(orgID int64) (int, error) {
+	defer telemetry.ObserveQuery("organization", "CountSites")()
+
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM organization_sites WHERE organization_id = ?`, orgID).Scan(&count)
+	return count, err
+}
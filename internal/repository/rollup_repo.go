@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+
+	"egide-server/internal/models"
+	"egide-server/internal/telemetry"
+)
+
+// RollupRepository stores health checks aggregated into hourly and daily
+// buckets. Bucket times are always stored and queried in UTC.
+type RollupRepository struct {
+	db *sql.DB
+}
+
+func NewRollupRepository(db *sql.DB) *RollupRepository {
+	return &RollupRepository{
+		db: db,
+	}
+}
+
+// Save inserts a rollup or replaces the existing one for the same bucket
+func (r *RollupRepository) Save(rollup *models.HealthCheckRollup) error {
+	defer telemetry.ObserveQuery("rollup", "Save")()
+
+	query := `
+		INSERT INTO health_check_rollups (
+			monitor_id, resolution, bucket_start, total, successes, maintenance,
+			latency_count, min_ms, avg_ms, max_ms, p50_ms, p95_ms, p99_ms, histogram
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(monitor_id, resolution, bucket_start) DO UPDATE SET
+			total = excluded.total,
+			successes = excluded.successes,
+			maintenance = excluded.maintenance,
+			latency_count = excluded.latency_count,
+			min_ms = excluded.min_ms,
+			avg_ms = excluded.avg_ms,
+			max_ms = excluded.max_ms,
+			p50_ms = excluded.p50_ms,
+			p95_ms = excluded.p95_ms,
+			p99_ms = excluded.p99_ms,
+			histogram = excluded.histogram
+	`
+
+	_, err := r.db.Exec(
+		query,
+		rollup.MonitorID,
+		rollup.Resolution,
+		rollup.BucketStart.UTC(),
+		rollup.Total,
+		rollup.Successes,
+		rollup.Maintenance,
+		rollup.LatencyCount,
+		rollup.MinMs,
+		rollup.AvgMs,
+		rollup.MaxMs,
+		rollup.P50Ms,
+		rollup.P95Ms,
+		rollup.P99Ms,
+		joinCounts(rollup.Histogram),
+	)
+
+	return err
+}
+
+// FindInRange returns the rollups of the given monitors whose bucket starts within [start, end)
+func (r *RollupRepository) FindInRange(resolution models.RollupResolution, monitorIDs []int64, start, end time.Time) ([]*models.HealthCheckRollup, error) {
+	defer telemetry.ObserveQuery("rollup", "FindInRange")()
+
+	if len(monitorIDs) == 0 {
+		return nil, nil
+	}
+
+	placeholders, ids := int64Args(monitorIDs)
+	args := append([]interface{}{resolution, start.UTC(), end.UTC()}, ids...)
+	return r.findInRange(`AND monitor_id IN (`+placeholders+`)`, args...)
+}
+
+// FindAllInRange returns the rollups of every monitor whose bucket starts
+// within [start, end), for rolling them up further
+func (r *RollupRepository) FindAllInRange(resolution models.RollupResolution, start, end time.Time) ([]*models.HealthCheckRollup, error) {
+	defer telemetry.ObserveQuery("rollup", "FindAllInRange")()
+
+	return r.findInRange(``, resolution, start.UTC(), end.UTC())
+}
+
+func (r *RollupRepository) findInRange(filter string, args ...interface{}) ([]*models.HealthCheckRollup, error) {
+	query := `
+		SELECT monitor_id, resolution, bucket_start, total, successes, maintenance,
+			latency_count, min_ms, avg_ms, max_ms, p50_ms, p95_ms, p99_ms, histogram
+		FROM health_check_rollups
+		WHERE resolution = ? AND bucket_start >= ? AND bucket_start < ? ` + filter + `
+		ORDER BY bucket_start ASC, monitor_id ASC
+	`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rollups []*models.HealthCheckRollup
+	for rows.Next() {
+		var rollup models.HealthCheckRollup
+		var resolution, histogram string
+		err := rows.Scan(
+			&rollup.MonitorID,
+			&resolution,
+			&rollup.BucketStart,
+			&rollup.Total,
+			&rollup.Successes,
+			&rollup.Maintenance,
+			&rollup.LatencyCount,
+			&rollup.MinMs,
+			&rollup.AvgMs,
+			&rollup.MaxMs,
+			&rollup.P50Ms,
+			&rollup.P95Ms,
+			&rollup.P99Ms,
+			&histogram,
+		)
+		if err != nil {
+			return nil, err
+		}
+		rollup.Resolution = models.RollupResolution(resolution)
+		rollup.Histogram = splitCounts(histogram)
+		rollups = append(rollups, &rollup)
+	}
+
+	return rollups, rows.Err()
+}
+
+// LatestBucket returns the start of the most recent bucket of a resolution,
+// or false when nothing was rolled up yet
+func (r *RollupRepository) LatestBucket(resolution models.RollupResolution) (time.Time, bool, error) {
+	defer telemetry.ObserveQuery("rollup", "LatestBucket")()
+
+	query := `
+		SELECT bucket_start
+		FROM health_check_rollups
+		WHERE resolution = ?
+		ORDER BY bucket_start DESC
+		LIMIT 1
+	`
+
+	var bucket time.Time
+	err := r.db.QueryRow(query, resolution).Scan(&bucket)
+	if err == sql.ErrNoRows {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	return bucket.UTC(), true, nil
+}
+
+// GetDailyStats returns the daily check counts of a monitor since the given day
+func (r *RollupRepository) GetDailyStats(monitorID int64, since time.Time) ([]*models.DailyCheckStats, error) {
+	defer telemetry.ObserveQuery("rollup", "GetDailyStats")()
+
+	rollups, err := r.FindInRange(models.DailyRollup, []int64{monitorID}, since, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]*models.DailyCheckStats, 0, len(rollups))
+	for _, rollup := range rollups {
+		stats = append(stats, &models.DailyCheckStats{
+			Day:       rollup.BucketStart.Format("2006-01-02"),
+			Total:     rollup.Total,
+			Successes: rollup.Successes,
+		})
+	}
+
+	return stats, nil
+}
+
+// DeleteBefore removes the rollups of a resolution older than cutoff
+func (r *RollupRepository) DeleteBefore(resolution models.RollupResolution, cutoff time.Time) error {
+	defer telemetry.ObserveQuery("rollup", "DeleteBefore")()
+
+	query := `DELETE FROM health_check_rollups WHERE resolution = ? AND bucket_start < ?`
+	_, err := r.db.Exec(query, resolution, cutoff.UTC())
+	return err
+}
+
+func joinCounts(counts []int) string {
+	values := make([]string, len(counts))
+	for i, count := range counts {
+		values[i] = strconv.Itoa(count)
+	}
+	return strings.Join(values, ",")
+}
+
+func splitCounts(value string) []int {
+	if value == "" {
+		return nil
+	}
+
+	parts := strings.Split(value, ",")
+	counts := make([]int, len(parts))
+	for i, part := range parts {
+		counts[i], _ = strconv.Atoi(part)
+	}
+	return counts
+}
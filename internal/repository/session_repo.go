@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"egide-server/internal/models"
+	"egide-server/internal/telemetry"
+)
+
+const sessionColumns = `id, user_id, refresh_token_hash, COALESCE(previous_token_hash, ''), user_agent, ip_address, created_at, last_used_at, expires_at, revoked_at, two_factor_verified_at`
+
+type SessionRepository struct {
+	db *sql.DB
+}
+
+func NewSessionRepository(db *sql.DB) *SessionRepository {
+	return &SessionRepository{
+		db: db,
+	}
+}
+
+func (r *SessionRepository) Create(session *models.Session) (int64, error) {
+	defer telemetry.ObserveQuery("session", "Create")()
+
+	query := `
+		INSERT INTO sessions (user_id, refresh_token_hash, user_agent, ip_address, created_at, last_used_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.Exec(
+		query,
+		session.UserID,
+		session.RefreshTokenHash,
+		session.UserAgent,
+		session.IPAddress,
+		session.CreatedAt.UTC(),
+		session.LastUsedAt.UTC(),
+		session.ExpiresAt.UTC(),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+func (r *SessionRepository) FindByID(id int64) (*models.Session, error) {
+	defer telemetry.ObserveQuery("session", "FindByID")()
+
+	return r.findSession(`SELECT `+sessionColumns+` FROM sessions WHERE id = ?`, id)
+}
+
+// FindByTokenHash finds the session of a refresh token, current or already rotated
+func (r *SessionRepository) FindByTokenHash(hash string) (*models.Session, error) {
+	defer telemetry.ObserveQuery("session", "FindByTokenHash")()
+
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE refresh_token_hash = ? OR previous_token_hash = ?`
+	return r.findSession(query, hash, hash)
+}
+
+// FindActiveByUserID returns the sessions of a user that are neither revoked
+// nor expired, most recently used first
+func (r *SessionRepository) FindActiveByUserID(userID int64, now time.Time) ([]*models.Session, error) {
+	defer telemetry.ObserveQuery("session", "FindActiveByUserID")()
+
+	query := `
+		SELECT ` + sessionColumns + `
+		FROM sessions
+		WHERE user_id = ? AND revoked_at IS NULL AND expires_at > ?
+		ORDER BY last_used_at DESC
+	`
+
+	rows, err := r.db.Query(query, userID, now.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []*models.Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// IsActive reports whether a session is neither revoked nor expired
+func (r *SessionRepository) IsActive(id int64, now time.Time) (bool, error) {
+	defer telemetry.ObserveQuery("session", "IsActive")()
+
+	var count int
+	err := r.db.QueryRow(
+		`SELECT COUNT(*) FROM sessions WHERE id = ? AND revoked_at IS NULL AND expires_at > ?`,
+		id, now.UTC(),
+	).Scan(&count)
+
+	return count > 0, err
+}
+
+// Rotate replaces the refresh token of a session, provided it is still the
+// given one, so that two concurrent refreshes can't both succeed
+func (r *SessionRepository) Rotate(session *models.Session, oldHash string) (bool, error) {
+	defer telemetry.ObserveQuery("session", "Rotate")()
+
+	query := `
+		UPDATE sessions
+		SET refresh_token_hash = ?, previous_token_hash = ?, user_agent = ?, ip_address = ?, last_used_at = ?, expires_at = ?
+		WHERE id = ? AND refresh_token_hash = ? AND revoked_at IS NULL
+	`
+
+	result, err := r.db.Exec(
+		query,
+		session.RefreshTokenHash,
+		oldHash,
+		session.UserAgent,
+		session.IPAddress,
+		session.LastUsedAt.UTC(),
+		session.ExpiresAt.UTC(),
+		session.ID,
+		oldHash,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+func (r *SessionRepository) Revoke(id int64, at time.Time) error {
+	defer telemetry.ObserveQuery("session", "Revoke")()
+
+	_, err := r.db.Exec(`UPDATE sessions SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`, at.UTC(), id)
+	return err
+}
+
+// MarkTwoFactorVerified records that the user of a session verified a second factor
+func (r *SessionRepository) MarkTwoFactorVerified(id int64, at time.Time) error {
+	defer telemetry.ObserveQuery("session", "MarkTwoFactorVerified")()
+
+	_, err := r.db.Exec(`UPDATE sessions SET two_factor_verified_at = ? WHERE id = ?`, at.UTC(), id)
+	return err
+}
+
+// DeleteExpired removes the sessions that expired before now
+func (r *SessionRepository) DeleteExpired(now time.Time) error {
+	defer telemetry.ObserveQuery("session", "DeleteExpired")()
+
+	_, err := r.db.Exec(`DELETE FROM sessions WHERE expires_at < ?`, now.UTC())
+	return err
+}
+
+func (r *SessionRepository) findSession(query string, args ...interface{}) (*models.Session, error) {
+	session, err := scanSession(r.db.QueryRow(query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("session not found")
+		}
+		return nil, err
+	}
+
+	return session, nil
+}
+
+func scanSession(row rowScanner) (*models.Session, error) {
+	var session models.Session
+
+	err := row.Scan(
+		&session.ID,
+		&session.UserID,
+		&session.RefreshTokenHash,
+		&session.PreviousTokenHash,
+		&session.UserAgent,
+		&session.IPAddress,
+		&session.CreatedAt,
+		&session.LastUsedAt,
+		&session.ExpiresAt,
+		&session.RevokedAt,
+		&session.TwoFactorVerifiedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"egide-server/internal/models"
+	"egide-server/internal/telemetry"
+)
+
+type SigningKeyRepository struct {
+	db *sql.DB
+}
+
+func NewSigningKeyRepository(db *sql.DB) *SigningKeyRepository {
+	return &SigningKeyRepository{
+		db: db,
+	}
+}
+
+func (r *SigningKeyRepository) Create(key *models.SigningKey) (int64, error) {
+	defer telemetry.ObserveQuery("signing_key", "Create")()
+
+	query := `
+		INSERT INTO signing_keys (kid, algorithm, private_key, public_key, created_at, rotates_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.Exec(
+		query,
+		key.KeyID,
+		key.Algorithm,
+		key.PrivateKey,
+		key.PublicKey,
+		key.CreatedAt.UTC(),
+		key.RotatesAt.UTC(),
+		key.ExpiresAt.UTC(),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+// FindValid returns the keys that haven't expired at now, newest first
+func (r *SigningKeyRepository) FindValid(now time.Time) ([]*models.SigningKey, error) {
+	defer telemetry.ObserveQuery("signing_key", "FindValid")()
+
+	query := `
+		SELECT id, kid, algorithm, private_key, public_key, created_at, rotates_at, expires_at
+		FROM signing_keys
+		WHERE expires_at > ?
+		ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := r.db.Query(query, now.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []*models.SigningKey{}
+	for rows.Next() {
+		var key models.SigningKey
+		err := rows.Scan(
+			&key.ID,
+			&key.KeyID,
+			&key.Algorithm,
+			&key.PrivateKey,
+			&key.PublicKey,
+			&key.CreatedAt,
+			&key.RotatesAt,
+			&key.ExpiresAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, &key)
+	}
+
+	return keys, rows.Err()
+}
+
+// DeleteExpired removes the keys whose tokens are no longer accepted
+func (r *SigningKeyRepository) DeleteExpired(now time.Time) error {
+	defer telemetry.ObserveQuery("signing_key", "DeleteExpired")()
+
+	_, err := r.db.Exec(`DELETE FROM signing_keys WHERE expires_at <= ?`, now.UTC())
+	return err
+}
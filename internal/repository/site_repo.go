@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"egide-server/internal/models"
+	"egide-server/internal/telemetry"
 )
 
 type SiteRepository struct {
@@ -19,13 +20,20 @@ func NewSiteRepository(db *sql.DB) *SiteRepository {
 }
 
 func (r *SiteRepository) Create(site *models.Site) (int64, error) {
+	defer telemetry.ObserveQuery("site", "Create")()
+
 	query := `
 		INSERT INTO sites (user_id, domain, protection_mode, active, verified, created_at, updated_at)
 		VALUES (?, ?, ?, ?, ?, ?, ?)
 	`
 
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
 	now := time.Now()
-	result, err := r.db.Exec(
+	result, err := tx.Exec(
 		query,
 		site.UserID,
 		site.Domain,
@@ -36,17 +44,36 @@ func (r *SiteRepository) Create(site *models.Site) (int64, error) {
 		now,
 	)
 	if err != nil {
+		tx.Rollback()
 		return 0, err
 	}
 
-	return result.LastInsertId()
+	id, err := result.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	// Sites of an organization are owned by it rather than by their creator
+	if site.OrganizationID != nil {
+		_, err := tx.Exec(`INSERT INTO organization_sites (site_id, organization_id) VALUES (?, ?)`, id, *site.OrganizationID)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+
+	return id, tx.Commit()
 }
 
 func (r *SiteRepository) FindByID(id int64) (*models.Site, error) {
+	defer telemetry.ObserveQuery("site", "FindByID")()
+
 	query := `
-		SELECT id, user_id, domain, protection_mode, active, verified, created_at, updated_at
-		FROM sites
-		WHERE id = ?
+		SELECT s.id, s.user_id, os.organization_id, s.domain, s.protection_mode, s.active, s.verified, s.created_at, s.updated_at
+		FROM sites s
+		LEFT JOIN organization_sites os ON os.site_id = s.id
+		WHERE s.id = ?
 	`
 
 	var site models.Site
@@ -55,6 +82,7 @@ func (r *SiteRepository) FindByID(id int64) (*models.Site, error) {
 	err := r.db.QueryRow(query, id).Scan(
 		&site.ID,
 		&site.UserID,
+		&site.OrganizationID,
 		&site.Domain,
 		&protectionMode,
 		&site.Active,
@@ -74,12 +102,16 @@ func (r *SiteRepository) FindByID(id int64) (*models.Site, error) {
 	return &site, nil
 }
 
+// FindByUserID returns the sites a user owns personally, outside of organizations
 func (r *SiteRepository) FindByUserID(userID int64) ([]*models.Site, error) {
+	defer telemetry.ObserveQuery("site", "FindByUserID")()
+
 	query := `
-		SELECT id, user_id, domain, protection_mode, active, verified, created_at, updated_at
-		FROM sites
-		WHERE user_id = ?
-		ORDER BY created_at DESC
+		SELECT s.id, s.user_id, os.organization_id, s.domain, s.protection_mode, s.active, s.verified, s.created_at, s.updated_at
+		FROM sites s
+		LEFT JOIN organization_sites os ON os.site_id = s.id
+		WHERE s.user_id = ? AND os.site_id IS NULL
+		ORDER BY s.created_at DESC
 	`
 
 	rows, err := r.db.Query(query, userID)
@@ -96,6 +128,58 @@ func (r *SiteRepository) FindByUserID(userID int64) ([]*models.Site, error) {
 		err := rows.Scan(
 			&site.ID,
 			&site.UserID,
+			&site.OrganizationID,
+			&site.Domain,
+			&protectionMode,
+			&site.Active,
+			&site.Verified,
+			&site.CreatedAt,
+			&site.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		site.ProtectionMode = models.ProtectionMode(protectionMode)
+		sites = append(sites, &site)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sites, nil
+}
+
+// FindAccessibleByUserID returns the sites a user owns personally and the
+// sites of the organizations they are a member of
+func (r *SiteRepository) FindAccessibleByUserID(userID int64) ([]*models.Site, error) {
+	defer telemetry.ObserveQuery("site", "FindAccessibleByUserID")()
+
+	query := `
+		SELECT s.id, s.user_id, os.organization_id, s.domain, s.protection_mode, s.active, s.verified, s.created_at, s.updated_at
+		FROM sites s
+		LEFT JOIN organization_sites os ON os.site_id = s.id
+		WHERE (os.site_id IS NULL AND s.user_id = ?)
+		   OR os.organization_id IN (SELECT organization_id FROM organization_members WHERE user_id = ?)
+		ORDER BY s.created_at DESC
+	`
+
+	rows, err := r.db.Query(query, userID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sites []*models.Site
+	for rows.Next() {
+		var site models.Site
+		var protectionMode string
+
+		err := rows.Scan(
+			&site.ID,
+			&site.UserID,
+			&site.OrganizationID,
 			&site.Domain,
 			&protectionMode,
 			&site.Active,
@@ -119,10 +203,13 @@ func (r *SiteRepository) FindByUserID(userID int64) ([]*models.Site, error) {
 }
 
 func (r *SiteRepository) FindByDomain(userID int64, domain string) (*models.Site, error) {
+	defer telemetry.ObserveQuery("site", "FindByDomain")()
+
 	query := `
-		SELECT id, user_id, domain, protection_mode, active, verified, created_at, updated_at
-		FROM sites
-		WHERE user_id = ? AND domain = ?
+		SELECT s.id, s.user_id, os.organization_id, s.domain, s.protection_mode, s.active, s.verified, s.created_at, s.updated_at
+		FROM sites s
+		LEFT JOIN organization_sites os ON os.site_id = s.id
+		WHERE s.user_id = ? AND s.domain = ?
 	`
 
 	var site models.Site
@@ -131,6 +218,7 @@ func (r *SiteRepository) FindByDomain(userID int64, domain string) (*models.Site
 	err := r.db.QueryRow(query, userID, domain).Scan(
 		&site.ID,
 		&site.UserID,
+		&site.OrganizationID,
 		&site.Domain,
 		&protectionMode,
 		&site.Active,
@@ -151,6 +239,8 @@ func (r *SiteRepository) FindByDomain(userID int64, domain string) (*models.Site
 }
 
 func (r *SiteRepository) Update(site *models.Site) error {
+	defer telemetry.ObserveQuery("site", "Update")()
+
 	query := `
 		UPDATE sites
 		SET domain = ?, protection_mode = ?, active = ?, verified = ?, updated_at = ?
@@ -171,6 +261,8 @@ func (r *SiteRepository) Update(site *models.Site) error {
 }
 
 func (r *SiteRepository) UpdateVerificationStatus(id int64, verified bool) error {
+	defer telemetry.ObserveQuery("site", "UpdateVerificationStatus")()
+
 	query := `
 		UPDATE sites
 		SET verified = ?, updated_at = ?
@@ -188,7 +280,82 @@ func (r *SiteRepository) UpdateVerificationStatus(id int64, verified bool) error
 }
 
 func (r *SiteRepository) Delete(id int64) error {
-	query := `DELETE FROM sites WHERE id = ?`
-	_, err := r.db.Exec(query, id)
-	return err
+	defer telemetry.ObserveQuery("site", "Delete")()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := deleteMonitorData(tx, `SELECT id FROM monitors WHERE site_id = ?`, id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Everything else referring to the site, which foreign keys don't cascade to
+	queries := []string{
+		`DELETE FROM alert_rule_channels WHERE rule_id IN (SELECT id FROM alert_rules WHERE site_id = ?)`,
+		`DELETE FROM alert_incidents WHERE rule_id IN (SELECT id FROM alert_rules WHERE site_id = ?)`,
+		`DELETE FROM alert_rules WHERE site_id = ?`,
+		`DELETE FROM slo_channels WHERE slo_id IN (SELECT id FROM slos WHERE site_id = ?)`,
+		`DELETE FROM slos WHERE site_id = ?`,
+		`DELETE FROM monitors WHERE site_id = ?`,
+		`DELETE FROM threats WHERE site_id = ?`,
+		`DELETE FROM maintenance_windows WHERE site_id = ?`,
+		`DELETE FROM badge_settings WHERE site_id = ?`,
+		`DELETE FROM traffic_counters WHERE site_id = ?`,
+		`DELETE FROM analytics_top_values WHERE site_id = ?`,
+		`DELETE FROM content_changes WHERE site_id = ?`,
+		`DELETE FROM site_transfers WHERE site_id = ?`,
+		`DELETE FROM organization_sites WHERE site_id = ?`,
+		`DELETE FROM sites WHERE id = ?`,
+	}
+	for _, query := range queries {
+		if _, err := tx.Exec(query, id); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// FindActiveByDomain finds the active site serving a domain, used to attribute
+// data reported by edge nodes. Only verified sites can be activated.
+func (r *SiteRepository) FindActiveByDomain(domain string) (*models.Site, error) {
+	defer telemetry.ObserveQuery("site", "FindActiveByDomain")()
+
+	query := `
+		SELECT s.id, s.user_id, os.organization_id, s.domain, s.protection_mode, s.active, s.verified, s.created_at, s.updated_at
+		FROM sites s
+		LEFT JOIN organization_sites os ON os.site_id = s.id
+		WHERE s.domain = ? AND s.active = TRUE
+		ORDER BY s.created_at ASC
+		LIMIT 1
+	`
+
+	var site models.Site
+	var protectionMode string
+
+	err := r.db.QueryRow(query, domain).Scan(
+		&site.ID,
+		&site.UserID,
+		&site.OrganizationID,
+		&site.Domain,
+		&protectionMode,
+		&site.Active,
+		&site.Verified,
+		&site.CreatedAt,
+		&site.UpdatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("site not found")
+		}
+		return nil, err
+	}
+
+	site.ProtectionMode = models.ProtectionMode(protectionMode)
+	return &site, nil
 }
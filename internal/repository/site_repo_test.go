@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+
+	"egide-server/internal/migration"
+	"egide-server/internal/models"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestSiteUpdate(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	if err := migration.RunMigrations(db, "../../migrations"); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := db.Exec(`INSERT INTO users (username) VALUES ('owner')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userID, _ := result.LastInsertId()
+
+	sites := NewSiteRepository(db)
+	id, err := sites.Create(&models.Site{UserID: userID, Domain: "example.com", ProtectionMode: models.SimpleProtection})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := sites.Create(&models.Site{UserID: userID, Domain: "other.com", ProtectionMode: models.SimpleProtection})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	site, err := sites.FindByID(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	site.Domain = "www.example.com"
+	site.Active = true
+	if err := sites.Update(site); err != nil {
+		t.Fatalf("Update() = %v", err)
+	}
+	if err := sites.UpdateVerificationStatus(id, true); err != nil {
+		t.Fatalf("UpdateVerificationStatus() = %v", err)
+	}
+
+	site, err = sites.FindByID(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if site.Domain != "www.example.com" || !site.Active || !site.Verified {
+		t.Errorf("updated site = %q active=%v verified=%v; want www.example.com active verified", site.Domain, site.Active, site.Verified)
+	}
+
+	// Only the targeted row changes
+	untouched, err := sites.FindByID(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if untouched.Domain != "other.com" || untouched.Active || untouched.Verified {
+		t.Errorf("other site = %q active=%v verified=%v; want it untouched", untouched.Domain, untouched.Active, untouched.Verified)
+	}
+}
+
+func TestSiteDelete(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	if err := migration.RunMigrations(db, "../../migrations"); err != nil {
+		t.Fatal(err)
+	}
+
+	exec := func(query string, args ...interface{}) int64 {
+		t.Helper()
+		result, err := db.Exec(query, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, _ := result.LastInsertId()
+		return id
+	}
+
+	userID := exec(`INSERT INTO users (username) VALUES ('owner')`)
+	channelID := exec(`INSERT INTO notification_channels (user_id, name, type, target) VALUES (?, 'Mail', 'email', 'ops@example.com')`, userID)
+	pageID := exec(`INSERT INTO status_pages (user_id, slug, title) VALUES (?, 'status', 'Status')`, userID)
+
+	sites := NewSiteRepository(db)
+	siteIDs := []int64{}
+	for _, domain := range []string{"example.com", "other.com"} {
+		siteID, err := sites.Create(&models.Site{UserID: userID, Domain: domain, ProtectionMode: models.SimpleProtection})
+		if err != nil {
+			t.Fatal(err)
+		}
+		siteIDs = append(siteIDs, siteID)
+
+		monitorID := exec(`INSERT INTO monitors (site_id, name, url) VALUES (?, 'Home', ?)`, siteID, "https://"+domain)
+		exec(`INSERT INTO health_checks (monitor_id, response_time_ms, success) VALUES (?, 100, TRUE)`, monitorID)
+		exec(`INSERT INTO status_page_monitors (status_page_id, monitor_id) VALUES (?, ?)`, pageID, monitorID)
+		ruleID := exec(`INSERT INTO alert_rules (user_id, name, type, site_id, monitor_id) VALUES (?, 'Down', 'monitor_down', ?, ?)`, userID, siteID, monitorID)
+		exec(`INSERT INTO alert_rule_channels (rule_id, channel_id) VALUES (?, ?)`, ruleID, channelID)
+		sloID := exec(`INSERT INTO slos (site_id, name, target) VALUES (?, 'Uptime', 99.9)`, siteID)
+		exec(`INSERT INTO slo_channels (slo_id, channel_id) VALUES (?, ?)`, sloID, channelID)
+		exec(`INSERT INTO threats (site_id, nature, status, sources, time) VALUES (?, 1, 0, '[]', CURRENT_TIMESTAMP)`, siteID)
+		exec(`INSERT INTO maintenance_windows (site_id, name, recurrence, starts_at, duration_minutes) VALUES (?, 'Deploy', 'none', CURRENT_TIMESTAMP, 30)`, siteID)
+	}
+
+	if err := sites.Delete(siteIDs[0]); err != nil {
+		t.Fatalf("Delete() = %v", err)
+	}
+
+	// Only the rows of the other site remain
+	for _, table := range []string{"monitors", "health_checks", "status_page_monitors", "alert_rules", "alert_rule_channels", "slos", "slo_channels", "threats", "maintenance_windows"} {
+		var count int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM ` + table).Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		if count != 1 {
+			t.Errorf("%s has %d rows after deleting a site; want 1", table, count)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"egide-server/internal/models"
+	"egide-server/internal/telemetry"
+)
+
+const siteTransferColumns = `
+	t.id, t.site_id, s.domain, t.from_user_id, t.from_organization_id, t.to_user_id, t.to_organization_id,
+	t.status, t.initiated_by, t.completed_by, t.created_at, t.completed_at
+	FROM site_transfers t JOIN sites s ON s.id = t.site_id`
+
+type SiteTransferRepository struct {
+	db *sql.DB
+}
+
+func NewSiteTransferRepository(db *sql.DB) *SiteTransferRepository {
+	return &SiteTransferRepository{
+		db: db,
+	}
+}
+
+// Create stores a pending transfer along with its audit entry
+func (r *SiteTransferRepository) Create(transfer *models.SiteTransfer, entry *models.AuditEntry) (int64, error) {
+	defer telemetry.ObserveQuery("site_transfer", "Create")()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+		INSERT INTO site_transfers (site_id, from_user_id, from_organization_id, to_user_id, to_organization_id, status, initiated_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := tx.Exec(
+		query,
+		transfer.SiteID,
+		transfer.FromUserID,
+		transfer.FromOrganizationID,
+		transfer.ToUserID,
+		transfer.ToOrganizationID,
+		models.TransferPending,
+		transfer.InitiatedBy,
+		transfer.CreatedAt.UTC(),
+	)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if err := insertAuditEntry(tx, entry); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+func (r *SiteTransferRepository) FindByID(id int64) (*models.SiteTransfer, error) {
+	defer telemetry.ObserveQuery("site_transfer", "FindByID")()
+
+	return r.findSiteTransfer(`SELECT `+siteTransferColumns+` WHERE t.id = ?`, id)
+}
+
+func (r *SiteTransferRepository) FindPendingBySiteID(siteID int64) (*models.SiteTransfer, error) {
+	defer telemetry.ObserveQuery("site_transfer", "FindPendingBySiteID")()
+
+	return r.findSiteTransfer(`SELECT `+siteTransferColumns+` WHERE t.site_id = ? AND t.status = ?`, siteID, models.TransferPending)
+}
+
+// FindIncoming returns the pending transfers a user can accept: those to
+// them, and those to organizations they administer
+func (r *SiteTransferRepository) FindIncoming(userID int64) ([]*models.SiteTransfer, error) {
+	defer telemetry.ObserveQuery("site_transfer", "FindIncoming")()
+
+	query := `SELECT ` + siteTransferColumns + `
+		WHERE t.status = ? AND (t.to_user_id = ? OR t.to_organization_id IN (
+			SELECT organization_id FROM organization_members WHERE user_id = ? AND role IN (?, ?)
+		))
+		ORDER BY t.created_at DESC, t.id DESC
+	`
+
+	rows, err := r.db.Query(query, models.TransferPending, userID, userID, models.RoleOwner, models.RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transfers := []*models.SiteTransfer{}
+	for rows.Next() {
+		transfer, err := scanSiteTransfer(rows)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	return transfers, rows.Err()
+}
+
+// Close ends a pending transfer without moving the site, as declined or
+// cancelled. It returns false when the transfer isn't pending anymore.
+func (r *SiteTransferRepository) Close(transfer *models.SiteTransfer, status models.TransferStatus, userID int64, at time.Time, entry *models.AuditEntry) (bool, error) {
+	defer telemetry.ObserveQuery("site_transfer", "Close")()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+
+	closed, err := closeSiteTransfer(tx, transfer.ID, status, userID, at)
+	if err != nil || !closed {
+		tx.Rollback()
+		return false, err
+	}
+
+	if err := insertAuditEntry(tx, entry); err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// Complete moves the site of a pending transfer to its recipient, with
+// ownerID becoming the user owning it. Monitors, SLOs, maintenance windows
+// and threats follow the site. Its alert rules go to the new owner, and
+// notification channels and status pages of users losing access to the site
+// stop referencing it. It returns false when the transfer isn't pending
+// anymore.
+func (r *SiteTransferRepository) Complete(transfer *models.SiteTransfer, ownerID int64, at time.Time, entry *models.AuditEntry) (bool, error) {
+	defer telemetry.ObserveQuery("site_transfer", "Complete")()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+
+	closed, err := closeSiteTransfer(tx, transfer.ID, models.TransferAccepted, ownerID, at)
+	if err != nil || !closed {
+		tx.Rollback()
+		return false, err
+	}
+
+	// Users keeping access: the owner and the members of the recipient organization
+	const keepingAccess = `(SELECT ? UNION SELECT user_id FROM organization_members WHERE organization_id = ?)`
+
+	type statement struct {
+		query string
+		args  []interface{}
+	}
+	statements := []statement{
+		{`UPDATE sites SET user_id = ?, updated_at = ? WHERE id = ?`, []interface{}{ownerID, at.UTC(), transfer.SiteID}},
+		{`DELETE FROM organization_sites WHERE site_id = ?`, []interface{}{transfer.SiteID}},
+		{`UPDATE alert_rules SET user_id = ?, updated_at = ? WHERE site_id = ?`, []interface{}{ownerID, at.UTC(), transfer.SiteID}},
+		{
+			`DELETE FROM alert_rule_channels
+			WHERE rule_id IN (SELECT id FROM alert_rules WHERE site_id = ?)
+			AND channel_id NOT IN (SELECT id FROM notification_channels WHERE user_id = ?)`,
+			[]interface{}{transfer.SiteID, ownerID},
+		},
+		{
+			`DELETE FROM slo_channels
+			WHERE slo_id IN (SELECT id FROM slos WHERE site_id = ?)
+			AND channel_id NOT IN (SELECT id FROM notification_channels WHERE user_id IN ` + keepingAccess + `)`,
+			[]interface{}{transfer.SiteID, ownerID, transfer.ToOrganizationID},
+		},
+		{
+			`DELETE FROM status_page_monitors
+			WHERE monitor_id IN (SELECT id FROM monitors WHERE site_id = ?)
+			AND status_page_id NOT IN (SELECT id FROM status_pages WHERE user_id IN ` + keepingAccess + `)`,
+			[]interface{}{transfer.SiteID, ownerID, transfer.ToOrganizationID},
+		},
+	}
+	if transfer.ToOrganizationID != nil {
+		statements = append(statements, statement{
+			`INSERT INTO organization_sites (site_id, organization_id) VALUES (?, ?)`,
+			[]interface{}{transfer.SiteID, *transfer.ToOrganizationID},
+		})
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement.query, statement.args...); err != nil {
+			tx.Rollback()
+			return false, err
+		}
+	}
+
+	if err := insertAuditEntry(tx, entry); err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func closeSiteTransfer(tx *sql.Tx, id int64, status models.TransferStatus, userID int64, at time.Time) (bool, error) {
+	result, err := tx.Exec(
+		`UPDATE site_transfers SET status = ?, completed_by = ?, completed_at = ? WHERE id = ? AND status = ?`,
+		status, userID, at.UTC(), id, models.TransferPending,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	count, err := result.RowsAffected()
+	return count > 0, err
+}
+
+func (r *SiteTransferRepository) findSiteTransfer(query string, args ...interface{}) (*models.SiteTransfer, error) {
+	transfer, err := scanSiteTransfer(r.db.QueryRow(query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("site transfer not found")
+		}
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+func scanSiteTransfer(row rowScanner) (*models.SiteTransfer, error) {
+	var transfer models.SiteTransfer
+
+	err := row.Scan(
+		&transfer.ID,
+		&transfer.SiteID,
+		&transfer.Domain,
+		&transfer.FromUserID,
+		&transfer.FromOrganizationID,
+		&transfer.ToUserID,
+		&transfer.ToOrganizationID,
+		&transfer.Status,
+		&transfer.InitiatedBy,
+		&transfer.CompletedBy,
+		&transfer.CreatedAt,
+		&transfer.CompletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &transfer, nil
+}
@@ -0,0 +1,254 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"egide-server/internal/models"
+	"egide-server/internal/telemetry"
+)
+
+type SloRepository struct {
+	db *sql.DB
+}
+
+func NewSloRepository(db *sql.DB) *SloRepository {
+	return &SloRepository{
+		db: db,
+	}
+}
+
+// Create stores a new SLO together with its notification channels
+func (r *SloRepository) Create(slo *models.Slo) (int64, error) {
+	defer telemetry.ObserveQuery("slo", "Create")()
+
+	query := `
+		INSERT INTO slos (site_id, monitor_id, name, target, latency_threshold_ms, window_days, alert_enabled, burn_rate_threshold, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	result, err := tx.Exec(
+		query,
+		slo.SiteID,
+		slo.MonitorID,
+		slo.Name,
+		slo.Target,
+		slo.LatencyThresholdMs,
+		slo.WindowDays,
+		slo.AlertEnabled,
+		slo.BurnRateThreshold,
+		now,
+		now,
+	)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	sloID, err := result.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if err := replaceSloChannels(tx, sloID, slo.ChannelIDs); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	return sloID, tx.Commit()
+}
+
+func (r *SloRepository) FindByID(id int64) (*models.Slo, error) {
+	defer telemetry.ObserveQuery("slo", "FindByID")()
+
+	query := `
+		SELECT id, site_id, monitor_id, name, target, latency_threshold_ms, window_days, alert_enabled, burn_rate_threshold, burning_since, created_at, updated_at
+		FROM slos
+		WHERE id = ?
+	`
+
+	slos, err := r.findSlos(query, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(slos) == 0 {
+		return nil, errors.New("SLO not found")
+	}
+
+	return slos[0], nil
+}
+
+func (r *SloRepository) FindBySiteID(siteID int64) ([]*models.Slo, error) {
+	defer telemetry.ObserveQuery("slo", "FindBySiteID")()
+
+	query := `
+		SELECT id, site_id, monitor_id, name, target, latency_threshold_ms, window_days, alert_enabled, burn_rate_threshold, burning_since, created_at, updated_at
+		FROM slos
+		WHERE site_id = ?
+		ORDER BY id ASC
+	`
+
+	return r.findSlos(query, siteID)
+}
+
+// FindAlerting returns the SLOs with burn rate alerts enabled
+func (r *SloRepository) FindAlerting() ([]*models.Slo, error) {
+	defer telemetry.ObserveQuery("slo", "FindAlerting")()
+
+	query := `
+		SELECT id, site_id, monitor_id, name, target, latency_threshold_ms, window_days, alert_enabled, burn_rate_threshold, burning_since, created_at, updated_at
+		FROM slos
+		WHERE alert_enabled = TRUE
+	`
+
+	return r.findSlos(query)
+}
+
+// Update updates an SLO and replaces its notification channels
+func (r *SloRepository) Update(slo *models.Slo) error {
+	defer telemetry.ObserveQuery("slo", "Update")()
+
+	query := `
+		UPDATE slos
+		SET monitor_id = ?, name = ?, target = ?, latency_threshold_ms = ?, window_days = ?, alert_enabled = ?, burn_rate_threshold = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		query,
+		slo.MonitorID,
+		slo.Name,
+		slo.Target,
+		slo.LatencyThresholdMs,
+		slo.WindowDays,
+		slo.AlertEnabled,
+		slo.BurnRateThreshold,
+		time.Now(),
+		slo.ID,
+	)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := replaceSloChannels(tx, slo.ID, slo.ChannelIDs); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SetBurningSince records when an SLO started burning its error budget too
+// fast, nil once it recovered
+func (r *SloRepository) SetBurningSince(id int64, since *time.Time) error {
+	defer telemetry.ObserveQuery("slo", "SetBurningSince")()
+
+	_, err := r.db.Exec(`UPDATE slos SET burning_since = ? WHERE id = ?`, since, id)
+	return err
+}
+
+func (r *SloRepository) Delete(id int64) error {
+	defer telemetry.ObserveQuery("slo", "Delete")()
+
+	_, err := r.db.Exec(`DELETE FROM slos WHERE id = ?`, id)
+	return err
+}
+
+func (r *SloRepository) findSlos(query string, args ...interface{}) ([]*models.Slo, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	slos := []*models.Slo{}
+	for rows.Next() {
+		var slo models.Slo
+
+		err := rows.Scan(
+			&slo.ID,
+			&slo.SiteID,
+			&slo.MonitorID,
+			&slo.Name,
+			&slo.Target,
+			&slo.LatencyThresholdMs,
+			&slo.WindowDays,
+			&slo.AlertEnabled,
+			&slo.BurnRateThreshold,
+			&slo.BurningSince,
+			&slo.CreatedAt,
+			&slo.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		slos = append(slos, &slo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for _, slo := range slos {
+		slo.ChannelIDs, err = r.findSloChannelIDs(slo.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return slos, nil
+}
+
+func (r *SloRepository) findSloChannelIDs(sloID int64) ([]int64, error) {
+	rows, err := r.db.Query(`SELECT channel_id FROM slo_channels WHERE slo_id = ?`, sloID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	channelIDs := []int64{}
+	for rows.Next() {
+		var channelID int64
+		if err := rows.Scan(&channelID); err != nil {
+			return nil, err
+		}
+		channelIDs = append(channelIDs, channelID)
+	}
+
+	return channelIDs, rows.Err()
+}
+
+func replaceSloChannels(tx *sql.Tx, sloID int64, channelIDs []int64) error {
+	if _, err := tx.Exec(`DELETE FROM slo_channels WHERE slo_id = ?`, sloID); err != nil {
+		return err
+	}
+
+	for _, channelID := range channelIDs {
+		_, err := tx.Exec(
+			`INSERT OR IGNORE INTO slo_channels (slo_id, channel_id) VALUES (?, ?)`,
+			sloID,
+			channelID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,306 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"egide-server/internal/models"
+	"egide-server/internal/telemetry"
+)
+
+type StatusPageRepository struct {
+	db *sql.DB
+}
+
+func NewStatusPageRepository(db *sql.DB) *StatusPageRepository {
+	return &StatusPageRepository{
+		db: db,
+	}
+}
+
+// Create stores a new status page together with its monitors
+func (r *StatusPageRepository) Create(page *models.StatusPage) (int64, error) {
+	defer telemetry.ObserveQuery("status_page", "Create")()
+
+	query := `
+		INSERT INTO status_pages (user_id, slug, title, description, custom_domain, pending_domain, domain_token, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	result, err := tx.Exec(
+		query,
+		page.UserID,
+		page.Slug,
+		page.Title,
+		page.Description,
+		page.CustomDomain,
+		page.PendingDomain,
+		page.DomainToken,
+		now,
+		now,
+	)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	pageID, err := result.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if err := replaceStatusPageMonitors(tx, pageID, page.MonitorIDs); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	return pageID, tx.Commit()
+}
+
+func (r *StatusPageRepository) FindByID(id int64) (*models.StatusPage, error) {
+	defer telemetry.ObserveQuery("status_page", "FindByID")()
+
+	return r.findOne(`WHERE id = ?`, id)
+}
+
+func (r *StatusPageRepository) FindBySlug(slug string) (*models.StatusPage, error) {
+	defer telemetry.ObserveQuery("status_page", "FindBySlug")()
+
+	return r.findOne(`WHERE slug = ?`, slug)
+}
+
+func (r *StatusPageRepository) FindByCustomDomain(domain string) (*models.StatusPage, error) {
+	defer telemetry.ObserveQuery("status_page", "FindByCustomDomain")()
+
+	return r.findOne(`WHERE custom_domain = ?`, domain)
+}
+
+func (r *StatusPageRepository) FindByUserID(userID int64) ([]*models.StatusPage, error) {
+	defer telemetry.ObserveQuery("status_page", "FindByUserID")()
+
+	query := `
+		SELECT id, user_id, slug, title, description, custom_domain, pending_domain, domain_token, created_at, updated_at
+		FROM status_pages
+		WHERE user_id = ?
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pages []*models.StatusPage
+	for rows.Next() {
+		page, err := scanStatusPage(rows)
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, page)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for _, page := range pages {
+		page.MonitorIDs, err = r.findMonitorIDs(page.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return pages, nil
+}
+
+func (r *StatusPageRepository) Update(page *models.StatusPage) error {
+	defer telemetry.ObserveQuery("status_page", "Update")()
+
+	query := `
+		UPDATE status_pages
+		SET slug = ?, title = ?, description = ?, custom_domain = ?, pending_domain = ?, domain_token = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		query,
+		page.Slug,
+		page.Title,
+		page.Description,
+		page.CustomDomain,
+		page.PendingDomain,
+		page.DomainToken,
+		time.Now(),
+		page.ID,
+	)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := replaceStatusPageMonitors(tx, page.ID, page.MonitorIDs); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// VerifyDomain makes the pending domain of a status page its custom domain.
+// Proving control of the domain takes it over from any page it served before.
+func (r *StatusPageRepository) VerifyDomain(id int64, domain string) error {
+	defer telemetry.ObserveQuery("status_page", "VerifyDomain")()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if _, err := tx.Exec(`UPDATE status_pages SET custom_domain = NULL, updated_at = ? WHERE custom_domain = ? AND id != ?`, now, domain, id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	query := `
+		UPDATE status_pages
+		SET custom_domain = pending_domain, pending_domain = NULL, domain_token = NULL, updated_at = ?
+		WHERE id = ? AND pending_domain = ?
+	`
+
+	result, err := tx.Exec(query, now, id, domain)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		tx.Rollback()
+		return errors.New("status page domain changed")
+	}
+
+	return tx.Commit()
+}
+
+func (r *StatusPageRepository) Delete(id int64) error {
+	defer telemetry.ObserveQuery("status_page", "Delete")()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM status_page_monitors WHERE status_page_id = ?`, id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM status_pages WHERE id = ?`, id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *StatusPageRepository) findOne(where string, args ...interface{}) (*models.StatusPage, error) {
+	query := `
+		SELECT id, user_id, slug, title, description, custom_domain, pending_domain, domain_token, created_at, updated_at
+		FROM status_pages
+	` + where
+
+	page, err := scanStatusPage(r.db.QueryRow(query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("status page not found")
+		}
+		return nil, err
+	}
+
+	page.MonitorIDs, err = r.findMonitorIDs(page.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+func (r *StatusPageRepository) findMonitorIDs(pageID int64) ([]int64, error) {
+	query := `
+		SELECT monitor_id
+		FROM status_page_monitors
+		WHERE status_page_id = ?
+		ORDER BY position ASC
+	`
+
+	rows, err := r.db.Query(query, pageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	monitorIDs := []int64{}
+	for rows.Next() {
+		var monitorID int64
+		if err := rows.Scan(&monitorID); err != nil {
+			return nil, err
+		}
+		monitorIDs = append(monitorIDs, monitorID)
+	}
+
+	return monitorIDs, rows.Err()
+}
+
+func scanStatusPage(row rowScanner) (*models.StatusPage, error) {
+	var page models.StatusPage
+	err := row.Scan(
+		&page.ID,
+		&page.UserID,
+		&page.Slug,
+		&page.Title,
+		&page.Description,
+		&page.CustomDomain,
+		&page.PendingDomain,
+		&page.DomainToken,
+		&page.CreatedAt,
+		&page.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func replaceStatusPageMonitors(tx *sql.Tx, pageID int64, monitorIDs []int64) error {
+	if _, err := tx.Exec(`DELETE FROM status_page_monitors WHERE status_page_id = ?`, pageID); err != nil {
+		return err
+	}
+
+	for position, monitorID := range monitorIDs {
+		_, err := tx.Exec(
+			`INSERT OR IGNORE INTO status_page_monitors (status_page_id, monitor_id, position) VALUES (?, ?, ?)`,
+			pageID,
+			monitorID,
+			position,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
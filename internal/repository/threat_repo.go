@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"egide-server/internal/models"
+	"egide-server/internal/telemetry"
+)
+
+type ThreatRepository struct {
+	db *sql.DB
+}
+
+func NewThreatRepository(db *sql.DB) *ThreatRepository {
+	return &ThreatRepository{
+		db: db,
+	}
+}
+
+func (r *ThreatRepository) Create(threat *models.Threat) (int64, error) {
+	defer telemetry.ObserveQuery("threat", "Create")()
+
+	query := `
+		INSERT INTO threats (site_id, nature, status, sources, time, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.Exec(
+		query,
+		threat.SiteID,
+		threat.Nature,
+		threat.Status,
+		strings.Join(threat.Source, ","),
+		threat.Time,
+		time.Now(),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+// FindRecentBySiteIDs returns the most recent threats of the given sites, newest first
+func (r *ThreatRepository) FindRecentBySiteIDs(siteIDs []int64, since time.Time, limit int) ([]*models.Threat, error) {
+	defer telemetry.ObserveQuery("threat", "FindRecentBySiteIDs")()
+
+	if len(siteIDs) == 0 {
+		return []*models.Threat{}, nil
+	}
+
+	placeholders, args := int64Args(siteIDs)
+	query := `
+		SELECT t.id, t.site_id, s.domain, t.nature, t.status, t.sources, t.time
+		FROM threats t
+		JOIN sites s ON s.id = t.site_id
+		WHERE t.site_id IN (` + placeholders + `) AND t.time >= ?
+		ORDER BY t.time DESC
+		LIMIT ?
+	`
+	args = append(args, since, limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	threats := []*models.Threat{}
+	for rows.Next() {
+		var threat models.Threat
+		var sources string
+
+		err := rows.Scan(
+			&threat.ID,
+			&threat.SiteID,
+			&threat.Site,
+			&threat.Nature,
+			&threat.Status,
+			&sources,
+			&threat.Time,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		threat.Source = strings.Split(sources, ",")
+		threats = append(threats, &threat)
+	}
+
+	return threats, rows.Err()
+}
+
+// CountBySiteSince returns the number of threats a site received since the given time
+func (r *ThreatRepository) CountBySiteSince(siteID int64, since time.Time) (int, error) {
+	defer telemetry.ObserveQuery("threat", "CountBySiteSince")()
+
+	query := `SELECT COUNT(*) FROM threats WHERE site_id = ? AND time >= ?`
+
+	var count int
+	err := r.db.QueryRow(query, siteID, since).Scan(&count)
+	return count, err
+}
+
+// CountByNature returns the number of threats per nature for the given sites
+func (r *ThreatRepository) CountByNature(siteIDs []int64, since time.Time) (map[models.ThreatNature]int, error) {
+	defer telemetry.ObserveQuery("threat", "CountByNature")()
+
+	counts := make(map[models.ThreatNature]int)
+	if len(siteIDs) == 0 {
+		return counts, nil
+	}
+
+	placeholders, args := int64Args(siteIDs)
+	query := `
+		SELECT nature, COUNT(*)
+		FROM threats
+		WHERE site_id IN (` + placeholders + `) AND time >= ?
+		GROUP BY nature
+	`
+	args = append(args, since)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var nature models.ThreatNature
+		var count int
+		if err := rows.Scan(&nature, &count); err != nil {
+			return nil, err
+		}
+		counts[nature] = count
+	}
+
+	return counts, rows.Err()
+}
+
+// CountBySiteNatureStatus returns the number of threats of the given sites
+// per site, nature and status since they were first reported
+func (r *ThreatRepository) CountBySiteNatureStatus(siteIDs []int64) ([]*models.ThreatCount, error) {
+	defer telemetry.ObserveQuery("threat", "CountBySiteNatureStatus")()
+
+	counts := []*models.ThreatCount{}
+	if len(siteIDs) == 0 {
+		return counts, nil
+	}
+
+	placeholders, args := int64Args(siteIDs)
+	query := `
+		SELECT site_id, nature, status, COUNT(*)
+		FROM threats
+		WHERE site_id IN (` + placeholders + `)
+		GROUP BY site_id, nature, status
+	`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var count models.ThreatCount
+		if err := rows.Scan(&count.SiteID, &count.Nature, &count.Status, &count.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, &count)
+	}
+
+	return counts, rows.Err()
+}
+
+// int64Args builds the placeholders and arguments of an IN clause
+func int64Args(ids []int64) (string, []interface{}) {
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+	return strings.Join(placeholders, ","), args
+}
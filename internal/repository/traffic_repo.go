@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"egide-server/internal/models"
+	"egide-server/internal/telemetry"
+)
+
+// TrafficRepository stores the request counters reported by edge nodes.
+// Periods are always stored and queried in UTC.
+type TrafficRepository struct {
+	db *sql.DB
+}
+
+func NewTrafficRepository(db *sql.DB) *TrafficRepository {
+	return &TrafficRepository{
+		db: db,
+	}
+}
+
+// Save stores the counters of a period, replacing what the same node
+// reported earlier for it so retried reports aren't counted twice
+func (r *TrafficRepository) Save(counter *models.TrafficCounter) error {
+	defer telemetry.ObserveQuery("traffic", "Save")()
+
+	query := `
+		INSERT INTO traffic_counters (site_id, node, period_start, requests, blocked, challenged, cached, bytes)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(site_id, node, period_start) DO UPDATE SET
+			requests = excluded.requests,
+			blocked = excluded.blocked,
+			challenged = excluded.challenged,
+			cached = excluded.cached,
+			bytes = excluded.bytes
+	`
+
+	_, err := r.db.Exec(
+		query,
+		counter.SiteID,
+		counter.Node,
+		counter.PeriodStart.UTC(),
+		counter.Requests,
+		counter.Blocked,
+		counter.Challenged,
+		counter.Cached,
+		counter.Bytes,
+	)
+
+	return err
+}
+
+// GetTotals sums the counters of the given sites for the periods starting within [start, end)
+func (r *TrafficRepository) GetTotals(siteIDs []int64, start, end time.Time) (*models.TrafficTotals, error) {
+	defer telemetry.ObserveQuery("traffic", "GetTotals")()
+
+	var totals models.TrafficTotals
+	if len(siteIDs) == 0 {
+		return &totals, nil
+	}
+
+	placeholders, args := int64Args(siteIDs)
+	query := `
+		SELECT COALESCE(SUM(requests), 0), COALESCE(SUM(blocked), 0), COALESCE(SUM(challenged), 0),
+			COALESCE(SUM(cached), 0), COALESCE(SUM(bytes), 0)
+		FROM traffic_counters
+		WHERE site_id IN (` + placeholders + `) AND period_start >= ? AND period_start < ?
+	`
+	args = append(args, start.UTC(), end.UTC())
+
+	err := r.db.QueryRow(query, args...).Scan(
+		&totals.Requests,
+		&totals.Blocked,
+		&totals.Challenged,
+		&totals.Cached,
+		&totals.Bytes,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &totals, nil
+}
+
+// DeleteBefore removes the counters of periods starting before cutoff
+func (r *TrafficRepository) DeleteBefore(cutoff time.Time) error {
+	defer telemetry.ObserveQuery("traffic", "DeleteBefore")()
+
+	query := `DELETE FROM traffic_counters WHERE period_start < ?`
+	_, err := r.db.Exec(query, cutoff.UTC())
+	return err
+}
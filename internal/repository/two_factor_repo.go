@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"egide-server/internal/models"
+	"egide-server/internal/telemetry"
+)
+
+type TwoFactorRepository struct {
+	db *sql.DB
+}
+
+func NewTwoFactorRepository(db *sql.DB) *TwoFactorRepository {
+	return &TwoFactorRepository{
+		db: db,
+	}
+}
+
+func (r *TwoFactorRepository) FindByUserID(userID int64) (*models.TwoFactor, error) {
+	defer telemetry.ObserveQuery("two_factor", "FindByUserID")()
+
+	query := `SELECT user_id, secret, enabled_at, last_counter, created_at FROM user_two_factor WHERE user_id = ?`
+
+	var twoFactor models.TwoFactor
+	err := r.db.QueryRow(query, userID).Scan(
+		&twoFactor.UserID,
+		&twoFactor.Secret,
+		&twoFactor.EnabledAt,
+		&twoFactor.LastCounter,
+		&twoFactor.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("two-factor authentication not found")
+		}
+		return nil, err
+	}
+
+	return &twoFactor, nil
+}
+
+// SavePending stores the secret of an enrollment, replacing a previous one
+// unless two-factor authentication is already enabled
+func (r *TwoFactorRepository) SavePending(twoFactor *models.TwoFactor) (bool, error) {
+	defer telemetry.ObserveQuery("two_factor", "SavePending")()
+
+	query := `
+		INSERT INTO user_two_factor (user_id, secret, created_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			secret = excluded.secret,
+			last_counter = 0,
+			created_at = excluded.created_at
+		WHERE user_two_factor.enabled_at IS NULL
+	`
+
+	result, err := r.db.Exec(query, twoFactor.UserID, twoFactor.Secret, twoFactor.CreatedAt.UTC())
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// UseCounter records the period of an accepted code, provided no code of
+// that period or a later one was accepted before
+func (r *TwoFactorRepository) UseCounter(userID, counter int64) (bool, error) {
+	defer telemetry.ObserveQuery("two_factor", "UseCounter")()
+
+	result, err := r.db.Exec(
+		`UPDATE user_two_factor SET last_counter = ? WHERE user_id = ? AND last_counter < ?`,
+		counter, userID, counter,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// BeginAttempt counts an attempt to verify a code of a user, unless
+// verification is locked out at now. The attempt counts as failed until
+// ResetAttempts; the one reaching maxAttempts locks verification out until
+// lockedUntil. It reports whether the attempt may proceed.
+func (r *TwoFactorRepository) BeginAttempt(userID int64, now time.Time, maxAttempts int, lockedUntil time.Time) (bool, error) {
+	defer telemetry.ObserveQuery("two_factor", "BeginAttempt")()
+
+	query := `
+		UPDATE user_two_factor
+		SET failed_attempts = CASE WHEN failed_attempts + 1 >= ? THEN 0 ELSE failed_attempts + 1 END,
+			locked_until = CASE WHEN failed_attempts + 1 >= ? THEN ? ELSE locked_until END
+		WHERE user_id = ? AND (locked_until IS NULL OR locked_until <= ?)
+	`
+
+	result, err := r.db.Exec(query, maxAttempts, maxAttempts, lockedUntil.UTC(), userID, now.UTC())
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// ResetAttempts clears the failed attempts of a user after a valid code
+func (r *TwoFactorRepository) ResetAttempts(userID int64) error {
+	defer telemetry.ObserveQuery("two_factor", "ResetAttempts")()
+
+	_, err := r.db.Exec(`UPDATE user_two_factor SET failed_attempts = 0, locked_until = NULL WHERE user_id = ?`, userID)
+	return err
+}
+
+// Enable confirms an enrollment, replacing the recovery codes of the user
+func (r *TwoFactorRepository) Enable(userID int64, at time.Time, codeHashes []string) error {
+	defer telemetry.ObserveQuery("two_factor", "Enable")()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`UPDATE user_two_factor SET enabled_at = ? WHERE user_id = ?`, at.UTC(), userID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := replaceRecoveryCodes(tx, userID, codeHashes); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ReplaceRecoveryCodes invalidates the recovery codes of a user for new ones
+func (r *TwoFactorRepository) ReplaceRecoveryCodes(userID int64, codeHashes []string) error {
+	defer telemetry.ObserveQuery("two_factor", "ReplaceRecoveryCodes")()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := replaceRecoveryCodes(tx, userID, codeHashes); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UseRecoveryCode marks an unused recovery code of a user as used
+func (r *TwoFactorRepository) UseRecoveryCode(userID int64, codeHash string, at time.Time) (bool, error) {
+	defer telemetry.ObserveQuery("two_factor", "UseRecoveryCode")()
+
+	result, err := r.db.Exec(
+		`UPDATE recovery_codes SET used_at = ? WHERE user_id = ? AND code_hash = ? AND used_at IS NULL`,
+		at.UTC(), userID, codeHash,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+func (r *TwoFactorRepository) CountRecoveryCodes(userID int64) (int, error) {
+	defer telemetry.ObserveQuery("two_factor", "CountRecoveryCodes")()
+
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM recovery_codes WHERE user_id = ? AND used_at IS NULL`, userID).Scan(&count)
+	return count, err
+}
+
+// Delete disables two-factor authentication, removing the recovery codes
+func (r *TwoFactorRepository) Delete(userID int64) error {
+	defer telemetry.ObserveQuery("two_factor", "Delete")()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM recovery_codes WHERE user_id = ?`, userID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM user_two_factor WHERE user_id = ?`, userID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func replaceRecoveryCodes(tx *sql.Tx, userID int64, codeHashes []string) error {
+	if _, err := tx.Exec(`DELETE FROM recovery_codes WHERE user_id = ?`, userID); err != nil {
+		return err
+	}
+
+	for _, hash := range codeHashes {
+		if _, err := tx.Exec(`INSERT INTO recovery_codes (user_id, code_hash) VALUES (?, ?)`, userID, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"egide-server/internal/models"
+	"egide-server/internal/telemetry"
 )
 
 // UserRepository handles database operations for users
@@ -20,87 +21,74 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 	}
 }
 
-// Create adds a new user to the database
-func (r *UserRepository) Create(user *models.User) (int64, error) {
-	query := `
-		INSERT INTO users (github_id, username, email, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?)
-	`
+// CreateWithIdentity adds a new user signed in with the given identity
+func (r *UserRepository) CreateWithIdentity(user *models.User, identity *models.Identity) (int64, error) {
+	defer telemetry.ObserveQuery("user", "CreateWithIdentity")()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
 
 	now := time.Now()
-	result, err := r.db.Exec(
-		query,
-		user.GitHubID,
+	result, err := tx.Exec(
+		`INSERT INTO users (username, email, created_at, updated_at) VALUES (?, ?, ?, ?)`,
 		user.Username,
 		user.Email,
 		now,
 		now,
 	)
 	if err != nil {
+		tx.Rollback()
 		return 0, err
 	}
 
-	return result.LastInsertId()
+	userID, err := result.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	identity.UserID = userID
+	if _, err := createIdentity(tx, identity); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	return userID, tx.Commit()
 }
 
 // FindByID finds a user by ID
 func (r *UserRepository) FindByID(id int64) (*models.User, error) {
+	defer telemetry.ObserveQuery("user", "FindByID")()
+
 	query := `
-		SELECT id, github_id, username, email, created_at, updated_at
+		SELECT id, username, email, created_at, updated_at
 		FROM users
 		WHERE id = ?
 	`
 
-	var user models.User
-	err := r.db.QueryRow(query, id).Scan(
-		&user.ID,
-		&user.GitHubID,
-		&user.Username,
-		&user.Email,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
-
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("user not found")
-		}
-		return nil, err
-	}
-
-	return &user, nil
+	return r.findUser(query, id)
 }
 
-// FindByGitHubID finds a user by GitHub ID
-func (r *UserRepository) FindByGitHubID(githubID string) (*models.User, error) {
+// FindByIdentity finds the user owning an identity at a provider
+func (r *UserRepository) FindByIdentity(provider, subject string) (*models.User, error) {
+	defer telemetry.ObserveQuery("user", "FindByIdentity")()
+
 	query := `
-		SELECT id, github_id, username, email, created_at, updated_at
-		FROM users
-		WHERE github_id = ?
+		SELECT u.id, u.username, u.email, u.created_at, u.updated_at
+		FROM users u
+		JOIN user_identities i ON i.user_id = u.id
+		WHERE i.provider = ? AND i.subject = ?
 	`
 
-	var user models.User
-	err := r.db.QueryRow(query, githubID).Scan(
-		&user.ID,
-		&user.GitHubID,
-		&user.Username,
-		&user.Email,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
-
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("user not found")
-		}
-		return nil, err
-	}
-
-	return &user, nil
+	return r.findUser(query, provider, subject)
 }
 
 // Update updates an existing user
 func (r *UserRepository) Update(user *models.User) error {
+	defer telemetry.ObserveQuery("user", "Update")()
+
 	query := `
 		UPDATE users
 		SET username = ?, email = ?, updated_at = ?
@@ -117,3 +105,26 @@ func (r *UserRepository) Update(user *models.User) error {
 
 	return err
 }
+
+func (r *UserRepository) findUser(query string, args ...interface{}) (*models.User, error) {
+	var user models.User
+	var email sql.NullString
+
+	err := r.db.QueryRow(query, args...).Scan(
+		&user.ID,
+		&user.Username,
+		&email,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+	user.Email = email.String
+
+	return &user, nil
+}
@@ -0,0 +1,345 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"egide-server/internal/models"
+	"egide-server/internal/telemetry"
+)
+
+type WebhookRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{
+		db: db,
+	}
+}
+
+func (r *WebhookRepository) CreateSubscription(sub *models.WebhookSubscription) (int64, error) {
+	defer telemetry.ObserveQuery("webhook", "CreateSubscription")()
+
+	query := `
+		INSERT INTO webhook_subscriptions (user_id, url, secret, events, active, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	now := time.Now()
+	result, err := r.db.Exec(
+		query,
+		sub.UserID,
+		sub.URL,
+		sub.Secret,
+		joinEvents(sub.Events),
+		sub.Active,
+		now,
+		now,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+func (r *WebhookRepository) FindSubscriptionByID(id int64) (*models.WebhookSubscription, error) {
+	defer telemetry.ObserveQuery("webhook", "FindSubscriptionByID")()
+
+	query := `
+		SELECT id, user_id, url, secret, events, active, created_at, updated_at
+		FROM webhook_subscriptions
+		WHERE id = ?
+	`
+
+	sub, err := scanSubscription(r.db.QueryRow(query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("webhook subscription not found")
+		}
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+func (r *WebhookRepository) FindSubscriptionsByUserID(userID int64) ([]*models.WebhookSubscription, error) {
+	defer telemetry.ObserveQuery("webhook", "FindSubscriptionsByUserID")()
+
+	query := `
+		SELECT id, user_id, url, secret, events, active, created_at, updated_at
+		FROM webhook_subscriptions
+		WHERE user_id = ?
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []*models.WebhookSubscription
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+
+	return subs, rows.Err()
+}
+
+func (r *WebhookRepository) UpdateSubscription(sub *models.WebhookSubscription) error {
+	defer telemetry.ObserveQuery("webhook", "UpdateSubscription")()
+
+	query := `
+		UPDATE webhook_subscriptions
+		SET url = ?, events = ?, active = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	_, err := r.db.Exec(
+		query,
+		sub.URL,
+		joinEvents(sub.Events),
+		sub.Active,
+		time.Now(),
+		sub.ID,
+	)
+
+	return err
+}
+
+func (r *WebhookRepository) DeleteSubscription(id int64) error {
+	defer telemetry.ObserveQuery("webhook", "DeleteSubscription")()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM webhook_deliveries WHERE subscription_id = ?`, id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM webhook_subscriptions WHERE id = ?`, id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *WebhookRepository) CreateDelivery(delivery *models.WebhookDelivery) (int64, error) {
+	defer telemetry.ObserveQuery("webhook", "CreateDelivery")()
+
+	query := `
+		INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.Exec(
+		query,
+		delivery.SubscriptionID,
+		delivery.EventID,
+		delivery.EventType,
+		delivery.Payload,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		time.Now(),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+func (r *WebhookRepository) FindDeliveryByID(id int64) (*models.WebhookDelivery, error) {
+	defer telemetry.ObserveQuery("webhook", "FindDeliveryByID")()
+
+	query := `
+		SELECT id, subscription_id, event_id, event_type, payload, status, attempts,
+			next_attempt_at, last_status_code, last_error, created_at, delivered_at
+		FROM webhook_deliveries
+		WHERE id = ?
+	`
+
+	delivery, err := scanDelivery(r.db.QueryRow(query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("webhook delivery not found")
+		}
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// FindDeliveriesBySubscriptionID returns the delivery log of a subscription, newest first
+func (r *WebhookRepository) FindDeliveriesBySubscriptionID(subscriptionID int64, limit int) ([]*models.WebhookDelivery, error) {
+	defer telemetry.ObserveQuery("webhook", "FindDeliveriesBySubscriptionID")()
+
+	query := `
+		SELECT id, subscription_id, event_id, event_type, payload, status, attempts,
+			next_attempt_at, last_status_code, last_error, created_at, delivered_at
+		FROM webhook_deliveries
+		WHERE subscription_id = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?
+	`
+
+	return r.findDeliveries(query, subscriptionID, limit)
+}
+
+// FindDueDeliveries returns pending deliveries whose next attempt is due
+func (r *WebhookRepository) FindDueDeliveries(now time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	defer telemetry.ObserveQuery("webhook", "FindDueDeliveries")()
+
+	query := `
+		SELECT id, subscription_id, event_id, event_type, payload, status, attempts,
+			next_attempt_at, last_status_code, last_error, created_at, delivered_at
+		FROM webhook_deliveries
+		WHERE status = 'pending' AND next_attempt_at <= ?
+		ORDER BY next_attempt_at ASC
+		LIMIT ?
+	`
+
+	return r.findDeliveries(query, now, limit)
+}
+
+// CountPending returns the number of deliveries waiting to be sent or retried
+func (r *WebhookRepository) CountPending() (int, error) {
+	defer telemetry.ObserveQuery("webhook", "CountPending")()
+
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM webhook_deliveries WHERE status = 'pending'`).Scan(&count)
+	return count, err
+}
+
+// UpdateDeliveryAttempt records the outcome of a delivery attempt
+func (r *WebhookRepository) UpdateDeliveryAttempt(delivery *models.WebhookDelivery) error {
+	defer telemetry.ObserveQuery("webhook", "UpdateDeliveryAttempt")()
+
+	query := `
+		UPDATE webhook_deliveries
+		SET status = ?, attempts = ?, next_attempt_at = ?, last_status_code = ?, last_error = ?, delivered_at = ?
+		WHERE id = ?
+	`
+
+	_, err := r.db.Exec(
+		query,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		delivery.LastStatusCode,
+		delivery.LastError,
+		delivery.DeliveredAt,
+		delivery.ID,
+	)
+
+	return err
+}
+
+// DeleteOldDeliveries removes finished deliveries older than the specified duration
+func (r *WebhookRepository) DeleteOldDeliveries(olderThan time.Duration) error {
+	defer telemetry.ObserveQuery("webhook", "DeleteOldDeliveries")()
+
+	cutoff := time.Now().Add(-olderThan)
+	query := `DELETE FROM webhook_deliveries WHERE status != 'pending' AND created_at < ?`
+	_, err := r.db.Exec(query, cutoff)
+	return err
+}
+
+func (r *WebhookRepository) findDeliveries(query string, args ...interface{}) ([]*models.WebhookDelivery, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*models.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSubscription(row rowScanner) (*models.WebhookSubscription, error) {
+	var sub models.WebhookSubscription
+	var events string
+
+	err := row.Scan(
+		&sub.ID,
+		&sub.UserID,
+		&sub.URL,
+		&sub.Secret,
+		&events,
+		&sub.Active,
+		&sub.CreatedAt,
+		&sub.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, event := range strings.Split(events, ",") {
+		if event != "" {
+			sub.Events = append(sub.Events, models.EventType(event))
+		}
+	}
+
+	return &sub, nil
+}
+
+func scanDelivery(row rowScanner) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	var eventType, status string
+
+	err := row.Scan(
+		&delivery.ID,
+		&delivery.SubscriptionID,
+		&delivery.EventID,
+		&eventType,
+		&delivery.Payload,
+		&status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&delivery.LastStatusCode,
+		&delivery.LastError,
+		&delivery.CreatedAt,
+		&delivery.DeliveredAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery.EventType = models.EventType(eventType)
+	delivery.Status = models.DeliveryStatus(status)
+	return &delivery, nil
+}
+
+func joinEvents(events []models.EventType) string {
+	names := make([]string, len(events))
+	for i, event := range events {
+		names[i] = string(event)
+	}
+	return strings.Join(names, ",")
+}
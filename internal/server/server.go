@@ -12,34 +12,106 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 	"egide-server/internal/auth"
+	"egide-server/internal/authz"
 	"egide-server/internal/config"
 	"egide-server/internal/handlers"
+	"egide-server/internal/models"
 	"egide-server/internal/repository"
 	"egide-server/internal/service"
+	"egide-server/internal/telemetry"
 )
 
 type Server struct {
 	server            *http.Server
 	config            *config.Config
 	monitoringService *service.MonitoringService
+	alertService      *service.AlertService
+	webhookService    *service.WebhookService
+	rollupService     *service.RollupService
+	trafficService    *service.TrafficService
+	analyticsService  *service.AnalyticsService
+	sloService        *service.SloService
+	signingKeyService *service.SigningKeyService
 }
 
 func New(cfg *config.Config, db *sql.DB) *Server {
 	// Init repos
 	userRepo := repository.NewUserRepository(db)
+	identityRepo := repository.NewIdentityRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	accessTokenRepo := repository.NewAccessTokenRepository(db)
+	signingKeyRepo := repository.NewSigningKeyRepository(db)
+	twoFactorRepo := repository.NewTwoFactorRepository(db)
 	siteRepo := repository.NewSiteRepository(db)
+	organizationRepo := repository.NewOrganizationRepository(db)
+	invitationRepo := repository.NewInvitationRepository(db)
+	siteTransferRepo := repository.NewSiteTransferRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
 	healthCheckRepo := repository.NewHealthCheckRepository(db)
+	monitorRepo := repository.NewMonitorRepository(db)
+	alertRepo := repository.NewAlertRepository(db)
+	channelRepo := repository.NewNotificationChannelRepository(db)
+	threatRepo := repository.NewThreatRepository(db)
+	webhookRepo := repository.NewWebhookRepository(db)
+	maintenanceRepo := repository.NewMaintenanceRepository(db)
+	statusPageRepo := repository.NewStatusPageRepository(db)
+	badgeRepo := repository.NewBadgeRepository(db)
+	rollupRepo := repository.NewRollupRepository(db)
+	trafficRepo := repository.NewTrafficRepository(db)
+	analyticsRepo := repository.NewAnalyticsRepository(db)
+	sloRepo := repository.NewSloRepository(db)
+	certificateRepo := repository.NewCertificateRepository(db)
+	contentRepo := repository.NewContentRepository(db)
 
 	// Init services
-	authService := auth.NewGitHubService(cfg)
-	threatService := service.NewThreatService()
-	monitoringService := service.NewMonitoringService(healthCheckRepo)
-	metricsService := service.NewMetricsService(healthCheckRepo)
+	providers := auth.NewProviders(cfg)
+	keyCipher, err := auth.NewKeyCipher(cfg.JWTSecret, auth.PurposeSigningKeys)
+	if err != nil {
+		log.Fatalf("Failed to init signing key encryption: %v", err)
+	}
+	signingKeyService := service.NewSigningKeyService(signingKeyRepo, keyCipher, cfg.JWTSigningAlgorithm, cfg.JWTKeyRotation)
+	tokenService := auth.NewTokenService(signingKeyService)
+	twoFactorCipher, err := auth.NewKeyCipher(cfg.JWTSecret, auth.PurposeTwoFactor)
+	if err != nil {
+		log.Fatalf("Failed to init two-factor secret encryption: %v", err)
+	}
+	twoFactorService := service.NewTwoFactorService(twoFactorRepo, sessionRepo, twoFactorCipher)
+	// State cookies are only sent over HTTPS when callbacks are served over HTTPS
+	stateCookie := auth.NewStateCookie(cfg.JWTSecret, cfg.SecureCallbacks())
+	linkTokens := auth.NewLinkTokens(cfg.JWTSecret)
+	sessionService := service.NewSessionService(sessionRepo, tokenService)
+	accessTokenService := service.NewAccessTokenService(accessTokenRepo)
+	authorizer := authz.NewAuthorizer(siteRepo, organizationRepo, twoFactorRepo)
+	organizationService := service.NewOrganizationService(organizationRepo, twoFactorRepo)
+	siteTransferService := service.NewSiteTransferService(siteTransferRepo, siteRepo, userRepo, organizationRepo, authorizer)
+	identityService := service.NewIdentityService(identityRepo)
+	webhookService := service.NewWebhookService(webhookRepo)
+	threatService := service.NewThreatService(threatRepo, authorizer, webhookService)
+	maintenanceService := service.NewMaintenanceService(maintenanceRepo)
+	rollupService := service.NewRollupService(healthCheckRepo, rollupRepo)
+	trafficService := service.NewTrafficService(trafficRepo)
+	analyticsService := service.NewAnalyticsService(analyticsRepo)
+	notificationService := service.NewNotificationService(cfg)
+	invitationService := service.NewInvitationService(invitationRepo, organizationRepo, notificationService, cfg.FrontendURL)
+	certificateService := service.NewCertificateService(certificateRepo, siteRepo, authorizer, channelRepo, notificationService, webhookService, cfg.TLSExpiryWarningDays)
+	contentService := service.NewContentService(contentRepo, siteRepo, authorizer, channelRepo, threatService, notificationService)
+	monitoringService := service.NewMonitoringService(healthCheckRepo, monitorRepo, maintenanceService, certificateService, contentService)
+	metricsService := service.NewMetricsService(healthCheckRepo, rollupService, trafficService)
+	statusPageService := service.NewStatusPageService(monitorRepo, authorizer, healthCheckRepo, rollupService, maintenanceService)
+	badgeService := service.NewBadgeService(rollupService)
+	exporterService := service.NewExporterService(monitorRepo, healthCheckRepo, threatRepo, maintenanceService)
+	alertService := service.NewAlertService(alertRepo, channelRepo, monitorRepo, siteRepo, authorizer, healthCheckRepo, threatService, notificationService, webhookService, maintenanceService)
+	sloService := service.NewSloService(sloRepo, siteRepo, authorizer, monitorRepo, healthCheckRepo, channelRepo, notificationService, webhookService, maintenanceService)
 
-	authMiddleware := auth.NewMiddleware(cfg.JWTSecret)
+	telemetry.RegisterQueue("webhook_deliveries", webhookService.QueueDepth)
+	telemetry.RegisterQueue("analytics_flush", analyticsService.QueueDepth)
+
+	authMiddleware := auth.NewMiddleware(tokenService, sessionService, accessTokenService)
+	edgeMiddleware := auth.NewEdgeMiddleware(cfg.EdgeToken)
 	r := chi.NewRouter()
 
 	r.Use(middleware.Logger)
+	r.Use(telemetry.Middleware)
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.Timeout(30 * time.Second))
 	r.Use(cors.Handler(cors.Options{
@@ -51,11 +123,32 @@ func New(cfg *config.Config, db *sql.DB) *Server {
 		MaxAge:           300,
 	}))
 
-	authHandler := handlers.NewAuthHandler(authService, userRepo, cfg)
-	siteHandler := handlers.NewSiteHandler(siteRepo)
+	authHandler := handlers.NewAuthHandler(providers, sessionService, stateCookie, linkTokens, userRepo, identityRepo, identityService, cfg)
+	siteHandler := handlers.NewSiteHandler(siteRepo, authorizer, webhookService, twoFactorService)
 	userHandler := handlers.NewUserHandler(userRepo)
-	threatHandler := handlers.NewThreatHandler(siteRepo, threatService)
-	metricsHandler := handlers.NewMetricsHandler(metricsService)
+	threatHandler := handlers.NewThreatHandler(authorizer, threatService)
+	metricsHandler := handlers.NewMetricsHandler(metricsService, authorizer, monitorRepo)
+	monitorHandler := handlers.NewMonitorHandler(authorizer, monitorRepo)
+	alertHandler := handlers.NewAlertHandler(alertRepo, channelRepo, authorizer, monitorRepo, notificationService)
+	maintenanceHandler := handlers.NewMaintenanceHandler(authorizer, maintenanceRepo)
+	webhookHandler := handlers.NewWebhookHandler(webhookRepo, webhookService)
+	edgeHandler := handlers.NewEdgeHandler(siteRepo, threatService, trafficService, analyticsService)
+	statusPageHandler := handlers.NewStatusPageHandler(statusPageRepo, authorizer, monitorRepo, statusPageService, cfg)
+	badgeHandler := handlers.NewBadgeHandler(authorizer, siteRepo, monitorRepo, badgeRepo, badgeService)
+	analyticsHandler := handlers.NewAnalyticsHandler(authorizer, analyticsService)
+	exporterHandler := handlers.NewExporterHandler(authorizer, exporterService)
+	sloHandler := handlers.NewSloHandler(authorizer, monitorRepo, channelRepo, sloRepo, sloService)
+	certificateHandler := handlers.NewCertificateHandler(authorizer, certificateService)
+	contentHandler := handlers.NewContentHandler(authorizer, monitorRepo, contentService)
+	identityHandler := handlers.NewIdentityHandler(providers, stateCookie, linkTokens, identityService, notificationService, cfg)
+	sessionHandler := handlers.NewSessionHandler(sessionService)
+	jwksHandler := handlers.NewJWKSHandler(signingKeyService)
+	twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorService, userRepo)
+	accessTokenHandler := handlers.NewAccessTokenHandler(accessTokenService, twoFactorService)
+	organizationHandler := handlers.NewOrganizationHandler(organizationRepo, organizationService, authorizer, twoFactorService)
+	invitationHandler := handlers.NewInvitationHandler(invitationService, authorizer)
+	siteTransferHandler := handlers.NewSiteTransferHandler(siteTransferService, authorizer, twoFactorService)
+	auditHandler := handlers.NewAuditHandler(auditRepo, authorizer)
 
 	// Public routes
 	r.Group(func(r chi.Router) {
@@ -64,10 +157,41 @@ func New(cfg *config.Config, db *sql.DB) *Server {
 			w.Write([]byte("OK"))
 		})
 		
+		// Prometheus metrics of the server itself
+		r.Method("GET", "/metrics", telemetry.Handler(cfg.MetricsToken))
+		
+		// Public keys of access tokens
+		r.Get("/.well-known/jwks.json", jwksHandler.GetJWKS)
+		
 		// Auth routes
 		r.Route("/auth", func(r chi.Router) {
-			r.Get("/github", authHandler.GitHubLogin)
 			r.Get("/callback", authHandler.GitHubCallback)
+			r.Post("/email/verify", authHandler.VerifyEmail)
+			r.Post("/refresh", authHandler.Refresh)
+			r.Post("/logout", authHandler.Logout)
+			r.Get("/{provider}", authHandler.Login)
+			r.Get("/{provider}/callback", authHandler.Callback)
+		})
+		
+		// Public status pages
+		r.Get("/", statusPageHandler.CustomDomainStatusPage)
+		r.Get("/status/{slug}", statusPageHandler.PublicStatusPage)
+		r.Get("/api/status/{slug}", statusPageHandler.PublicStatusJSON)
+		
+		// Embeddable badges
+		r.Get("/badges/sites/{id}/uptime.svg", badgeHandler.SiteUptimeBadge)
+		r.Get("/badges/sites/{id}/protected.svg", badgeHandler.ProtectionBadge)
+		r.Get("/badges/monitors/{id}/uptime.svg", badgeHandler.MonitorUptimeBadge)
+	})
+
+	// Edge node routes
+	r.Group(func(r chi.Router) {
+		r.Use(edgeMiddleware.Authenticate)
+		
+		r.Route("/api/edge", func(r chi.Router) {
+			r.Post("/threats", edgeHandler.IngestThreats)
+			r.Post("/traffic", edgeHandler.IngestTraffic)
+			r.Post("/requests", edgeHandler.IngestRequests)
 		})
 	})
 
@@ -77,11 +201,63 @@ func New(cfg *config.Config, db *sql.DB) *Server {
 		
 		// User routes
 		r.Route("/api/users", func(r chi.Router) {
+			r.Use(auth.RequireSession)
+			
 			r.Get("/me", userHandler.GetCurrentUser)
+			r.Get("/me/identities", identityHandler.ListIdentities)
+			r.Post("/me/identities/{provider}", identityHandler.LinkIdentity)
+			r.Delete("/me/identities/{identityID}", identityHandler.UnlinkIdentity)
+			r.Get("/me/sessions", sessionHandler.ListSessions)
+			r.Delete("/me/sessions/{sessionID}", sessionHandler.RevokeSession)
+			r.Get("/me/tokens", accessTokenHandler.ListAccessTokens)
+			r.Post("/me/tokens", accessTokenHandler.CreateAccessToken)
+			r.Delete("/me/tokens/{tokenID}", accessTokenHandler.RevokeAccessToken)
+			r.Get("/me/two-factor", twoFactorHandler.GetTwoFactor)
+			r.Post("/me/two-factor", twoFactorHandler.EnrollTwoFactor)
+			r.Delete("/me/two-factor", twoFactorHandler.DisableTwoFactor)
+			r.Post("/me/two-factor/confirm", twoFactorHandler.ConfirmTwoFactor)
+			r.Post("/me/two-factor/verify", twoFactorHandler.VerifyTwoFactor)
+			r.Post("/me/two-factor/recovery-codes", twoFactorHandler.RegenerateRecoveryCodes)
+		})
+		
+		// Organization routes
+		r.Route("/api/organizations", func(r chi.Router) {
+			r.Use(auth.RequireSession)
+			
+			r.Get("/", organizationHandler.ListOrganizations)
+			r.Post("/", organizationHandler.CreateOrganization)
+			r.Get("/{orgID}", organizationHandler.GetOrganization)
+			r.Put("/{orgID}", organizationHandler.UpdateOrganization)
+			r.Delete("/{orgID}", organizationHandler.DeleteOrganization)
+			r.Get("/{orgID}/members", organizationHandler.ListMembers)
+			r.Put("/{orgID}/members/{userID}", organizationHandler.UpdateMember)
+			r.Delete("/{orgID}/members/{userID}", organizationHandler.RemoveMember)
+			r.Get("/{orgID}/invitations", invitationHandler.ListInvitations)
+			r.Post("/{orgID}/invitations", invitationHandler.CreateInvitation)
+			r.Post("/{orgID}/invitations/{invitationID}/resend", invitationHandler.ResendInvitation)
+			r.Delete("/{orgID}/invitations/{invitationID}", invitationHandler.RevokeInvitation)
+		})
+		
+		// Invitation routes
+		r.Route("/api/invitations", func(r chi.Router) {
+			r.Use(auth.RequireSession)
+			
+			r.Post("/accept", invitationHandler.AcceptInvitation)
+		})
+		
+		// Incoming site transfer routes
+		r.Route("/api/transfers", func(r chi.Router) {
+			r.Use(auth.RequireSession)
+			
+			r.Get("/", siteTransferHandler.ListIncomingTransfers)
+			r.Post("/{transferID}/accept", siteTransferHandler.AcceptTransfer)
+			r.Post("/{transferID}/decline", siteTransferHandler.DeclineTransfer)
 		})
 		
 		// Site routes
 		r.Route("/api/sites", func(r chi.Router) {
+			r.Use(auth.RequireScopeByMethod(models.ScopeSitesRead, models.ScopeSitesWrite))
+			
 			r.Get("/", siteHandler.ListSites)
 			r.Post("/", siteHandler.CreateSite)
 			r.Get("/{id}", siteHandler.GetSite)
@@ -89,17 +265,90 @@ func New(cfg *config.Config, db *sql.DB) *Server {
 			r.Delete("/{id}", siteHandler.DeleteSite)
 			r.Post("/{id}/verify", siteHandler.VerifySite)
 			r.Post("/{id}/activate", siteHandler.ToggleSiteActivation)
+			r.Get("/{id}/monitors", monitorHandler.ListMonitors)
+			r.Post("/{id}/monitors", monitorHandler.CreateMonitor)
+			r.Put("/{id}/monitors/{monitorID}", monitorHandler.UpdateMonitor)
+			r.Delete("/{id}/monitors/{monitorID}", monitorHandler.DeleteMonitor)
+			r.Get("/{id}/maintenance", maintenanceHandler.ListWindows)
+			r.Post("/{id}/maintenance", maintenanceHandler.CreateWindow)
+			r.Put("/{id}/maintenance/{windowID}", maintenanceHandler.UpdateWindow)
+			r.Delete("/{id}/maintenance/{windowID}", maintenanceHandler.DeleteWindow)
+			r.Get("/{id}/badge", badgeHandler.GetSettings)
+			r.Put("/{id}/badge", badgeHandler.UpdateSettings)
+			r.Get("/{id}/analytics", analyticsHandler.GetSiteAnalytics)
+			r.Get("/{id}/slos", sloHandler.ListSlos)
+			r.Post("/{id}/slos", sloHandler.CreateSlo)
+			r.Get("/{id}/slos/{sloID}", sloHandler.GetSlo)
+			r.Put("/{id}/slos/{sloID}", sloHandler.UpdateSlo)
+			r.Delete("/{id}/slos/{sloID}", sloHandler.DeleteSlo)
+			r.Get("/{id}/certificates", certificateHandler.ListCertificates)
+			r.Get("/{id}/monitors/{monitorID}/content", contentHandler.GetContentCheck)
+			r.Put("/{id}/monitors/{monitorID}/content", contentHandler.UpdateContentCheck)
+			r.Post("/{id}/monitors/{monitorID}/content/baseline", contentHandler.ResetBaseline)
+			r.Get("/{id}/content-changes", contentHandler.ListContentChanges)
+			r.Get("/{id}/transfer", siteTransferHandler.GetTransfer)
+			r.With(auth.RequireSession).Post("/{id}/transfer", siteTransferHandler.RequestTransfer)
+			r.With(auth.RequireSession).Delete("/{id}/transfer", siteTransferHandler.CancelTransfer)
+			r.With(auth.RequireSession).Get("/{id}/audit", auditHandler.ListSiteAudit)
 		})
 		
 		// Threat routes
 		r.Route("/api/threats", func(r chi.Router) {
+			r.Use(auth.RequireScope(models.ScopeThreatsRead))
+			
 			r.Get("/", threatHandler.GetRecentThreats)
 			r.Get("/distribution", threatHandler.GetThreatDistribution)
 		})
 		
 		// Metrics routes
 		r.Route("/api/metrics", func(r chi.Router) {
+			r.Use(auth.RequireScope(models.ScopeMetricsRead))
+			
 			r.Get("/kpi", metricsHandler.GetKpi)
+			r.Get("/latency", metricsHandler.GetLatency)
+			r.Get("/prometheus", exporterHandler.GetPrometheusMetrics)
+		})
+		
+		// Alerting routes
+		r.Route("/api/alerts", func(r chi.Router) {
+			r.Use(auth.RequireSession)
+			
+			r.Get("/rules", alertHandler.ListRules)
+			r.Post("/rules", alertHandler.CreateRule)
+			r.Get("/rules/{id}", alertHandler.GetRule)
+			r.Put("/rules/{id}", alertHandler.UpdateRule)
+			r.Delete("/rules/{id}", alertHandler.DeleteRule)
+			r.Get("/channels", alertHandler.ListChannels)
+			r.Post("/channels", alertHandler.CreateChannel)
+			r.Put("/channels/{id}", alertHandler.UpdateChannel)
+			r.Delete("/channels/{id}", alertHandler.DeleteChannel)
+			r.Post("/channels/{id}/test", alertHandler.TestChannel)
+			r.Get("/incidents", alertHandler.ListIncidents)
+		})
+		
+		// Status page routes
+		r.Route("/api/status-pages", func(r chi.Router) {
+			r.Use(auth.RequireSession)
+			
+			r.Get("/", statusPageHandler.ListStatusPages)
+			r.Post("/", statusPageHandler.CreateStatusPage)
+			r.Get("/{id}", statusPageHandler.GetStatusPage)
+			r.Put("/{id}", statusPageHandler.UpdateStatusPage)
+			r.Delete("/{id}", statusPageHandler.DeleteStatusPage)
+			r.Post("/{id}/domain/verify", statusPageHandler.VerifyStatusPageDomain)
+		})
+		
+		// Webhook routes
+		r.Route("/api/webhooks", func(r chi.Router) {
+			r.Use(auth.RequireSession)
+			
+			r.Get("/", webhookHandler.ListSubscriptions)
+			r.Post("/", webhookHandler.CreateSubscription)
+			r.Get("/{id}", webhookHandler.GetSubscription)
+			r.Put("/{id}", webhookHandler.UpdateSubscription)
+			r.Delete("/{id}", webhookHandler.DeleteSubscription)
+			r.Get("/{id}/deliveries", webhookHandler.ListDeliveries)
+			r.Post("/{id}/deliveries/{deliveryID}/redeliver", webhookHandler.Redeliver)
 		})
 	})
 
@@ -110,6 +359,13 @@ func New(cfg *config.Config, db *sql.DB) *Server {
 		},
 		config:            cfg,
 		monitoringService: monitoringService,
+		alertService:      alertService,
+		webhookService:    webhookService,
+		rollupService:     rollupService,
+		trafficService:    trafficService,
+		analyticsService:  analyticsService,
+		sloService:        sloService,
+		signingKeyService: signingKeyService,
 	}
 }
 
@@ -118,6 +374,13 @@ func (s *Server) Start() error {
 	
 	// Start monitoring service
 	s.monitoringService.Start()
+	s.alertService.Start()
+	s.webhookService.Start()
+	s.rollupService.Start()
+	s.trafficService.Start()
+	s.analyticsService.Start()
+	s.sloService.Start()
+	s.signingKeyService.Start()
 	
 	return s.server.ListenAndServe()
 }
@@ -126,6 +389,27 @@ func (s *Server) Shutdown(ctx context.Context) error {
 	log.Println("Stopping monitoring service...")
 	s.monitoringService.Stop()
 	
+	log.Println("Stopping alert service...")
+	s.alertService.Stop()
+	
+	log.Println("Stopping webhook service...")
+	s.webhookService.Stop()
+	
+	log.Println("Stopping rollup service...")
+	s.rollupService.Stop()
+	
+	log.Println("Stopping traffic service...")
+	s.trafficService.Stop()
+	
+	log.Println("Stopping analytics service...")
+	s.analyticsService.Stop()
+	
+	log.Println("Stopping SLO service...")
+	s.sloService.Stop()
+	
+	log.Println("Stopping signing key service...")
+	s.signingKeyService.Stop()
+	
 	log.Println("Shutting down HTTP server...")
 	return s.server.Shutdown(ctx)
 }
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"egide-server/internal/auth"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+)
+
+// accessTokenPrefixLength is how much of a token is kept in clear to recognize it
+const accessTokenPrefixLength = len(auth.AccessTokenPrefix) + 6
+
+// lastUsedResolution limits how often using a token is written to the database
+const lastUsedResolution = time.Minute
+
+// ErrInvalidAccessToken is returned for unknown or expired personal access tokens
+var ErrInvalidAccessToken = errors.New("invalid or expired access token")
+
+// AccessTokenService manages the personal access tokens used by automation.
+// Tokens are only stored hashed, so they can't be shown again after creation
+type AccessTokenService struct {
+	accessTokenRepo *repository.AccessTokenRepository
+}
+
+// NewAccessTokenService creates a new access token service
+func NewAccessTokenService(accessTokenRepo *repository.AccessTokenRepository) *AccessTokenService {
+	return &AccessTokenService{
+		accessTokenRepo: accessTokenRepo,
+	}
+}
+
+// Create mints a token for a user, returned with its clear value
+func (s *AccessTokenService) Create(userID int64, input *models.AccessTokenInput) (*models.AccessToken, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	value := auth.AccessTokenPrefix + hex.EncodeToString(b)
+
+	now := time.Now()
+	token := &models.AccessToken{
+		UserID:    userID,
+		Name:      input.Name,
+		TokenHash: hashToken(value),
+		Prefix:    value[:accessTokenPrefixLength],
+		CreatedAt: now,
+	}
+
+	seen := make(map[models.Scope]bool)
+	for _, scope := range input.Scopes {
+		if !seen[scope] {
+			seen[scope] = true
+			token.Scopes = append(token.Scopes, scope)
+		}
+	}
+
+	if input.ExpiresInDays != nil {
+		expiresAt := now.AddDate(0, 0, *input.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+
+	id, err := s.accessTokenRepo.Create(token)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := s.accessTokenRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	created.Token = value
+	return created, nil
+}
+
+// List returns the tokens of a user, without their values
+func (s *AccessTokenService) List(userID int64) ([]*models.AccessToken, error) {
+	return s.accessTokenRepo.FindByUserID(userID)
+}
+
+// Revoke deletes a token of a user
+func (s *AccessTokenService) Revoke(userID, tokenID int64) error {
+	token, err := s.accessTokenRepo.FindByID(tokenID)
+	if err != nil || token.UserID != userID {
+		return errors.New("access token not found")
+	}
+
+	return s.accessTokenRepo.Delete(token.ID)
+}
+
+// Authenticate returns the token matching a clear value, provided it hasn't expired
+func (s *AccessTokenService) Authenticate(value string) (*models.AccessToken, error) {
+	token, err := s.accessTokenRepo.FindByHash(hashToken(value))
+	if err != nil {
+		return nil, ErrInvalidAccessToken
+	}
+
+	now := time.Now()
+	if token.ExpiresAt != nil && !token.ExpiresAt.After(now) {
+		return nil, ErrInvalidAccessToken
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= lastUsedResolution {
+		if err := s.accessTokenRepo.UpdateLastUsed(token.ID, now); err != nil {
+			return nil, err
+		}
+		token.LastUsedAt = &now
+	}
+
+	return token, nil
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"egide-server/internal/auth"
+	"egide-server/internal/migration"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+)
+
+func TestAccessTokens(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	if err := migration.RunMigrations(db, "../../migrations"); err != nil {
+		t.Fatal(err)
+	}
+
+	userID := insert(t, db, `INSERT INTO users (username) VALUES ('test')`)
+	repo := repository.NewAccessTokenRepository(db)
+	tokens := NewAccessTokenService(repo)
+
+	days := 30
+	created, err := tokens.Create(userID, &models.AccessTokenInput{
+		Name:          "ci",
+		Scopes:        []models.Scope{models.ScopeSitesWrite, models.ScopeSitesRead, models.ScopeSitesWrite},
+		ExpiresInDays: &days,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(created.Token, auth.AccessTokenPrefix) || !strings.HasPrefix(created.Token, created.Prefix) {
+		t.Errorf("token %q, prefix %q", created.Token, created.Prefix)
+	}
+	if len(created.Scopes) != 2 {
+		t.Errorf("scopes = %v; want duplicates removed", created.Scopes)
+	}
+	if created.ExpiresAt == nil || created.ExpiresAt.Before(time.Now().AddDate(0, 0, 29)) {
+		t.Errorf("expires at %v; want in 30 days", created.ExpiresAt)
+	}
+
+	// Only the hash of the token is stored
+	var stored int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM access_tokens WHERE token_hash = ?`, created.Token).Scan(&stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored != 0 {
+		t.Error("the token is stored in clear")
+	}
+
+	token, err := tokens.Authenticate(created.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.UserID != userID || !token.HasScope(models.ScopeSitesWrite) || token.HasScope(models.ScopeThreatsRead) {
+		t.Errorf("Authenticate() = %+v", token)
+	}
+	if token.LastUsedAt == nil {
+		t.Error("last use not recorded")
+	}
+
+	list, err := tokens.List(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Token != "" {
+		t.Errorf("List() = %+v; want one token without its value", list)
+	}
+
+	if _, err := tokens.Authenticate(created.Token + "x"); err != ErrInvalidAccessToken {
+		t.Errorf("unknown token: err = %v; want %v", err, ErrInvalidAccessToken)
+	}
+
+	// Expired tokens are refused
+	if _, err := db.Exec(`UPDATE access_tokens SET expires_at = ?`, time.Now().Add(-time.Minute).UTC()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tokens.Authenticate(created.Token); err != ErrInvalidAccessToken {
+		t.Errorf("expired token: err = %v; want %v", err, ErrInvalidAccessToken)
+	}
+
+	if err := tokens.Revoke(userID+1, created.ID); err == nil {
+		t.Error("expected an error revoking the token of another user")
+	}
+	if err := tokens.Revoke(userID, created.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.FindByID(created.ID); err == nil {
+		t.Error("the token should be deleted")
+	}
+}
@@ -0,0 +1,359 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"egide-server/internal/authz"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+)
+
+const (
+	// Alert evaluation interval, aligned with the monitoring interval
+	AlertEvaluationInterval = CheckInterval
+
+	// Default window used when a rule does not specify one
+	DefaultAlertWindow = 5 * time.Minute
+)
+
+// IncidentEvent is the webhook payload of incident events
+type IncidentEvent struct {
+	Rule     *models.AlertRule     `json:"rule"`
+	Incident *models.AlertIncident `json:"incident"`
+}
+
+// AlertService evaluates alert rules in the background and notifies their channels
+// once per incident, when it starts firing and when it resolves
+type AlertService struct {
+	alertRepo           *repository.AlertRepository
+	channelRepo         *repository.NotificationChannelRepository
+	monitorRepo         *repository.MonitorRepository
+	siteRepo            *repository.SiteRepository
+	authorizer          *authz.Authorizer
+	healthCheckRepo     *repository.HealthCheckRepository
+	threatService       *ThreatService
+	notificationService *NotificationService
+	webhookService      *WebhookService
+	maintenance         *MaintenanceService
+	stopChan            chan struct{}
+}
+
+// NewAlertService creates a new alert service
+func NewAlertService(
+	alertRepo *repository.AlertRepository,
+	channelRepo *repository.NotificationChannelRepository,
+	monitorRepo *repository.MonitorRepository,
+	siteRepo *repository.SiteRepository,
+	authorizer *authz.Authorizer,
+	healthCheckRepo *repository.HealthCheckRepository,
+	threatService *ThreatService,
+	notificationService *NotificationService,
+	webhookService *WebhookService,
+	maintenance *MaintenanceService,
+) *AlertService {
+	return &AlertService{
+		alertRepo:           alertRepo,
+		channelRepo:         channelRepo,
+		monitorRepo:         monitorRepo,
+		siteRepo:            siteRepo,
+		authorizer:          authorizer,
+		healthCheckRepo:     healthCheckRepo,
+		threatService:       threatService,
+		notificationService: notificationService,
+		webhookService:      webhookService,
+		maintenance:         maintenance,
+		stopChan:            make(chan struct{}),
+	}
+}
+
+// Start begins the background evaluation loop
+func (s *AlertService) Start() {
+	log.Println("Starting alert service...")
+
+	ticker := time.NewTicker(AlertEvaluationInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.evaluate()
+			case <-s.stopChan:
+				log.Println("Alert service stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop gracefully stops the alert service
+func (s *AlertService) Stop() {
+	close(s.stopChan)
+}
+
+// evaluate runs every enabled rule once
+func (s *AlertService) evaluate() {
+	rules, err := s.alertRepo.FindEnabled()
+	if err != nil {
+		log.Printf("Failed to load alert rules: %v", err)
+		return
+	}
+
+	inMaintenance, err := s.maintenance.SitesInMaintenance(time.Now())
+	if err != nil {
+		log.Printf("Failed to load maintenance windows: %v", err)
+		inMaintenance = map[int64]bool{}
+	}
+
+	for _, rule := range rules {
+		// Alerts are suppressed while their site is under maintenance,
+		// open incidents are left untouched until the window ends
+		if rule.SiteID != nil && inMaintenance[*rule.SiteID] {
+			continue
+		}
+
+		// Rules of users who lost access to their site, such as members who
+		// left its organization, stay silent
+		if !s.canView(rule) {
+			continue
+		}
+
+		firing, message, err := s.evaluateRule(rule)
+		if err != nil {
+			log.Printf("Failed to evaluate alert rule %d: %v", rule.ID, err)
+			continue
+		}
+
+		if err := s.updateIncident(rule, firing, message); err != nil {
+			log.Printf("Failed to update incident for alert rule %d: %v", rule.ID, err)
+		}
+	}
+}
+
+// evaluateRule reports whether a rule's condition currently holds
+func (s *AlertService) evaluateRule(rule *models.AlertRule) (bool, string, error) {
+	now := time.Now()
+	window := ruleWindow(rule)
+
+	switch rule.Type {
+	case models.MonitorDownRule:
+		monitor, err := s.ruleMonitor(rule)
+		if err != nil {
+			return false, "", err
+		}
+
+		consecutive := int(rule.Threshold)
+		if consecutive < 1 {
+			consecutive = 1
+		}
+
+		checks, err := s.healthCheckRepo.GetLatestChecks(monitor.ID, consecutive)
+		if err != nil {
+			return false, "", err
+		}
+
+		if !isMonitorDown(checks, consecutive) {
+			return false, "", nil
+		}
+		return true, fmt.Sprintf("Monitor %s (%s) failed its last %d check(s): %s",
+			monitor.Name, monitor.URL, consecutive, checkError(checks[0])), nil
+
+	case models.LatencyAboveRule:
+		monitor, err := s.ruleMonitor(rule)
+		if err != nil {
+			return false, "", err
+		}
+
+		checks, err := s.healthCheckRepo.GetMonitorChecksInRange(monitor.ID, now.Add(-window), now)
+		if err != nil {
+			return false, "", err
+		}
+
+		above, fastest := isLatencyAbove(checks, int(rule.Threshold))
+		if !above {
+			return false, "", nil
+		}
+		return true, fmt.Sprintf("Monitor %s (%s) has been slower than %.0fms for %s (fastest: %dms)",
+			monitor.Name, monitor.URL, rule.Threshold, window, fastest), nil
+
+	case models.ThreatRateRule:
+		if rule.SiteID == nil {
+			return false, "", fmt.Errorf("rule has no site")
+		}
+
+		site, err := s.siteRepo.FindByID(*rule.SiteID)
+		if err != nil {
+			return false, "", err
+		}
+
+		count, err := s.threatService.CountThreatsSince(site, now.Add(-window))
+		if err != nil {
+			return false, "", err
+		}
+
+		rate := threatRate(count, window)
+		if rate <= rule.Threshold {
+			return false, "", nil
+		}
+		return true, fmt.Sprintf("Site %s received %.1f threats/min over the last %s (threshold: %.1f)",
+			site.Domain, rate, window, rule.Threshold), nil
+
+	default:
+		return false, "", fmt.Errorf("unknown rule type: %s", rule.Type)
+	}
+}
+
+// updateIncident opens or resolves the rule's incident, notifying only on transitions
+func (s *AlertService) updateIncident(rule *models.AlertRule, firing bool, message string) error {
+	incident, err := s.alertRepo.FindOpenIncident(rule.ID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	if firing && incident == nil {
+		incident = &models.AlertIncident{
+			RuleID:    rule.ID,
+			Status:    models.IncidentFiring,
+			Message:   message,
+			StartedAt: now,
+		}
+
+		incident.ID, err = s.alertRepo.CreateIncident(incident)
+		if err != nil {
+			return err
+		}
+
+		s.webhookService.Publish(rule.UserID, models.EventIncidentOpened, &IncidentEvent{Rule: rule, Incident: incident})
+		s.notify(rule, &Notification{
+			Title:   fmt.Sprintf("Alert firing: %s", rule.Name),
+			Message: message,
+			Status:  models.IncidentFiring,
+			RuleID:  rule.ID,
+			Time:    now,
+		})
+		return nil
+	}
+
+	if !firing && incident != nil {
+		if err := s.alertRepo.ResolveIncident(incident.ID, now); err != nil {
+			return err
+		}
+		incident.Status = models.IncidentResolved
+		incident.ResolvedAt = &now
+
+		s.webhookService.Publish(rule.UserID, models.EventIncidentClosed, &IncidentEvent{Rule: rule, Incident: incident})
+
+		s.notify(rule, &Notification{
+			Title:   fmt.Sprintf("Alert resolved: %s", rule.Name),
+			Message: fmt.Sprintf("%s\nResolved after %s.", incident.Message, now.Sub(incident.StartedAt).Round(time.Second)),
+			Status:  models.IncidentResolved,
+			RuleID:  rule.ID,
+			Time:    now,
+		})
+	}
+
+	return nil
+}
+
+// notify sends a notification to every enabled channel of a rule
+func (s *AlertService) notify(rule *models.AlertRule, n *Notification) {
+	channels, err := s.channelRepo.FindByRuleID(rule.ID)
+	if err != nil {
+		log.Printf("Failed to load channels for alert rule %d: %v", rule.ID, err)
+		return
+	}
+
+	for _, channel := range channels {
+		if err := s.notificationService.Send(channel, n); err != nil {
+			log.Printf("Failed to notify channel %d (%s): %v", channel.ID, channel.Type, err)
+		}
+	}
+}
+
+// canView reports whether the owner of a rule may still view the site it watches
+func (s *AlertService) canView(rule *models.AlertRule) bool {
+	siteID := rule.SiteID
+	if siteID == nil && rule.MonitorID != nil {
+		monitor, err := s.monitorRepo.FindByID(*rule.MonitorID)
+		if err != nil {
+			return false
+		}
+		siteID = &monitor.SiteID
+	}
+	if siteID == nil {
+		return false
+	}
+
+	_, err := s.authorizer.Site(rule.UserID, *siteID, authz.ViewSite)
+	return err == nil
+}
+
+func (s *AlertService) ruleMonitor(rule *models.AlertRule) (*models.Monitor, error) {
+	if rule.MonitorID == nil {
+		return nil, fmt.Errorf("rule has no monitor")
+	}
+	return s.monitorRepo.FindByID(*rule.MonitorID)
+}
+
+func ruleWindow(rule *models.AlertRule) time.Duration {
+	if rule.WindowMinutes <= 0 {
+		return DefaultAlertWindow
+	}
+	return time.Duration(rule.WindowMinutes) * time.Minute
+}
+
+// isMonitorDown reports whether the latest `consecutive` checks (newest first) all failed.
+// Checks performed during a maintenance window don't count, so a monitor is
+// only down once it failed enough checks after the window ended.
+func isMonitorDown(checks []*models.HealthCheck, consecutive int) bool {
+	if len(checks) < consecutive {
+		return false
+	}
+
+	for _, check := range checks[:consecutive] {
+		if check.Success || check.Maintenance {
+			return false
+		}
+	}
+	return true
+}
+
+// isLatencyAbove reports whether every successful check exceeded the threshold,
+// along with the fastest response time seen. Failed checks are ignored since
+// they are covered by monitor_down rules, and so are checks performed during
+// a maintenance window.
+func isLatencyAbove(checks []*models.HealthCheck, thresholdMs int) (bool, int) {
+	fastest := -1
+	for _, check := range checks {
+		if !check.Success || check.Maintenance {
+			continue
+		}
+		if fastest < 0 || check.ResponseTimeMs < fastest {
+			fastest = check.ResponseTimeMs
+		}
+	}
+
+	if fastest < 0 {
+		return false, 0
+	}
+	return fastest > thresholdMs, fastest
+}
+
+// threatRate returns the number of threats per minute over the window
+func threatRate(count int, window time.Duration) float64 {
+	minutes := window.Minutes()
+	if minutes <= 0 {
+		return 0
+	}
+	return float64(count) / minutes
+}
+
+func checkError(check *models.HealthCheck) string {
+	if check.Error != nil {
+		return *check.Error
+	}
+	return "unknown error"
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"egide-server/internal/models"
+)
+
+func TestIsMonitorDown(t *testing.T) {
+	failed := &models.HealthCheck{Success: false}
+	ok := &models.HealthCheck{Success: true}
+	maintenance := &models.HealthCheck{Success: false, Maintenance: true}
+
+	tests := []struct {
+		name        string
+		checks      []*models.HealthCheck
+		consecutive int
+		want        bool
+	}{
+		{"no checks", nil, 1, false},
+		{"latest failed", []*models.HealthCheck{failed, ok}, 1, true},
+		{"latest succeeded", []*models.HealthCheck{ok, failed}, 1, false},
+		{"not enough failures", []*models.HealthCheck{failed, ok, failed}, 3, false},
+		{"enough failures", []*models.HealthCheck{failed, failed, failed}, 3, true},
+		{"fewer checks than required", []*models.HealthCheck{failed}, 2, false},
+		// A maintenance window just ended: only one failure happened since
+		{"failed during maintenance", []*models.HealthCheck{failed, maintenance, maintenance}, 3, false},
+		{"failed after maintenance", []*models.HealthCheck{failed, failed, failed, maintenance}, 3, true},
+	}
+
+	for _, tt := range tests {
+		if got := isMonitorDown(tt.checks, tt.consecutive); got != tt.want {
+			t.Errorf("%s: isMonitorDown() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsLatencyAbove(t *testing.T) {
+	checks := []*models.HealthCheck{
+		{Success: true, ResponseTimeMs: 900},
+		{Success: true, ResponseTimeMs: 700},
+		{Success: false, ResponseTimeMs: 10000},
+	}
+
+	above, fastest := isLatencyAbove(checks, 500)
+	if !above {
+		t.Errorf("expected latency to be above 500ms")
+	}
+	if fastest != 700 {
+		t.Errorf("fastest response time: got %d, want 700", fastest)
+	}
+
+	if above, _ := isLatencyAbove(checks, 800); above {
+		t.Errorf("expected latency not to be above 800ms")
+	}
+
+	// Slow checks of a maintenance window that just ended don't count
+	afterMaintenance := []*models.HealthCheck{
+		{Success: true, ResponseTimeMs: 300},
+		{Success: true, ResponseTimeMs: 5000, Maintenance: true},
+	}
+	if above, fastest := isLatencyAbove(afterMaintenance, 500); above || fastest != 300 {
+		t.Errorf("isLatencyAbove() after maintenance = %v, %d; want false, 300", above, fastest)
+	}
+	if above, _ := isLatencyAbove(afterMaintenance[1:], 500); above {
+		t.Errorf("checks during maintenance should not count towards latency")
+	}
+
+	onlyFailures := []*models.HealthCheck{{Success: false, ResponseTimeMs: 10000}}
+	if above, _ := isLatencyAbove(onlyFailures, 500); above {
+		t.Errorf("failed checks should not count towards latency")
+	}
+}
+
+func TestThreatRate(t *testing.T) {
+	if rate := threatRate(50, 5*time.Minute); rate != 10 {
+		t.Errorf("threatRate() = %v, want 10", rate)
+	}
+
+	if rate := threatRate(50, 0); rate != 0 {
+		t.Errorf("threatRate() with empty window = %v, want 0", rate)
+	}
+}
@@ -0,0 +1,295 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+)
+
+const (
+	// AnalyticsTopKCapacity is the number of counters kept per site, dimension and hour
+	AnalyticsTopKCapacity = 100
+
+	// AnalyticsFlushInterval is how often in-memory counters are written to the database
+	AnalyticsFlushInterval = time.Minute
+
+	// AnalyticsRetention is how long hourly top values are kept
+	AnalyticsRetention = 90 * 24 * time.Hour
+
+	// maxAnalyticsValueLength truncates long paths and user agents
+	maxAnalyticsValueLength = 256
+)
+
+// analyticsKey identifies the sketch of one site, dimension and hour
+type analyticsKey struct {
+	siteID    int64
+	dimension models.AnalyticsDimension
+	bucket    int64 // unix time of the start of the hour
+}
+
+type analyticsSketch struct {
+	topK  *TopK
+	dirty bool
+}
+
+// AnalyticsService aggregates sampled requests into hourly top values per
+// site and dimension. Counting happens in memory with bounded-size sketches
+// that are flushed to the database periodically.
+type AnalyticsService struct {
+	analyticsRepo *repository.AnalyticsRepository
+	mu            sync.Mutex
+	sketches      map[analyticsKey]*analyticsSketch
+	evictions     uint64 // sketches forgotten by Flush, see Record
+	stopChan      chan struct{}
+	doneChan      chan struct{}
+}
+
+// NewAnalyticsService creates a new analytics service
+func NewAnalyticsService(analyticsRepo *repository.AnalyticsRepository) *AnalyticsService {
+	return &AnalyticsService{
+		analyticsRepo: analyticsRepo,
+		sketches:      make(map[analyticsKey]*analyticsSketch),
+		stopChan:      make(chan struct{}),
+		doneChan:      make(chan struct{}),
+	}
+}
+
+// Start begins flushing counters and removing expired ones in the background
+func (s *AnalyticsService) Start() {
+	log.Println("Starting analytics service...")
+
+	ticker := time.NewTicker(AnalyticsFlushInterval)
+	go func() {
+		defer close(s.doneChan)
+		defer ticker.Stop()
+
+		s.cleanup()
+		lastCleanup := time.Now()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.Flush(time.Now())
+				if time.Since(lastCleanup) > 6*time.Hour {
+					s.cleanup()
+					lastCleanup = time.Now()
+				}
+			case <-s.stopChan:
+				s.Flush(time.Now())
+				log.Println("Analytics service stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop flushes pending counters and stops the analytics service
+func (s *AnalyticsService) Stop() {
+	close(s.stopChan)
+	<-s.doneChan
+}
+
+// Record counts a sampled request of a site in every dimension. Samples
+// dated in the future, e.g. by a node with a skewed clock, count in the
+// current hour.
+func (s *AnalyticsService) Record(site *models.Site, sample *models.RequestSampleInput) error {
+	at := time.Now()
+	if sample.Time != nil && sample.Time.Before(at) {
+		at = *sample.Time
+	}
+	bucket := at.UTC().Truncate(time.Hour)
+
+	weight := int64(1)
+	if sample.SampleRate > 0 {
+		weight = int64(math.Round(1 / sample.SampleRate))
+	}
+
+	values := map[analyticsKey]string{}
+	for dimension, value := range analyticsValues(sample) {
+		values[analyticsKey{site.ID, dimension, bucket.Unix()}] = value
+	}
+
+	for {
+		s.mu.Lock()
+		missing := []analyticsKey{}
+		for key := range values {
+			if _, ok := s.sketches[key]; !ok {
+				missing = append(missing, key)
+			}
+		}
+		if len(missing) == 0 {
+			for key, value := range values {
+				sketch := s.sketches[key]
+				sketch.topK.Add(value, weight)
+				sketch.dirty = true
+			}
+			s.mu.Unlock()
+			return nil
+		}
+		evictions := s.evictions
+		s.mu.Unlock()
+
+		// Buckets are read without holding the lock, so ingestion doesn't
+		// wait on the database
+		loaded, err := s.loadSketches(missing)
+		if err != nil {
+			return err
+		}
+
+		s.mu.Lock()
+		// A sketch flushed and forgotten meanwhile may be newer than what
+		// was loaded, in which case the buckets are loaded again
+		if s.evictions == evictions {
+			for key, sketch := range loaded {
+				if _, ok := s.sketches[key]; !ok {
+					s.sketches[key] = sketch
+				}
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// loadSketches seeds the sketches of keys from the database, for buckets
+// already flushed before (e.g. before a restart)
+func (s *AnalyticsService) loadSketches(keys []analyticsKey) (map[analyticsKey]*analyticsSketch, error) {
+	sketches := make(map[analyticsKey]*analyticsSketch, len(keys))
+	for _, key := range keys {
+		stored, err := s.analyticsRepo.FindBucket(key.siteID, key.dimension, time.Unix(key.bucket, 0))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load analytics bucket: %v", err)
+		}
+
+		sketch := &analyticsSketch{topK: NewTopK(AnalyticsTopKCapacity)}
+		sketch.topK.Load(stored)
+		sketches[key] = sketch
+	}
+	return sketches, nil
+}
+
+// Flush writes the counters that changed since the last flush and forgets
+// the sketches of past hours
+func (s *AnalyticsService) Flush(now time.Time) {
+	currentBucket := now.UTC().Truncate(time.Hour).Unix()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, sketch := range s.sketches {
+		if sketch.dirty {
+			err := s.analyticsRepo.ReplaceBucket(key.siteID, key.dimension, time.Unix(key.bucket, 0), sketch.topK.Values())
+			if err != nil {
+				log.Printf("Failed to flush analytics for site %d: %v", key.siteID, err)
+				continue
+			}
+			sketch.dirty = false
+		}
+
+		if key.bucket < currentBucket {
+			delete(s.sketches, key)
+			s.evictions++
+		}
+	}
+}
+
+// QueueDepth returns the number of sketches with counters not flushed yet
+func (s *AnalyticsService) QueueDepth() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pending := 0
+	for _, sketch := range s.sketches {
+		if sketch.dirty {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// GetSiteAnalytics returns the limit top values of every dimension for a
+// site within [start, end), at hourly granularity
+func (s *AnalyticsService) GetSiteAnalytics(siteID int64, start, end time.Time, limit int) (*models.SiteAnalytics, error) {
+	analytics := &models.SiteAnalytics{
+		SiteID: siteID,
+		From:   start,
+		To:     end,
+		Top:    make(map[models.AnalyticsDimension][]*models.TopValue, len(models.AnalyticsDimensions)),
+	}
+
+	from := start.UTC().Truncate(time.Hour)
+	for _, dimension := range models.AnalyticsDimensions {
+		values, err := s.analyticsRepo.FindTopValues(siteID, dimension, from, end, limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get top %s values: %v", dimension, err)
+		}
+		analytics.Top[dimension] = values
+	}
+
+	return analytics, nil
+}
+
+func (s *AnalyticsService) cleanup() {
+	if err := s.analyticsRepo.DeleteBefore(time.Now().Add(-AnalyticsRetention)); err != nil {
+		log.Printf("Failed to cleanup old analytics: %v", err)
+	}
+}
+
+// analyticsValues normalizes the values of a sample for each dimension,
+// leaving out the ones that weren't reported
+func analyticsValues(sample *models.RequestSampleInput) map[models.AnalyticsDimension]string {
+	values := map[models.AnalyticsDimension]string{
+		models.PathDimension:       truncate(stripQuery(sample.Path)),
+		models.StatusCodeDimension: strconv.Itoa(sample.StatusCode),
+	}
+
+	if sample.UserAgent != "" {
+		values[models.UserAgentDimension] = truncate(sample.UserAgent)
+	}
+	if host := referrerHost(sample.Referrer); host != "" {
+		values[models.ReferrerDimension] = host
+	}
+	if sample.Country != "" {
+		values[models.CountryDimension] = strings.ToUpper(sample.Country)
+	}
+	if sample.ASN > 0 {
+		values[models.ASNDimension] = "AS" + strconv.FormatInt(sample.ASN, 10)
+	}
+
+	return values
+}
+
+// stripQuery drops query strings, they are too unique to aggregate and may hold secrets
+func stripQuery(path string) string {
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		return path[:i]
+	}
+	return path
+}
+
+// referrerHost keeps the host of a referrer only
+func referrerHost(referrer string) string {
+	if referrer == "" {
+		return ""
+	}
+	u, err := url.Parse(referrer)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// truncate shortens long values without splitting a UTF-8 character
+func truncate(value string) string {
+	if len(value) > maxAnalyticsValueLength {
+		return strings.ToValidUTF8(value[:maxAnalyticsValueLength], "")
+	}
+	return value
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"egide-server/internal/migration"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+)
+
+func TestRecordAnalytics(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	if err := migration.RunMigrations(db, "../../migrations"); err != nil {
+		t.Fatal(err)
+	}
+
+	userID := insert(t, db, `INSERT INTO users (username) VALUES ('test')`)
+	site := &models.Site{ID: insert(t, db, `INSERT INTO sites (user_id, domain, protection_mode) VALUES (?, 'example.com', 'simple')`, userID)}
+
+	analyticsRepo := repository.NewAnalyticsRepository(db)
+	analytics := NewAnalyticsService(analyticsRepo)
+
+	// A sample from a node whose clock is two days ahead counts now
+	now := time.Now()
+	future := now.Add(48 * time.Hour)
+	if err := analytics.Record(site, &models.RequestSampleInput{Site: "example.com", Time: &future, Path: "/", StatusCode: 200}); err != nil {
+		t.Fatal(err)
+	}
+	analytics.Flush(now)
+
+	hour := now.UTC().Truncate(time.Hour)
+	paths, err := analyticsRepo.FindBucket(site.ID, models.PathDimension, hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 1 || paths[0].Count != 1 {
+		t.Errorf("current hour paths = %v; want / once", paths)
+	}
+	if paths, _ := analyticsRepo.FindBucket(site.ID, models.PathDimension, future.UTC().Truncate(time.Hour)); len(paths) != 0 {
+		t.Errorf("future hour paths = %v; want none", paths)
+	}
+
+	// After a restart, the counters of the hour carry on from the database
+	restarted := NewAnalyticsService(analyticsRepo)
+	if err := restarted.Record(site, &models.RequestSampleInput{Site: "example.com", Time: &now, Path: "/", StatusCode: 200}); err != nil {
+		t.Fatal(err)
+	}
+	restarted.Flush(now)
+
+	paths, err = analyticsRepo.FindBucket(site.ID, models.PathDimension, hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 1 || paths[0].Count != 2 {
+		t.Errorf("current hour paths after restart = %v; want / twice", paths)
+	}
+}
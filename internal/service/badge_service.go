@@ -0,0 +1,129 @@
+package service
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+)
+
+// DefaultBadgePeriod is used when no period is requested
+const DefaultBadgePeriod = "30d"
+
+// BadgePeriods are the periods an uptime badge can cover
+var BadgePeriods = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+	"90d": 90 * 24 * time.Hour,
+}
+
+// Badge colours, matching the usual README badge palette
+const (
+	BadgeColorGood    = "#4c1"
+	BadgeColorFair    = "#97ca00"
+	BadgeColorWarning = "#dfb317"
+	BadgeColorBad     = "#e05d44"
+	BadgeColorUnknown = "#9f9f9f"
+	BadgeColorEgide   = "#007ec6"
+)
+
+// BadgeService renders embeddable SVG badges from health check data
+type BadgeService struct {
+	rollupService *RollupService
+}
+
+// NewBadgeService creates a new badge service
+func NewBadgeService(rollupService *RollupService) *BadgeService {
+	return &BadgeService{
+		rollupService: rollupService,
+	}
+}
+
+// UptimeBadge renders the uptime of the given monitors over a period
+func (s *BadgeService) UptimeBadge(monitorIDs []int64, period time.Duration) ([]byte, error) {
+	now := time.Now()
+	summary, err := s.rollupService.Summarize(monitorIDs, now.Add(-period), now)
+	if err != nil {
+		return nil, err
+	}
+
+	uptime, ok := summary.Uptime()
+	if !ok {
+		return RenderBadge("uptime", "no data", BadgeColorUnknown), nil
+	}
+
+	return RenderBadge("uptime", formatBadgeUptime(uptime), uptimeColor(uptime)), nil
+}
+
+// ProtectionBadge renders the "protected by Egide" badge of a site
+func (s *BadgeService) ProtectionBadge(active bool) []byte {
+	if !active {
+		return RenderBadge("protected by", "Egide (paused)", BadgeColorUnknown)
+	}
+	return RenderBadge("protected by", "Egide", BadgeColorEgide)
+}
+
+// RenderBadge draws a flat two-part badge with a grey label and a coloured value
+func RenderBadge(label, value, color string) []byte {
+	labelWidth := textWidth(label) + 10
+	valueWidth := textWidth(value) + 10
+	width := labelWidth + valueWidth
+	label, value = html.EscapeString(label), html.EscapeString(value)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">`, width, label, value)
+	fmt.Fprintf(&b, `<title>%s: %s</title>`, label, value)
+	b.WriteString(`<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`)
+	fmt.Fprintf(&b, `<clipPath id="r"><rect width="%d" height="20" rx="3" fill="#fff"/></clipPath>`, width)
+	b.WriteString(`<g clip-path="url(#r)">`)
+	fmt.Fprintf(&b, `<rect width="%d" height="20" fill="#555"/>`, labelWidth)
+	fmt.Fprintf(&b, `<rect x="%d" width="%d" height="20" fill="%s"/>`, labelWidth, valueWidth, color)
+	fmt.Fprintf(&b, `<rect width="%d" height="20" fill="url(#s)"/>`, width)
+	b.WriteString(`</g>`)
+	b.WriteString(`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">`)
+	fmt.Fprintf(&b, `<text x="%d" y="15" fill="#010101" fill-opacity=".3">%s</text><text x="%d" y="14">%s</text>`, labelWidth/2, label, labelWidth/2, label)
+	fmt.Fprintf(&b, `<text x="%d" y="15" fill="#010101" fill-opacity=".3">%s</text><text x="%d" y="14">%s</text>`, labelWidth+valueWidth/2, value, labelWidth+valueWidth/2, value)
+	b.WriteString(`</g></svg>`)
+
+	return []byte(b.String())
+}
+
+// textWidth approximates the rendered width of text in 11px Verdana
+func textWidth(text string) int {
+	width := 0.0
+	for _, c := range text {
+		switch {
+		case strings.ContainsRune("il.:|!' ", c):
+			width += 3.5
+		case strings.ContainsRune("mwMW%", c):
+			width += 10
+		case c >= 'A' && c <= 'Z':
+			width += 7.5
+		default:
+			width += 6.5
+		}
+	}
+	return int(width + 0.5)
+}
+
+// formatBadgeUptime keeps two decimals without rounding 99.999% up to 100%
+func formatBadgeUptime(uptime float64) string {
+	if uptime >= 100 {
+		return "100%"
+	}
+	return fmt.Sprintf("%.2f%%", float64(int(uptime*100))/100)
+}
+
+func uptimeColor(uptime float64) string {
+	switch {
+	case uptime >= 99.9:
+		return BadgeColorGood
+	case uptime >= 99:
+		return BadgeColorFair
+	case uptime >= 95:
+		return BadgeColorWarning
+	default:
+		return BadgeColorBad
+	}
+}
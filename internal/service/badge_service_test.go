@@ -0,0 +1,36 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatBadgeUptime(t *testing.T) {
+	tests := []struct {
+		uptime float64
+		want   string
+	}{
+		{100, "100%"},
+		{99.999, "99.99%"},
+		{99.98, "99.98%"},
+		{42, "42.00%"},
+	}
+
+	for _, tt := range tests {
+		if got := formatBadgeUptime(tt.uptime); got != tt.want {
+			t.Errorf("formatBadgeUptime(%v) = %q, want %q", tt.uptime, got, tt.want)
+		}
+	}
+}
+
+func TestRenderBadgeEscapesText(t *testing.T) {
+	svg := string(RenderBadge("uptime", "<b>&", BadgeColorGood))
+
+	if !strings.HasPrefix(svg, "<svg") || !strings.HasSuffix(svg, "</svg>") {
+		t.Fatalf("badge is not an SVG document: %s", svg)
+	}
+
+	if strings.Contains(svg, "<b>") || !strings.Contains(svg, "&lt;b&gt;&amp;") {
+		t.Errorf("badge text was not escaped: %s", svg)
+	}
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"egide-server/internal/authz"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+)
+
+// ExpiredWarning is the warned threshold recorded once an expired certificate was reported
+const ExpiredWarning = -1
+
+// CertificateEvent is the webhook payload of certificate events
+type CertificateEvent struct {
+	Monitor     *models.Monitor        `json:"monitor"`
+	Certificate *models.TLSCertificate `json:"certificate"`
+	DaysLeft    int                    `json:"days_left"`
+}
+
+// CertificateService records the TLS certificates seen by HTTPS checks and
+// warns site owners before they expire
+type CertificateService struct {
+	certRepo            *repository.CertificateRepository
+	siteRepo            *repository.SiteRepository
+	authorizer          *authz.Authorizer
+	channelRepo         *repository.NotificationChannelRepository
+	notificationService *NotificationService
+	webhookService      *WebhookService
+	warningDays         []int
+	roots               *x509.CertPool // trusted roots, the system ones when nil
+}
+
+// NewCertificateService creates a new certificate service warning at the
+// given numbers of days before expiry, largest first
+func NewCertificateService(
+	certRepo *repository.CertificateRepository,
+	siteRepo *repository.SiteRepository,
+	authorizer *authz.Authorizer,
+	channelRepo *repository.NotificationChannelRepository,
+	notificationService *NotificationService,
+	webhookService *WebhookService,
+	warningDays []int,
+) *CertificateService {
+	return &CertificateService{
+		certRepo:            certRepo,
+		siteRepo:            siteRepo,
+		authorizer:          authorizer,
+		channelRepo:         channelRepo,
+		notificationService: notificationService,
+		webhookService:      webhookService,
+		warningDays:         warningDays,
+	}
+}
+
+// Record verifies the certificate chain presented to a check for host,
+// stores it even when it isn't trusted, and sends a warning when it crossed
+// an expiry threshold that wasn't notified yet or stopped being trusted. The
+// verification error is returned so the check can fail.
+func (s *CertificateService) Record(monitor *models.Monitor, host string, chain []*x509.Certificate, now time.Time) error {
+	if len(chain) == 0 {
+		return errors.New("no certificate presented")
+	}
+
+	cert := certificateFromChain(monitor.ID, chain, now)
+	verifyErr := verifyChain(chain, host, s.roots, now)
+	if verifyErr != nil {
+		message := verifyErr.Error()
+		cert.VerificationError = &message
+	}
+
+	// Warnings already sent carry over until the certificate is replaced
+	previous, err := s.certRepo.FindByMonitorID(monitor.ID)
+	sameCertificate := err == nil && previous.Fingerprint == cert.Fingerprint
+	if sameCertificate {
+		cert.WarnedDays = previous.WarnedDays
+	}
+
+	daysLeft := daysUntil(cert.NotAfter, now)
+	threshold, warn := expiryWarning(daysLeft, s.warningDays, cert.WarnedDays)
+	if warn {
+		cert.WarnedDays = &threshold
+	}
+
+	// Expiry has its own warnings, other failures are reported once per certificate
+	untrusted := verifyErr != nil && !isExpired(verifyErr) && !(sameCertificate && previous.VerificationError != nil)
+
+	if err := s.certRepo.Save(cert); err != nil {
+		log.Printf("Failed to save certificate of monitor %d: %v", monitor.ID, err)
+		return verifyErr
+	}
+
+	if warn {
+		s.warn(monitor, cert, daysLeft, now)
+	}
+	if untrusted {
+		s.warnUntrusted(monitor, cert, daysLeft, now)
+	}
+	return verifyErr
+}
+
+// GetSiteCertificates returns the last certificate seen by each monitor of a site
+func (s *CertificateService) GetSiteCertificates(siteID int64) ([]*models.TLSCertificate, error) {
+	return s.certRepo.FindBySiteID(siteID)
+}
+
+// warn notifies the users of the site and publishes a webhook event
+func (s *CertificateService) warn(monitor *models.Monitor, cert *models.TLSCertificate, daysLeft int, now time.Time) {
+	site, err := s.siteRepo.FindByID(monitor.SiteID)
+	if err != nil {
+		log.Printf("Failed to load site of monitor %d: %v", monitor.ID, err)
+		return
+	}
+
+	PublishSiteEvent(s.authorizer, s.webhookService, site, models.EventCertExpiring, &CertificateEvent{
+		Monitor:     monitor,
+		Certificate: cert,
+		DaysLeft:    daysLeft,
+	})
+
+	title := fmt.Sprintf("TLS certificate of %s expires in %d day(s)", site.Domain, daysLeft)
+	if daysLeft < 0 {
+		title = fmt.Sprintf("TLS certificate of %s has expired", site.Domain)
+	}
+	n := &Notification{
+		Title: title,
+		Message: fmt.Sprintf("The certificate of %s (%s), issued by %s, expires on %s.",
+			monitor.Name, monitor.URL, cert.Issuer, cert.NotAfter.UTC().Format(time.RFC1123)),
+		Status: models.IncidentFiring,
+		Time:   now,
+	}
+	notifySite(s.authorizer, s.channelRepo, s.notificationService, site, n)
+}
+
+// warnUntrusted notifies the users of the site that its certificate isn't
+// trusted anymore and publishes a webhook event
+func (s *CertificateService) warnUntrusted(monitor *models.Monitor, cert *models.TLSCertificate, daysLeft int, now time.Time) {
+	site, err := s.siteRepo.FindByID(monitor.SiteID)
+	if err != nil {
+		log.Printf("Failed to load site of monitor %d: %v", monitor.ID, err)
+		return
+	}
+
+	PublishSiteEvent(s.authorizer, s.webhookService, site, models.EventCertInvalid, &CertificateEvent{
+		Monitor:     monitor,
+		Certificate: cert,
+		DaysLeft:    daysLeft,
+	})
+
+	notifySite(s.authorizer, s.channelRepo, s.notificationService, site, &Notification{
+		Title: fmt.Sprintf("TLS certificate of %s is not trusted", site.Domain),
+		Message: fmt.Sprintf("The certificate of %s (%s), issued by %s, failed verification: %s.",
+			monitor.Name, monitor.URL, cert.Issuer, *cert.VerificationError),
+		Status: models.IncidentFiring,
+		Time:   now,
+	})
+}
+
+// verifyChain checks that the leaf certificate of a chain is valid for host
+// and chains up to a trusted root. Monitors skip verification during the
+// handshake so that untrusted certificates are still recorded.
+func verifyChain(chain []*x509.Certificate, host string, roots *x509.CertPool, now time.Time) error {
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := chain[0].Verify(x509.VerifyOptions{
+		DNSName:       host,
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+	})
+	return err
+}
+
+// isExpired reports whether a verification failed because a certificate of
+// the chain expired or isn't valid yet
+func isExpired(err error) bool {
+	var invalid x509.CertificateInvalidError
+	return errors.As(err, &invalid) && invalid.Reason == x509.Expired
+}
+
+// daysUntil returns the whole days left before t, rounded down so that a
+// certificate expired hours ago has -1 day left rather than 0
+func daysUntil(t, now time.Time) int {
+	return int(math.Floor(t.Sub(now).Hours() / 24))
+}
+
+// certificateFromChain describes the leaf certificate of a chain and its intermediates
+func certificateFromChain(monitorID int64, chain []*x509.Certificate, now time.Time) *models.TLSCertificate {
+	leaf := chain[0]
+	fingerprint := sha256.Sum256(leaf.Raw)
+
+	cert := &models.TLSCertificate{
+		MonitorID:    monitorID,
+		Subject:      leaf.Subject.String(),
+		Issuer:       leaf.Issuer.String(),
+		SANs:         append([]string{}, leaf.DNSNames...),
+		SerialNumber: leaf.SerialNumber.Text(16),
+		Fingerprint:  hex.EncodeToString(fingerprint[:]),
+		NotBefore:    leaf.NotBefore,
+		NotAfter:     leaf.NotAfter,
+		Chain:        []*models.CertificateIssuer{},
+		CheckedAt:    now,
+	}
+	for _, ip := range leaf.IPAddresses {
+		cert.SANs = append(cert.SANs, ip.String())
+	}
+
+	for _, intermediate := range chain[1:] {
+		cert.Chain = append(cert.Chain, &models.CertificateIssuer{
+			Subject:  intermediate.Subject.String(),
+			Issuer:   intermediate.Issuer.String(),
+			NotAfter: intermediate.NotAfter,
+		})
+	}
+
+	return cert
+}
+
+// expiryWarning returns the smallest threshold (in days, largest first) the
+// certificate crossed, ExpiredWarning once it expired, and whether it still
+// has to be notified
+func expiryWarning(daysLeft int, thresholds []int, warnedDays *int) (int, bool) {
+	crossed, found := 0, false
+	for _, threshold := range thresholds {
+		if daysLeft <= threshold {
+			crossed, found = threshold, true
+		}
+	}
+	if daysLeft < 0 {
+		crossed, found = ExpiredWarning, true
+	}
+
+	if !found {
+		return 0, false
+	}
+	if warnedDays != nil && *warnedDays <= crossed {
+		return 0, false
+	}
+	return crossed, true
+}
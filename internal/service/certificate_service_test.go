@@ -0,0 +1,188 @@
+package service
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"database/sql"
+	"math/big"
+	"testing"
+	"time"
+
+	"egide-server/internal/config"
+	"egide-server/internal/migration"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+)
+
+func TestExpiryWarning(t *testing.T) {
+	thresholds := []int{30, 14, 7}
+	warned := func(days int) *int { return &days }
+
+	tests := []struct {
+		daysLeft int
+		warned   *int
+		want     int
+		warn     bool
+	}{
+		{60, nil, 0, false},
+		{30, nil, 30, true},
+		{20, warned(30), 0, false},
+		{10, warned(30), 14, true},
+		{3, nil, 7, true},
+		{-1, warned(7), ExpiredWarning, true},
+		{-2, warned(ExpiredWarning), 0, false},
+	}
+
+	for _, tt := range tests {
+		got, warn := expiryWarning(tt.daysLeft, thresholds, tt.warned)
+		if got != tt.want || warn != tt.warn {
+			t.Errorf("expiryWarning(%d, %v) = %d, %v; want %d, %v", tt.daysLeft, tt.warned, got, warn, tt.want, tt.warn)
+		}
+	}
+}
+
+func TestRecordCertificate(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	if err := migration.RunMigrations(db, "../../migrations"); err != nil {
+		t.Fatal(err)
+	}
+
+	userID := insert(t, db, `INSERT INTO users (username) VALUES ('test')`)
+	siteID := insert(t, db, `INSERT INTO sites (user_id, domain, protection_mode) VALUES (?, 'example.com', 'simple')`, userID)
+	monitorID := insert(t, db, `INSERT INTO monitors (site_id, name, url) VALUES (?, 'Home', 'https://example.com')`, siteID)
+	monitor := &models.Monitor{ID: monitorID, SiteID: siteID, Name: "Home", URL: "https://example.com"}
+
+	certRepo := repository.NewCertificateRepository(db)
+	certificates := NewCertificateService(
+		certRepo,
+		repository.NewSiteRepository(db),
+		newTestAuthorizer(db),
+		repository.NewNotificationChannelRepository(db),
+		NewNotificationService(&config.Config{}),
+		NewWebhookService(repository.NewWebhookRepository(db)),
+		[]int{30, 14, 7},
+	)
+
+	now := time.Now()
+	chain := []*x509.Certificate{newTestCertificate(t, now.Add(20*24*time.Hour))}
+	renewed := []*x509.Certificate{newTestCertificate(t, now.Add(90*24*time.Hour))}
+	certificates.roots = x509.NewCertPool()
+	certificates.roots.AddCert(chain[0])
+	certificates.roots.AddCert(renewed[0])
+
+	if err := certificates.Record(monitor, "example.com", chain, now); err != nil {
+		t.Fatalf("Record() = %v", err)
+	}
+	cert, err := certRepo.FindByMonitorID(monitorID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.Subject != "CN=example.com" || len(cert.SANs) != 2 || cert.SANs[0] != "example.com" || cert.VerificationError != nil {
+		t.Errorf("unexpected certificate details: %+v", cert)
+	}
+	if cert.WarnedDays == nil || *cert.WarnedDays != 30 {
+		t.Fatalf("expected the 30 days warning to be recorded, got %v", cert.WarnedDays)
+	}
+
+	// Ten days later the 14 days threshold is crossed
+	certificates.Record(monitor, "example.com", chain, now.Add(10*24*time.Hour))
+	cert, err = certRepo.FindByMonitorID(monitorID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.WarnedDays == nil || *cert.WarnedDays != 14 {
+		t.Errorf("expected the 14 days warning to be recorded, got %v", cert.WarnedDays)
+	}
+
+	// Hours after expiry the certificate is still recorded, with a last warning
+	if err := certificates.Record(monitor, "example.com", chain, now.Add(20*24*time.Hour+12*time.Hour)); !isExpired(err) {
+		t.Errorf("Record() of an expired certificate = %v; want expired", err)
+	}
+	cert, err = certRepo.FindByMonitorID(monitorID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.WarnedDays == nil || *cert.WarnedDays != ExpiredWarning || cert.VerificationError == nil {
+		t.Errorf("expected the expiry to be recorded, got warned %v, error %v", cert.WarnedDays, cert.VerificationError)
+	}
+
+	// A renewed certificate starts over
+	certificates.Record(monitor, "example.com", renewed, now)
+	cert, err = certRepo.FindByMonitorID(monitorID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.WarnedDays != nil {
+		t.Errorf("expected no warning for a renewed certificate, got %d", *cert.WarnedDays)
+	}
+
+	// Untrusted certificates and other hosts are recorded and fail verification
+	if err := certificates.Record(monitor, "other.com", renewed, now); err == nil {
+		t.Error("Record() for another host succeeded")
+	}
+	untrusted := []*x509.Certificate{newTestCertificate(t, now.Add(90*24*time.Hour))}
+	if err := certificates.Record(monitor, "example.com", untrusted, now); err == nil || isExpired(err) {
+		t.Errorf("Record() of an untrusted certificate = %v; want unknown authority", err)
+	}
+	cert, err = certRepo.FindByMonitorID(monitorID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.Fingerprint != certificateFromChain(monitorID, untrusted, now).Fingerprint || cert.VerificationError == nil {
+		t.Errorf("expected the untrusted certificate to be recorded, got %+v", cert)
+	}
+}
+
+func TestDaysUntil(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		notAfter time.Time
+		want     int
+	}{
+		{now.Add(36 * time.Hour), 1},
+		{now.Add(12 * time.Hour), 0},
+		{now.Add(-12 * time.Hour), -1},
+		{now.Add(-36 * time.Hour), -2},
+	}
+
+	for _, tt := range tests {
+		if got := daysUntil(tt.notAfter, now); got != tt.want {
+			t.Errorf("daysUntil(%s) = %d; want %d", tt.notAfter, got, tt.want)
+		}
+	}
+}
+
+func newTestCertificate(t *testing.T, notAfter time.Time) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(notAfter.Unix()),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		DNSNames:     []string{"example.com", "www.example.com"},
+		NotBefore:    notAfter.Add(-90 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"egide-server/internal/authz"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+)
+
+const (
+	// MaxContentSize is the size of the response body hashed by content checks
+	MaxContentSize = 1 << 20
+
+	// Number of changed lines quoted in the summary of a content change
+	contentSampleLines = 3
+
+	// Changed lines quoted in a summary are truncated to this many characters
+	contentSampleLength = 120
+)
+
+// ContentService detects unexpected changes of the pages of hardened sites,
+// such as defacements, by comparing their content to a baseline
+type ContentService struct {
+	contentRepo         *repository.ContentRepository
+	siteRepo            *repository.SiteRepository
+	authorizer          *authz.Authorizer
+	channelRepo         *repository.NotificationChannelRepository
+	threatService       *ThreatService
+	notificationService *NotificationService
+}
+
+// NewContentService creates a new content service
+func NewContentService(
+	contentRepo *repository.ContentRepository,
+	siteRepo *repository.SiteRepository,
+	authorizer *authz.Authorizer,
+	channelRepo *repository.NotificationChannelRepository,
+	threatService *ThreatService,
+	notificationService *NotificationService,
+) *ContentService {
+	return &ContentService{
+		contentRepo:         contentRepo,
+		siteRepo:            siteRepo,
+		authorizer:          authorizer,
+		channelRepo:         channelRepo,
+		threatService:       threatService,
+		notificationService: notificationService,
+	}
+}
+
+// Inspect compares the body returned to a successful check with the baseline
+// of the monitor, reporting a defacement threat when it changed. The first
+// content seen becomes the baseline.
+func (s *ContentService) Inspect(monitor *models.Monitor, body io.Reader, now time.Time) {
+	check, err := s.contentRepo.FindCheck(monitor.ID)
+	if err != nil {
+		log.Printf("Failed to load content check of monitor %d: %v", monitor.ID, err)
+		return
+	}
+	if !check.Enabled {
+		return
+	}
+
+	site, err := s.siteRepo.FindByID(monitor.SiteID)
+	if err != nil {
+		log.Printf("Failed to load site of monitor %d: %v", monitor.ID, err)
+		return
+	}
+	if site.ProtectionMode != models.HardenedProtection {
+		return
+	}
+
+	raw, err := io.ReadAll(io.LimitReader(body, MaxContentSize))
+	if err != nil {
+		log.Printf("Failed to read content of monitor %d: %v", monitor.ID, err)
+		return
+	}
+
+	content, err := normalizeContent(string(raw), check.IgnorePatterns)
+	if err != nil {
+		log.Printf("Invalid ignore pattern for monitor %d: %v", monitor.ID, err)
+		return
+	}
+	hash := contentHash(content)
+
+	switch {
+	case check.BaselineHash == "":
+		check.BaselineHash = hash
+		check.BaselineBody = content
+		check.BaselineAt = &now
+		check.LastHash = ""
+	case hash == check.BaselineHash:
+		// Back to the baseline, a later change is reported again
+		if check.LastHash == "" {
+			return
+		}
+		check.LastHash = ""
+	case hash == check.LastHash:
+		// This change was already reported
+		return
+	default:
+		s.report(site, monitor, check, hash, diffSummary(check.BaselineBody, content, site.Verified), now)
+		check.LastHash = hash
+	}
+
+	if err := s.contentRepo.SaveCheck(check); err != nil {
+		log.Printf("Failed to save content check of monitor %d: %v", monitor.ID, err)
+	}
+}
+
+// GetCheck returns the content check configuration of a monitor
+func (s *ContentService) GetCheck(monitorID int64) (*models.ContentCheck, error) {
+	return s.contentRepo.FindCheck(monitorID)
+}
+
+// Configure enables or disables content checks on a monitor. Changing the
+// ignore patterns resets the baseline since hashes are no longer comparable.
+func (s *ContentService) Configure(monitorID int64, input *models.ContentCheckInput) (*models.ContentCheck, error) {
+	patterns := input.IgnorePatterns
+	if patterns == nil {
+		patterns = []string{}
+	}
+	if _, err := compilePatterns(patterns); err != nil {
+		return nil, err
+	}
+
+	check, err := s.contentRepo.FindCheck(monitorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.Join(patterns, "\n") != strings.Join(check.IgnorePatterns, "\n") {
+		resetBaseline(check)
+	}
+	check.Enabled = *input.Enabled
+	check.IgnorePatterns = patterns
+
+	if err := s.contentRepo.SaveCheck(check); err != nil {
+		return nil, err
+	}
+	return check, nil
+}
+
+// ResetBaseline accepts the current content of a monitor after a legitimate
+// change: the next check becomes the new baseline
+func (s *ContentService) ResetBaseline(monitorID int64) (*models.ContentCheck, error) {
+	check, err := s.contentRepo.FindCheck(monitorID)
+	if err != nil {
+		return nil, err
+	}
+
+	resetBaseline(check)
+	if err := s.contentRepo.SaveCheck(check); err != nil {
+		return nil, err
+	}
+	return check, nil
+}
+
+// GetSiteChanges returns the latest content changes detected on a site
+func (s *ContentService) GetSiteChanges(siteID int64, limit int) ([]*models.ContentChange, error) {
+	return s.contentRepo.FindChangesBySiteID(siteID, limit)
+}
+
+// report records a content change, surfaces it as a defacement threat and
+// notifies the enabled channels of the site owner
+func (s *ContentService) report(site *models.Site, monitor *models.Monitor, check *models.ContentCheck, hash, summary string, now time.Time) {
+	change := &models.ContentChange{
+		MonitorID:    monitor.ID,
+		SiteID:       site.ID,
+		BaselineHash: check.BaselineHash,
+		Hash:         hash,
+		Summary:      summary,
+		DetectedAt:   now.UTC(),
+	}
+
+	threat := &models.Threat{
+		Nature: models.Defacement,
+		Status: models.Detected,
+		Source: []string{monitor.URL},
+		Time:   now,
+	}
+	if err := s.threatService.Ingest(site, threat); err != nil {
+		log.Printf("Failed to record defacement threat for monitor %d: %v", monitor.ID, err)
+	} else {
+		change.ThreatID = &threat.ID
+	}
+
+	if _, err := s.contentRepo.CreateChange(change); err != nil {
+		log.Printf("Failed to save content change of monitor %d: %v", monitor.ID, err)
+	}
+
+	n := &Notification{
+		Title:   fmt.Sprintf("Content of %s changed", site.Domain),
+		Message: fmt.Sprintf("The content of %s (%s) no longer matches its baseline: %s", monitor.Name, monitor.URL, summary),
+		Status:  models.IncidentFiring,
+		Time:    now,
+	}
+	notifySite(s.authorizer, s.channelRepo, s.notificationService, site, n)
+}
+
+func resetBaseline(check *models.ContentCheck) {
+	check.BaselineHash = ""
+	check.BaselineBody = ""
+	check.BaselineAt = nil
+	check.LastHash = ""
+}
+
+// compilePatterns compiles the ignore patterns of a content check
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ignore pattern %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// normalizeContent removes the regions matched by the ignore patterns
+func normalizeContent(content string, patterns []string) (string, error) {
+	compiled, err := compilePatterns(patterns)
+	if err != nil {
+		return "", err
+	}
+	for _, re := range compiled {
+		content = re.ReplaceAllString(content, "")
+	}
+	return content, nil
+}
+
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// diffSummary describes the lines added and removed between two contents.
+// The first added lines are only quoted for verified sites: summaries are
+// sent to notification channels and webhooks, and the fetched content of a
+// domain nobody proved to own isn't ours to relay.
+func diffSummary(baseline, content string, quote bool) string {
+	before := lineCounts(baseline)
+	after := lineCounts(content)
+
+	added, removed := 0, 0
+	samples := []string{}
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if before[line] > 0 {
+			before[line]--
+			continue
+		}
+		added++
+		if quote && len(samples) < contentSampleLines {
+			if utf8.RuneCountInString(line) > contentSampleLength {
+				line = string([]rune(line)[:contentSampleLength]) + "..."
+			}
+			samples = append(samples, line)
+		}
+	}
+	for line, count := range lineCounts(baseline) {
+		if after[line] < count {
+			removed += count - after[line]
+		}
+	}
+
+	summary := fmt.Sprintf("+%d -%d lines", added, removed)
+	if len(samples) > 0 {
+		summary += "; added: " + strings.Join(samples, " | ")
+	}
+	return summary
+}
+
+// lineCounts counts the non-blank lines of a content, ignoring indentation
+func lineCounts(content string) map[string]int {
+	counts := map[string]int{}
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			counts[line]++
+		}
+	}
+	return counts
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"egide-server/internal/config"
+	"egide-server/internal/migration"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+)
+
+func TestNormalizeContent(t *testing.T) {
+	patterns := []string{`<input name="csrf" value="[^"]*">`, `Generated at \d+`}
+
+	a, err := normalizeContent(`<p>Hello</p><input name="csrf" value="abc">Generated at 1`, patterns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := normalizeContent(`<p>Hello</p><input name="csrf" value="xyz">Generated at 2`, patterns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contentHash(a) != contentHash(b) {
+		t.Errorf("ignored regions should not change the hash: %q != %q", a, b)
+	}
+
+	if _, err := normalizeContent("", []string{"("}); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
+
+func TestDiffSummary(t *testing.T) {
+	baseline := "<h1>Welcome</h1>\n<p>Shop</p>\n<p>Contact</p>"
+	content := "<h1>Hacked by someone</h1>\n<p>Shop</p>"
+
+	got := diffSummary(baseline, content, true)
+	want := "+1 -2 lines; added: <h1>Hacked by someone</h1>"
+	if got != want {
+		t.Errorf("diffSummary() = %q; want %q", got, want)
+	}
+
+	// Only line counts for unverified sites
+	got = diffSummary(baseline, content, false)
+	want = "+1 -2 lines"
+	if got != want {
+		t.Errorf("diffSummary() without quotes = %q; want %q", got, want)
+	}
+
+	// Long lines are cut between characters, not in the middle of one
+	long := strings.Repeat("é", contentSampleLength+10)
+	got = diffSummary("", long, true)
+	want = "+1 -0 lines; added: " + strings.Repeat("é", contentSampleLength) + "..."
+	if got != want || !utf8.ValidString(got) {
+		t.Errorf("diffSummary() of a long line = %q; want %q", got, want)
+	}
+}
+
+func TestInspectContent(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	if err := migration.RunMigrations(db, "../../migrations"); err != nil {
+		t.Fatal(err)
+	}
+
+	userID := insert(t, db, `INSERT INTO users (username) VALUES ('test')`)
+	siteID := insert(t, db, `INSERT INTO sites (user_id, domain, protection_mode, verified) VALUES (?, 'example.com', 'hardened', TRUE)`, userID)
+	monitorID := insert(t, db, `INSERT INTO monitors (site_id, name, url) VALUES (?, 'Home', 'https://example.com')`, siteID)
+	monitor := &models.Monitor{ID: monitorID, SiteID: siteID, Name: "Home", URL: "https://example.com"}
+
+	contentRepo := repository.NewContentRepository(db)
+	webhookService := NewWebhookService(repository.NewWebhookRepository(db))
+	content := NewContentService(
+		contentRepo,
+		repository.NewSiteRepository(db),
+		newTestAuthorizer(db),
+		repository.NewNotificationChannelRepository(db),
+		NewThreatService(repository.NewThreatRepository(db), newTestAuthorizer(db), webhookService),
+		NewNotificationService(&config.Config{}),
+	)
+
+	enabled := true
+	if _, err := content.Configure(monitorID, &models.ContentCheckInput{Enabled: &enabled, IgnorePatterns: []string{`nonce-\w+`}}); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	content.Inspect(monitor, strings.NewReader("<h1>Welcome</h1>\nnonce-abc"), now)
+	check, err := content.GetCheck(monitorID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if check.BaselineHash == "" || check.BaselineAt == nil {
+		t.Fatal("the first content should become the baseline")
+	}
+
+	// Ignored regions don't count as changes
+	content.Inspect(monitor, strings.NewReader("<h1>Welcome</h1>\nnonce-def"), now.Add(time.Minute))
+	assertChanges(t, content, siteID, 0)
+
+	// A change is reported once as long as the content stays the same
+	content.Inspect(monitor, strings.NewReader("<h1>Hacked</h1>\nnonce-ghi"), now.Add(2*time.Minute))
+	content.Inspect(monitor, strings.NewReader("<h1>Hacked</h1>\nnonce-jkl"), now.Add(3*time.Minute))
+	changes := assertChanges(t, content, siteID, 1)
+	if changes[0].ThreatID == nil {
+		t.Fatal("the change should be surfaced as a threat")
+	}
+	if !strings.Contains(changes[0].Summary, "<h1>Hacked</h1>") {
+		t.Errorf("summary %q should quote the added line", changes[0].Summary)
+	}
+
+	var nature models.ThreatNature
+	if err := db.QueryRow(`SELECT nature FROM threats WHERE id = ?`, *changes[0].ThreatID).Scan(&nature); err != nil {
+		t.Fatal(err)
+	}
+	if nature != models.Defacement {
+		t.Errorf("threat nature = %d; want %d", nature, models.Defacement)
+	}
+
+	// Accepting the change makes the next content the baseline
+	if _, err := content.ResetBaseline(monitorID); err != nil {
+		t.Fatal(err)
+	}
+	content.Inspect(monitor, strings.NewReader("<h1>Hacked</h1>\nnonce-mno"), now.Add(4*time.Minute))
+	content.Inspect(monitor, strings.NewReader("<h1>Hacked</h1>\nnonce-pqr"), now.Add(5*time.Minute))
+	assertChanges(t, content, siteID, 1)
+}
+
+func assertChanges(t *testing.T, content *ContentService, siteID int64, want int) []*models.ContentChange {
+	t.Helper()
+
+	changes, err := content.GetSiteChanges(siteID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != want {
+		t.Fatalf("got %d content changes; want %d", len(changes), want)
+	}
+	return changes
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"strconv"
+	"time"
+
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+	"egide-server/internal/telemetry"
+)
+
+// Label values of threat natures and statuses in the Prometheus exposition
+var (
+	threatNatureLabels = map[models.ThreatNature]string{
+		models.AICrawler:    "ai_crawler",
+		models.DDoS:         "ddos",
+		models.BruteForce:   "brute_force",
+		models.XSS:          "xss",
+		models.SQLInjection: "sql_injection",
+		models.Defacement:   "defacement",
+	}
+
+	threatStatusLabels = map[models.ThreatStatus]string{
+		models.Blocked:    "blocked",
+		models.Detected:   "detected",
+		models.InAnalysis: "in_analysis",
+	}
+)
+
+// ExporterService renders the monitoring and threat data of an account's
+// sites as Prometheus metrics, so customers can alert on them with their
+// own Prometheus and Alertmanager
+type ExporterService struct {
+	monitorRepo     *repository.MonitorRepository
+	healthCheckRepo *repository.HealthCheckRepository
+	threatRepo      *repository.ThreatRepository
+	maintenance     *MaintenanceService
+}
+
+// NewExporterService creates a new exporter service
+func NewExporterService(monitorRepo *repository.MonitorRepository, healthCheckRepo *repository.HealthCheckRepository, threatRepo *repository.ThreatRepository, maintenance *MaintenanceService) *ExporterService {
+	return &ExporterService{
+		monitorRepo:     monitorRepo,
+		healthCheckRepo: healthCheckRepo,
+		threatRepo:      threatRepo,
+		maintenance:     maintenance,
+	}
+}
+
+// Export builds a registry holding the current metrics of the given sites
+func (s *ExporterService) Export(sites []*models.Site) (*telemetry.Registry, error) {
+	now := time.Now()
+	registry := telemetry.NewRegistry()
+
+	siteUp := registry.NewGaugeVec("egide_site_up",
+		"Whether all monitors of the site passed their last check (1) or not (0). Absent without recent checks.",
+		"site")
+	siteMaintenance := registry.NewGaugeVec("egide_site_maintenance",
+		"Whether the site is in a maintenance window.",
+		"site")
+	configVersion := registry.NewGaugeVec("egide_site_config_version",
+		"Version of the site configuration, as the unix time of its last change.",
+		"site", "protection_mode")
+	monitorUp := registry.NewGaugeVec("egide_monitor_up",
+		"Whether the last check of the monitor succeeded (1) or not (0). Absent without recent checks.",
+		"site", "monitor_id", "monitor")
+	responseTime := registry.NewGaugeVec("egide_monitor_response_time_seconds",
+		"Response time of the last check of the monitor.",
+		"site", "monitor_id", "monitor")
+	lastCheck := registry.NewGaugeVec("egide_monitor_last_check_timestamp_seconds",
+		"Unix time of the last check of the monitor.",
+		"site", "monitor_id", "monitor")
+	threats := registry.NewCounterVec("egide_site_threats_total",
+		"Threats reported for the site, by nature and status.",
+		"site", "nature", "status")
+
+	inMaintenance, err := s.maintenance.SitesInMaintenance(now)
+	if err != nil {
+		return nil, err
+	}
+
+	domains := make(map[int64]string, len(sites))
+	siteIDs := make([]int64, 0, len(sites))
+	for _, site := range sites {
+		domains[site.ID] = site.Domain
+		siteIDs = append(siteIDs, site.ID)
+
+		configVersion.Set(float64(site.UpdatedAt.Unix()), site.Domain, string(site.ProtectionMode))
+		siteMaintenance.Set(boolValue(inMaintenance[site.ID]), site.Domain)
+
+		monitors, err := s.monitorRepo.FindBySiteID(site.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		fresh, allUp := 0, true
+		for _, monitor := range monitors {
+			if !monitor.Enabled {
+				continue
+			}
+
+			latest, err := s.healthCheckRepo.GetLatestChecks(monitor.ID, 1)
+			if err != nil {
+				return nil, err
+			}
+			if len(latest) == 0 {
+				continue
+			}
+
+			check := latest[0]
+			labels := []string{site.Domain, strconv.FormatInt(monitor.ID, 10), monitor.Name}
+			lastCheck.Set(float64(check.Timestamp.Unix()), labels...)
+			responseTime.Set(float64(check.ResponseTimeMs)/1000, labels...)
+
+			// A stale result would keep reporting a site up after monitoring stopped
+			if now.Sub(check.Timestamp) > StaleCheckAge {
+				continue
+			}
+			monitorUp.Set(boolValue(check.Success), labels...)
+			fresh++
+			allUp = allUp && check.Success
+		}
+
+		if fresh > 0 {
+			siteUp.Set(boolValue(allUp), site.Domain)
+		}
+	}
+
+	counts, err := s.threatRepo.CountBySiteNatureStatus(siteIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, count := range counts {
+		threats.Add(float64(count.Count), domains[count.SiteID], labelOrUnknown(threatNatureLabels[count.Nature]), labelOrUnknown(threatStatusLabels[count.Status]))
+	}
+
+	return registry, nil
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func labelOrUnknown(label string) string {
+	if label == "" {
+		return "unknown"
+	}
+	return label
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+)
+
+var (
+	// ErrIdentityInUse is returned when linking an identity that belongs to another user
+	ErrIdentityInUse = errors.New("identity already linked to another account")
+
+	// ErrLastIdentity is returned when unlinking would leave a user unable to sign in
+	ErrLastIdentity = errors.New("cannot unlink the last identity able to sign in")
+)
+
+// IdentityService manages the identities users sign in with
+type IdentityService struct {
+	identityRepo *repository.IdentityRepository
+}
+
+// NewIdentityService creates a new identity service
+func NewIdentityService(identityRepo *repository.IdentityRepository) *IdentityService {
+	return &IdentityService{
+		identityRepo: identityRepo,
+	}
+}
+
+// List returns the identities of a user
+func (s *IdentityService) List(userID int64) ([]*models.Identity, error) {
+	return s.identityRepo.FindByUserID(userID)
+}
+
+// Link attaches an identity to a user. Linking an identity the user already
+// has refreshes its profile.
+func (s *IdentityService) Link(userID int64, identity *models.Identity) (*models.Identity, error) {
+	existing, err := s.identityRepo.FindByProviderSubject(identity.Provider, identity.Subject)
+	if err == nil {
+		if existing.UserID != userID {
+			return nil, ErrIdentityInUse
+		}
+		existing.Username = identity.Username
+		existing.Email = identity.Email
+		return existing, s.identityRepo.UpdateProfile(existing)
+	}
+
+	identity.UserID = userID
+	identity.ID, err = s.identityRepo.Create(identity)
+	if err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+// LinkEmail attaches a verified email address to a user
+func (s *IdentityService) LinkEmail(userID int64, email string) (*models.Identity, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	return s.Link(userID, &models.Identity{
+		Provider: models.EmailIdentity,
+		Subject:  email,
+		Username: email,
+		Email:    email,
+	})
+}
+
+// Unlink removes an identity of a user, refusing to remove the last one
+// the user can sign in with
+func (s *IdentityService) Unlink(userID, identityID int64) error {
+	deleted, err := s.identityRepo.DeleteUnlessLastSignIn(userID, identityID)
+	if err != nil {
+		return err
+	}
+	if deleted {
+		return nil
+	}
+
+	identity, err := s.identityRepo.FindByID(identityID)
+	if err != nil || identity.UserID != userID {
+		return errors.New("identity not found")
+	}
+	return ErrLastIdentity
+}
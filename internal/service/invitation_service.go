@@ -0,0 +1,222 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+)
+
+// DefaultInvitationTTL is how long invitations stay valid when no expiry is given
+const DefaultInvitationTTL = 7 * 24 * time.Hour
+
+var (
+	// ErrInvalidInvitation is returned for unknown, expired or already accepted invitations
+	ErrInvalidInvitation = errors.New("invalid or expired invitation")
+
+	// ErrInvitationNotFound is returned for invitations outside of an organization
+	ErrInvitationNotFound = errors.New("invitation not found")
+
+	// ErrAlreadyInvited is returned when an address already has a pending invitation
+	ErrAlreadyInvited = errors.New("address already has a pending invitation")
+
+	// ErrInvitationNotSent is returned when the invitation email can't be sent
+	ErrInvitationNotSent = errors.New("failed to send invitation email")
+)
+
+// InvitationService invites people by email to join organizations. The
+// token sent by email is only stored hashed, and whoever signs in with it
+// joins the organization: the address only tells where to send it.
+type InvitationService struct {
+	invitationRepo      *repository.InvitationRepository
+	organizationRepo    *repository.OrganizationRepository
+	notificationService *NotificationService
+	frontendURL         string
+}
+
+// NewInvitationService creates a new invitation service
+func NewInvitationService(
+	invitationRepo *repository.InvitationRepository,
+	organizationRepo *repository.OrganizationRepository,
+	notificationService *NotificationService,
+	frontendURL string,
+) *InvitationService {
+	return &InvitationService{
+		invitationRepo:      invitationRepo,
+		organizationRepo:    organizationRepo,
+		notificationService: notificationService,
+		frontendURL:         frontendURL,
+	}
+}
+
+// List returns the pending invitations of an organization
+func (s *InvitationService) List(orgID int64) ([]*models.Invitation, error) {
+	return s.invitationRepo.FindPending(orgID)
+}
+
+// Invite creates an invitation and emails it. org.Role is the role of the
+// inviting user: only owners invite owners.
+func (s *InvitationService) Invite(org *models.Organization, userID int64, input *models.InvitationInput) (*models.Invitation, error) {
+	if input.Role == models.RoleOwner && org.Role != models.RoleOwner {
+		return nil, ErrOwnerRequired
+	}
+
+	email := strings.ToLower(strings.TrimSpace(input.Email))
+	pending, err := s.invitationRepo.FindPending(org.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, invitation := range pending {
+		if invitation.Email == email {
+			return nil, ErrAlreadyInvited
+		}
+	}
+
+	ttl := DefaultInvitationTTL
+	if input.ExpiresInDays != nil {
+		ttl = time.Duration(*input.ExpiresInDays) * 24 * time.Hour
+	}
+
+	token, err := newInvitationToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	invitation := &models.Invitation{
+		OrganizationID: org.ID,
+		Email:          email,
+		Role:           input.Role,
+		TokenHash:      hashToken(token),
+		InvitedBy:      userID,
+		ExpiresAt:      now.Add(ttl),
+		SentAt:         now,
+		CreatedAt:      now,
+	}
+	invitation.ID, err = s.invitationRepo.Create(invitation)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.send(org, invitation, token); err != nil {
+		// Nobody can accept an invitation that wasn't received
+		s.invitationRepo.Delete(invitation.ID)
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+// Resend emails a pending invitation again with a new token, valid as long
+// as the first one was. The previous email can't be used anymore.
+func (s *InvitationService) Resend(org *models.Organization, id int64) (*models.Invitation, error) {
+	invitation, err := s.pendingInvitation(org, id)
+	if err != nil {
+		return nil, err
+	}
+	if invitation.Role == models.RoleOwner && org.Role != models.RoleOwner {
+		return nil, ErrOwnerRequired
+	}
+
+	token, err := newInvitationToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	invitation.TokenHash = hashToken(token)
+	invitation.ExpiresAt = now.Add(invitation.ExpiresAt.Sub(invitation.SentAt))
+	invitation.SentAt = now
+	if err := s.invitationRepo.UpdateToken(invitation); err != nil {
+		return nil, err
+	}
+
+	if err := s.send(org, invitation, token); err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+// Revoke deletes a pending invitation
+func (s *InvitationService) Revoke(org *models.Organization, id int64) error {
+	invitation, err := s.pendingInvitation(org, id)
+	if err != nil {
+		return err
+	}
+	if invitation.Role == models.RoleOwner && org.Role != models.RoleOwner {
+		return ErrOwnerRequired
+	}
+
+	return s.invitationRepo.Delete(invitation.ID)
+}
+
+// Accept makes the user a member of the organization of an invitation, with
+// the role it was sent with
+func (s *InvitationService) Accept(userID int64, token string) (*models.Organization, error) {
+	invitation, err := s.invitationRepo.FindByHash(hashToken(token))
+	if err != nil {
+		return nil, ErrInvalidInvitation
+	}
+
+	now := time.Now()
+	if invitation.AcceptedAt != nil || now.After(invitation.ExpiresAt) {
+		return nil, ErrInvalidInvitation
+	}
+
+	if _, err := s.organizationRepo.FindMember(invitation.OrganizationID, userID); err == nil {
+		return nil, ErrAlreadyMember
+	}
+
+	accepted, err := s.invitationRepo.Accept(invitation, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !accepted {
+		return nil, ErrInvalidInvitation
+	}
+
+	org, err := s.organizationRepo.FindByID(invitation.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	org.Role = invitation.Role
+	return org, nil
+}
+
+func (s *InvitationService) pendingInvitation(org *models.Organization, id int64) (*models.Invitation, error) {
+	invitation, err := s.invitationRepo.FindByID(id)
+	if err != nil || invitation.OrganizationID != org.ID || invitation.AcceptedAt != nil {
+		return nil, ErrInvitationNotFound
+	}
+	return invitation, nil
+}
+
+func (s *InvitationService) send(org *models.Organization, invitation *models.Invitation, token string) error {
+	link := fmt.Sprintf("%s/invitations/accept?token=%s", s.frontendURL, url.QueryEscape(token))
+	err := s.notificationService.SendEmail(invitation.Email, &Notification{
+		Title: fmt.Sprintf("Join %s on Egide", org.Name),
+		Message: fmt.Sprintf("You are invited to join the %s organization on Egide as %s.\n\nOpen the following link before %s to accept:\n\n%s\n\nIf you don't expect it, ignore this email.",
+			org.Name, invitation.Role, invitation.ExpiresAt.UTC().Format("2006-01-02 15:04 MST"), link),
+		Time: invitation.SentAt,
+	})
+	if err != nil {
+		log.Printf("Failed to send invitation %d to %s: %v", invitation.ID, invitation.Email, err)
+		return ErrInvitationNotSent
+	}
+	return nil
+}
+
+func newInvitationToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
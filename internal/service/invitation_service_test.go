@@ -0,0 +1,208 @@
+package service
+
+import (
+	"database/sql"
+	"net"
+	"net/textproto"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"egide-server/internal/config"
+	"egide-server/internal/migration"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+)
+
+// newMailSink starts a local SMTP server keeping the messages it receives,
+// and returns a configuration sending emails to it
+func newMailSink(t *testing.T) (*config.Config, <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	messages := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSMTP(conn, messages)
+		}
+	}()
+
+	cfg := &config.Config{}
+	cfg.SMTP.Host = "127.0.0.1"
+	cfg.SMTP.Port = listener.Addr().(*net.TCPAddr).Port
+	cfg.SMTP.From = "egide@localhost"
+	return cfg, messages
+}
+
+func serveSMTP(conn net.Conn, messages chan<- string) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+
+	text.PrintfLine("220 localhost")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+
+		switch strings.ToUpper(strings.SplitN(line, " ", 2)[0]) {
+		case "DATA":
+			text.PrintfLine("354 go ahead")
+			lines, err := text.ReadDotLines()
+			if err != nil {
+				return
+			}
+			messages <- strings.Join(lines, "\n")
+			text.PrintfLine("250 queued")
+		case "QUIT":
+			text.PrintfLine("221 bye")
+			return
+		default:
+			text.PrintfLine("250 ok")
+		}
+	}
+}
+
+func TestInvitations(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	if err := migration.RunMigrations(db, "../../migrations"); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, messages := newMailSink(t)
+	ownerID := insert(t, db, `INSERT INTO users (username) VALUES ('owner')`)
+	inviteeID := insert(t, db, `INSERT INTO users (username) VALUES ('invitee')`)
+	organizationRepo := repository.NewOrganizationRepository(db)
+	invitationRepo := repository.NewInvitationRepository(db)
+	invitations := NewInvitationService(invitationRepo, organizationRepo, NewNotificationService(cfg), "https://egide.test")
+
+	org, err := NewOrganizationService(organizationRepo, repository.NewTwoFactorRepository(db)).
+		Create(ownerID, &models.OrganizationInput{Name: "Team"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := &models.InvitationInput{Email: "Invitee@Example.com", Role: models.RoleEditor}
+	invitation, err := invitations.Invite(org, ownerID, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if invitation.Email != "invitee@example.com" {
+		t.Errorf("email = %q; want it lowercased", invitation.Email)
+	}
+	if _, err := invitations.Invite(org, ownerID, input); err != ErrAlreadyInvited {
+		t.Errorf("Invite() twice = %v; want %v", err, ErrAlreadyInvited)
+	}
+
+	token := receiveInvitation(t, messages, "invitee@example.com")
+	if hashToken(token) != invitation.TokenHash {
+		t.Error("emailed token doesn't match the invitation")
+	}
+
+	// Resending replaces the token
+	if _, err := invitations.Resend(org, invitation.ID); err != nil {
+		t.Fatal(err)
+	}
+	resent := receiveInvitation(t, messages, "invitee@example.com")
+	if _, err := invitations.Accept(inviteeID, token); err != ErrInvalidInvitation {
+		t.Errorf("Accept() with the first token = %v; want %v", err, ErrInvalidInvitation)
+	}
+
+	joined, err := invitations.Accept(inviteeID, resent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if joined.ID != org.ID || joined.Role != models.RoleEditor {
+		t.Errorf("Accept() = %+v; want editor of %d", joined, org.ID)
+	}
+	member, err := organizationRepo.FindMember(org.ID, inviteeID)
+	if err != nil || member.Role != models.RoleEditor {
+		t.Errorf("member = %+v, %v; want editor", member, err)
+	}
+	if _, err := invitations.Accept(inviteeID, resent); err != ErrInvalidInvitation {
+		t.Errorf("Accept() twice = %v; want %v", err, ErrInvalidInvitation)
+	}
+	if pending, _ := invitations.List(org.ID); len(pending) != 0 {
+		t.Errorf("%d pending invitations after acceptance; want none", len(pending))
+	}
+
+	// Expired and revoked invitations can't be accepted
+	expired, err := invitations.Invite(org, ownerID, &models.InvitationInput{Email: "late@example.com", Role: models.RoleViewer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token = receiveInvitation(t, messages, "late@example.com")
+	if _, err := db.Exec(`UPDATE organization_invitations SET expires_at = ? WHERE id = ?`, time.Now().Add(-time.Minute).UTC(), expired.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := invitations.Accept(ownerID, token); err != ErrInvalidInvitation {
+		t.Errorf("Accept() expired = %v; want %v", err, ErrInvalidInvitation)
+	}
+
+	if err := invitations.Revoke(org, expired.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := invitations.Resend(org, expired.ID); err != ErrInvitationNotFound {
+		t.Errorf("Resend() revoked = %v; want %v", err, ErrInvitationNotFound)
+	}
+
+	// Only owners invite owners
+	asAdmin := *org
+	asAdmin.Role = models.RoleAdmin
+	if _, err := invitations.Invite(&asAdmin, ownerID, &models.InvitationInput{Email: "boss@example.com", Role: models.RoleOwner}); err != ErrOwnerRequired {
+		t.Errorf("admin inviting an owner = %v; want %v", err, ErrOwnerRequired)
+	}
+
+	// Organization names can't add headers to the email
+	org.Name = "Team\r\nBcc: attacker@example.com"
+	if _, err := invitations.Invite(org, ownerID, &models.InvitationInput{Email: "crlf@example.com", Role: models.RoleViewer}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case message := <-messages:
+		header, _, _ := strings.Cut(message, "\n\n")
+		for _, line := range strings.Split(header, "\n") {
+			if strings.HasPrefix(strings.ToLower(line), "bcc:") {
+				t.Errorf("organization name injected a header:\n%s", header)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no invitation email received")
+	}
+}
+
+var invitationLink = regexp.MustCompile(`https://egide\.test/invitations/accept\?token=([0-9a-f]+)`)
+
+// receiveInvitation waits for an invitation email and returns its token
+func receiveInvitation(t *testing.T, messages <-chan string, to string) string {
+	t.Helper()
+
+	select {
+	case message := <-messages:
+		if !strings.Contains(message, "To: "+to) {
+			t.Errorf("invitation sent to the wrong address:\n%s", message)
+		}
+		match := invitationLink.FindStringSubmatch(message)
+		if match == nil {
+			t.Fatalf("no invitation link in:\n%s", message)
+		}
+		return match[1]
+	case <-time.After(5 * time.Second):
+		t.Fatal("no invitation email received")
+	}
+	return ""
+}
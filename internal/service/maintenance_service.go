@@ -0,0 +1,110 @@
+package service
+
+import (
+	"time"
+
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+)
+
+// MaintenanceService answers whether sites are inside a maintenance window
+type MaintenanceService struct {
+	maintenanceRepo *repository.MaintenanceRepository
+}
+
+// NewMaintenanceService creates a new maintenance service
+func NewMaintenanceService(maintenanceRepo *repository.MaintenanceRepository) *MaintenanceService {
+	return &MaintenanceService{
+		maintenanceRepo: maintenanceRepo,
+	}
+}
+
+// SitesInMaintenance returns the IDs of the sites inside a maintenance window at t
+func (s *MaintenanceService) SitesInMaintenance(t time.Time) (map[int64]bool, error) {
+	windows, err := s.maintenanceRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	sites := make(map[int64]bool)
+	for _, window := range windows {
+		if WindowCovers(window, t) {
+			sites[window.SiteID] = true
+		}
+	}
+
+	return sites, nil
+}
+
+// InMaintenance reports whether a site is inside a maintenance window at t
+func (s *MaintenanceService) InMaintenance(siteID int64, t time.Time) (bool, error) {
+	windows, err := s.maintenanceRepo.FindBySiteID(siteID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, window := range windows {
+		if WindowCovers(window, t) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// WindowCovers reports whether t falls inside an occurrence of the window.
+// Recurring windows repeat at the wall-clock time of StartsAt in the window's
+// time zone, so they follow daylight saving changes.
+func WindowCovers(window *models.MaintenanceWindow, t time.Time) bool {
+	duration := time.Duration(window.DurationMinutes) * time.Minute
+	if t.Before(window.StartsAt) {
+		return false
+	}
+
+	if window.Recurrence == models.OneOffMaintenance {
+		return t.Before(window.StartsAt.Add(duration))
+	}
+
+	loc, err := time.LoadLocation(window.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	start := window.StartsAt.In(loc)
+	local := t.In(loc)
+
+	weekdays := window.Weekdays
+	if len(weekdays) == 0 {
+		weekdays = []time.Weekday{start.Weekday()}
+	}
+
+	// An occurrence covering t started at most `duration` before it
+	days := int(duration.Hours()/24) + 1
+	for i := 0; i <= days; i++ {
+		day := local.AddDate(0, 0, -i)
+		occurrence := time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), start.Second(), 0, loc)
+
+		if occurrence.Before(window.StartsAt) {
+			continue
+		}
+
+		if window.Recurrence == models.WeeklyMaintenance && !containsWeekday(weekdays, occurrence.Weekday()) {
+			continue
+		}
+
+		if !t.Before(occurrence) && t.Before(occurrence.Add(duration)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsWeekday(weekdays []time.Weekday, day time.Weekday) bool {
+	for _, d := range weekdays {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
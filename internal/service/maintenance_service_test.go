@@ -0,0 +1,96 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"egide-server/internal/models"
+)
+
+func TestWindowCoversOneOff(t *testing.T) {
+	start := time.Date(2025, 3, 10, 22, 0, 0, 0, time.UTC)
+	window := &models.MaintenanceWindow{
+		Recurrence:      models.OneOffMaintenance,
+		StartsAt:        start,
+		DurationMinutes: 120,
+		TimeZone:        "UTC",
+	}
+
+	tests := []struct {
+		t    time.Time
+		want bool
+	}{
+		{start.Add(-time.Minute), false},
+		{start, true},
+		{start.Add(119 * time.Minute), true},
+		{start.Add(120 * time.Minute), false},
+		{start.AddDate(0, 0, 1), false},
+	}
+
+	for _, tt := range tests {
+		if got := WindowCovers(window, tt.t); got != tt.want {
+			t.Errorf("WindowCovers(%s) = %v, want %v", tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestWindowCoversDailyAcrossMidnight(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+
+	// Every day from 23:30 to 00:30, Paris time
+	window := &models.MaintenanceWindow{
+		Recurrence:      models.DailyMaintenance,
+		StartsAt:        time.Date(2025, 3, 1, 23, 30, 0, 0, paris),
+		DurationMinutes: 60,
+		TimeZone:        "Europe/Paris",
+	}
+
+	tests := []struct {
+		t    time.Time
+		want bool
+	}{
+		{time.Date(2025, 2, 28, 23, 45, 0, 0, paris), false}, // before the first occurrence
+		{time.Date(2025, 3, 1, 23, 45, 0, 0, paris), true},
+		{time.Date(2025, 3, 2, 0, 15, 0, 0, paris), true},
+		{time.Date(2025, 3, 2, 0, 45, 0, 0, paris), false},
+		// After the switch to summer time the window keeps its wall-clock time
+		{time.Date(2025, 4, 10, 23, 35, 0, 0, paris), true},
+		{time.Date(2025, 4, 10, 22, 35, 0, 0, paris), false},
+	}
+
+	for _, tt := range tests {
+		if got := WindowCovers(window, tt.t); got != tt.want {
+			t.Errorf("WindowCovers(%s) = %v, want %v", tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestWindowCoversWeekly(t *testing.T) {
+	// Mondays and Thursdays from 02:00 to 03:00 UTC
+	window := &models.MaintenanceWindow{
+		Recurrence:      models.WeeklyMaintenance,
+		StartsAt:        time.Date(2025, 3, 3, 2, 0, 0, 0, time.UTC), // a Monday
+		DurationMinutes: 60,
+		Weekdays:        []time.Weekday{time.Monday, time.Thursday},
+		TimeZone:        "UTC",
+	}
+
+	tests := []struct {
+		t    time.Time
+		want bool
+	}{
+		{time.Date(2025, 3, 10, 2, 30, 0, 0, time.UTC), true},  // Monday
+		{time.Date(2025, 3, 13, 2, 30, 0, 0, time.UTC), true},  // Thursday
+		{time.Date(2025, 3, 12, 2, 30, 0, 0, time.UTC), false}, // Wednesday
+		{time.Date(2025, 3, 10, 3, 30, 0, 0, time.UTC), false},
+	}
+
+	for _, tt := range tests {
+		if got := WindowCovers(window, tt.t); got != tt.want {
+			t.Errorf("WindowCovers(%s) = %v, want %v", tt.t, got, tt.want)
+		}
+	}
+}
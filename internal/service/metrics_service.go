@@ -2,7 +2,7 @@ package service
 
 import (
 	"fmt"
-	"math/rand"
+	"math"
 	"time"
 
 	"egide-server/internal/repository"
@@ -18,89 +18,170 @@ type KpiMetric struct {
 
 // KpiData represents all KPI metrics for the dashboard
 type KpiData struct {
-	TotalRequests  KpiMetric `json:"totalRequests"`
-	BlockedThreats KpiMetric `json:"blockedThreats"`
-	ResponseTime   KpiMetric `json:"responseTime"`
-	Uptime         KpiMetric `json:"uptime"`
+	TotalRequests  KpiMetric             `json:"totalRequests"`
+	BlockedThreats KpiMetric             `json:"blockedThreats"`
+	ResponseTime   KpiMetric             `json:"responseTime"`
+	Uptime         KpiMetric             `json:"uptime"`
+	Traffic        *models.TrafficTotals `json:"traffic"`
+	Period         KpiPeriod             `json:"period"`
+}
+
+// KpiPeriod describes the range the KPIs cover and the one changes are computed against
+type KpiPeriod struct {
+	From        time.Time  `json:"from"`
+	To          time.Time  `json:"to"`
+	Compare     string     `json:"compare"`
+	CompareFrom *time.Time `json:"compareFrom,omitempty"`
+	CompareTo   *time.Time `json:"compareTo,omitempty"`
+}
+
+// KPI comparison modes
+const (
+	// CompareNone computes no change
+	CompareNone = "none"
+
+	// ComparePrevious compares with the period of the same length right before
+	ComparePrevious = "previous"
+
+	// CompareYearAgo compares with the same dates one year earlier
+	CompareYearAgo = "year_ago"
+)
+
+// KpiQuery selects the period, sites and monitors KPIs are computed for.
+// Only the given sites and monitors are covered: callers pass those the
+// user may view, and none means no data.
+type KpiQuery struct {
+	From       time.Time
+	To         time.Time
+	Compare    string
+	SiteIDs    []int64
+	MonitorIDs []int64
+}
+
+// ComparisonWindow returns the range the given period is compared against,
+// or false when compare is CompareNone. Year-ago windows are shifted in the
+// time zone of from and to.
+func ComparisonWindow(from, to time.Time, compare string) (time.Time, time.Time, bool) {
+	switch compare {
+	case ComparePrevious:
+		return from.Add(-to.Sub(from)), from, true
+	case CompareYearAgo:
+		return from.AddDate(-1, 0, 0), to.AddDate(-1, 0, 0), true
+	default:
+		return time.Time{}, time.Time{}, false
+	}
+}
+
+// LatencyBuckets are the upper bounds (in ms) of the latency histogram buckets.
+// Slower checks fall into a final unbounded bucket.
+var LatencyBuckets = []int{50, 100, 200, 300, 500, 750, 1000, 2000, 5000, 10000}
+
+// HistogramBucket counts the checks whose response time is at most LeMs
+// and above the previous bucket's bound. LeMs is nil for the last bucket.
+type HistogramBucket struct {
+	LeMs  *int `json:"le_ms"`
+	Count int  `json:"count"`
+}
+
+// LatencyStats summarises the response times of a monitor's successful checks
+type LatencyStats struct {
+	MonitorID  int64                   `json:"monitor_id"`
+	From       time.Time               `json:"from"`
+	To         time.Time               `json:"to"`
+	Resolution models.RollupResolution `json:"resolution"` // raw, hour or day
+	Count      int                     `json:"count"`
+	MinMs      int                     `json:"min_ms"`
+	MaxMs      int                     `json:"max_ms"`
+	MeanMs     float64                 `json:"mean_ms"`
+	P50Ms      int                     `json:"p50_ms"`
+	P90Ms      int                     `json:"p90_ms"`
+	P95Ms      int                     `json:"p95_ms"`
+	P99Ms      int                     `json:"p99_ms"`
+	Histogram  []*HistogramBucket      `json:"histogram"`
 }
 
 // MetricsService handles metrics data operations
 type MetricsService struct {
-	rand               *rand.Rand
 	healthCheckRepo    *repository.HealthCheckRepository
+	rollupService      *RollupService
+	trafficService     *TrafficService
 }
 
 // NewMetricsService creates a new metrics service
-func NewMetricsService(healthCheckRepo *repository.HealthCheckRepository) *MetricsService {
-	source := rand.NewSource(time.Now().UnixNano())
+func NewMetricsService(healthCheckRepo *repository.HealthCheckRepository, rollupService *RollupService, trafficService *TrafficService) *MetricsService {
 	return &MetricsService{
-		rand:            rand.New(source),
 		healthCheckRepo: healthCheckRepo,
+		rollupService:   rollupService,
+		trafficService:  trafficService,
 	}
 }
 
-// GetKpiData returns KPI data for the given user's sites
-func (s *MetricsService) GetKpiData(userID int64) (*KpiData, error) {
-	now := time.Now()
-	
-	// Get current month data (last 30 days)
-	currentStart := now.AddDate(0, 0, -30)
-	currentChecks, err := s.healthCheckRepo.GetChecksInRange(currentStart, now)
+// GetKpiData returns KPI data for the selected monitors over the query period,
+// with changes computed against its comparison window
+func (s *MetricsService) GetKpiData(query *KpiQuery) (*KpiData, error) {
+	current, err := s.rollupService.Summarize(query.MonitorIDs, query.From, query.To)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get current health checks: %v", err)
 	}
 	
-	// Get previous month data (30-60 days ago)
-	previousStart := now.AddDate(0, 0, -60)
-	previousEnd := now.AddDate(0, 0, -30)
-	previousChecks, err := s.healthCheckRepo.GetChecksInRange(previousStart, previousEnd)
+	traffic, err := s.trafficService.GetTotals(query.SiteIDs, query.From, query.To)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get previous health checks: %v", err)
+		return nil, fmt.Errorf("failed to get traffic counters: %v", err)
 	}
 	
+	period := KpiPeriod{From: query.From, To: query.To, Compare: query.Compare}
+	
 	// Calculate uptime and response time metrics
-	currentUptime, currentAvgResponseTime := s.calculateMetrics(currentChecks)
-	previousUptime, previousAvgResponseTime := s.calculateMetrics(previousChecks)
+	currentUptime, currentAvgResponseTime := calculateMetrics(current)
 	
 	// Calculate change percentages
 	var uptimeChange *float64
 	var responseTimeChange *float64
+	var totalRequestsChange *float64
+	var blockedThreatsChange *float64
 	
-	if len(previousChecks) > 0 {
-		uptimeChangeVal := currentUptime - previousUptime
-		uptimeChange = &uptimeChangeVal
+	if compareFrom, compareTo, ok := ComparisonWindow(query.From, query.To, query.Compare); ok {
+		period.CompareFrom = &compareFrom
+		period.CompareTo = &compareTo
+		
+		previous, err := s.rollupService.Summarize(query.MonitorIDs, compareFrom, compareTo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get previous health checks: %v", err)
+		}
+		previousUptime, previousAvgResponseTime := calculateMetrics(previous)
+		
+		if previous.Total > 0 {
+			uptimeChangeVal := round(currentUptime-previousUptime, 2)
+			uptimeChange = &uptimeChangeVal
+			
+			if previousAvgResponseTime > 0 {
+				responseTimeChangeVal := round(((currentAvgResponseTime-previousAvgResponseTime)/previousAvgResponseTime)*100, 1)
+				responseTimeChange = &responseTimeChangeVal
+			}
+		}
 		
-		if previousAvgResponseTime > 0 {
-			responseTimeChangeVal := ((currentAvgResponseTime - previousAvgResponseTime) / previousAvgResponseTime) * 100
-			responseTimeChange = &responseTimeChangeVal
+		previousTraffic, err := s.trafficService.GetTotals(query.SiteIDs, compareFrom, compareTo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get previous traffic counters: %v", err)
 		}
+		totalRequestsChange = percentChange(traffic.Requests, previousTraffic.Requests)
+		blockedThreatsChange = percentChange(traffic.Blocked, previousTraffic.Blocked)
 	}
 	
-	// Generate mock data for total requests and blocked threats (as before)
-	totalRequests := 500000 + s.rand.Intn(1000000)
-	totalRequestsChange := (s.rand.Float64() * 30) - 15
-	
-	blockedThreats := 2000 + s.rand.Intn(5000)
-	blockedThreatsChange := (s.rand.Float64() * 20) - 10
-	
 	// Calculate downtime information
-	downtimeInfo := s.calculateDowntimeInfo(currentChecks)
+	downtimeInfo := calculateDowntimeInfo(current)
 	
 	// Format response time
 	responseTimeStr := fmt.Sprintf("%.0fms", currentAvgResponseTime)
 	
-	// Round values
-	totalRequestsChangeRounded := round(totalRequestsChange, 1)
-	blockedThreatsChangeRounded := round(blockedThreatsChange, 1)
-	
 	return &KpiData{
 		TotalRequests: KpiMetric{
-			Value:  totalRequests,
-			Change: &totalRequestsChangeRounded,
+			Value:  traffic.Requests,
+			Change: totalRequestsChange,
 		},
 		BlockedThreats: KpiMetric{
-			Value:  blockedThreats,
-			Change: &blockedThreatsChangeRounded,
+			Value:  traffic.Blocked,
+			Change: blockedThreatsChange,
 		},
 		ResponseTime: KpiMetric{
 			Value:  responseTimeStr,
@@ -111,45 +192,147 @@ func (s *MetricsService) GetKpiData(userID int64) (*KpiData, error) {
 			Change:   uptimeChange,
 			Subvalue: &downtimeInfo,
 		},
+		Traffic: traffic,
+		Period:  period,
 	}, nil
 }
 
-// calculateMetrics computes uptime percentage and average response time from health checks
-func (s *MetricsService) calculateMetrics(checks []*models.HealthCheck) (uptime float64, avgResponseTime float64) {
-	if len(checks) == 0 {
-		return 100.0, 0.0 // Default to 100% uptime if no data
+// GetLatencyStats computes the latency percentiles and histogram of a monitor
+// between start and end. Failed checks are left out: their response time is
+// the request timeout, not a latency.
+//
+// Short recent ranges are computed exactly from raw checks, longer ones from
+// rollups with percentiles estimated from the histogram.
+func (s *MetricsService) GetLatencyStats(monitorID int64, start, end time.Time) (*LatencyStats, error) {
+	var stats *LatencyStats
+	if resolution := ResolutionFor(start, end, time.Now()); resolution == RawResolution {
+		times, err := s.healthCheckRepo.GetResponseTimes(monitorID, start, end)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get response times: %v", err)
+		}
+		stats = computeLatencyStats(times)
+	} else {
+		summary, err := s.rollupService.Summarize([]int64{monitorID}, start, end)
+		if err != nil {
+			return nil, err
+		}
+		stats = summaryLatencyStats(summary)
 	}
-	
-	successCount := 0
-	totalResponseTime := 0
-	
-	for _, check := range checks {
-		if check.Success {
-			successCount++
+
+	stats.MonitorID = monitorID
+	stats.From = start
+	stats.To = end
+	return stats, nil
+}
+
+// summaryLatencyStats builds latency stats out of a rolled up summary
+func summaryLatencyStats(summary *CheckSummary) *LatencyStats {
+	stats := &LatencyStats{
+		Resolution: summary.Resolution,
+		Count:      summary.LatencyCount,
+		Histogram:  make([]*HistogramBucket, 0, len(summary.Histogram)),
+	}
+
+	for i, count := range summary.Histogram {
+		bucket := &HistogramBucket{Count: count}
+		if i < len(LatencyBuckets) {
+			bucket.LeMs = &LatencyBuckets[i]
 		}
-		totalResponseTime += check.ResponseTimeMs
+		stats.Histogram = append(stats.Histogram, bucket)
+	}
+
+	if summary.LatencyCount == 0 {
+		return stats
+	}
+
+	stats.MinMs = summary.MinMs
+	stats.MaxMs = summary.MaxMs
+	stats.MeanMs = round(summary.AvgLatency(), 1)
+	stats.P50Ms = summary.Percentile(50)
+	stats.P90Ms = summary.Percentile(90)
+	stats.P95Ms = summary.Percentile(95)
+	stats.P99Ms = summary.Percentile(99)
+	return stats
+}
+
+// computeLatencyStats summarises response times sorted in ascending order
+func computeLatencyStats(sorted []int) *LatencyStats {
+	stats := &LatencyStats{
+		Resolution: RawResolution,
+		Count:      len(sorted),
+		Histogram:  latencyHistogram(sorted),
+	}
+	if len(sorted) == 0 {
+		return stats
+	}
+
+	total := 0
+	for _, ms := range sorted {
+		total += ms
+	}
+
+	stats.MinMs = sorted[0]
+	stats.MaxMs = sorted[len(sorted)-1]
+	stats.MeanMs = round(float64(total)/float64(len(sorted)), 1)
+	stats.P50Ms = percentile(sorted, 50)
+	stats.P90Ms = percentile(sorted, 90)
+	stats.P95Ms = percentile(sorted, 95)
+	stats.P99Ms = percentile(sorted, 99)
+	return stats
+}
+
+// percentile returns the nearest-rank percentile of sorted values
+func percentile(sorted []int, p float64) int {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// latencyHistogram counts sorted response times into LatencyBuckets
+func latencyHistogram(sorted []int) []*HistogramBucket {
+	buckets := make([]*HistogramBucket, 0, len(LatencyBuckets)+1)
+	for i := range LatencyBuckets {
+		buckets = append(buckets, &HistogramBucket{LeMs: &LatencyBuckets[i]})
+	}
+	buckets = append(buckets, &HistogramBucket{})
+
+	i := 0
+	for _, ms := range sorted {
+		for i < len(LatencyBuckets) && ms > LatencyBuckets[i] {
+			i++
+		}
+		buckets[i].Count++
+	}
+
+	return buckets
+}
+
+// calculateMetrics computes uptime percentage and average response time from
+// a summary of health checks. Only successful checks count towards the
+// response time.
+func calculateMetrics(summary *CheckSummary) (uptime float64, avgResponseTime float64) {
+	uptime, ok := summary.Uptime()
+	if !ok {
+		return 100.0, 0.0 // Default to 100% uptime if no data
 	}
 	
-	uptime = (float64(successCount) / float64(len(checks))) * 100
-	avgResponseTime = float64(totalResponseTime) / float64(len(checks))
-	
-	return uptime, avgResponseTime
+	return uptime, summary.AvgLatency()
 }
 
 // calculateDowntimeInfo creates a human-readable downtime string
-func (s *MetricsService) calculateDowntimeInfo(checks []*models.HealthCheck) string {
-	if len(checks) == 0 {
+func calculateDowntimeInfo(summary *CheckSummary) string {
+	if summary.Total == 0 {
 		return "No monitoring data available"
 	}
 	
-	// Calculate total downtime in the period
-	downtime := 0
-	for _, check := range checks {
-		if !check.Success {
-			// Each failed check represents ~1 minute of downtime (our check interval)
-			downtime += 60 // seconds
-		}
-	}
+	// Each failed check represents one check interval of downtime
+	downtime := (summary.Total - summary.Successes) * int(CheckInterval.Seconds())
 	
 	if downtime == 0 {
 		return "No downtime recorded"
@@ -165,11 +348,18 @@ func (s *MetricsService) calculateDowntimeInfo(checks []*models.HealthCheck) str
 	return fmt.Sprintf("Total downtime: %ds", remainingSeconds)
 }
 
+// percentChange returns the change from previous to current in percent, or
+// nil when there is nothing to compare with
+func percentChange(current, previous int64) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	change := round(float64(current-previous)/float64(previous)*100, 1)
+	return &change
+}
+
 // round rounds a float64 to the specified number of decimal places
 func round(value float64, decimals int) float64 {
-	precision := 1.0
-	for i := 0; i < decimals; i++ {
-		precision *= 10
-	}
-	return float64(int(value*precision+0.5)) / precision
+	precision := math.Pow(10, float64(decimals))
+	return math.Round(value*precision) / precision
 }
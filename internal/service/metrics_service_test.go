@@ -0,0 +1,65 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComputeLatencyStats(t *testing.T) {
+	sorted := make([]int, 100)
+	for i := range sorted {
+		sorted[i] = (i + 1) * 10 // 10ms .. 1000ms
+	}
+
+	stats := computeLatencyStats(sorted)
+
+	if stats.P50Ms != 500 || stats.P90Ms != 900 || stats.P95Ms != 950 || stats.P99Ms != 990 {
+		t.Errorf("unexpected percentiles: p50=%d p90=%d p95=%d p99=%d", stats.P50Ms, stats.P90Ms, stats.P95Ms, stats.P99Ms)
+	}
+
+	if stats.MinMs != 10 || stats.MaxMs != 1000 || stats.MeanMs != 505 {
+		t.Errorf("unexpected min/max/mean: %d/%d/%v", stats.MinMs, stats.MaxMs, stats.MeanMs)
+	}
+
+	// 50, 100, 200, 300, 500, 750, 1000, 2000, 5000, 10000, +Inf
+	want := []int{5, 5, 10, 10, 20, 25, 25, 0, 0, 0, 0}
+	for i, bucket := range stats.Histogram {
+		if bucket.Count != want[i] {
+			t.Errorf("bucket %d: got %d, want %d", i, bucket.Count, want[i])
+		}
+	}
+	if stats.Histogram[len(stats.Histogram)-1].LeMs != nil {
+		t.Error("last bucket should be unbounded")
+	}
+}
+
+func TestComputeLatencyStatsEmpty(t *testing.T) {
+	stats := computeLatencyStats(nil)
+
+	if stats.Count != 0 || stats.P99Ms != 0 {
+		t.Errorf("unexpected stats for no data: %+v", stats)
+	}
+
+	if len(stats.Histogram) != len(LatencyBuckets)+1 {
+		t.Errorf("unexpected number of buckets: %d", len(stats.Histogram))
+	}
+}
+
+func TestComparisonWindow(t *testing.T) {
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)
+
+	start, end, ok := ComparisonWindow(from, to, ComparePrevious)
+	if !ok || !start.Equal(time.Date(2025, 1, 30, 0, 0, 0, 0, time.UTC)) || !end.Equal(from) {
+		t.Errorf("unexpected previous window: %s - %s", start, end)
+	}
+
+	start, end, ok = ComparisonWindow(from, to, CompareYearAgo)
+	if !ok || !start.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected year ago window: %s - %s", start, end)
+	}
+
+	if _, _, ok := ComparisonWindow(from, to, CompareNone); ok {
+		t.Error("no comparison window expected for none")
+	}
+}
@@ -2,40 +2,52 @@ package service
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"log"
 	"net/http"
+	"sync"
 	"time"
 
 	"egide-server/internal/models"
 	"egide-server/internal/repository"
+	"egide-server/internal/telemetry"
 )
 
 const (
-	// URL to monitor - your personal blog protected by Egide
-	MonitorURL = "https://0xhenrique.neocities.org/"
-	
 	// Monitoring interval
 	CheckInterval = 60 * time.Second
 	
 	// Request timeout - anything above this is considered "down"
 	RequestTimeout = 10 * time.Second
-	
-	// Data retention period
-	DataRetention = 60 * 24 * time.Hour // 60 days
 )
 
 type MonitoringService struct {
 	healthCheckRepo *repository.HealthCheckRepository
+	monitorRepo     *repository.MonitorRepository
+	maintenance     *MaintenanceService
+	certificates    *CertificateService
+	content         *ContentService
 	httpClient      *http.Client
 	stopChan        chan struct{}
 }
 
-func NewMonitoringService(healthCheckRepo *repository.HealthCheckRepository) *MonitoringService {
+func NewMonitoringService(healthCheckRepo *repository.HealthCheckRepository, monitorRepo *repository.MonitorRepository, maintenance *MaintenanceService, certificates *CertificateService, content *ContentService) *MonitoringService {
+	// Monitors only reach public addresses. Certificates are verified by the
+	// certificate service, so that expired or untrusted ones are still
+	// recorded and reported.
+	transport := newOutboundTransport(RequestTimeout)
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+
 	return &MonitoringService{
 		healthCheckRepo: healthCheckRepo,
+		monitorRepo:     monitorRepo,
+		maintenance:     maintenance,
+		certificates:    certificates,
+		content:         content,
 		httpClient: &http.Client{
-			Timeout: RequestTimeout,
+			Timeout:   RequestTimeout,
+			Transport: transport,
 		},
 		stopChan: make(chan struct{}),
 	}
@@ -45,41 +57,24 @@ func NewMonitoringService(healthCheckRepo *repository.HealthCheckRepository) *Mo
 func (s *MonitoringService) Start() {
 	log.Println("Starting monitoring service...")
 	
-	// Run cleanup immediately on start
-	s.cleanup()
-	
 	// Start monitoring loop
 	ticker := time.NewTicker(CheckInterval)
 	go func() {
 		defer ticker.Stop()
 		
 		// Perform initial check
-		s.performHealthCheck()
+		s.performHealthChecks()
 		
 		for {
 			select {
 			case <-ticker.C:
-				s.performHealthCheck()
+				s.performHealthChecks()
 			case <-s.stopChan:
 				log.Println("Monitoring service stopped")
 				return
 			}
 		}
 	}()
-	
-	// Start cleanup routine (runs every 6 hours)
-	cleanupTicker := time.NewTicker(6 * time.Hour)
-	go func() {
-		defer cleanupTicker.Stop()
-		for {
-			select {
-			case <-cleanupTicker.C:
-				s.cleanup()
-			case <-s.stopChan:
-				return
-			}
-		}
-	}()
 }
 
 // Stop gracefully stops the monitoring service
@@ -87,13 +82,39 @@ func (s *MonitoringService) Stop() {
 	close(s.stopChan)
 }
 
+// performHealthChecks checks every enabled monitor concurrently
+func (s *MonitoringService) performHealthChecks() {
+	monitors, err := s.monitorRepo.FindEnabled()
+	if err != nil {
+		log.Printf("Failed to load monitors: %v", err)
+		return
+	}
+	
+	// Checks of sites under maintenance are tagged so they don't count against uptime
+	inMaintenance, err := s.maintenance.SitesInMaintenance(time.Now())
+	if err != nil {
+		log.Printf("Failed to load maintenance windows: %v", err)
+		inMaintenance = map[int64]bool{}
+	}
+	
+	var wg sync.WaitGroup
+	for _, monitor := range monitors {
+		wg.Add(1)
+		go func(monitor *models.Monitor) {
+			defer wg.Done()
+			s.performHealthCheck(monitor, inMaintenance[monitor.SiteID])
+		}(monitor)
+	}
+	wg.Wait()
+}
+
 // performHealthCheck executes a single health check
-func (s *MonitoringService) performHealthCheck() {
+func (s *MonitoringService) performHealthCheck(monitor *models.Monitor, maintenance bool) {
 	start := time.Now()
 	
-	req, err := http.NewRequestWithContext(context.Background(), "GET", MonitorURL, nil)
+	req, err := http.NewRequestWithContext(context.Background(), "GET", monitor.URL, nil)
 	if err != nil {
-		s.recordFailure(start, nil, fmt.Sprintf("Failed to create request: %v", err))
+		s.recordFailure(monitor, maintenance, start, nil, fmt.Sprintf("Failed to create request: %v", err))
 		return
 	}
 	
@@ -104,19 +125,36 @@ func (s *MonitoringService) performHealthCheck() {
 	responseTime := time.Since(start)
 	
 	if err != nil {
-		s.recordFailure(start, nil, fmt.Sprintf("Request failed: %v", err))
+		telemetry.ObserveCheck(telemetry.CheckError, responseTime)
+		s.recordFailure(monitor, maintenance, start, nil, fmt.Sprintf("Request failed: %v", err))
 		return
 	}
 	defer resp.Body.Close()
 	
+	// Keep track of the certificate of HTTPS endpoints, which fails the check when it isn't trusted
+	if resp.TLS != nil {
+		if err := s.certificates.Record(monitor, resp.Request.URL.Hostname(), resp.TLS.PeerCertificates, start); err != nil {
+			telemetry.ObserveCheck(telemetry.CheckError, responseTime)
+			s.recordFailure(monitor, maintenance, start, &resp.StatusCode, fmt.Sprintf("Invalid certificate: %v", err))
+			return
+		}
+	}
+	
 	// Consider 5xx responses as failures
 	success := resp.StatusCode < 500
+	if success {
+		telemetry.ObserveCheck(telemetry.CheckUp, responseTime)
+	} else {
+		telemetry.ObserveCheck(telemetry.CheckDown, responseTime)
+	}
 	
 	check := &models.HealthCheck{
+		MonitorID:      &monitor.ID,
 		Timestamp:      start,
 		ResponseTimeMs: int(responseTime.Milliseconds()),
 		StatusCode:     &resp.StatusCode,
 		Success:        success,
+		Maintenance:    maintenance,
 	}
 	
 	if !success {
@@ -124,6 +162,11 @@ func (s *MonitoringService) performHealthCheck() {
 		check.Error = &errorMsg
 	}
 	
+	// Content legitimately changes during maintenance, e.g. deployments
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 && !maintenance {
+		s.content.Inspect(monitor, resp.Body, start)
+	}
+	
 	_, err = s.healthCheckRepo.Create(check)
 	if err != nil {
 		log.Printf("Failed to save health check: %v", err)
@@ -131,20 +174,22 @@ func (s *MonitoringService) performHealthCheck() {
 	}
 	
 	if success {
-		log.Printf("Health check OK: %dms (HTTP %d)", check.ResponseTimeMs, resp.StatusCode)
+		log.Printf("Health check OK [%s]: %dms (HTTP %d)", monitor.URL, check.ResponseTimeMs, resp.StatusCode)
 	} else {
-		log.Printf("Health check FAILED: %dms (HTTP %d)", check.ResponseTimeMs, resp.StatusCode)
+		log.Printf("Health check FAILED [%s]: %dms (HTTP %d)", monitor.URL, check.ResponseTimeMs, resp.StatusCode)
 	}
 }
 
 // recordFailure records a failed health check
-func (s *MonitoringService) recordFailure(timestamp time.Time, statusCode *int, errorMsg string) {
+func (s *MonitoringService) recordFailure(monitor *models.Monitor, maintenance bool, timestamp time.Time, statusCode *int, errorMsg string) {
 	check := &models.HealthCheck{
+		MonitorID:      &monitor.ID,
 		Timestamp:      timestamp,
 		ResponseTimeMs: int(RequestTimeout.Milliseconds()), // Use timeout as response time for failures
 		StatusCode:     statusCode,
 		Success:        false,
 		Error:          &errorMsg,
+		Maintenance:    maintenance,
 	}
 	
 	_, err := s.healthCheckRepo.Create(check)
@@ -153,18 +198,6 @@ func (s *MonitoringService) recordFailure(timestamp time.Time, statusCode *int,
 		return
 	}
 	
-	log.Printf("Health check FAILED: %s", errorMsg)
+	log.Printf("Health check FAILED [%s]: %s", monitor.URL, errorMsg)
 }
 
-// cleanup removes old health check data
-func (s *MonitoringService) cleanup() {
-	log.Println("Running health check data cleanup...")
-	
-	err := s.healthCheckRepo.DeleteOldChecks(DataRetention)
-	if err != nil {
-		log.Printf("Failed to cleanup old health checks: %v", err)
-		return
-	}
-	
-	log.Println("Health check cleanup completed")
-}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"egide-server/internal/config"
+	"egide-server/internal/models"
+)
+
+// Notification is the channel-agnostic content of an alert message
+type Notification struct {
+	Title   string                `json:"title"`
+	Message string                `json:"message"`
+	Status  models.IncidentStatus `json:"status"`
+	RuleID  int64                 `json:"rule_id,omitempty"`
+	Time    time.Time             `json:"time"`
+}
+
+// NotificationService delivers notifications through the supported channel types
+type NotificationService struct {
+	config     *config.Config
+	httpClient *http.Client
+}
+
+// NewNotificationService creates a new notification service
+func NewNotificationService(cfg *config.Config) *NotificationService {
+	return &NotificationService{
+		config:     cfg,
+		httpClient: newOutboundClient(10 * time.Second),
+	}
+}
+
+// Send delivers a notification through the given channel
+func (s *NotificationService) Send(channel *models.NotificationChannel, n *Notification) error {
+	switch channel.Type {
+	case models.WebhookChannel:
+		return s.postJSON(channel.Target, n)
+	case models.SlackChannel, models.MatrixChannel:
+		// Matrix bridges such as hookshot accept the Slack payload format
+		return s.postJSON(channel.Target, map[string]string{
+			"text":     formatNotification(n),
+			"username": "Egide",
+		})
+	case models.DiscordChannel:
+		return s.postJSON(channel.Target, map[string]string{
+			"content":  formatNotification(n),
+			"username": "Egide",
+		})
+	case models.EmailChannel:
+		return s.sendEmail(channel.Target, n)
+	default:
+		return fmt.Errorf("unsupported channel type: %s", channel.Type)
+	}
+}
+
+func (s *NotificationService) postJSON(url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Egide-Notifier/1.0")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned HTTP %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// SendEmail sends a notification to an email address outside of any channel,
+// e.g. to verify the address
+func (s *NotificationService) SendEmail(to string, n *Notification) error {
+	return s.sendEmail(to, n)
+}
+
+func (s *NotificationService) sendEmail(to string, n *Notification) error {
+	smtpConfig := s.config.SMTP
+	if smtpConfig.Host == "" {
+		return errors.New("SMTP is not configured")
+	}
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", smtpConfig.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", encodeHeader(n.Title))
+	fmt.Fprintf(&msg, "Date: %s\r\n", n.Time.Format(time.RFC1123Z))
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(n.Message)
+	msg.WriteString("\r\n")
+
+	var smtpAuth smtp.Auth
+	if smtpConfig.Username != "" {
+		smtpAuth = smtp.PlainAuth("", smtpConfig.Username, smtpConfig.Password, smtpConfig.Host)
+	}
+
+	addr := fmt.Sprintf("%s:%d", smtpConfig.Host, smtpConfig.Port)
+	return smtp.SendMail(addr, smtpAuth, smtpConfig.From, []string{to}, []byte(msg.String()))
+}
+
+// encodeHeader makes a value such as a title that embeds user input safe for
+// a mail header: line breaks can't start new headers and non-ASCII text is
+// encoded as RFC 2047 words
+func encodeHeader(value string) string {
+	value = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ").Replace(value)
+	return mime.QEncoding.Encode("utf-8", value)
+}
+
+// formatNotification renders a notification as a single chat message
+func formatNotification(n *Notification) string {
+	return fmt.Sprintf("[%s] %s\n%s", strings.ToUpper(string(n.Status)), n.Title, n.Message)
+}
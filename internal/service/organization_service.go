@@ -0,0 +1,162 @@
+package service
+
+import (
+	"errors"
+
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+)
+
+var (
+	// ErrAlreadyMember is returned when a member accepts an invitation to their organization
+	ErrAlreadyMember = errors.New("user is already a member of the organization")
+
+	// ErrMemberNotFound is returned for users outside of an organization
+	ErrMemberNotFound = errors.New("member not found")
+
+	// ErrOwnerRequired is returned when a non-owner grants, changes or removes an owner
+	ErrOwnerRequired = errors.New("only owners can manage owners")
+
+	// ErrLastOwner is returned when an organization would be left without owner
+	ErrLastOwner = errors.New("an organization needs at least one owner")
+
+	// ErrOrganizationHasSites is returned when deleting an organization still owning sites
+	ErrOrganizationHasSites = errors.New("organization still owns sites")
+)
+
+// OrganizationService manages organizations and their members. Callers check
+// with the authorizer that the acting user may perform the operation, the
+// service enforces the rules about owners.
+type OrganizationService struct {
+	organizationRepo *repository.OrganizationRepository
+	twoFactorRepo    *repository.TwoFactorRepository
+}
+
+// NewOrganizationService creates a new organization service
+func NewOrganizationService(
+	organizationRepo *repository.OrganizationRepository,
+	twoFactorRepo *repository.TwoFactorRepository,
+) *OrganizationService {
+	return &OrganizationService{
+		organizationRepo: organizationRepo,
+		twoFactorRepo:    twoFactorRepo,
+	}
+}
+
+// Create creates an organization owned by the user
+func (s *OrganizationService) Create(userID int64, input *models.OrganizationInput) (*models.Organization, error) {
+	org := &models.Organization{Name: input.Name}
+	if input.RequireTwoFactor != nil && *input.RequireTwoFactor {
+		if err := s.checkTwoFactorEnabled(userID); err != nil {
+			return nil, err
+		}
+		org.RequireTwoFactor = true
+	}
+
+	id, err := s.organizationRepo.Create(org, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	org, err = s.organizationRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	org.Role = models.RoleOwner
+	return org, nil
+}
+
+// Update renames an organization and changes its two-factor requirement.
+// Requiring two-factor authentication takes the user to have it enabled, so
+// that they don't lock themselves out.
+func (s *OrganizationService) Update(userID int64, org *models.Organization, input *models.OrganizationInput) error {
+	org.Name = input.Name
+	if input.RequireTwoFactor != nil {
+		if *input.RequireTwoFactor && !org.RequireTwoFactor {
+			if err := s.checkTwoFactorEnabled(userID); err != nil {
+				return err
+			}
+		}
+		org.RequireTwoFactor = *input.RequireTwoFactor
+	}
+
+	return s.organizationRepo.Update(org)
+}
+
+// Delete removes an organization, which mustn't own sites anymore
+func (s *OrganizationService) Delete(org *models.Organization) error {
+	count, err := s.organizationRepo.CountSites(org.ID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrOrganizationHasSites
+	}
+
+	return s.organizationRepo.Delete(org.ID)
+}
+
+// UpdateMemberRole changes the role of a member. org.Role is the role of the
+// acting user: only owners grant or take away the owner role, and the last
+// owner can't be demoted.
+func (s *OrganizationService) UpdateMemberRole(org *models.Organization, userID int64, role models.Role) (*models.OrganizationMember, error) {
+	member, err := s.organizationRepo.FindMember(org.ID, userID)
+	if err != nil {
+		return nil, ErrMemberNotFound
+	}
+
+	if (member.Role == models.RoleOwner || role == models.RoleOwner) && org.Role != models.RoleOwner {
+		return nil, ErrOwnerRequired
+	}
+	if member.Role == models.RoleOwner && role != models.RoleOwner {
+		if err := s.checkNotLastOwner(org.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.organizationRepo.UpdateMemberRole(org.ID, userID, role); err != nil {
+		return nil, err
+	}
+	member.Role = role
+	return member, nil
+}
+
+// RemoveMember removes a user from an organization. org.Role is the role of
+// the acting user: only owners remove owners, and the last owner can't
+// leave.
+func (s *OrganizationService) RemoveMember(org *models.Organization, userID int64) error {
+	member, err := s.organizationRepo.FindMember(org.ID, userID)
+	if err != nil {
+		return ErrMemberNotFound
+	}
+
+	if member.Role == models.RoleOwner {
+		if org.Role != models.RoleOwner {
+			return ErrOwnerRequired
+		}
+		if err := s.checkNotLastOwner(org.ID); err != nil {
+			return err
+		}
+	}
+
+	return s.organizationRepo.RemoveMember(org.ID, userID)
+}
+
+func (s *OrganizationService) checkNotLastOwner(orgID int64) error {
+	count, err := s.organizationRepo.CountOwners(orgID)
+	if err != nil {
+		return err
+	}
+	if count <= 1 {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+func (s *OrganizationService) checkTwoFactorEnabled(userID int64) error {
+	twoFactor, err := s.twoFactorRepo.FindByUserID(userID)
+	if err != nil || twoFactor.EnabledAt == nil {
+		return ErrTwoFactorNotEnabled
+	}
+	return nil
+}
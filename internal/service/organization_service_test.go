@@ -0,0 +1,79 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+
+	"egide-server/internal/migration"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+)
+
+func TestOrganizationOwners(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	if err := migration.RunMigrations(db, "../../migrations"); err != nil {
+		t.Fatal(err)
+	}
+
+	ownerID := insert(t, db, `INSERT INTO users (username) VALUES ('owner')`)
+	adminID := insert(t, db, `INSERT INTO users (username) VALUES ('admin')`)
+	organizationRepo := repository.NewOrganizationRepository(db)
+	organizations := NewOrganizationService(organizationRepo, repository.NewTwoFactorRepository(db))
+
+	// Requiring two-factor authentication takes having it
+	enabled := true
+	if _, err := organizations.Create(ownerID, &models.OrganizationInput{Name: "Team", RequireTwoFactor: &enabled}); err != ErrTwoFactorNotEnabled {
+		t.Errorf("Create() requiring two-factor = %v; want %v", err, ErrTwoFactorNotEnabled)
+	}
+
+	org, err := organizations.Create(ownerID, &models.OrganizationInput{Name: "Team"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if org.Role != models.RoleOwner {
+		t.Errorf("creator role = %q; want owner", org.Role)
+	}
+
+	// Members join through invitations
+	if err := organizationRepo.AddMember(&models.OrganizationMember{OrganizationID: org.ID, UserID: adminID, Role: models.RoleAdmin}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Admins don't touch owners
+	asAdmin := *org
+	asAdmin.Role = models.RoleAdmin
+	if _, err := organizations.UpdateMemberRole(&asAdmin, adminID, models.RoleOwner); err != ErrOwnerRequired {
+		t.Errorf("admin promoting to owner = %v; want %v", err, ErrOwnerRequired)
+	}
+	if err := organizations.RemoveMember(&asAdmin, ownerID); err != ErrOwnerRequired {
+		t.Errorf("admin removing owner = %v; want %v", err, ErrOwnerRequired)
+	}
+
+	// The last owner can't leave nor be demoted
+	if _, err := organizations.UpdateMemberRole(org, ownerID, models.RoleAdmin); err != ErrLastOwner {
+		t.Errorf("demoting last owner = %v; want %v", err, ErrLastOwner)
+	}
+	if err := organizations.RemoveMember(org, ownerID); err != ErrLastOwner {
+		t.Errorf("removing last owner = %v; want %v", err, ErrLastOwner)
+	}
+
+	if _, err := organizations.UpdateMemberRole(org, adminID, models.RoleOwner); err != nil {
+		t.Fatal(err)
+	}
+	if err := organizations.RemoveMember(org, ownerID); err != nil {
+		t.Errorf("leaving with another owner = %v", err)
+	}
+
+	// Organizations owning sites can't be deleted
+	siteID := insert(t, db, `INSERT INTO sites (user_id, domain, protection_mode, active, verified, created_at, updated_at) VALUES (?, 'team.com', 'simple', 0, 0, datetime('now'), datetime('now'))`, adminID)
+	insert(t, db, `INSERT INTO organization_sites (site_id, organization_id) VALUES (?, ?)`, siteID, org.ID)
+	if err := organizations.Delete(org); err != ErrOrganizationHasSites {
+		t.Errorf("Delete() with sites = %v; want %v", err, ErrOrganizationHasSites)
+	}
+}
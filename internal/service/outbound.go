@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateAddress is returned for webhook, notification and monitor targets that
+// resolve to the loopback, private, link-local or otherwise internal ranges
+var ErrPrivateAddress = errors.New("target must resolve to a public address")
+
+// Ranges not covered by the net.IP predicates
+var internalNetworks = []*net.IPNet{
+	mustParseCIDR("0.0.0.0/8"),     // "this" network
+	mustParseCIDR("100.64.0.0/10"), // carrier-grade NAT, used by some cloud metadata services
+	mustParseCIDR("192.0.0.0/24"),  // IETF protocol assignments
+	mustParseCIDR("198.18.0.0/15"), // benchmarking
+	mustParseCIDR("240.0.0.0/4"),   // reserved, including broadcast
+	mustParseCIDR("64:ff9b::/96"),  // NAT64 of IPv4 addresses
+}
+
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return network
+}
+
+// isPublicIP reports whether an address may be reached by outgoing requests.
+// Link-local addresses include the 169.254.169.254 metadata endpoint.
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, network := range internalNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckPublicURL checks that an HTTP(S) URL only resolves to public addresses,
+// so that users can't point webhooks at the internal network
+func CheckPublicURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported scheme: %s", u.Scheme)
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", u.Hostname(), err)
+	}
+	for _, addr := range addrs {
+		if !isPublicIP(addr.IP) {
+			return ErrPrivateAddress
+		}
+	}
+	return nil
+}
+
+// checkPublicAddress refuses connections to internal addresses. It runs on
+// the resolved address of every dial, redirects included, so a hostname
+// can't be rebound to an internal address after CheckPublicURL.
+func checkPublicAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+		return ErrPrivateAddress
+	}
+	return nil
+}
+
+// newOutboundClient returns an HTTP client for requests to user-supplied URLs
+func newOutboundClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: newOutboundTransport(timeout),
+	}
+}
+
+// newOutboundTransport returns a transport only connecting to public
+// addresses. Proxies from the environment are ignored since they would
+// connect on our behalf, past the check.
+func newOutboundTransport(timeout time.Duration) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: checkPublicAddress,
+	}
+
+	return &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: timeout,
+		MaxIdleConns:        100,
+		IdleConnTimeout:     90 * time.Second,
+	}
+}
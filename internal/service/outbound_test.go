@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCheckPublicURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://93.184.216.34/hook", true},
+		{"https://[2606:2800:220:1:248:1893:25c8:1946]/hook", true},
+		{"http://169.254.169.254/latest/meta-data/", false},
+		{"http://127.0.0.1:8080/", false},
+		{"http://localhost/", false},
+		{"http://10.0.0.5/", false},
+		{"http://192.168.1.1/", false},
+		{"http://100.100.100.200/", false},
+		{"http://0.0.0.0/", false},
+		{"http://[::1]/", false},
+		{"http://[fe80::1]/", false},
+		{"http://[fd00::1]/", false},
+		{"http://[::ffff:127.0.0.1]/", false},
+		{"ftp://93.184.216.34/", false},
+	}
+
+	for _, tt := range tests {
+		err := CheckPublicURL(context.Background(), tt.url)
+		if (err == nil) != tt.want {
+			t.Errorf("CheckPublicURL(%s) = %v; want allowed=%v", tt.url, err, tt.want)
+		}
+	}
+}
+
+func TestOutboundClientRefusesInternalAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	_, err := newOutboundClient(time.Second).Post(server.URL, "application/json", nil)
+	if !errors.Is(err, ErrPrivateAddress) {
+		t.Errorf("request to %s = %v; want %v", server.URL, err, ErrPrivateAddress)
+	}
+}
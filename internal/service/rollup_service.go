@@ -0,0 +1,520 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+)
+
+const (
+	// RollupInterval is how often new raw checks are rolled up
+	RollupInterval = 15 * time.Minute
+
+	// RawDataRetention is how long individual health checks are kept
+	RawDataRetention = 30 * 24 * time.Hour
+
+	// HourlyRollupRetention is how long hourly rollups are kept
+	HourlyRollupRetention = 90 * 24 * time.Hour
+
+	// DailyRollupRetention is how long daily rollups are kept
+	DailyRollupRetention = 2 * 365 * 24 * time.Hour
+
+	// MaxRawQueryRange is the longest range served from raw checks, longer
+	// ranges are served from rollups
+	MaxRawQueryRange = 2 * 24 * time.Hour
+
+	// MaxHourlyQueryRange is the longest range served from hourly rollups
+	MaxHourlyQueryRange = 90 * 24 * time.Hour
+)
+
+// RawResolution means a query is answered from individual health checks
+const RawResolution models.RollupResolution = "raw"
+
+// CheckSummary aggregates health checks over a range, whatever resolution
+// they were read at
+type CheckSummary struct {
+	Resolution   models.RollupResolution
+	Total        int // checks outside maintenance
+	Successes    int
+	Maintenance  int
+	LatencyCount int // successful checks
+	MinMs        int
+	MaxMs        int
+	LatencySum   float64
+	Histogram    []int // counts per LatencyBuckets, plus the unbounded bucket
+}
+
+// Uptime returns the percentage of successful checks, or false without data
+func (c *CheckSummary) Uptime() (float64, bool) {
+	if c.Total == 0 {
+		return 0, false
+	}
+	return float64(c.Successes) / float64(c.Total) * 100, true
+}
+
+// AvgLatency returns the mean response time of successful checks
+func (c *CheckSummary) AvgLatency() float64 {
+	if c.LatencyCount == 0 {
+		return 0
+	}
+	return c.LatencySum / float64(c.LatencyCount)
+}
+
+// Percentile estimates a latency percentile from the histogram, interpolating
+// linearly inside the bucket holding the requested rank
+func (c *CheckSummary) Percentile(p float64) int {
+	if c.LatencyCount == 0 {
+		return 0
+	}
+
+	rank := p / 100 * float64(c.LatencyCount)
+	seen := 0
+	for i, count := range c.Histogram {
+		if count == 0 || float64(seen+count) < rank {
+			seen += count
+			continue
+		}
+
+		lower, upper := c.MinMs, c.MaxMs
+		if i > 0 && LatencyBuckets[i-1] > lower {
+			lower = LatencyBuckets[i-1]
+		}
+		if i < len(LatencyBuckets) && LatencyBuckets[i] < upper {
+			upper = LatencyBuckets[i]
+		}
+
+		value := float64(lower) + (rank-float64(seen))/float64(count)*float64(upper-lower)
+		return int(value + 0.5)
+	}
+
+	return c.MaxMs
+}
+
+// addCheck adds a raw health check to the summary
+func (c *CheckSummary) addCheck(check *models.HealthCheck) {
+	if check.Maintenance {
+		c.Maintenance++
+	} else {
+		c.Total++
+		if check.Success {
+			c.Successes++
+		}
+	}
+
+	if check.Success {
+		c.addLatency(check.ResponseTimeMs)
+	}
+}
+
+func (c *CheckSummary) addLatency(ms int) {
+	if c.LatencyCount == 0 || ms < c.MinMs {
+		c.MinMs = ms
+	}
+	if ms > c.MaxMs {
+		c.MaxMs = ms
+	}
+	c.LatencyCount++
+	c.LatencySum += float64(ms)
+	c.Histogram[latencyBucket(ms)]++
+}
+
+// addRollup merges a rollup into the summary
+func (c *CheckSummary) addRollup(rollup *models.HealthCheckRollup) {
+	c.Total += rollup.Total
+	c.Successes += rollup.Successes
+	c.Maintenance += rollup.Maintenance
+
+	if rollup.LatencyCount == 0 {
+		return
+	}
+	if c.LatencyCount == 0 || rollup.MinMs < c.MinMs {
+		c.MinMs = rollup.MinMs
+	}
+	if rollup.MaxMs > c.MaxMs {
+		c.MaxMs = rollup.MaxMs
+	}
+	c.LatencyCount += rollup.LatencyCount
+	c.LatencySum += rollup.AvgMs * float64(rollup.LatencyCount)
+	for i, count := range rollup.Histogram {
+		if i < len(c.Histogram) {
+			c.Histogram[i] += count
+		}
+	}
+}
+
+// rollup turns the summary into a rollup of one bucket
+func (c *CheckSummary) rollup(monitorID int64, resolution models.RollupResolution, bucket time.Time) *models.HealthCheckRollup {
+	return &models.HealthCheckRollup{
+		MonitorID:    monitorID,
+		Resolution:   resolution,
+		BucketStart:  bucket,
+		Total:        c.Total,
+		Successes:    c.Successes,
+		Maintenance:  c.Maintenance,
+		LatencyCount: c.LatencyCount,
+		MinMs:        c.MinMs,
+		AvgMs:        round(c.AvgLatency(), 1),
+		MaxMs:        c.MaxMs,
+		P50Ms:        c.Percentile(50),
+		P95Ms:        c.Percentile(95),
+		P99Ms:        c.Percentile(99),
+		Histogram:    c.Histogram,
+	}
+}
+
+func newCheckSummary(resolution models.RollupResolution) *CheckSummary {
+	return &CheckSummary{
+		Resolution: resolution,
+		Histogram:  make([]int, len(LatencyBuckets)+1),
+	}
+}
+
+// latencyBucket returns the index of the histogram bucket holding ms
+func latencyBucket(ms int) int {
+	for i, bound := range LatencyBuckets {
+		if ms <= bound {
+			return i
+		}
+	}
+	return len(LatencyBuckets)
+}
+
+// RollupService aggregates raw health checks into hourly and daily rollups,
+// enforces retention and answers range queries at the right resolution
+type RollupService struct {
+	healthCheckRepo *repository.HealthCheckRepository
+	rollupRepo      *repository.RollupRepository
+	stopChan        chan struct{}
+}
+
+// NewRollupService creates a new rollup service
+func NewRollupService(healthCheckRepo *repository.HealthCheckRepository, rollupRepo *repository.RollupRepository) *RollupService {
+	return &RollupService{
+		healthCheckRepo: healthCheckRepo,
+		rollupRepo:      rollupRepo,
+		stopChan:        make(chan struct{}),
+	}
+}
+
+// Start begins rolling up health checks in the background
+func (s *RollupService) Start() {
+	log.Println("Starting rollup service...")
+
+	ticker := time.NewTicker(RollupInterval)
+	go func() {
+		defer ticker.Stop()
+
+		s.run(time.Now())
+
+		for {
+			select {
+			case <-ticker.C:
+				s.run(time.Now())
+			case <-s.stopChan:
+				log.Println("Rollup service stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop gracefully stops the rollup service
+func (s *RollupService) Stop() {
+	close(s.stopChan)
+}
+
+func (s *RollupService) run(now time.Time) {
+	if err := s.RollupHours(now); err != nil {
+		log.Printf("Failed to roll up hourly health checks: %v", err)
+		return
+	}
+
+	if err := s.RollupDays(now); err != nil {
+		log.Printf("Failed to roll up daily health checks: %v", err)
+		return
+	}
+
+	if err := s.cleanup(now); err != nil {
+		log.Printf("Failed to cleanup old health checks: %v", err)
+	}
+}
+
+// RollupHours aggregates every complete hour that wasn't rolled up yet.
+// An hour is complete once the slowest check started in it has finished.
+func (s *RollupService) RollupHours(now time.Time) error {
+	from, err := s.nextBucket(models.HourlyRollup, now.Add(-RawDataRetention))
+	if err != nil {
+		return err
+	}
+
+	complete := now.Add(-RequestTimeout).UTC()
+	for hour := from; !hour.Add(time.Hour).After(complete); hour = hour.Add(time.Hour) {
+		checks, err := s.healthCheckRepo.GetAllChecksInRange(hour, hour.Add(time.Hour))
+		if err != nil {
+			return err
+		}
+
+		summaries := make(map[int64]*CheckSummary)
+		for _, check := range checks {
+			if check.MonitorID == nil {
+				continue
+			}
+			summary, ok := summaries[*check.MonitorID]
+			if !ok {
+				summary = newCheckSummary(models.HourlyRollup)
+				summaries[*check.MonitorID] = summary
+			}
+			summary.addCheck(check)
+		}
+
+		for monitorID, summary := range summaries {
+			rollup := summary.rollup(monitorID, models.HourlyRollup, hour)
+
+			// Hourly percentiles are exact, daily ones come from the histogram
+			times := successfulLatencies(checks, monitorID)
+			rollup.P50Ms = percentile(times, 50)
+			rollup.P95Ms = percentile(times, 95)
+			rollup.P99Ms = percentile(times, 99)
+
+			if err := s.rollupRepo.Save(rollup); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// RollupDays aggregates the hourly rollups of every complete UTC day that
+// wasn't rolled up yet
+func (s *RollupService) RollupDays(now time.Time) error {
+	lastHour, ok, err := s.rollupRepo.LatestBucket(models.HourlyRollup)
+	if err != nil || !ok {
+		return err
+	}
+
+	from, err := s.nextBucket(models.DailyRollup, now.Add(-HourlyRollupRetention))
+	if err != nil {
+		return err
+	}
+
+	complete := lastHour.Add(time.Hour)
+	for day := from; !day.AddDate(0, 0, 1).After(complete); day = day.AddDate(0, 0, 1) {
+		hours, err := s.rollupRepo.FindAllInRange(models.HourlyRollup, day, day.AddDate(0, 0, 1))
+		if err != nil {
+			return err
+		}
+
+		summaries := make(map[int64]*CheckSummary)
+		for _, hour := range hours {
+			summary, ok := summaries[hour.MonitorID]
+			if !ok {
+				summary = newCheckSummary(models.DailyRollup)
+				summaries[hour.MonitorID] = summary
+			}
+			summary.addRollup(hour)
+		}
+
+		for monitorID, summary := range summaries {
+			if err := s.rollupRepo.Save(summary.rollup(monitorID, models.DailyRollup, day)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// nextBucket returns the first bucket of a resolution that wasn't rolled up
+// yet, starting at the bucket holding fallback when nothing was
+func (s *RollupService) nextBucket(resolution models.RollupResolution, fallback time.Time) (time.Time, error) {
+	latest, ok, err := s.rollupRepo.LatestBucket(resolution)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if !ok {
+		return bucketStart(resolution, fallback), nil
+	}
+	return bucketEnd(resolution, latest), nil
+}
+
+// cleanup enforces retention. Raw checks and hourly rollups are only deleted
+// once they are covered by the next resolution, or past retention when
+// nothing was rolled up yet: rolling up starts at the retention limit and
+// will never cover them.
+func (s *RollupService) cleanup(now time.Time) error {
+	rawCutoff := now.Add(-RawDataRetention)
+	if lastHour, ok, err := s.rollupRepo.LatestBucket(models.HourlyRollup); err != nil {
+		return err
+	} else if ok && lastHour.Before(rawCutoff) {
+		rawCutoff = lastHour
+	}
+
+	hourlyCutoff := now.Add(-HourlyRollupRetention)
+	if lastDay, ok, err := s.rollupRepo.LatestBucket(models.DailyRollup); err != nil {
+		return err
+	} else if ok && lastDay.Before(hourlyCutoff) {
+		hourlyCutoff = lastDay
+	}
+
+	if err := s.healthCheckRepo.DeleteChecksBefore(rawCutoff); err != nil {
+		return err
+	}
+	if err := s.rollupRepo.DeleteBefore(models.HourlyRollup, hourlyCutoff); err != nil {
+		return err
+	}
+	return s.rollupRepo.DeleteBefore(models.DailyRollup, now.Add(-DailyRollupRetention))
+}
+
+// ResolutionFor picks the resolution a range is answered at: raw checks for
+// short recent ranges, hourly rollups up to MaxHourlyQueryRange and daily
+// rollups beyond
+func ResolutionFor(start, end, now time.Time) models.RollupResolution {
+	length := end.Sub(start)
+
+	switch {
+	case length <= MaxRawQueryRange && !start.Before(now.Add(-RawDataRetention)):
+		return RawResolution
+	case length <= MaxHourlyQueryRange && !start.Before(now.Add(-HourlyRollupRetention)):
+		return models.HourlyRollup
+	default:
+		return models.DailyRollup
+	}
+}
+
+// Summarize aggregates the checks of the given monitors within [start, end).
+// Rollups cover the range up to the last rolled up bucket and raw checks
+// cover what's more recent.
+func (s *RollupService) Summarize(monitorIDs []int64, start, end time.Time) (*CheckSummary, error) {
+	resolution := ResolutionFor(start, end, time.Now())
+	summary := newCheckSummary(resolution)
+
+	// Coarser buckets first, each one handing the edges of the range over to the next finer one
+	tiers := []models.RollupResolution{}
+	switch resolution {
+	case models.DailyRollup:
+		tiers = append(tiers, models.DailyRollup, models.HourlyRollup)
+	case models.HourlyRollup:
+		tiers = append(tiers, models.HourlyRollup)
+	}
+
+	if err := s.summarize(summary, tiers, monitorIDs, start, end); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// summarize adds the buckets of the first tier lying within [start, end) to
+// the summary, and covers the partial buckets at both ends and what wasn't
+// rolled up yet with the next tiers, raw checks last. Checks outside the
+// range are never counted, but the edges lose what finer data retention
+// already deleted.
+func (s *RollupService) summarize(summary *CheckSummary, tiers []models.RollupResolution, monitorIDs []int64, start, end time.Time) error {
+	if !start.Before(end) {
+		return nil
+	}
+
+	if len(tiers) == 0 {
+		checks, err := s.healthCheckRepo.GetChecksInRange(monitorIDs, start, end)
+		if err != nil {
+			return fmt.Errorf("failed to get health checks: %v", err)
+		}
+		for _, check := range checks {
+			summary.addCheck(check)
+		}
+		return nil
+	}
+
+	tier := tiers[0]
+	latest, ok, err := s.rollupRepo.LatestBucket(tier)
+	if err != nil {
+		return fmt.Errorf("failed to get latest %s rollup: %v", tier, err)
+	}
+	if !ok {
+		return s.summarize(summary, tiers[1:], monitorIDs, start, end)
+	}
+
+	// Whole buckets only
+	from := bucketStart(tier, start)
+	if from.Before(start) {
+		from = bucketEnd(tier, from)
+	}
+	until := bucketStart(tier, end)
+	if rolledUp := bucketEnd(tier, latest); rolledUp.Before(until) {
+		until = rolledUp
+	}
+	if !from.Before(until) {
+		return s.summarize(summary, tiers[1:], monitorIDs, start, end)
+	}
+
+	rollups, err := s.rollupRepo.FindInRange(tier, monitorIDs, from, until)
+	if err != nil {
+		return fmt.Errorf("failed to get %s rollups: %v", tier, err)
+	}
+	for _, rollup := range rollups {
+		summary.addRollup(rollup)
+	}
+
+	if err := s.summarize(summary, tiers[1:], monitorIDs, start, from); err != nil {
+		return err
+	}
+	return s.summarize(summary, tiers[1:], monitorIDs, until, end)
+}
+
+// GetDailyStats returns the per-day check counts of a monitor since a day,
+// from daily rollups where available and raw checks for the recent days
+func (s *RollupService) GetDailyStats(monitorID int64, since time.Time) ([]*models.DailyCheckStats, error) {
+	rolled, err := s.rollupRepo.GetDailyStats(monitorID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := s.healthCheckRepo.GetDailyStats(monitorID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	// Raw checks of old days may already be partly deleted, rollups win
+	days := make(map[string]bool, len(rolled))
+	for _, day := range rolled {
+		days[day.Day] = true
+	}
+	for _, day := range raw {
+		if !days[day.Day] {
+			rolled = append(rolled, day)
+		}
+	}
+
+	return rolled, nil
+}
+
+func successfulLatencies(checks []*models.HealthCheck, monitorID int64) []int {
+	var times []int
+	for _, check := range checks {
+		if check.Success && check.MonitorID != nil && *check.MonitorID == monitorID {
+			times = append(times, check.ResponseTimeMs)
+		}
+	}
+	sort.Ints(times)
+	return times
+}
+
+func bucketStart(resolution models.RollupResolution, t time.Time) time.Time {
+	t = t.UTC()
+	if resolution == models.DailyRollup {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return t.Truncate(time.Hour)
+}
+
+func bucketEnd(resolution models.RollupResolution, bucket time.Time) time.Time {
+	if resolution == models.DailyRollup {
+		return bucket.AddDate(0, 0, 1)
+	}
+	return bucket.Add(time.Hour)
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"egide-server/internal/authz"
+	"egide-server/internal/migration"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestCheckSummaryPercentile(t *testing.T) {
+	summary := newCheckSummary(models.HourlyRollup)
+	for ms := 101; ms <= 200; ms++ {
+		summary.addLatency(ms)
+	}
+
+	// Every value sits in the 100-200ms bucket, interpolation spreads them evenly
+	if p50 := summary.Percentile(50); p50 < 148 || p50 > 152 {
+		t.Errorf("unexpected p50: %d", p50)
+	}
+	if p99 := summary.Percentile(99); p99 < 197 || p99 > 200 {
+		t.Errorf("unexpected p99: %d", p99)
+	}
+	if p100 := summary.Percentile(100); p100 != 200 {
+		t.Errorf("p100 should be the max, got %d", p100)
+	}
+}
+
+func TestResolutionFor(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		start time.Time
+		want  models.RollupResolution
+	}{
+		{now.Add(-time.Hour), RawResolution},
+		{now.AddDate(0, 0, -30), models.HourlyRollup},
+		{now.AddDate(-1, 0, 0), models.DailyRollup},
+	}
+
+	for _, tt := range tests {
+		if got := ResolutionFor(tt.start, now, now); got != tt.want {
+			t.Errorf("ResolutionFor(%s) = %s, want %s", now.Sub(tt.start), got, tt.want)
+		}
+	}
+
+	// Old short ranges are past raw retention
+	start := now.AddDate(0, 0, -60)
+	if got := ResolutionFor(start, start.Add(time.Hour), now); got != models.HourlyRollup {
+		t.Errorf("ResolutionFor(old hour) = %s, want hour", got)
+	}
+}
+
+func TestRollupAndSummarize(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	if err := migration.RunMigrations(db, "../../migrations"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Foreign keys of the monitor
+	userID := insert(t, db, `INSERT INTO users (username) VALUES ('test')`)
+	siteID := insert(t, db, `INSERT INTO sites (user_id, domain, protection_mode) VALUES (?, 'example.com', 'simple')`, userID)
+	monitorID := insert(t, db, `INSERT INTO monitors (site_id, name, url) VALUES (?, 'Home', 'https://example.com')`, siteID)
+
+	healthCheckRepo := repository.NewHealthCheckRepository(db)
+	rollupRepo := repository.NewRollupRepository(db)
+	rollups := NewRollupService(healthCheckRepo, rollupRepo)
+
+	// Three days of checks every 10 minutes, one failure per hour
+	now := time.Now()
+	start := now.Add(-72 * time.Hour).Truncate(time.Hour)
+	for ts := start; ts.Before(now); ts = ts.Add(10 * time.Minute) {
+		check := &models.HealthCheck{
+			MonitorID:      &monitorID,
+			Timestamp:      ts,
+			ResponseTimeMs: 120,
+			Success:        ts.Minute() != 0,
+		}
+		if _, err := healthCheckRepo.Create(check); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := rollups.RollupHours(now); err != nil {
+		t.Fatal(err)
+	}
+	if err := rollups.RollupDays(now); err != nil {
+		t.Fatal(err)
+	}
+
+	hours, err := rollupRepo.FindAllInRange(models.HourlyRollup, start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hours) != 1 || hours[0].Total != 6 || hours[0].Successes != 5 || hours[0].P50Ms != 120 {
+		t.Fatalf("unexpected hourly rollup: %+v", hours)
+	}
+
+	if _, ok, err := rollupRepo.LatestBucket(models.DailyRollup); err != nil || !ok {
+		t.Fatalf("expected daily rollups, got ok=%v err=%v", ok, err)
+	}
+
+	// Rolling up again must not count checks twice
+	if err := rollups.RollupHours(now); err != nil {
+		t.Fatal(err)
+	}
+
+	// Served from daily and hourly rollups plus the raw checks of the current hour
+	summary, err := rollups.Summarize([]int64{monitorID}, start, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Resolution != models.HourlyRollup {
+		t.Errorf("unexpected resolution: %s", summary.Resolution)
+	}
+
+	raw, err := healthCheckRepo.GetChecksInRange([]int64{monitorID}, start, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Total != len(raw) {
+		t.Errorf("summary covers %d checks, want %d", summary.Total, len(raw))
+	}
+
+	uptime, _ := summary.Uptime()
+	if uptime < 83 || uptime > 84 {
+		t.Errorf("unexpected uptime: %.2f", uptime)
+	}
+
+	// Buckets partly outside the range don't count, whatever the resolution
+	tests := []struct {
+		from, to time.Time
+		want     models.RollupResolution
+	}{
+		{start.Add(25 * time.Minute), now, models.HourlyRollup},
+		{start.Add(time.Hour + 25*time.Minute), now.Add(-95 * time.Minute), models.HourlyRollup},
+		{start.Add(25*time.Hour + 25*time.Minute), start.Add(25*time.Hour + 91*24*time.Hour), models.DailyRollup},
+	}
+	for _, tt := range tests {
+		summary, err := rollups.Summarize([]int64{monitorID}, tt.from, tt.to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw, err := healthCheckRepo.GetChecksInRange([]int64{monitorID}, tt.from, tt.to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if summary.Resolution != tt.want || summary.Total != len(raw) {
+			t.Errorf("summary of [%s, %s) covers %d checks at %s; want %d at %s", tt.from, tt.to, summary.Total, summary.Resolution, len(raw), tt.want)
+		}
+	}
+
+	// No monitors, no data
+	if summary, err := rollups.Summarize(nil, start, now); err != nil || summary.Total != 0 {
+		t.Errorf("summary without monitors covers %d checks (%v); want none", summary.Total, err)
+	}
+}
+
+func TestCleanupPastRetention(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	if err := migration.RunMigrations(db, "../../migrations"); err != nil {
+		t.Fatal(err)
+	}
+
+	userID := insert(t, db, `INSERT INTO users (username) VALUES ('test')`)
+	siteID := insert(t, db, `INSERT INTO sites (user_id, domain, protection_mode) VALUES (?, 'example.com', 'simple')`, userID)
+	monitorID := insert(t, db, `INSERT INTO monitors (site_id, name, url) VALUES (?, 'Home', 'https://example.com')`, siteID)
+
+	// Checks of the URL monitored before monitors existed go with the next start
+	healthCheckRepo := repository.NewHealthCheckRepository(db)
+	now := time.Now()
+	if _, err := healthCheckRepo.Create(&models.HealthCheck{Timestamp: now, ResponseTimeMs: 100, Success: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := migration.RunMigrations(db, "../../migrations"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Checks too old to ever be rolled up are deleted, even with no rollup yet
+	for _, ts := range []time.Time{now.Add(-RawDataRetention - time.Hour), now.Add(-time.Minute)} {
+		if _, err := healthCheckRepo.Create(&models.HealthCheck{MonitorID: &monitorID, Timestamp: ts, ResponseTimeMs: 100, Success: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := NewRollupService(healthCheckRepo, repository.NewRollupRepository(db)).cleanup(now); err != nil {
+		t.Fatal(err)
+	}
+
+	var total, legacy int
+	if err := db.QueryRow(`SELECT COUNT(*), COUNT(*) FILTER (WHERE monitor_id IS NULL) FROM health_checks`).Scan(&total, &legacy); err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || legacy != 0 {
+		t.Errorf("%d checks remain, %d without monitor; want only the recent check", total, legacy)
+	}
+}
+
+func insert(t *testing.T, db *sql.DB, query string, args ...interface{}) int64 {
+	result, err := db.Exec(query, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// newTestAuthorizer returns an authorizer over the test database
+func newTestAuthorizer(db *sql.DB) *authz.Authorizer {
+	return authz.NewAuthorizer(
+		repository.NewSiteRepository(db),
+		repository.NewOrganizationRepository(db),
+		repository.NewTwoFactorRepository(db),
+	)
+}
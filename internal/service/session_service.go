@@ -0,0 +1,194 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+
+	"egide-server/internal/auth"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+)
+
+// RefreshTokenTTL is how long a session stays alive without being refreshed
+const RefreshTokenTTL = 30 * 24 * time.Hour
+
+var (
+	// ErrInvalidRefreshToken is returned for unknown, expired or revoked refresh tokens
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+
+	// ErrRefreshTokenReused is returned when a rotated refresh token is used
+	// again, which means it leaked: the session is revoked
+	ErrRefreshTokenReused = errors.New("refresh token reused, session revoked")
+)
+
+// TokenPair is the credentials of a session returned to the frontend
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	ExpiresIn    int    `json:"expiry"`
+	RefreshToken string `json:"refresh_token"`
+	UserID       int64  `json:"user_id"`
+	SessionID    int64  `json:"session_id"`
+}
+
+// SessionService keeps users signed in with short-lived access tokens renewed
+// by refresh tokens stored server-side, which rotate on every use
+type SessionService struct {
+	sessionRepo  *repository.SessionRepository
+	tokenService *auth.TokenService
+}
+
+// NewSessionService creates a new session service
+func NewSessionService(sessionRepo *repository.SessionRepository, tokenService *auth.TokenService) *SessionService {
+	return &SessionService{
+		sessionRepo:  sessionRepo,
+		tokenService: tokenService,
+	}
+}
+
+// Start opens a new session for a user who just signed in
+func (s *SessionService) Start(userID int64, userAgent, ipAddress string) (*TokenPair, error) {
+	now := time.Now()
+	if err := s.sessionRepo.DeleteExpired(now); err != nil {
+		log.Printf("Failed to delete expired sessions: %v", err)
+	}
+
+	refreshToken, hash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session := &models.Session{
+		UserID:           userID,
+		RefreshTokenHash: hash,
+		UserAgent:        userAgent,
+		IPAddress:        ipAddress,
+		CreatedAt:        now,
+		LastUsedAt:       now,
+		ExpiresAt:        now.Add(RefreshTokenTTL),
+	}
+	session.ID, err = s.sessionRepo.Create(session)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.tokenPair(session, refreshToken)
+}
+
+// Refresh exchanges a refresh token for a new access token and refresh token
+func (s *SessionService) Refresh(refreshToken, userAgent, ipAddress string) (*TokenPair, error) {
+	now := time.Now()
+	hash := hashToken(refreshToken)
+
+	session, err := s.sessionRepo.FindByTokenHash(hash)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	if session.RevokedAt != nil || !session.ExpiresAt.After(now) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if session.RefreshTokenHash != hash {
+		if err := s.sessionRepo.Revoke(session.ID, now); err != nil {
+			return nil, err
+		}
+		log.Printf("Refresh token of session %d reused, session revoked", session.ID)
+		return nil, ErrRefreshTokenReused
+	}
+
+	newToken, newHash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session.RefreshTokenHash = newHash
+	session.UserAgent = userAgent
+	session.IPAddress = ipAddress
+	session.LastUsedAt = now
+	session.ExpiresAt = now.Add(RefreshTokenTTL)
+
+	rotated, err := s.sessionRepo.Rotate(session, hash)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		// Another request rotated the token in between
+		return nil, ErrInvalidRefreshToken
+	}
+
+	return s.tokenPair(session, newToken)
+}
+
+// Logout revokes the session of a refresh token
+func (s *SessionService) Logout(refreshToken string) error {
+	session, err := s.sessionRepo.FindByTokenHash(hashToken(refreshToken))
+	if err != nil {
+		return ErrInvalidRefreshToken
+	}
+
+	return s.sessionRepo.Revoke(session.ID, time.Now())
+}
+
+// List returns the active sessions of a user, flagging the current one
+func (s *SessionService) List(userID, currentSessionID int64) ([]*models.Session, error) {
+	sessions, err := s.sessionRepo.FindActiveByUserID(userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, session := range sessions {
+		session.Current = session.ID == currentSessionID
+	}
+	return sessions, nil
+}
+
+// Revoke ends a session of a user
+func (s *SessionService) Revoke(userID, sessionID int64) error {
+	session, err := s.sessionRepo.FindByID(sessionID)
+	if err != nil || session.UserID != userID {
+		return errors.New("session not found")
+	}
+
+	return s.sessionRepo.Revoke(session.ID, time.Now())
+}
+
+// IsActive reports whether a session is neither revoked nor expired
+func (s *SessionService) IsActive(sessionID int64) (bool, error) {
+	return s.sessionRepo.IsActive(sessionID, time.Now())
+}
+
+func (s *SessionService) tokenPair(session *models.Session, refreshToken string) (*TokenPair, error) {
+	accessToken, err := s.tokenService.GenerateToken(session.UserID, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		ExpiresIn:    int(auth.AccessTokenTTL.Seconds()),
+		RefreshToken: refreshToken,
+		UserID:       session.UserID,
+		SessionID:    session.ID,
+	}, nil
+}
+
+// newRefreshToken returns a random refresh token and the hash stored for it
+func newRefreshToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, hashToken(token), nil
+}
+
+// hashToken returns the hash stored in place of a secret token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+
+	"egide-server/internal/auth"
+	"egide-server/internal/migration"
+	"egide-server/internal/repository"
+)
+
+func TestSessionRefreshRotation(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	if err := migration.RunMigrations(db, "../../migrations"); err != nil {
+		t.Fatal(err)
+	}
+
+	userID := insert(t, db, `INSERT INTO users (username) VALUES ('test')`)
+	tokens := auth.NewTokenService(newTestSigningKeys(t, db))
+	sessions := NewSessionService(repository.NewSessionRepository(db), tokens)
+
+	first, err := sessions.Start(userID, "test-agent", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := tokens.ParseToken(first.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.UserID != userID || claims.SessionID != first.SessionID {
+		t.Errorf("access token claims = %+v", claims)
+	}
+
+	// Refreshing rotates the refresh token within the same session
+	second, err := sessions.Refresh(first.RefreshToken, "test-agent", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.SessionID != first.SessionID || second.RefreshToken == first.RefreshToken {
+		t.Errorf("Refresh() = %+v; want a new refresh token for session %d", second, first.SessionID)
+	}
+
+	// Using the rotated token again revokes the session
+	if _, err := sessions.Refresh(first.RefreshToken, "attacker", "10.0.0.1"); err != ErrRefreshTokenReused {
+		t.Errorf("reusing a rotated token: err = %v; want %v", err, ErrRefreshTokenReused)
+	}
+	if _, err := sessions.Refresh(second.RefreshToken, "test-agent", "127.0.0.1"); err != ErrInvalidRefreshToken {
+		t.Errorf("refreshing a revoked session: err = %v; want %v", err, ErrInvalidRefreshToken)
+	}
+	if active, _ := sessions.IsActive(first.SessionID); active {
+		t.Error("the session should be revoked")
+	}
+
+	// Logout and revocation end the session
+	third, err := sessions.Start(userID, "other-agent", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fourth, err := sessions.Start(userID, "third-agent", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := sessions.List(userID, third.SessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("got %d active sessions; want 2", len(list))
+	}
+	for _, session := range list {
+		if session.Current != (session.ID == third.SessionID) {
+			t.Errorf("session %d: current = %v", session.ID, session.Current)
+		}
+	}
+
+	if err := sessions.Logout(third.RefreshToken); err != nil {
+		t.Fatal(err)
+	}
+	if err := sessions.Revoke(userID+1, fourth.SessionID); err == nil {
+		t.Error("expected an error revoking the session of another user")
+	}
+	if err := sessions.Revoke(userID, fourth.SessionID); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err = sessions.List(userID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 0 {
+		t.Errorf("got %d active sessions after logout; want 0", len(list))
+	}
+}
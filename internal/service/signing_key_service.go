@@ -0,0 +1,251 @@
+package service
+
+import (
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"egide-server/internal/auth"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+)
+
+const (
+	// KeyRotationCheckInterval is how often the signing key is checked for rotation
+	KeyRotationCheckInterval = time.Hour
+
+	// KeyVerificationGrace is how long a rotated key keeps verifying the tokens
+	// it signed, and stays published in the JWKS
+	KeyVerificationGrace = 24 * time.Hour
+
+	// keyCacheTTL is how long keys are used before being read again, to pick
+	// up keys rotated by other instances
+	keyCacheTTL = time.Minute
+
+	// keyReloadInterval limits how often an unknown kid reloads the keys
+	keyReloadInterval = 10 * time.Second
+)
+
+// SigningKeyService rotates the keys signing access tokens. Keys are stored
+// encrypted in the database, and previous keys keep verifying tokens until
+// they expire, so rotating doesn't sign anyone out.
+type SigningKeyService struct {
+	keyRepo   *repository.SigningKeyRepository
+	cipher    *auth.KeyCipher
+	algorithm string
+	rotation  time.Duration
+	stopChan  chan struct{}
+
+	mu        sync.Mutex
+	signing   *auth.SigningKey
+	rotatesAt time.Time
+	public    map[string]crypto.PublicKey
+	jwks      []auth.JSONWebKey
+	loadedAt  time.Time
+}
+
+// NewSigningKeyService creates a new signing key service, signing with keys
+// of the algorithm replaced every rotation
+func NewSigningKeyService(keyRepo *repository.SigningKeyRepository, cipher *auth.KeyCipher, algorithm string, rotation time.Duration) *SigningKeyService {
+	return &SigningKeyService{
+		keyRepo:   keyRepo,
+		cipher:    cipher,
+		algorithm: algorithm,
+		rotation:  rotation,
+		stopChan:  make(chan struct{}),
+	}
+}
+
+// Start begins the periodic rotation of signing keys
+func (s *SigningKeyService) Start() {
+	log.Println("Starting signing key service...")
+
+	ticker := time.NewTicker(KeyRotationCheckInterval)
+	go func() {
+		defer ticker.Stop()
+
+		if err := s.Rotate(time.Now()); err != nil {
+			log.Printf("Failed to rotate signing keys: %v", err)
+		}
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.Rotate(time.Now()); err != nil {
+					log.Printf("Failed to rotate signing keys: %v", err)
+				}
+			case <-s.stopChan:
+				log.Println("Signing key service stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop gracefully stops the signing key service
+func (s *SigningKeyService) Stop() {
+	close(s.stopChan)
+}
+
+// Rotate creates a new signing key when the current one is due for rotation,
+// and deletes the keys that expired
+func (s *SigningKeyService) Rotate(now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.keyRepo.DeleteExpired(now); err != nil {
+		return err
+	}
+	if err := s.load(now); err != nil {
+		return err
+	}
+	if s.signing != nil && s.rotatesAt.After(now) {
+		return nil
+	}
+
+	return s.create(now)
+}
+
+// SigningKey returns the key signing new tokens
+func (s *SigningKeyService) SigningKey() (*auth.SigningKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.loadedAt) >= keyCacheTTL || (s.signing != nil && !s.rotatesAt.After(now)) {
+		if err := s.load(now); err != nil {
+			return nil, err
+		}
+	}
+	if s.signing == nil || !s.rotatesAt.After(now) {
+		if err := s.create(now); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.signing, nil
+}
+
+// VerificationKey returns the public key of a key that hasn't expired
+func (s *SigningKeyService) VerificationKey(kid string) (crypto.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	_, known := s.public[kid]
+	if now.Sub(s.loadedAt) >= keyCacheTTL || (!known && now.Sub(s.loadedAt) >= keyReloadInterval) {
+		if err := s.load(now); err != nil {
+			return nil, err
+		}
+	}
+
+	key, ok := s.public[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+// JWKS returns the public keys accepted for access tokens
+func (s *SigningKeyService) JWKS() (*auth.JSONWebKeySet, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.loadedAt) >= keyCacheTTL {
+		if err := s.load(now); err != nil {
+			return nil, err
+		}
+	}
+
+	keys := make([]auth.JSONWebKey, len(s.jwks))
+	copy(keys, s.jwks)
+	return &auth.JSONWebKeySet{Keys: keys}, nil
+}
+
+// load reads the keys that haven't expired. The signing key is the newest key
+// of the configured algorithm that isn't due for rotation and can be decrypted
+func (s *SigningKeyService) load(now time.Time) error {
+	keys, err := s.keyRepo.FindValid(now)
+	if err != nil {
+		return err
+	}
+
+	s.signing = nil
+	s.rotatesAt = time.Time{}
+	s.public = make(map[string]crypto.PublicKey, len(keys))
+	s.jwks = make([]auth.JSONWebKey, 0, len(keys))
+
+	for _, key := range keys {
+		public, err := x509.ParsePKIXPublicKey(key.PublicKey)
+		if err != nil {
+			log.Printf("Failed to parse signing key %s: %v", key.KeyID, err)
+			continue
+		}
+		jwk, err := auth.NewJSONWebKey(key.KeyID, key.Algorithm, public)
+		if err != nil {
+			log.Printf("Failed to publish signing key %s: %v", key.KeyID, err)
+			continue
+		}
+		s.public[key.KeyID] = public
+		s.jwks = append(s.jwks, jwk)
+
+		if s.signing != nil || key.Algorithm != s.algorithm || !key.RotatesAt.After(now) {
+			continue
+		}
+		private, err := s.cipher.Open(key.PrivateKey)
+		if err != nil {
+			log.Printf("Failed to open signing key %s: %v", key.KeyID, err)
+			continue
+		}
+		s.signing = &auth.SigningKey{ID: key.KeyID, Algorithm: key.Algorithm, PrivateKey: private}
+		s.rotatesAt = key.RotatesAt
+	}
+
+	s.loadedAt = now
+	return nil
+}
+
+// create generates and stores a new signing key, then reloads the keys
+func (s *SigningKeyService) create(now time.Time) error {
+	key, err := auth.GenerateSigningKey(s.algorithm)
+	if err != nil {
+		return err
+	}
+
+	sealed, err := s.cipher.Seal(key.PrivateKey)
+	if err != nil {
+		return err
+	}
+	public, err := x509.MarshalPKIXPublicKey(key.PrivateKey.Public())
+	if err != nil {
+		return err
+	}
+
+	rotatesAt := now.Add(s.rotation)
+	_, err = s.keyRepo.Create(&models.SigningKey{
+		KeyID:      key.ID,
+		Algorithm:  key.Algorithm,
+		PrivateKey: sealed,
+		PublicKey:  public,
+		CreatedAt:  now,
+		RotatesAt:  rotatesAt,
+		ExpiresAt:  rotatesAt.Add(KeyVerificationGrace),
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("Created signing key %s (%s), rotating at %s", key.ID, key.Algorithm, rotatesAt.Format(time.RFC3339))
+
+	if err := s.load(now); err != nil {
+		return err
+	}
+	if s.signing == nil {
+		return errors.New("failed to load the new signing key")
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"crypto/x509"
+	"database/sql"
+	"testing"
+	"time"
+
+	"egide-server/internal/auth"
+	"egide-server/internal/migration"
+	"egide-server/internal/repository"
+)
+
+func newTestSigningKeys(t *testing.T, db *sql.DB) *SigningKeyService {
+	cipher, err := auth.NewKeyCipher("secret", auth.PurposeSigningKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewSigningKeyService(repository.NewSigningKeyRepository(db), cipher, auth.AlgorithmEdDSA, 24*time.Hour)
+}
+
+func TestSigningKeyRotation(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	if err := migration.RunMigrations(db, "../../migrations"); err != nil {
+		t.Fatal(err)
+	}
+
+	keys := newTestSigningKeys(t, db)
+	tokens := auth.NewTokenService(keys)
+
+	first, err := keys.SigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := tokens.GenerateToken(42, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Private keys are stored encrypted
+	var private []byte
+	if err := db.QueryRow(`SELECT private_key FROM signing_keys WHERE kid = ?`, first.ID).Scan(&private); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := x509.ParsePKCS8PrivateKey(private); err == nil {
+		t.Error("the private key is stored in clear")
+	}
+
+	// Rotating before the key is due keeps it
+	if err := keys.Rotate(time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if current, _ := keys.SigningKey(); current.ID != first.ID {
+		t.Errorf("key rotated after an hour")
+	}
+
+	// Once due, a new key signs while the previous one still verifies
+	rotation := time.Now().Add(25 * time.Hour)
+	if err := keys.Rotate(rotation); err != nil {
+		t.Fatal(err)
+	}
+	second := keys.signing
+	if second == nil || second.ID == first.ID {
+		t.Fatalf("signing key = %+v; want a new key", second)
+	}
+	if _, err := keys.VerificationKey(first.ID); err != nil {
+		t.Errorf("previous key no longer verifies: %v", err)
+	}
+
+	set, err := keys.JWKS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(set.Keys) != 2 || set.Keys[0].Kid != second.ID || set.Keys[0].Kty != "OKP" {
+		t.Errorf("JWKS = %+v; want the new key then the previous one", set.Keys)
+	}
+
+	// A restarted server reads the keys back from the database
+	restarted := auth.NewTokenService(newTestSigningKeys(t, db))
+	if claims, err := restarted.ParseToken(token); err != nil || claims.UserID != 42 {
+		t.Errorf("ParseToken() after restart = %+v, %v", claims, err)
+	}
+
+	// The previous key expires after the grace period
+	if err := keys.Rotate(rotation.Add(KeyVerificationGrace)); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := keys.public[first.ID]; ok {
+		t.Error("expired key still verifies")
+	}
+}
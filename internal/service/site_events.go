@@ -0,0 +1,56 @@
+package service
+
+import (
+	"log"
+
+	"egide-server/internal/authz"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+)
+
+// PublishSiteEvent queues a webhook event of a site for every user who may
+// view it: its owner, or the members of its organization
+func PublishSiteEvent(authorizer *authz.Authorizer, webhookService *WebhookService, site *models.Site, eventType models.EventType, data interface{}) {
+	userIDs, err := authorizer.Audience(site)
+	if err != nil {
+		log.Printf("Failed to load the users of site %d: %v", site.ID, err)
+		return
+	}
+
+	for _, userID := range userIDs {
+		webhookService.Publish(userID, eventType, data)
+	}
+}
+
+// notifySite sends a notification to the enabled channels of every user who
+// may view a site
+func notifySite(
+	authorizer *authz.Authorizer,
+	channelRepo *repository.NotificationChannelRepository,
+	notificationService *NotificationService,
+	site *models.Site,
+	n *Notification,
+) {
+	userIDs, err := authorizer.Audience(site)
+	if err != nil {
+		log.Printf("Failed to load the users of site %d: %v", site.ID, err)
+		return
+	}
+
+	for _, userID := range userIDs {
+		channels, err := channelRepo.FindByUserID(userID)
+		if err != nil {
+			log.Printf("Failed to load notification channels of user %d: %v", userID, err)
+			continue
+		}
+
+		for _, channel := range channels {
+			if !channel.Enabled {
+				continue
+			}
+			if err := notificationService.Send(channel, n); err != nil {
+				log.Printf("Failed to notify channel %d (%s): %v", channel.ID, channel.Type, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+
+	"egide-server/internal/migration"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+)
+
+func TestPublishSiteEventReachesOrganizationMembers(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	if err := migration.RunMigrations(db, "../../migrations"); err != nil {
+		t.Fatal(err)
+	}
+
+	ownerID := insert(t, db, `INSERT INTO users (username) VALUES ('owner')`)
+	memberID := insert(t, db, `INSERT INTO users (username) VALUES ('member')`)
+	outsiderID := insert(t, db, `INSERT INTO users (username) VALUES ('outsider')`)
+
+	organizationRepo := repository.NewOrganizationRepository(db)
+	orgID, err := organizationRepo.Create(&models.Organization{Name: "Team"}, ownerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := organizationRepo.AddMember(&models.OrganizationMember{OrganizationID: orgID, UserID: memberID, Role: models.RoleViewer}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The site was created by a former member, who must not hear about it anymore
+	siteRepo := repository.NewSiteRepository(db)
+	siteID, err := siteRepo.Create(&models.Site{UserID: outsiderID, OrganizationID: &orgID, Domain: "team.example.com", ProtectionMode: models.SimpleProtection})
+	if err != nil {
+		t.Fatal(err)
+	}
+	site, err := siteRepo.FindByID(siteID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	webhookRepo := repository.NewWebhookRepository(db)
+	subscriptions := map[int64]int64{}
+	for _, userID := range []int64{ownerID, memberID, outsiderID} {
+		id, err := webhookRepo.CreateSubscription(&models.WebhookSubscription{
+			UserID: userID,
+			URL:    "https://hooks.example.com",
+			Secret: "secret",
+			Events: []models.EventType{models.EventSiteUpdated},
+			Active: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		subscriptions[userID] = id
+	}
+
+	PublishSiteEvent(newTestAuthorizer(db), NewWebhookService(webhookRepo), site, models.EventSiteUpdated, site)
+
+	for userID, want := range map[int64]int{ownerID: 1, memberID: 1, outsiderID: 0} {
+		deliveries, err := webhookRepo.FindDeliveriesBySubscriptionID(subscriptions[userID], 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(deliveries) != want {
+			t.Errorf("user %d received %d deliveries; want %d", userID, len(deliveries), want)
+		}
+	}
+}
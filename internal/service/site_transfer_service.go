@@ -0,0 +1,233 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"egide-server/internal/authz"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+)
+
+var (
+	// ErrTransferNotFound is returned for transfers that aren't pending or
+	// that the user can't act on
+	ErrTransferNotFound = errors.New("site transfer not found")
+
+	// ErrTransferPending is returned when a site already has a pending transfer
+	ErrTransferPending = errors.New("site already has a pending transfer")
+
+	// ErrInvalidRecipient is returned for unknown recipients and transfers to the current owner
+	ErrInvalidRecipient = errors.New("invalid transfer recipient")
+
+	// ErrDomainTaken is returned when the new owner already has a site with the same domain
+	ErrDomainTaken = errors.New("recipient already has a site with this domain")
+)
+
+// SiteTransferService hands sites over between users and organizations. The
+// owner of a site requests the transfer, and the recipient accepts it: the
+// user, or an admin of the organization, who then becomes the user owning
+// the site. Every step is recorded in the audit log.
+type SiteTransferService struct {
+	transferRepo     *repository.SiteTransferRepository
+	siteRepo         *repository.SiteRepository
+	userRepo         *repository.UserRepository
+	organizationRepo *repository.OrganizationRepository
+	authorizer       *authz.Authorizer
+}
+
+// NewSiteTransferService creates a new site transfer service
+func NewSiteTransferService(
+	transferRepo *repository.SiteTransferRepository,
+	siteRepo *repository.SiteRepository,
+	userRepo *repository.UserRepository,
+	organizationRepo *repository.OrganizationRepository,
+	authorizer *authz.Authorizer,
+) *SiteTransferService {
+	return &SiteTransferService{
+		transferRepo:     transferRepo,
+		siteRepo:         siteRepo,
+		userRepo:         userRepo,
+		organizationRepo: organizationRepo,
+		authorizer:       authorizer,
+	}
+}
+
+// Request starts transferring a site the user may transfer
+func (s *SiteTransferService) Request(userID int64, site *models.Site, input *models.SiteTransferInput) (*models.SiteTransfer, error) {
+	if input.ToUserID != nil {
+		if site.OrganizationID == nil && *input.ToUserID == site.UserID {
+			return nil, ErrInvalidRecipient
+		}
+		if _, err := s.userRepo.FindByID(*input.ToUserID); err != nil {
+			return nil, ErrInvalidRecipient
+		}
+	} else {
+		if site.OrganizationID != nil && *input.ToOrganizationID == *site.OrganizationID {
+			return nil, ErrInvalidRecipient
+		}
+		if _, err := s.organizationRepo.FindByID(*input.ToOrganizationID); err != nil {
+			return nil, ErrInvalidRecipient
+		}
+	}
+
+	if _, err := s.transferRepo.FindPendingBySiteID(site.ID); err == nil {
+		return nil, ErrTransferPending
+	}
+
+	transfer := &models.SiteTransfer{
+		SiteID:             site.ID,
+		Domain:             site.Domain,
+		FromUserID:         site.UserID,
+		FromOrganizationID: site.OrganizationID,
+		ToUserID:           input.ToUserID,
+		ToOrganizationID:   input.ToOrganizationID,
+		Status:             models.TransferPending,
+		InitiatedBy:        userID,
+		CreatedAt:          time.Now(),
+	}
+
+	entry, err := auditTransfer(userID, models.AuditSiteTransferRequested, transfer)
+	if err != nil {
+		return nil, err
+	}
+
+	transfer.ID, err = s.transferRepo.Create(transfer, entry)
+	if err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// Pending returns the pending transfer of a site
+func (s *SiteTransferService) Pending(siteID int64) (*models.SiteTransfer, error) {
+	transfer, err := s.transferRepo.FindPendingBySiteID(siteID)
+	if err != nil {
+		return nil, ErrTransferNotFound
+	}
+	return transfer, nil
+}
+
+// Cancel withdraws the pending transfer of a site the user may transfer
+func (s *SiteTransferService) Cancel(userID int64, site *models.Site) error {
+	transfer, err := s.Pending(site.ID)
+	if err != nil {
+		return err
+	}
+	return s.close(userID, transfer, models.TransferCancelled, models.AuditSiteTransferCancelled)
+}
+
+// Incoming returns the pending transfers the user can accept
+func (s *SiteTransferService) Incoming(userID int64) ([]*models.SiteTransfer, error) {
+	return s.transferRepo.FindIncoming(userID)
+}
+
+// Accept completes a transfer to the user or to an organization they
+// administer, and returns the site with its new owner
+func (s *SiteTransferService) Accept(userID, transferID int64) (*models.Site, error) {
+	transfer, err := s.incoming(userID, transferID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Users own a single site per domain, in organizations or not
+	if existing, err := s.siteRepo.FindByDomain(userID, transfer.Domain); err == nil && existing.ID != transfer.SiteID {
+		return nil, ErrDomainTaken
+	}
+
+	now := time.Now()
+	transfer.Status = models.TransferAccepted
+	transfer.CompletedBy = &userID
+	transfer.CompletedAt = &now
+	entry, err := auditTransfer(userID, models.AuditSiteTransferred, transfer)
+	if err != nil {
+		return nil, err
+	}
+
+	completed, err := s.transferRepo.Complete(transfer, userID, now, entry)
+	if err != nil {
+		return nil, err
+	}
+	if !completed {
+		return nil, ErrTransferNotFound
+	}
+
+	return s.siteRepo.FindByID(transfer.SiteID)
+}
+
+// Decline refuses a transfer to the user or to an organization they administer
+func (s *SiteTransferService) Decline(userID, transferID int64) error {
+	transfer, err := s.incoming(userID, transferID)
+	if err != nil {
+		return err
+	}
+	return s.close(userID, transfer, models.TransferDeclined, models.AuditSiteTransferDeclined)
+}
+
+// incoming loads a pending transfer the user can accept
+func (s *SiteTransferService) incoming(userID, transferID int64) (*models.SiteTransfer, error) {
+	transfer, err := s.transferRepo.FindByID(transferID)
+	if err != nil || transfer.Status != models.TransferPending {
+		return nil, ErrTransferNotFound
+	}
+
+	if transfer.ToUserID != nil {
+		if *transfer.ToUserID != userID {
+			return nil, ErrTransferNotFound
+		}
+		return transfer, nil
+	}
+
+	if _, err := s.authorizer.Organization(userID, *transfer.ToOrganizationID, authz.ManageMembers); err != nil {
+		if errors.Is(err, authz.ErrTwoFactorRequired) {
+			return nil, err
+		}
+		return nil, ErrTransferNotFound
+	}
+	return transfer, nil
+}
+
+func (s *SiteTransferService) close(userID int64, transfer *models.SiteTransfer, status models.TransferStatus, action models.AuditAction) error {
+	now := time.Now()
+	transfer.Status = status
+	transfer.CompletedBy = &userID
+	transfer.CompletedAt = &now
+	entry, err := auditTransfer(userID, action, transfer)
+	if err != nil {
+		return err
+	}
+
+	closed, err := s.transferRepo.Close(transfer, status, userID, now, entry)
+	if err != nil {
+		return err
+	}
+	if !closed {
+		return ErrTransferNotFound
+	}
+	return nil
+}
+
+// auditTransfer builds the audit entry of a step of a transfer. The entry
+// holds the whole transfer, the organization it refers to is the one giving
+// the site away, or else the one receiving it
+func auditTransfer(userID int64, action models.AuditAction, transfer *models.SiteTransfer) (*models.AuditEntry, error) {
+	details, err := json.Marshal(transfer)
+	if err != nil {
+		return nil, err
+	}
+
+	organizationID := transfer.FromOrganizationID
+	if organizationID == nil {
+		organizationID = transfer.ToOrganizationID
+	}
+
+	return &models.AuditEntry{
+		ActorID:        &userID,
+		Action:         action,
+		SiteID:         &transfer.SiteID,
+		OrganizationID: organizationID,
+		Details:        details,
+		CreatedAt:      time.Now(),
+	}, nil
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+
+	"egide-server/internal/authz"
+	"egide-server/internal/migration"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+)
+
+func TestSiteTransfer(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	if err := migration.RunMigrations(db, "../../migrations"); err != nil {
+		t.Fatal(err)
+	}
+
+	contractorID := insert(t, db, `INSERT INTO users (username) VALUES ('contractor')`)
+	clientID := insert(t, db, `INSERT INTO users (username) VALUES ('client')`)
+	otherID := insert(t, db, `INSERT INTO users (username) VALUES ('other')`)
+
+	siteRepo := repository.NewSiteRepository(db)
+	organizationRepo := repository.NewOrganizationRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	transfers := NewSiteTransferService(
+		repository.NewSiteTransferRepository(db),
+		siteRepo,
+		repository.NewUserRepository(db),
+		organizationRepo,
+		authz.NewAuthorizer(siteRepo, organizationRepo, repository.NewTwoFactorRepository(db)),
+	)
+
+	// A site of the contractor, with its monitoring and history
+	site := &models.Site{UserID: contractorID, Domain: "client.com", ProtectionMode: models.SimpleProtection}
+	site.ID, err = siteRepo.Create(site)
+	if err != nil {
+		t.Fatal(err)
+	}
+	monitorID := insert(t, db, `INSERT INTO monitors (site_id, name, url) VALUES (?, 'Home', 'https://client.com')`, site.ID)
+	channelID := insert(t, db, `INSERT INTO notification_channels (user_id, name, type, target) VALUES (?, 'Mail', 'email', 'ops@contractor.com')`, contractorID)
+	ruleID := insert(t, db, `INSERT INTO alert_rules (user_id, name, type, site_id, monitor_id) VALUES (?, 'Down', 'monitor_down', ?, ?)`, contractorID, site.ID, monitorID)
+	insert(t, db, `INSERT INTO alert_rule_channels (rule_id, channel_id) VALUES (?, ?)`, ruleID, channelID)
+	pageID := insert(t, db, `INSERT INTO status_pages (user_id, slug, title) VALUES (?, 'contractor', 'Status')`, contractorID)
+	insert(t, db, `INSERT INTO status_page_monitors (status_page_id, monitor_id) VALUES (?, ?)`, pageID, monitorID)
+	insert(t, db, `INSERT INTO threats (site_id, nature, status, sources, time) VALUES (?, 1, 1, '[]', datetime('now'))`, site.ID)
+
+	if _, err := transfers.Request(contractorID, site, &models.SiteTransferInput{ToUserID: &contractorID}); err != ErrInvalidRecipient {
+		t.Errorf("Request() to the owner = %v; want %v", err, ErrInvalidRecipient)
+	}
+
+	transfer, err := transfers.Request(contractorID, site, &models.SiteTransferInput{ToUserID: &clientID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := transfers.Request(contractorID, site, &models.SiteTransferInput{ToUserID: &otherID}); err != ErrTransferPending {
+		t.Errorf("second Request() = %v; want %v", err, ErrTransferPending)
+	}
+
+	// Only the recipient sees and accepts the transfer
+	if _, err := transfers.Accept(otherID, transfer.ID); err != ErrTransferNotFound {
+		t.Errorf("Accept() by another user = %v; want %v", err, ErrTransferNotFound)
+	}
+	if incoming, _ := transfers.Incoming(clientID); len(incoming) != 1 || incoming[0].Domain != "client.com" {
+		t.Errorf("Incoming(client) = %+v; want the transfer", incoming)
+	}
+
+	transferred, err := transfers.Accept(clientID, transfer.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transferred.UserID != clientID || transferred.OrganizationID != nil {
+		t.Errorf("site owned by %d after transfer; want %d", transferred.UserID, clientID)
+	}
+	if _, err := transfers.Accept(clientID, transfer.ID); err != ErrTransferNotFound {
+		t.Errorf("Accept() twice = %v; want %v", err, ErrTransferNotFound)
+	}
+
+	var ruleOwner, ruleChannels, pageMonitors, threats int64
+	db.QueryRow(`SELECT user_id FROM alert_rules WHERE id = ?`, ruleID).Scan(&ruleOwner)
+	db.QueryRow(`SELECT COUNT(*) FROM alert_rule_channels WHERE rule_id = ?`, ruleID).Scan(&ruleChannels)
+	db.QueryRow(`SELECT COUNT(*) FROM status_page_monitors WHERE status_page_id = ?`, pageID).Scan(&pageMonitors)
+	db.QueryRow(`SELECT COUNT(*) FROM threats WHERE site_id = ?`, site.ID).Scan(&threats)
+	if ruleOwner != clientID || ruleChannels != 0 {
+		t.Errorf("alert rule owned by %d with %d channels; want %d without the contractor's channel", ruleOwner, ruleChannels, clientID)
+	}
+	if pageMonitors != 0 {
+		t.Error("the contractor's status page still shows the transferred monitor")
+	}
+	if threats != 1 {
+		t.Errorf("%d threats after transfer; want the history kept", threats)
+	}
+
+	// The client moves the site to an organization, which declines once
+	org, err := NewOrganizationService(organizationRepo, repository.NewTwoFactorRepository(db)).
+		Create(otherID, &models.OrganizationInput{Name: "Agency"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transfer, err = transfers.Request(clientID, transferred, &models.SiteTransferInput{ToOrganizationID: &org.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := transfers.Decline(otherID, transfer.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	transfer, err = transfers.Request(clientID, transferred, &models.SiteTransferInput{ToOrganizationID: &org.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	transferred, err = transfers.Accept(otherID, transfer.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transferred.OrganizationID == nil || *transferred.OrganizationID != org.ID {
+		t.Errorf("site in organization %v after transfer; want %d", transferred.OrganizationID, org.ID)
+	}
+
+	entries, err := auditRepo.FindBySiteID(site.ID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.AuditAction{
+		models.AuditSiteTransferred,
+		models.AuditSiteTransferRequested,
+		models.AuditSiteTransferDeclined,
+		models.AuditSiteTransferRequested,
+		models.AuditSiteTransferred,
+		models.AuditSiteTransferRequested,
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("%d audit entries; want %d", len(entries), len(want))
+	}
+	for i, entry := range entries {
+		if entry.Action != want[i] {
+			t.Errorf("audit entry %d = %s; want %s", i, entry.Action, want[i])
+		}
+	}
+}
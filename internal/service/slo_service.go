@@ -0,0 +1,328 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"egide-server/internal/authz"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+)
+
+const (
+	// DefaultSloWindowDays is the rolling window of an SLO when none is given
+	DefaultSloWindowDays = 30
+
+	// DefaultBurnRateThreshold fires when 2% of a 30-day budget burns in an hour
+	DefaultBurnRateThreshold = 14.4
+
+	// Burn rates are evaluated over a long window to avoid flapping and a
+	// short one so that alerts stop soon after the problem is fixed
+	SloLongBurnWindow  = time.Hour
+	SloShortBurnWindow = 5 * time.Minute
+
+	// SloEvaluationInterval is how often burn rate alerts are evaluated
+	SloEvaluationInterval = CheckInterval
+)
+
+// SloStatus is the current attainment of an SLO over its window
+type SloStatus struct {
+	Slo         *models.Slo `json:"slo"`
+	From        time.Time   `json:"from"`
+	To          time.Time   `json:"to"`
+	TotalChecks int         `json:"total_checks"`
+	GoodChecks  int         `json:"good_checks"`
+
+	// Percentage of good checks, nil without data
+	Attainment *float64 `json:"attainment"`
+
+	// Percentage of the error budget left, negative once it is exhausted
+	ErrorBudgetRemaining *float64 `json:"error_budget_remaining"`
+
+	// Ratio between the error rate and the rate the target allows: at 1 the
+	// budget is exactly spent at the end of the window
+	BurnRate      *float64 `json:"burn_rate"`
+	BurnRateLong  *float64 `json:"burn_rate_1h"`
+	BurnRateShort *float64 `json:"burn_rate_5m"`
+}
+
+// SloEvent is the webhook payload of SLO events
+type SloEvent struct {
+	Slo    *models.Slo `json:"slo"`
+	Status *SloStatus  `json:"status"`
+}
+
+// SloService computes the error budget of SLOs from health checks and alerts
+// when it burns too fast
+type SloService struct {
+	sloRepo             *repository.SloRepository
+	siteRepo            *repository.SiteRepository
+	authorizer          *authz.Authorizer
+	monitorRepo         *repository.MonitorRepository
+	healthCheckRepo     *repository.HealthCheckRepository
+	channelRepo         *repository.NotificationChannelRepository
+	notificationService *NotificationService
+	webhookService      *WebhookService
+	maintenance         *MaintenanceService
+	stopChan            chan struct{}
+}
+
+// NewSloService creates a new SLO service
+func NewSloService(
+	sloRepo *repository.SloRepository,
+	siteRepo *repository.SiteRepository,
+	authorizer *authz.Authorizer,
+	monitorRepo *repository.MonitorRepository,
+	healthCheckRepo *repository.HealthCheckRepository,
+	channelRepo *repository.NotificationChannelRepository,
+	notificationService *NotificationService,
+	webhookService *WebhookService,
+	maintenance *MaintenanceService,
+) *SloService {
+	return &SloService{
+		sloRepo:             sloRepo,
+		siteRepo:            siteRepo,
+		authorizer:          authorizer,
+		monitorRepo:         monitorRepo,
+		healthCheckRepo:     healthCheckRepo,
+		channelRepo:         channelRepo,
+		notificationService: notificationService,
+		webhookService:      webhookService,
+		maintenance:         maintenance,
+		stopChan:            make(chan struct{}),
+	}
+}
+
+// Start begins evaluating burn rate alerts in the background
+func (s *SloService) Start() {
+	log.Println("Starting SLO service...")
+
+	ticker := time.NewTicker(SloEvaluationInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.Evaluate(time.Now())
+			case <-s.stopChan:
+				log.Println("SLO service stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop gracefully stops the SLO service
+func (s *SloService) Stop() {
+	close(s.stopChan)
+}
+
+// Status computes the attainment, error budget and burn rates of an SLO at now
+func (s *SloService) Status(slo *models.Slo, now time.Time) (*SloStatus, error) {
+	monitorIDs, err := s.monitorIDs(slo)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &SloStatus{
+		Slo:  slo,
+		From: now.AddDate(0, 0, -slo.WindowDays),
+		To:   now,
+	}
+
+	status.TotalChecks, status.GoodChecks, err = s.countChecks(slo, monitorIDs, status.From, now)
+	if err != nil {
+		return nil, err
+	}
+	status.Attainment = attainment(status.TotalChecks, status.GoodChecks)
+	status.BurnRate = burnRate(status.TotalChecks, status.GoodChecks, slo.Target)
+	if status.BurnRate != nil {
+		remaining := round((1-*status.BurnRate)*100, 2)
+		status.ErrorBudgetRemaining = &remaining
+	}
+
+	total, good, err := s.countChecks(slo, monitorIDs, now.Add(-SloLongBurnWindow), now)
+	if err != nil {
+		return nil, err
+	}
+	status.BurnRateLong = burnRate(total, good, slo.Target)
+
+	total, good, err = s.countChecks(slo, monitorIDs, now.Add(-SloShortBurnWindow), now)
+	if err != nil {
+		return nil, err
+	}
+	status.BurnRateShort = burnRate(total, good, slo.Target)
+
+	return status, nil
+}
+
+// Evaluate checks the burn rate of every SLO with alerts enabled, notifying
+// when one starts or stops burning its budget too fast
+func (s *SloService) Evaluate(now time.Time) {
+	slos, err := s.sloRepo.FindAlerting()
+	if err != nil {
+		log.Printf("Failed to load SLOs: %v", err)
+		return
+	}
+
+	inMaintenance, err := s.maintenance.SitesInMaintenance(now)
+	if err != nil {
+		log.Printf("Failed to load maintenance windows: %v", err)
+		inMaintenance = map[int64]bool{}
+	}
+
+	for _, slo := range slos {
+		// Maintenance checks don't count against the budget, and the state
+		// is kept as is until the window ends
+		if inMaintenance[slo.SiteID] {
+			continue
+		}
+
+		if err := s.evaluateSlo(slo, now); err != nil {
+			log.Printf("Failed to evaluate SLO %d: %v", slo.ID, err)
+		}
+	}
+}
+
+func (s *SloService) evaluateSlo(slo *models.Slo, now time.Time) error {
+	status, err := s.Status(slo, now)
+	if err != nil {
+		return err
+	}
+
+	burning := isFastBurn(status, slo.BurnRateThreshold)
+	if burning == (slo.BurningSince != nil) {
+		return nil
+	}
+
+	site, err := s.siteRepo.FindByID(slo.SiteID)
+	if err != nil {
+		return err
+	}
+
+	if burning {
+		if err := s.sloRepo.SetBurningSince(slo.ID, &now); err != nil {
+			return err
+		}
+		slo.BurningSince = &now
+
+		PublishSiteEvent(s.authorizer, s.webhookService, site, models.EventSloBurning, &SloEvent{Slo: slo, Status: status})
+		s.notify(slo, site, &Notification{
+			Title: fmt.Sprintf("SLO burning: %s", slo.Name),
+			Message: fmt.Sprintf("The error budget of %s (%.3g%% over %d days) on %s is burning %.1fx faster than allowed over the last hour (threshold: %.1fx). %s of the budget is left.",
+				slo.Name, slo.Target, slo.WindowDays, site.Domain, *status.BurnRateLong, slo.BurnRateThreshold, formatBudget(status.ErrorBudgetRemaining)),
+			Status: models.IncidentFiring,
+			Time:   now,
+		})
+		return nil
+	}
+
+	since := *slo.BurningSince
+	if err := s.sloRepo.SetBurningSince(slo.ID, nil); err != nil {
+		return err
+	}
+	slo.BurningSince = nil
+
+	PublishSiteEvent(s.authorizer, s.webhookService, site, models.EventSloRecovered, &SloEvent{Slo: slo, Status: status})
+	s.notify(slo, site, &Notification{
+		Title: fmt.Sprintf("SLO recovered: %s", slo.Name),
+		Message: fmt.Sprintf("The error budget of %s on %s is no longer burning too fast, after %s. %s of the budget is left.",
+			slo.Name, site.Domain, now.Sub(since).Round(time.Second), formatBudget(status.ErrorBudgetRemaining)),
+		Status: models.IncidentResolved,
+		Time:   now,
+	})
+	return nil
+}
+
+// notify sends a notification to every enabled channel of an SLO whose
+// owner may still view its site
+func (s *SloService) notify(slo *models.Slo, site *models.Site, n *Notification) {
+	channels, err := s.channelRepo.FindBySloID(slo.ID)
+	if err != nil {
+		log.Printf("Failed to load channels for SLO %d: %v", slo.ID, err)
+		return
+	}
+
+	userIDs, err := s.authorizer.Audience(site)
+	if err != nil {
+		log.Printf("Failed to load the users of site %d: %v", site.ID, err)
+		return
+	}
+	audience := map[int64]bool{}
+	for _, userID := range userIDs {
+		audience[userID] = true
+	}
+
+	for _, channel := range channels {
+		if !audience[channel.UserID] {
+			continue
+		}
+		if err := s.notificationService.Send(channel, n); err != nil {
+			log.Printf("Failed to notify channel %d (%s): %v", channel.ID, channel.Type, err)
+		}
+	}
+}
+
+// monitorIDs returns the monitors an SLO applies to
+func (s *SloService) monitorIDs(slo *models.Slo) ([]int64, error) {
+	if slo.MonitorID != nil {
+		return []int64{*slo.MonitorID}, nil
+	}
+
+	monitors, err := s.monitorRepo.FindBySiteID(slo.SiteID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, 0, len(monitors))
+	for _, monitor := range monitors {
+		ids = append(ids, monitor.ID)
+	}
+	return ids, nil
+}
+
+func (s *SloService) countChecks(slo *models.Slo, monitorIDs []int64, start, end time.Time) (int, int, error) {
+	latencyThreshold := -1
+	if slo.LatencyThresholdMs != nil {
+		latencyThreshold = *slo.LatencyThresholdMs
+	}
+	return s.healthCheckRepo.CountGoodChecks(monitorIDs, start, end, latencyThreshold)
+}
+
+// attainment returns the percentage of good checks, nil without checks
+func attainment(total, good int) *float64 {
+	if total == 0 {
+		return nil
+	}
+	value := round(float64(good)/float64(total)*100, 3)
+	return &value
+}
+
+// burnRate returns how many times faster than allowed by the target the
+// error budget is consumed, nil without checks
+func burnRate(total, good int, target float64) *float64 {
+	if total == 0 || target >= 100 {
+		return nil
+	}
+	errorRate := float64(total-good) / float64(total)
+	allowed := 1 - target/100
+	value := round(errorRate/allowed, 2)
+	return &value
+}
+
+// isFastBurn reports whether both the long and short burn rates exceed the threshold
+func isFastBurn(status *SloStatus, threshold float64) bool {
+	return status.BurnRateLong != nil && status.BurnRateShort != nil &&
+		*status.BurnRateLong > threshold && *status.BurnRateShort > threshold
+}
+
+func formatBudget(remaining *float64) string {
+	if remaining == nil {
+		return "All"
+	}
+	if *remaining <= 0 {
+		return "None"
+	}
+	return fmt.Sprintf("%.2f%%", *remaining)
+}
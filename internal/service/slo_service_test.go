@@ -0,0 +1,126 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"egide-server/internal/config"
+	"egide-server/internal/migration"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+)
+
+func TestBurnRate(t *testing.T) {
+	if rate := burnRate(0, 0, 99.9); rate != nil {
+		t.Errorf("expected no burn rate without checks, got %v", *rate)
+	}
+
+	// 1% of errors against a 0.1% budget
+	if rate := burnRate(1000, 990, 99.9); rate == nil || *rate != 10 {
+		t.Errorf("unexpected burn rate: %v", rate)
+	}
+
+	if value := attainment(1000, 990); value == nil || *value != 99 {
+		t.Errorf("unexpected attainment: %v", value)
+	}
+}
+
+func TestSloStatusAndFastBurn(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	if err := migration.RunMigrations(db, "../../migrations"); err != nil {
+		t.Fatal(err)
+	}
+
+	userID := insert(t, db, `INSERT INTO users (username) VALUES ('test')`)
+	siteID := insert(t, db, `INSERT INTO sites (user_id, domain, protection_mode) VALUES (?, 'example.com', 'simple')`, userID)
+	monitorID := insert(t, db, `INSERT INTO monitors (site_id, name, url) VALUES (?, 'Home', 'https://example.com')`, siteID)
+
+	healthCheckRepo := repository.NewHealthCheckRepository(db)
+	sloRepo := repository.NewSloRepository(db)
+	slos := NewSloService(
+		sloRepo,
+		repository.NewSiteRepository(db),
+		newTestAuthorizer(db),
+		repository.NewMonitorRepository(db),
+		healthCheckRepo,
+		repository.NewNotificationChannelRepository(db),
+		NewNotificationService(&config.Config{}),
+		NewWebhookService(repository.NewWebhookRepository(db)),
+		NewMaintenanceService(repository.NewMaintenanceRepository(db)),
+	)
+
+	record := func(ts time.Time, ms int, success bool) {
+		check := &models.HealthCheck{MonitorID: &monitorID, Timestamp: ts, ResponseTimeMs: ms, Success: success}
+		if _, err := healthCheckRepo.Create(check); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Two days of good checks, one slow check and an ongoing outage of the last hour
+	now := time.Now()
+	good := 0
+	for ts := now.Add(-48 * time.Hour); ts.Before(now.Add(-2 * time.Hour)); ts = ts.Add(10 * time.Minute) {
+		record(ts, 120, true)
+		good++
+	}
+	record(now.Add(-90*time.Minute), 800, true)
+	for i := 1; i <= 60; i++ {
+		record(now.Add(-time.Duration(i)*time.Minute), int(RequestTimeout/time.Millisecond), false)
+	}
+
+	latency := 500
+	slo := &models.Slo{
+		SiteID:             siteID,
+		Name:               "Availability",
+		Target:             99,
+		LatencyThresholdMs: &latency,
+		WindowDays:         30,
+		AlertEnabled:       true,
+		BurnRateThreshold:  DefaultBurnRateThreshold,
+		ChannelIDs:         []int64{},
+	}
+	slo.ID, err = sloRepo.Create(slo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := slos.Status(slo, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.TotalChecks != good+61 || status.GoodChecks != good {
+		t.Errorf("unexpected counts: %d/%d, want %d/%d", status.GoodChecks, status.TotalChecks, good, good+61)
+	}
+	if status.BurnRateLong == nil || *status.BurnRateLong != 100 {
+		t.Errorf("unexpected 1h burn rate: %v", status.BurnRateLong)
+	}
+	if status.ErrorBudgetRemaining == nil || *status.ErrorBudgetRemaining >= 0 {
+		t.Errorf("expected the error budget to be exhausted, got %v", status.ErrorBudgetRemaining)
+	}
+
+	slos.Evaluate(now)
+	slo, err = sloRepo.FindByID(slo.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slo.BurningSince == nil {
+		t.Fatal("expected the SLO to be burning")
+	}
+
+	// Without new failures the burn stops once the short window is clean
+	slos.Evaluate(now.Add(2 * time.Hour))
+	slo, err = sloRepo.FindByID(slo.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slo.BurningSince != nil {
+		t.Errorf("expected the SLO to have recovered, burning since %v", slo.BurningSince)
+	}
+}
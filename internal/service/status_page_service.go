@@ -0,0 +1,276 @@
+package service
+
+import (
+	"sort"
+	"time"
+
+	"egide-server/internal/authz"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+)
+
+const (
+	// Number of days shown in the uptime bars of a status page
+	StatusPageDays = 90
+
+	// How far back incidents are listed on a status page
+	StatusPageIncidentPeriod = 14 * 24 * time.Hour
+
+	// Maximum number of incidents listed on a status page
+	StatusPageIncidentLimit = 20
+
+	// A monitor without a check for this long is reported as unknown
+	StaleCheckAge = 5 * CheckInterval
+)
+
+// Current state of a monitor on a status page
+const (
+	MonitorUp          = "up"
+	MonitorDown        = "down"
+	MonitorMaintenance = "maintenance"
+	MonitorUnknown     = "unknown"
+)
+
+// Overall state of a status page
+const (
+	StatusOperational   = "operational"
+	StatusPartialOutage = "partial_outage"
+	StatusMajorOutage   = "major_outage"
+)
+
+// StatusPageView is the public representation of a status page
+type StatusPageView struct {
+	Title       string               `json:"title"`
+	Description string               `json:"description"`
+	Status      string               `json:"status"`
+	Monitors    []*StatusPageMonitor `json:"monitors"`
+	Incidents   []*StatusIncident    `json:"incidents"`
+	GeneratedAt time.Time            `json:"generated_at"`
+}
+
+// StatusPageMonitor is the public state and history of one monitor
+type StatusPageMonitor struct {
+	ID        int64          `json:"id"`
+	Name      string         `json:"name"`
+	Status    string         `json:"status"`
+	Uptime    *float64       `json:"uptime"` // over the whole period, nil without data
+	Days      []*DailyUptime `json:"days"`
+	LastCheck *time.Time     `json:"last_check,omitempty"`
+}
+
+// DailyUptime is one bar of the uptime history
+type DailyUptime struct {
+	Date   string   `json:"date"`
+	Uptime *float64 `json:"uptime"` // nil when there is no data for the day
+}
+
+// StatusIncident is an outage derived from consecutive failed checks
+type StatusIncident struct {
+	MonitorID   int64      `json:"monitor_id"`
+	MonitorName string     `json:"monitor_name"`
+	StartedAt   time.Time  `json:"started_at"`
+	ResolvedAt  *time.Time `json:"resolved_at,omitempty"`
+	Error       string     `json:"error"`
+}
+
+// StatusPageService builds public status pages from health-check data
+type StatusPageService struct {
+	monitorRepo     *repository.MonitorRepository
+	authorizer      *authz.Authorizer
+	healthCheckRepo *repository.HealthCheckRepository
+	rollupService   *RollupService
+	maintenance     *MaintenanceService
+}
+
+// NewStatusPageService creates a new status page service
+func NewStatusPageService(monitorRepo *repository.MonitorRepository, authorizer *authz.Authorizer, healthCheckRepo *repository.HealthCheckRepository, rollupService *RollupService, maintenance *MaintenanceService) *StatusPageService {
+	return &StatusPageService{
+		monitorRepo:     monitorRepo,
+		authorizer:      authorizer,
+		healthCheckRepo: healthCheckRepo,
+		rollupService:   rollupService,
+		maintenance:     maintenance,
+	}
+}
+
+// BuildView computes the public view of a status page
+func (s *StatusPageService) BuildView(page *models.StatusPage) (*StatusPageView, error) {
+	now := time.Now()
+	view := &StatusPageView{
+		Title:       page.Title,
+		Description: page.Description,
+		Monitors:    []*StatusPageMonitor{},
+		Incidents:   []*StatusIncident{},
+		GeneratedAt: now,
+	}
+
+	inMaintenance, err := s.maintenance.SitesInMaintenance(now)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, monitorID := range page.MonitorIDs {
+		monitor, err := s.monitorRepo.FindByID(monitorID)
+		if err != nil {
+			// Deleted monitors simply disappear from the page
+			continue
+		}
+
+		// So do the monitors the owner of the page lost access to, after
+		// leaving an organization or when the site was deleted
+		if _, err := s.authorizer.Site(page.UserID, monitor.SiteID, authz.EditSite); err != nil {
+			continue
+		}
+
+		entry, err := s.buildMonitor(monitor, inMaintenance[monitor.SiteID], now)
+		if err != nil {
+			return nil, err
+		}
+		view.Monitors = append(view.Monitors, entry)
+
+		failures, err := s.healthCheckRepo.GetFailedChecks(monitor.ID, now.Add(-StatusPageIncidentPeriod))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, incident := range groupIncidents(failures, entry.Status == MonitorDown) {
+			incident.MonitorID = monitor.ID
+			incident.MonitorName = monitor.Name
+			view.Incidents = append(view.Incidents, incident)
+		}
+	}
+
+	sortIncidents(view.Incidents)
+	if len(view.Incidents) > StatusPageIncidentLimit {
+		view.Incidents = view.Incidents[:StatusPageIncidentLimit]
+	}
+
+	view.Status = overallStatus(view.Monitors)
+	return view, nil
+}
+
+func (s *StatusPageService) buildMonitor(monitor *models.Monitor, maintenance bool, now time.Time) (*StatusPageMonitor, error) {
+	entry := &StatusPageMonitor{
+		ID:     monitor.ID,
+		Name:   monitor.Name,
+		Status: MonitorUnknown,
+	}
+
+	latest, err := s.healthCheckRepo.GetLatestChecks(monitor.ID, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(latest) > 0 {
+		entry.LastCheck = &latest[0].Timestamp
+		if now.Sub(latest[0].Timestamp) <= StaleCheckAge {
+			entry.Status = MonitorDown
+			if latest[0].Success {
+				entry.Status = MonitorUp
+			}
+		}
+	}
+	if maintenance {
+		entry.Status = MonitorMaintenance
+	}
+
+	since := startOfDay(now.UTC()).AddDate(0, 0, -(StatusPageDays - 1))
+	stats, err := s.rollupService.GetDailyStats(monitor.ID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.Days, entry.Uptime = dailyUptime(stats, since, StatusPageDays)
+	return entry, nil
+}
+
+// dailyUptime expands per-day stats into one bar per day, returning the overall uptime too
+func dailyUptime(stats []*models.DailyCheckStats, since time.Time, days int) ([]*DailyUptime, *float64) {
+	byDay := make(map[string]*models.DailyCheckStats, len(stats))
+	for _, day := range stats {
+		byDay[day.Day] = day
+	}
+
+	total, successes := 0, 0
+	bars := make([]*DailyUptime, 0, days)
+	for i := 0; i < days; i++ {
+		date := since.AddDate(0, 0, i).Format("2006-01-02")
+		bar := &DailyUptime{Date: date}
+
+		if day, ok := byDay[date]; ok && day.Total > 0 {
+			uptime := round(float64(day.Successes)/float64(day.Total)*100, 2)
+			bar.Uptime = &uptime
+			total += day.Total
+			successes += day.Successes
+		}
+
+		bars = append(bars, bar)
+	}
+
+	if total == 0 {
+		return bars, nil
+	}
+
+	uptime := round(float64(successes)/float64(total)*100, 3)
+	return bars, &uptime
+}
+
+// groupIncidents merges failed checks (oldest first) that are no more than two
+// check intervals apart into incidents. The last incident is left open when
+// the monitor is currently down.
+func groupIncidents(failures []*models.HealthCheck, ongoing bool) []*StatusIncident {
+	var incidents []*StatusIncident
+	var current *StatusIncident
+	var last time.Time
+
+	for _, check := range failures {
+		if current == nil || check.Timestamp.Sub(last) > 2*CheckInterval {
+			if current != nil {
+				resolved := last.Add(CheckInterval)
+				current.ResolvedAt = &resolved
+			}
+			current = &StatusIncident{
+				StartedAt: check.Timestamp,
+				Error:     checkError(check),
+			}
+			incidents = append(incidents, current)
+		}
+		last = check.Timestamp
+	}
+
+	if current != nil && !ongoing {
+		resolved := last.Add(CheckInterval)
+		current.ResolvedAt = &resolved
+	}
+
+	return incidents
+}
+
+// sortIncidents orders incidents newest first
+func sortIncidents(incidents []*StatusIncident) {
+	sort.Slice(incidents, func(i, j int) bool {
+		return incidents[i].StartedAt.After(incidents[j].StartedAt)
+	})
+}
+
+func overallStatus(monitors []*StatusPageMonitor) string {
+	down := 0
+	for _, monitor := range monitors {
+		if monitor.Status == MonitorDown {
+			down++
+		}
+	}
+
+	switch {
+	case down == 0:
+		return StatusOperational
+	case down == len(monitors):
+		return StatusMajorOutage
+	default:
+		return StatusPartialOutage
+	}
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"egide-server/internal/migration"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+)
+
+func TestDailyUptime(t *testing.T) {
+	since := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	stats := []*models.DailyCheckStats{
+		{Day: "2025-03-01", Total: 100, Successes: 100},
+		{Day: "2025-03-03", Total: 200, Successes: 190},
+	}
+
+	bars, uptime := dailyUptime(stats, since, 3)
+	if len(bars) != 3 {
+		t.Fatalf("unexpected number of bars: got %d, want 3", len(bars))
+	}
+
+	if bars[0].Uptime == nil || *bars[0].Uptime != 100 {
+		t.Errorf("unexpected uptime for %s: %v", bars[0].Date, bars[0].Uptime)
+	}
+	if bars[1].Uptime != nil {
+		t.Errorf("expected no data for %s, got %v", bars[1].Date, *bars[1].Uptime)
+	}
+	if bars[2].Uptime == nil || *bars[2].Uptime != 95 {
+		t.Errorf("unexpected uptime for %s: %v", bars[2].Date, bars[2].Uptime)
+	}
+
+	if uptime == nil || *uptime != 96.667 {
+		t.Errorf("unexpected overall uptime: %v", uptime)
+	}
+}
+
+func TestGroupIncidents(t *testing.T) {
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	badGateway := 502
+	timeout := "timeout"
+	failures := []*models.HealthCheck{
+		{Timestamp: start, StatusCode: &badGateway},
+		{Timestamp: start.Add(CheckInterval), StatusCode: &badGateway},
+		{Timestamp: start.Add(30 * CheckInterval), Error: &timeout},
+	}
+
+	incidents := groupIncidents(failures, true)
+	if len(incidents) != 2 {
+		t.Fatalf("unexpected number of incidents: got %d, want 2", len(incidents))
+	}
+
+	if incidents[0].ResolvedAt == nil || !incidents[0].ResolvedAt.Equal(start.Add(2*CheckInterval)) {
+		t.Errorf("unexpected resolution of first incident: %v", incidents[0].ResolvedAt)
+	}
+	if incidents[1].ResolvedAt != nil {
+		t.Errorf("expected ongoing incident, got resolved at %v", incidents[1].ResolvedAt)
+	}
+
+	if incidents := groupIncidents(failures, false); incidents[1].ResolvedAt == nil {
+		t.Error("expected last incident to be resolved when the monitor is up")
+	}
+}
+
+func TestBuildViewChecksAccess(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	if err := migration.RunMigrations(db, "../../migrations"); err != nil {
+		t.Fatal(err)
+	}
+
+	ownerID := insert(t, db, `INSERT INTO users (username) VALUES ('owner')`)
+	memberID := insert(t, db, `INSERT INTO users (username) VALUES ('member')`)
+
+	organizationRepo := repository.NewOrganizationRepository(db)
+	orgID, err := organizationRepo.Create(&models.Organization{Name: "Team"}, ownerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := organizationRepo.AddMember(&models.OrganizationMember{OrganizationID: orgID, UserID: memberID, Role: models.RoleEditor}); err != nil {
+		t.Fatal(err)
+	}
+
+	siteRepo := repository.NewSiteRepository(db)
+	teamSiteID, err := siteRepo.Create(&models.Site{UserID: ownerID, OrganizationID: &orgID, Domain: "team.example.com", ProtectionMode: models.SimpleProtection})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ownSiteID := insert(t, db, `INSERT INTO sites (user_id, domain, protection_mode) VALUES (?, 'own.example.com', 'simple')`, memberID)
+	teamMonitorID := insert(t, db, `INSERT INTO monitors (site_id, name, url) VALUES (?, 'Team', 'https://team.example.com')`, teamSiteID)
+	ownMonitorID := insert(t, db, `INSERT INTO monitors (site_id, name, url) VALUES (?, 'Own', 'https://own.example.com')`, ownSiteID)
+
+	healthCheckRepo := repository.NewHealthCheckRepository(db)
+	statusPages := NewStatusPageService(
+		repository.NewMonitorRepository(db),
+		newTestAuthorizer(db),
+		healthCheckRepo,
+		NewRollupService(healthCheckRepo, repository.NewRollupRepository(db)),
+		NewMaintenanceService(repository.NewMaintenanceRepository(db)),
+	)
+	page := &models.StatusPage{UserID: memberID, Title: "Status", MonitorIDs: []int64{teamMonitorID, ownMonitorID}}
+
+	assertMonitors := func(want ...string) {
+		t.Helper()
+
+		view, err := statusPages.BuildView(page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names := []string{}
+		for _, monitor := range view.Monitors {
+			names = append(names, monitor.Name)
+		}
+		if len(names) != len(want) || (len(want) > 0 && names[0] != want[0]) {
+			t.Errorf("status page monitors = %v; want %v", names, want)
+		}
+	}
+	assertMonitors("Team", "Own")
+
+	// A former member no longer publishes the monitors of the organization
+	if err := organizationRepo.RemoveMember(orgID, memberID); err != nil {
+		t.Fatal(err)
+	}
+	assertMonitors("Own")
+
+	// Nor those of a deleted site
+	if err := siteRepo.Delete(ownSiteID); err != nil {
+		t.Fatal(err)
+	}
+	assertMonitors()
+}
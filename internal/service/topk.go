@@ -0,0 +1,85 @@
+package service
+
+import (
+	"sort"
+
+	"egide-server/internal/models"
+)
+
+// TopK estimates the most frequent values of a stream in bounded memory
+// using the Space-Saving algorithm. It keeps at most capacity counters; when
+// full, a new value takes over the smallest counter and inherits its count
+// as error. Any value seen more than total/capacity times is guaranteed to
+// be tracked.
+type TopK struct {
+	capacity int
+	counters map[string]*models.TopValue
+}
+
+// NewTopK creates a sketch keeping at most capacity counters
+func NewTopK(capacity int) *TopK {
+	return &TopK{
+		capacity: capacity,
+		counters: make(map[string]*models.TopValue, capacity),
+	}
+}
+
+// Add counts weight occurrences of value
+func (t *TopK) Add(value string, weight int64) {
+	if counter, ok := t.counters[value]; ok {
+		counter.Count += weight
+		return
+	}
+
+	if len(t.counters) < t.capacity {
+		t.counters[value] = &models.TopValue{Value: value, Count: weight}
+		return
+	}
+
+	// Evict the smallest counter, the newcomer may have been counted there
+	min := t.min()
+	delete(t.counters, min.Value)
+	t.counters[value] = &models.TopValue{
+		Value: value,
+		Count: min.Count + weight,
+		Error: min.Count,
+	}
+}
+
+// Load seeds the sketch with previously computed counters
+func (t *TopK) Load(values []*models.TopValue) {
+	for _, value := range values {
+		if len(t.counters) >= t.capacity {
+			return
+		}
+		t.counters[value.Value] = &models.TopValue{Value: value.Value, Count: value.Count, Error: value.Error}
+	}
+}
+
+// Values returns the tracked counters, largest first
+func (t *TopK) Values() []*models.TopValue {
+	values := make([]*models.TopValue, 0, len(t.counters))
+	for _, counter := range t.counters {
+		values = append(values, &models.TopValue{Value: counter.Value, Count: counter.Count, Error: counter.Error})
+	}
+
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].Count != values[j].Count {
+			return values[i].Count > values[j].Count
+		}
+		return values[i].Value < values[j].Value
+	})
+	return values
+}
+
+// min returns the smallest counter. A linear scan is cheap at the small
+// capacities used here and only happens when a new value evicts another.
+func (t *TopK) min() *models.TopValue {
+	var min *models.TopValue
+	for _, counter := range t.counters {
+		if min == nil || counter.Count < min.Count {
+			min = counter
+		}
+	}
+	return min
+}
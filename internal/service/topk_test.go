@@ -0,0 +1,44 @@
+package service
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestTopKKeepsHeavyHitters(t *testing.T) {
+	topK := NewTopK(10)
+
+	// Two heavy hitters hidden in a long tail of unique values
+	for i := 0; i < 1000; i++ {
+		topK.Add("/", 1)
+		if i%2 == 0 {
+			topK.Add("/login", 1)
+		}
+		topK.Add("/tail/"+strconv.Itoa(i), 1)
+	}
+
+	values := topK.Values()
+	if len(values) != 10 {
+		t.Fatalf("sketch should be bounded to 10 counters, got %d", len(values))
+	}
+
+	if values[0].Value != "/" || values[0].Count != 1000 || values[0].Error != 0 {
+		t.Errorf("unexpected top value: %+v", values[0])
+	}
+	if values[1].Value != "/login" || values[1].Count-values[1].Error > 500 || values[1].Count < 500 {
+		t.Errorf("unexpected second value: %+v", values[1])
+	}
+}
+
+func TestTopKLoad(t *testing.T) {
+	topK := NewTopK(2)
+	topK.Add("a", 5)
+
+	restored := NewTopK(2)
+	restored.Load(topK.Values())
+	restored.Add("a", 1)
+
+	if values := restored.Values(); len(values) != 1 || values[0].Count != 6 {
+		t.Errorf("unexpected restored values: %+v", values)
+	}
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"log"
+	"time"
+
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+)
+
+// TrafficRetention keeps counters long enough for year-over-year comparisons
+const TrafficRetention = 400 * 24 * time.Hour
+
+// TrafficService stores and aggregates the request counters of edge nodes
+type TrafficService struct {
+	trafficRepo *repository.TrafficRepository
+	stopChan    chan struct{}
+}
+
+// NewTrafficService creates a new traffic service
+func NewTrafficService(trafficRepo *repository.TrafficRepository) *TrafficService {
+	return &TrafficService{
+		trafficRepo: trafficRepo,
+		stopChan:    make(chan struct{}),
+	}
+}
+
+// Start begins removing expired counters in the background
+func (s *TrafficService) Start() {
+	log.Println("Starting traffic service...")
+
+	ticker := time.NewTicker(6 * time.Hour)
+	go func() {
+		defer ticker.Stop()
+
+		s.cleanup()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.cleanup()
+			case <-s.stopChan:
+				log.Println("Traffic service stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop gracefully stops the traffic service
+func (s *TrafficService) Stop() {
+	close(s.stopChan)
+}
+
+// Record stores the counters an edge node reported for a site
+func (s *TrafficService) Record(site *models.Site, input *models.TrafficInput) error {
+	return s.trafficRepo.Save(&models.TrafficCounter{
+		SiteID:      site.ID,
+		Node:        input.Node,
+		PeriodStart: input.PeriodStart,
+		Requests:    input.Requests,
+		Blocked:     input.Blocked,
+		Challenged:  input.Challenged,
+		Cached:      input.Cached,
+		Bytes:       input.Bytes,
+	})
+}
+
+// GetTotals sums the counters of the given sites within [start, end)
+func (s *TrafficService) GetTotals(siteIDs []int64, start, end time.Time) (*models.TrafficTotals, error) {
+	return s.trafficRepo.GetTotals(siteIDs, start, end)
+}
+
+func (s *TrafficService) cleanup() {
+	if err := s.trafficRepo.DeleteBefore(time.Now().Add(-TrafficRetention)); err != nil {
+		log.Printf("Failed to cleanup old traffic counters: %v", err)
+	}
+}
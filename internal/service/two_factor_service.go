@@ -0,0 +1,288 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"egide-server/internal/auth"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+)
+
+const (
+	// StepUpTTL is how long a two-factor verification allows sensitive actions
+	StepUpTTL = 10 * time.Minute
+
+	// RecoveryCodeCount is how many recovery codes are issued at once
+	RecoveryCodeCount = 10
+
+	// MaxTwoFactorAttempts is how many wrong codes lock verification out for
+	// TwoFactorLockout, so that codes can't be guessed
+	MaxTwoFactorAttempts = 5
+	TwoFactorLockout     = 15 * time.Minute
+)
+
+var (
+	// ErrTwoFactorEnabled is returned when enrolling while two-factor authentication is enabled
+	ErrTwoFactorEnabled = errors.New("two-factor authentication is already enabled")
+
+	// ErrTwoFactorNotEnabled is returned when verifying without two-factor authentication
+	ErrTwoFactorNotEnabled = errors.New("two-factor authentication is not enabled")
+
+	// ErrInvalidTwoFactorCode is returned for wrong, expired or already used codes
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+
+	// ErrTwoFactorLocked is returned while verification is locked out after
+	// too many wrong codes
+	ErrTwoFactorLocked = errors.New("too many failed two-factor attempts")
+
+	// ErrStepUpRequired is returned when a sensitive action needs a recent
+	// two-factor verification of the session
+	ErrStepUpRequired = errors.New("two-factor verification required")
+)
+
+// TwoFactorService manages the TOTP authenticators of users and the step-up
+// verifications guarding sensitive actions. Secrets are stored encrypted,
+// recovery codes hashed.
+type TwoFactorService struct {
+	twoFactorRepo *repository.TwoFactorRepository
+	sessionRepo   *repository.SessionRepository
+	cipher        *auth.KeyCipher
+}
+
+// NewTwoFactorService creates a new two-factor service
+func NewTwoFactorService(twoFactorRepo *repository.TwoFactorRepository, sessionRepo *repository.SessionRepository, cipher *auth.KeyCipher) *TwoFactorService {
+	return &TwoFactorService{
+		twoFactorRepo: twoFactorRepo,
+		sessionRepo:   sessionRepo,
+		cipher:        cipher,
+	}
+}
+
+// Status returns whether a user enabled two-factor authentication
+func (s *TwoFactorService) Status(userID int64) (*models.TwoFactorStatus, error) {
+	status := &models.TwoFactorStatus{}
+
+	twoFactor, err := s.twoFactorRepo.FindByUserID(userID)
+	if err != nil || twoFactor.EnabledAt == nil {
+		return status, nil
+	}
+
+	status.Enabled = true
+	status.EnabledAt = twoFactor.EnabledAt
+	status.RecoveryCodesLeft, err = s.twoFactorRepo.CountRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// Enroll generates a new secret for a user, to be confirmed with a first code
+func (s *TwoFactorService) Enroll(userID int64, account string) (*models.TwoFactorEnrollment, error) {
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := s.cipher.Encrypt([]byte(secret))
+	if err != nil {
+		return nil, err
+	}
+
+	saved, err := s.twoFactorRepo.SavePending(&models.TwoFactor{
+		UserID:    userID,
+		Secret:    encrypted,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !saved {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	return &models.TwoFactorEnrollment{
+		Secret:          secret,
+		ProvisioningURI: auth.TOTPProvisioningURI(account, secret),
+	}, nil
+}
+
+// Confirm enables two-factor authentication with a code of the enrolled
+// authenticator, returning the recovery codes. The session is verified.
+func (s *TwoFactorService) Confirm(userID, sessionID int64, code string) ([]string, error) {
+	twoFactor, err := s.twoFactorRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if twoFactor.EnabledAt != nil {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	now := time.Now()
+	err = s.attempt(userID, now, func() error {
+		return s.checkTOTP(twoFactor, code, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.twoFactorRepo.Enable(userID, now, hashes); err != nil {
+		return nil, err
+	}
+
+	if sessionID != 0 {
+		if err := s.sessionRepo.MarkTwoFactorVerified(sessionID, now); err != nil {
+			return nil, err
+		}
+	}
+	return codes, nil
+}
+
+// Verify checks a TOTP or recovery code of a user, allowing sensitive actions
+// in the session for StepUpTTL
+func (s *TwoFactorService) Verify(userID, sessionID int64, code string) error {
+	twoFactor, err := s.twoFactorRepo.FindByUserID(userID)
+	if err != nil || twoFactor.EnabledAt == nil {
+		return ErrTwoFactorNotEnabled
+	}
+
+	now := time.Now()
+	code = normalizeCode(code)
+	err = s.attempt(userID, now, func() error {
+		if len(code) == auth.TOTPDigits {
+			return s.checkTOTP(twoFactor, code, now)
+		}
+		return s.useRecoveryCode(userID, code, now)
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.sessionRepo.MarkTwoFactorVerified(sessionID, now)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of a user
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID int64) ([]string, error) {
+	twoFactor, err := s.twoFactorRepo.FindByUserID(userID)
+	if err != nil || twoFactor.EnabledAt == nil {
+		return nil, ErrTwoFactorNotEnabled
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.twoFactorRepo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Disable removes the authenticator and recovery codes of a user
+func (s *TwoFactorService) Disable(userID int64) error {
+	return s.twoFactorRepo.Delete(userID)
+}
+
+// CheckStepUp returns ErrStepUpRequired unless the user hasn't enabled
+// two-factor authentication, or verified it in the session recently.
+// Personal access tokens, outside of any session, can't be verified.
+func (s *TwoFactorService) CheckStepUp(userID, sessionID int64) error {
+	twoFactor, err := s.twoFactorRepo.FindByUserID(userID)
+	if err != nil || twoFactor.EnabledAt == nil {
+		return nil
+	}
+	if sessionID == 0 {
+		return ErrStepUpRequired
+	}
+
+	session, err := s.sessionRepo.FindByID(sessionID)
+	if err != nil {
+		return err
+	}
+	if session.TwoFactorVerifiedAt == nil || time.Since(*session.TwoFactorVerifiedAt) > StepUpTTL {
+		return ErrStepUpRequired
+	}
+	return nil
+}
+
+// attempt runs check as an attempt of the user to verify a code, refusing it
+// while verification is locked out. Attempts are counted before the check so
+// that concurrent guesses can't exceed MaxTwoFactorAttempts.
+func (s *TwoFactorService) attempt(userID int64, now time.Time, check func() error) error {
+	allowed, err := s.twoFactorRepo.BeginAttempt(userID, now, MaxTwoFactorAttempts, now.Add(TwoFactorLockout))
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrTwoFactorLocked
+	}
+
+	if err := check(); err != nil {
+		return err
+	}
+	return s.twoFactorRepo.ResetAttempts(userID)
+}
+
+// checkTOTP validates a code of the authenticator, refusing codes of periods
+// already used so that an observed code can't be replayed
+func (s *TwoFactorService) checkTOTP(twoFactor *models.TwoFactor, code string, now time.Time) error {
+	secret, err := s.cipher.Decrypt(twoFactor.Secret)
+	if err != nil {
+		return err
+	}
+
+	counter, ok := auth.ValidateTOTP(string(secret), normalizeCode(code), now)
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+
+	used, err := s.twoFactorRepo.UseCounter(twoFactor.UserID, counter)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+func (s *TwoFactorService) useRecoveryCode(userID int64, code string, now time.Time) error {
+	used, err := s.twoFactorRepo.UseRecoveryCode(userID, hashToken(code), now)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// newRecoveryCodes returns recovery codes formatted as xxxxx-xxxxx, and the
+// hashes stored for them
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, RecoveryCodeCount)
+	hashes := make([]string, RecoveryCodeCount)
+
+	for i := range codes {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		code := hex.EncodeToString(b)
+		codes[i] = code[:5] + "-" + code[5:]
+		hashes[i] = hashToken(code)
+	}
+	return codes, hashes, nil
+}
+
+// normalizeCode removes the separators users may type in codes
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
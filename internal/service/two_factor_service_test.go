@@ -0,0 +1,158 @@
+package service
+
+import (
+	"database/sql"
+	"net/url"
+	"testing"
+	"time"
+
+	"egide-server/internal/auth"
+	"egide-server/internal/migration"
+	"egide-server/internal/repository"
+)
+
+func TestTwoFactorStepUp(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	if err := migration.RunMigrations(db, "../../migrations"); err != nil {
+		t.Fatal(err)
+	}
+
+	userID := insert(t, db, `INSERT INTO users (username) VALUES ('test')`)
+	sessionRepo := repository.NewSessionRepository(db)
+	sessions := NewSessionService(sessionRepo, auth.NewTokenService(newTestSigningKeys(t, db)))
+	cipher, err := auth.NewKeyCipher("secret", auth.PurposeTwoFactor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	twoFactor := NewTwoFactorService(repository.NewTwoFactorRepository(db), sessionRepo, cipher)
+
+	pair, err := sessions.Start(userID, "test-agent", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionID := pair.SessionID
+
+	// Without two-factor authentication, nothing needs a step-up
+	if err := twoFactor.CheckStepUp(userID, sessionID); err != nil {
+		t.Errorf("CheckStepUp() without two-factor = %v", err)
+	}
+
+	enrollment, err := twoFactor.Enroll(userID, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	uri, err := url.Parse(enrollment.ProvisioningURI)
+	if err != nil || uri.Query().Get("secret") != enrollment.Secret {
+		t.Errorf("provisioning URI %q", enrollment.ProvisioningURI)
+	}
+
+	// A pending enrollment doesn't enable anything
+	if status, _ := twoFactor.Status(userID); status.Enabled {
+		t.Error("two-factor enabled before confirmation")
+	}
+	if _, err := twoFactor.Confirm(userID, sessionID, "000000"); err != ErrInvalidTwoFactorCode {
+		t.Errorf("Confirm() with a wrong code = %v; want %v", err, ErrInvalidTwoFactorCode)
+	}
+
+	code, _ := auth.TOTPCode(enrollment.Secret, time.Now())
+	codes, err := twoFactor.Confirm(userID, sessionID, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != RecoveryCodeCount {
+		t.Errorf("got %d recovery codes; want %d", len(codes), RecoveryCodeCount)
+	}
+	if _, err := twoFactor.Enroll(userID, "test"); err != ErrTwoFactorEnabled {
+		t.Errorf("Enroll() once enabled = %v; want %v", err, ErrTwoFactorEnabled)
+	}
+
+	// Confirming verified the session
+	if err := twoFactor.CheckStepUp(userID, sessionID); err != nil {
+		t.Errorf("CheckStepUp() after confirmation = %v", err)
+	}
+
+	// Access tokens can't step up, nor can sessions verified too long ago
+	if err := twoFactor.CheckStepUp(userID, 0); err != ErrStepUpRequired {
+		t.Errorf("CheckStepUp() for an access token = %v; want %v", err, ErrStepUpRequired)
+	}
+	if err := sessionRepo.MarkTwoFactorVerified(sessionID, time.Now().Add(-StepUpTTL-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if err := twoFactor.CheckStepUp(userID, sessionID); err != ErrStepUpRequired {
+		t.Errorf("CheckStepUp() after %v = %v; want %v", StepUpTTL, err, ErrStepUpRequired)
+	}
+
+	// Codes can't be replayed
+	if err := twoFactor.Verify(userID, sessionID, code); err != ErrInvalidTwoFactorCode {
+		t.Errorf("Verify() with a used code = %v; want %v", err, ErrInvalidTwoFactorCode)
+	}
+	next, _ := auth.TOTPCode(enrollment.Secret, time.Now().Add(auth.TOTPPeriod))
+	if err := twoFactor.Verify(userID, sessionID, next); err != nil {
+		t.Fatal(err)
+	}
+	if err := twoFactor.CheckStepUp(userID, sessionID); err != nil {
+		t.Errorf("CheckStepUp() after verification = %v", err)
+	}
+
+	// Recovery codes work once
+	if err := twoFactor.Verify(userID, sessionID, codes[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := twoFactor.Verify(userID, sessionID, codes[0]); err != ErrInvalidTwoFactorCode {
+		t.Errorf("Verify() with a used recovery code = %v; want %v", err, ErrInvalidTwoFactorCode)
+	}
+	if status, _ := twoFactor.Status(userID); !status.Enabled || status.RecoveryCodesLeft != RecoveryCodeCount-1 {
+		t.Errorf("Status() = %+v", status)
+	}
+
+	regenerated, err := twoFactor.RegenerateRecoveryCodes(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := twoFactor.Verify(userID, sessionID, codes[1]); err != ErrInvalidTwoFactorCode {
+		t.Errorf("Verify() with a replaced recovery code = %v; want %v", err, ErrInvalidTwoFactorCode)
+	}
+	if err := twoFactor.Verify(userID, sessionID, regenerated[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	// Too many wrong codes lock verification out, even with a valid code
+	for i := 0; i < MaxTwoFactorAttempts; i++ {
+		if err := twoFactor.Verify(userID, sessionID, "000000"); err != ErrInvalidTwoFactorCode {
+			t.Fatalf("Verify() wrong code %d = %v; want %v", i+1, err, ErrInvalidTwoFactorCode)
+		}
+	}
+	if err := twoFactor.Verify(userID, sessionID, regenerated[1]); err != ErrTwoFactorLocked {
+		t.Errorf("Verify() once locked out = %v; want %v", err, ErrTwoFactorLocked)
+	}
+	if _, err := db.Exec(`UPDATE user_two_factor SET locked_until = ? WHERE user_id = ?`, time.Now().Add(-time.Second).UTC(), userID); err != nil {
+		t.Fatal(err)
+	}
+	if err := twoFactor.Verify(userID, sessionID, regenerated[1]); err != nil {
+		t.Errorf("Verify() after the lockout = %v", err)
+	}
+
+	// A valid code clears the failed attempts
+	for i := 0; i < MaxTwoFactorAttempts-1; i++ {
+		twoFactor.Verify(userID, sessionID, "000000")
+	}
+	if err := twoFactor.Verify(userID, sessionID, regenerated[2]); err != nil {
+		t.Fatal(err)
+	}
+	if err := twoFactor.Verify(userID, sessionID, "000000"); err != ErrInvalidTwoFactorCode {
+		t.Errorf("Verify() after a valid code = %v; want %v", err, ErrInvalidTwoFactorCode)
+	}
+
+	if err := twoFactor.Disable(userID); err != nil {
+		t.Fatal(err)
+	}
+	if err := twoFactor.CheckStepUp(userID, 0); err != nil {
+		t.Errorf("CheckStepUp() once disabled = %v", err)
+	}
+}
@@ -0,0 +1,317 @@
+package service
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+)
+
+const (
+	// Header carrying the timestamp and HMAC-SHA256 signature of the request body
+	WebhookSignatureHeader = "X-Egide-Signature"
+
+	// Age past which receivers should reject a signature, making replayed
+	// deliveries fail
+	WebhookSignatureTolerance = 5 * time.Minute
+
+	// Maximum number of attempts before a delivery is marked as failed
+	WebhookMaxAttempts = 8
+
+	// Delay before the first retry, doubled after every failed attempt
+	WebhookInitialBackoff = 30 * time.Second
+
+	// How often pending deliveries are polled
+	WebhookPollInterval = 10 * time.Second
+
+	// Delivery log retention period
+	WebhookDeliveryRetention = 30 * 24 * time.Hour
+)
+
+// WebhookEvent is the JSON body sent to subscribers
+type WebhookEvent struct {
+	ID        string           `json:"id"`
+	Type      models.EventType `json:"type"`
+	CreatedAt time.Time        `json:"created_at"`
+	Data      interface{}      `json:"data"`
+}
+
+// WebhookService fans events out to subscriptions and delivers them with retries
+type WebhookService struct {
+	webhookRepo *repository.WebhookRepository
+	httpClient  *http.Client
+	wake        chan struct{}
+	stopChan    chan struct{}
+}
+
+// NewWebhookService creates a new webhook service
+func NewWebhookService(webhookRepo *repository.WebhookRepository) *WebhookService {
+	return &WebhookService{
+		webhookRepo: webhookRepo,
+		httpClient:  newOutboundClient(10 * time.Second),
+		wake:        make(chan struct{}, 1),
+		stopChan:    make(chan struct{}),
+	}
+}
+
+// Start begins the background delivery loop
+func (s *WebhookService) Start() {
+	log.Println("Starting webhook service...")
+
+	ticker := time.NewTicker(WebhookPollInterval)
+	cleanupTicker := time.NewTicker(6 * time.Hour)
+	go func() {
+		defer ticker.Stop()
+		defer cleanupTicker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.deliverDue()
+			case <-s.wake:
+				s.deliverDue()
+			case <-cleanupTicker.C:
+				if err := s.webhookRepo.DeleteOldDeliveries(WebhookDeliveryRetention); err != nil {
+					log.Printf("Failed to cleanup old webhook deliveries: %v", err)
+				}
+			case <-s.stopChan:
+				log.Println("Webhook service stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop gracefully stops the webhook service
+func (s *WebhookService) Stop() {
+	close(s.stopChan)
+}
+
+// QueueDepth returns the number of deliveries waiting to be sent or retried
+func (s *WebhookService) QueueDepth() (int, error) {
+	return s.webhookRepo.CountPending()
+}
+
+// Publish queues an event for every active subscription of the user listening to it
+func (s *WebhookService) Publish(userID int64, eventType models.EventType, data interface{}) {
+	subs, err := s.webhookRepo.FindSubscriptionsByUserID(userID)
+	if err != nil {
+		log.Printf("Failed to load webhook subscriptions for user %d: %v", userID, err)
+		return
+	}
+
+	event := &WebhookEvent{
+		ID:        newEventID(),
+		Type:      eventType,
+		CreatedAt: time.Now(),
+		Data:      data,
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Failed to encode %s event: %v", eventType, err)
+		return
+	}
+
+	queued := false
+	for _, sub := range subs {
+		if !sub.Active || !subscribedTo(sub, eventType) {
+			continue
+		}
+
+		now := time.Now()
+		_, err := s.webhookRepo.CreateDelivery(&models.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			EventID:        event.ID,
+			EventType:      eventType,
+			Payload:        string(payload),
+			Status:         models.DeliveryPending,
+			NextAttemptAt:  &now,
+		})
+		if err != nil {
+			log.Printf("Failed to queue %s delivery for subscription %d: %v", eventType, sub.ID, err)
+			continue
+		}
+		queued = true
+	}
+
+	if queued {
+		s.notifyWorker()
+	}
+}
+
+// Redeliver queues a new delivery with the payload of an existing one
+func (s *WebhookService) Redeliver(delivery *models.WebhookDelivery) (*models.WebhookDelivery, error) {
+	now := time.Now()
+	redelivery := &models.WebhookDelivery{
+		SubscriptionID: delivery.SubscriptionID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Payload:        delivery.Payload,
+		Status:         models.DeliveryPending,
+		NextAttemptAt:  &now,
+	}
+
+	id, err := s.webhookRepo.CreateDelivery(redelivery)
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyWorker()
+	return s.webhookRepo.FindDeliveryByID(id)
+}
+
+// GenerateSecret returns a new random signing secret for a subscription
+func GenerateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// SignPayload returns the value of the signature header for a payload sent
+// at timestamp: "t=<unix time>,v1=<hex HMAC of "<unix time>.<payload>">".
+// Signing the timestamp lets receivers reject replays of old deliveries.
+func SignPayload(secret string, timestamp time.Time, payload []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t + "."))
+	mac.Write(payload)
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// notifyWorker wakes the delivery loop without blocking
+func (s *WebhookService) notifyWorker() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// deliverDue attempts every pending delivery whose next attempt is due
+func (s *WebhookService) deliverDue() {
+	deliveries, err := s.webhookRepo.FindDueDeliveries(time.Now(), 50)
+	if err != nil {
+		log.Printf("Failed to load pending webhook deliveries: %v", err)
+		return
+	}
+
+	for _, delivery := range deliveries {
+		sub, err := s.webhookRepo.FindSubscriptionByID(delivery.SubscriptionID)
+		if err != nil {
+			log.Printf("Failed to load webhook subscription %d: %v", delivery.SubscriptionID, err)
+			continue
+		}
+
+		// Disabling a subscription stops its queued deliveries too
+		if !sub.Active {
+			s.cancel(delivery, "subscription is inactive")
+			continue
+		}
+
+		s.attempt(sub, delivery)
+	}
+}
+
+// attempt sends a delivery once and schedules a retry on failure
+func (s *WebhookService) attempt(sub *models.WebhookSubscription, delivery *models.WebhookDelivery) {
+	statusCode, err := s.send(sub, delivery)
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastStatusCode = statusCode
+	delivery.LastError = nil
+
+	if err == nil {
+		delivery.Status = models.DeliverySucceeded
+		delivery.NextAttemptAt = nil
+		delivery.DeliveredAt = &now
+	} else {
+		errMsg := err.Error()
+		delivery.LastError = &errMsg
+
+		if delivery.Attempts >= WebhookMaxAttempts {
+			delivery.Status = models.DeliveryFailed
+			delivery.NextAttemptAt = nil
+		} else {
+			next := now.Add(webhookBackoff(delivery.Attempts))
+			delivery.NextAttemptAt = &next
+		}
+	}
+
+	if err := s.webhookRepo.UpdateDeliveryAttempt(delivery); err != nil {
+		log.Printf("Failed to record webhook delivery %d: %v", delivery.ID, err)
+	}
+}
+
+// cancel marks a delivery as failed without attempting it
+func (s *WebhookService) cancel(delivery *models.WebhookDelivery, reason string) {
+	delivery.Status = models.DeliveryFailed
+	delivery.NextAttemptAt = nil
+	delivery.LastStatusCode = nil
+	delivery.LastError = &reason
+
+	if err := s.webhookRepo.UpdateDeliveryAttempt(delivery); err != nil {
+		log.Printf("Failed to record webhook delivery %d: %v", delivery.ID, err)
+	}
+}
+
+func (s *WebhookService) send(sub *models.WebhookSubscription, delivery *models.WebhookDelivery) (*int, error) {
+	payload := []byte(delivery.Payload)
+
+	req, err := http.NewRequest("POST", sub.URL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Egide-Webhooks/1.0")
+	req.Header.Set("X-Egide-Event", string(delivery.EventType))
+	req.Header.Set("X-Egide-Delivery", fmt.Sprintf("%d", delivery.ID))
+	req.Header.Set(WebhookSignatureHeader, SignPayload(sub.Secret, time.Now(), payload))
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	statusCode := resp.StatusCode
+	if statusCode < 200 || statusCode >= 300 {
+		return &statusCode, fmt.Errorf("endpoint returned HTTP %d", statusCode)
+	}
+
+	return &statusCode, nil
+}
+
+// webhookBackoff returns the delay before the next attempt after `attempts` failures
+func webhookBackoff(attempts int) time.Duration {
+	return WebhookInitialBackoff * time.Duration(1<<uint(attempts-1))
+}
+
+func subscribedTo(sub *models.WebhookSubscription, eventType models.EventType) bool {
+	for _, event := range sub.Events {
+		if event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func newEventID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return "evt_" + hex.EncodeToString(b)
+}
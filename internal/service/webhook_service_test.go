@@ -0,0 +1,104 @@
+package service
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"egide-server/internal/migration"
+	"egide-server/internal/models"
+	"egide-server/internal/repository"
+)
+
+func TestSignPayload(t *testing.T) {
+	// Reference value computed with: printf '1700000000.{"id":"evt_1"}' | openssl dgst -sha256 -hmac secret
+	at := time.Unix(1700000000, 0)
+	got := SignPayload("secret", at, []byte(`{"id":"evt_1"}`))
+	want := "t=1700000000,v1=af784f27423c462e20039559cd4264140f7b7ed4c9090e26fd663faa5eeb8dda"
+	if got != want {
+		t.Errorf("SignPayload() = %s, want %s", got, want)
+	}
+
+	if SignPayload("secret", at, []byte("a")) == SignPayload("other", at, []byte("a")) {
+		t.Errorf("signatures with different secrets should differ")
+	}
+	if SignPayload("secret", at, []byte("a")) == SignPayload("secret", at.Add(time.Second), []byte("a")) {
+		t.Errorf("signatures at different times should differ")
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{7, 32 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := webhookBackoff(tt.attempts); got != tt.want {
+			t.Errorf("webhookBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestDeliverDueSkipsInactiveSubscriptions(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	if err := migration.RunMigrations(db, "../../migrations"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The endpoint would be refused anyway: reaching it means the delivery was attempted
+	attempted := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempted = true
+	}))
+	defer server.Close()
+
+	userID := insert(t, db, `INSERT INTO users (username) VALUES ('test')`)
+	webhookRepo := repository.NewWebhookRepository(db)
+	subID, err := webhookRepo.CreateSubscription(&models.WebhookSubscription{
+		UserID: userID,
+		URL:    server.URL,
+		Secret: "secret",
+		Events: []models.EventType{models.EventSiteUpdated},
+		Active: false,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	deliveryID, err := webhookRepo.CreateDelivery(&models.WebhookDelivery{
+		SubscriptionID: subID,
+		EventID:        "evt_1",
+		EventType:      models.EventSiteUpdated,
+		Payload:        `{}`,
+		Status:         models.DeliveryPending,
+		NextAttemptAt:  &now,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	NewWebhookService(webhookRepo).deliverDue()
+
+	delivery, err := webhookRepo.FindDeliveryByID(deliveryID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delivery.Status != models.DeliveryFailed || delivery.Attempts != 0 || attempted {
+		t.Errorf("delivery of an inactive subscription = %s after %d attempts (attempted=%v); want failed without attempts", delivery.Status, delivery.Attempts, attempted)
+	}
+}
@@ -0,0 +1,137 @@
+package telemetry
+
+import (
+	"net/http"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Outcomes of a monitoring check
+const (
+	CheckUp    = "up"    // the site answered without a server error
+	CheckDown  = "down"  // the site answered with a 5xx status
+	CheckError = "error" // the request failed (DNS, connection, timeout...)
+)
+
+// Default is the registry of the server's own metrics, served on /metrics
+var Default = NewRegistry()
+
+var (
+	// Buckets in seconds for HTTP requests and monitoring checks
+	requestBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+	// Buckets in seconds for database queries
+	queryBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1}
+
+	httpRequests = Default.NewCounterVec(
+		"egide_http_requests_total",
+		"HTTP requests handled, by method, route pattern and status code.",
+		"method", "route", "status",
+	)
+
+	httpDuration = Default.NewHistogramVec(
+		"egide_http_request_duration_seconds",
+		"Time spent handling HTTP requests, by method and route pattern.",
+		requestBuckets,
+		"method", "route",
+	)
+
+	queryDuration = Default.NewHistogramVec(
+		"egide_db_query_duration_seconds",
+		"Time spent in repository calls, by repository and operation.",
+		queryBuckets,
+		"repository", "operation",
+	)
+
+	checkDuration = Default.NewHistogramVec(
+		"egide_monitor_check_duration_seconds",
+		"Duration of monitoring checks, by outcome.",
+		requestBuckets,
+		"result",
+	)
+
+	checks = Default.NewCounterVec(
+		"egide_monitor_checks_total",
+		"Monitoring checks performed, by outcome.",
+		"result",
+	)
+
+	queueDepth = Default.NewGaugeFuncVec(
+		"egide_job_queue_depth",
+		"Jobs waiting to be processed by background services, by queue.",
+		"queue",
+	)
+
+	goroutines = Default.NewGaugeFuncVec(
+		"go_goroutines",
+		"Number of goroutines that currently exist.",
+	)
+)
+
+func init() {
+	goroutines.Set(func() (float64, error) {
+		return float64(runtime.NumGoroutine()), nil
+	})
+}
+
+// Middleware records the count and duration of HTTP requests per chi route.
+// The route pattern is used rather than the path so that IDs don't create
+// a series per resource.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		// chi fills the route context while routing, so the pattern is only
+		// known once the request has been handled
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			if pattern := rctx.RoutePattern(); pattern != "" {
+				route = pattern
+			}
+		}
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		httpRequests.Inc(r.Method, route, strconv.Itoa(status))
+		httpDuration.Observe(time.Since(start).Seconds(), r.Method, route)
+	})
+}
+
+// ObserveQuery starts timing a repository call, the returned function records it:
+//
+//	defer telemetry.ObserveQuery("site", "FindByID")()
+func ObserveQuery(repository, operation string) func() {
+	start := time.Now()
+	return func() {
+		queryDuration.Observe(time.Since(start).Seconds(), repository, operation)
+	}
+}
+
+// ObserveCheck records the outcome and duration of a monitoring check
+func ObserveCheck(result string, duration time.Duration) {
+	checks.Inc(result)
+	checkDuration.Observe(duration.Seconds(), result)
+}
+
+// RegisterQueue exposes the depth of a background job queue
+func RegisterQueue(queue string, depth func() (int, error)) {
+	queueDepth.Set(func() (float64, error) {
+		n, err := depth()
+		return float64(n), err
+	}, queue)
+}
+
+// Handler serves the default registry, see Registry.Handler
+func Handler(token string) http.Handler {
+	return Default.Handler(token)
+}
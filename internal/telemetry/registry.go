@@ -0,0 +1,376 @@
+package telemetry
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the media type of the Prometheus text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Registry holds metrics and renders them in the Prometheus text exposition format
+type Registry struct {
+	mu      sync.Mutex
+	metrics map[string]collector
+}
+
+type collector interface {
+	write(b *bytes.Buffer)
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		metrics: make(map[string]collector),
+	}
+}
+
+func (r *Registry) register(name string, c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.metrics[name]; ok {
+		panic("telemetry: metric " + name + " registered twice")
+	}
+	r.metrics[name] = c
+}
+
+// NewCounterVec creates and registers a counter partitioned by labels
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{
+		desc:   desc{name, help, "counter", labels},
+		values: make(map[string]*labeledValue),
+	}
+	r.register(name, c)
+	return c
+}
+
+// NewHistogramVec creates and registers a histogram partitioned by labels.
+// Buckets are upper bounds in ascending order, +Inf is implied.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{
+		desc:    desc{name, help, "histogram", labels},
+		buckets: buckets,
+		values:  make(map[string]*histogramValue),
+	}
+	r.register(name, h)
+	return h
+}
+
+// NewGaugeVec creates and registers a gauge partitioned by labels
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{
+		desc:   desc{name, help, "gauge", labels},
+		values: make(map[string]*labeledValue),
+	}
+	r.register(name, g)
+	return g
+}
+
+// NewGaugeFuncVec creates and registers a gauge whose values are computed at scrape time
+func (r *Registry) NewGaugeFuncVec(name, help string, labels ...string) *GaugeFuncVec {
+	g := &GaugeFuncVec{
+		desc:  desc{name, help, "gauge", labels},
+		funcs: make(map[string]*gaugeFunc),
+	}
+	r.register(name, g)
+	return g
+}
+
+// WriteText renders every metric, sorted by name
+func (r *Registry) WriteText(b *bytes.Buffer) {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	collectors := make([]collector, 0, len(names))
+	sort.Strings(names)
+	for _, name := range names {
+		collectors = append(collectors, r.metrics[name])
+	}
+	r.mu.Unlock()
+
+	for _, c := range collectors {
+		c.write(b)
+	}
+}
+
+// Handler serves the metrics. When token is not empty, scrapers must send it
+// as a bearer token.
+func (r *Registry) Handler(token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if token != "" {
+			provided := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		var b bytes.Buffer
+		r.WriteText(&b)
+
+		w.Header().Set("Content-Type", ContentType)
+		w.Header().Set("Cache-Control", "no-store")
+		if _, err := w.Write(b.Bytes()); err != nil {
+			log.Printf("Failed to write metrics: %v", err)
+		}
+	})
+}
+
+// desc describes a metric family
+type desc struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+}
+
+func (d *desc) writeHeader(b *bytes.Buffer) {
+	fmt.Fprintf(b, "# HELP %s %s\n", d.name, escapeHelp(d.help))
+	fmt.Fprintf(b, "# TYPE %s %s\n", d.name, d.kind)
+}
+
+// key identifies a combination of label values
+func (d *desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("telemetry: %s expects %d label values, got %d", d.name, len(d.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// writeSample renders one sample line, extra is an additional label such as le
+func (d *desc) writeSample(b *bytes.Buffer, suffix string, values []string, extraName, extraValue string, value float64) {
+	b.WriteString(d.name)
+	b.WriteString(suffix)
+
+	if len(values) > 0 || extraName != "" {
+		b.WriteByte('{')
+		for i, label := range d.labels {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(b, "%s=\"%s\"", label, escapeLabel(values[i]))
+		}
+		if extraName != "" {
+			if len(values) > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(b, "%s=\"%s\"", extraName, extraValue)
+		}
+		b.WriteByte('}')
+	}
+
+	b.WriteByte(' ')
+	b.WriteString(formatFloat(value))
+	b.WriteByte('\n')
+}
+
+// CounterVec is a monotonically increasing value per label combination
+type CounterVec struct {
+	desc
+	mu     sync.Mutex
+	values map[string]*labeledValue
+}
+
+type labeledValue struct {
+	labels []string
+	value  float64
+}
+
+// Inc increments the counter of the given label values by one
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add increments the counter of the given label values
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	key := c.key(labelValues)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	value, ok := c.values[key]
+	if !ok {
+		value = &labeledValue{labels: append([]string(nil), labelValues...)}
+		c.values[key] = value
+	}
+	value.value += delta
+}
+
+func (c *CounterVec) write(b *bytes.Buffer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.writeHeader(b)
+	for _, key := range sortedKeys(c.values) {
+		value := c.values[key]
+		c.writeSample(b, "", value.labels, "", "", value.value)
+	}
+}
+
+// HistogramVec counts observations in buckets per label combination
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	values  map[string]*histogramValue
+}
+
+type histogramValue struct {
+	labels []string
+	counts []uint64 // per bucket, not cumulative; the last one is +Inf
+	sum    float64
+	count  uint64
+}
+
+// Observe records a value for the given label values
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	key := h.key(labelValues)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	value, ok := h.values[key]
+	if !ok {
+		value = &histogramValue{
+			labels: append([]string(nil), labelValues...),
+			counts: make([]uint64, len(h.buckets)+1),
+		}
+		h.values[key] = value
+	}
+
+	i := sort.SearchFloat64s(h.buckets, v)
+	value.counts[i]++
+	value.sum += v
+	value.count++
+}
+
+func (h *HistogramVec) write(b *bytes.Buffer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.writeHeader(b)
+	for _, key := range sortedKeys(h.values) {
+		value := h.values[key]
+
+		var cumulative uint64
+		for i, le := range h.buckets {
+			cumulative += value.counts[i]
+			h.writeSample(b, "_bucket", value.labels, "le", formatFloat(le), float64(cumulative))
+		}
+		h.writeSample(b, "_bucket", value.labels, "le", "+Inf", float64(value.count))
+		h.writeSample(b, "_sum", value.labels, "", "", value.sum)
+		h.writeSample(b, "_count", value.labels, "", "", float64(value.count))
+	}
+}
+
+// GaugeVec is a value that can go up and down per label combination
+type GaugeVec struct {
+	desc
+	mu     sync.Mutex
+	values map[string]*labeledValue
+}
+
+// Set sets the gauge of the given label values
+func (g *GaugeVec) Set(v float64, labelValues ...string) {
+	key := g.key(labelValues)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.values[key] = &labeledValue{labels: append([]string(nil), labelValues...), value: v}
+}
+
+func (g *GaugeVec) write(b *bytes.Buffer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.writeHeader(b)
+	for _, key := range sortedKeys(g.values) {
+		value := g.values[key]
+		g.writeSample(b, "", value.labels, "", "", value.value)
+	}
+}
+
+// GaugeFuncVec is a gauge whose values are read from callbacks when scraped
+type GaugeFuncVec struct {
+	desc
+	mu    sync.Mutex
+	funcs map[string]*gaugeFunc
+}
+
+type gaugeFunc struct {
+	labels []string
+	fn     func() (float64, error)
+}
+
+// Set registers the callback computing the value of the given label values.
+// Samples whose callback fails are left out of the scrape.
+func (g *GaugeFuncVec) Set(fn func() (float64, error), labelValues ...string) {
+	key := g.key(labelValues)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.funcs[key] = &gaugeFunc{labels: append([]string(nil), labelValues...), fn: fn}
+}
+
+func (g *GaugeFuncVec) write(b *bytes.Buffer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.writeHeader(b)
+	for _, key := range sortedKeys(g.funcs) {
+		gauge := g.funcs[key]
+		value, err := gauge.fn()
+		if err != nil {
+			log.Printf("Failed to compute %s: %v", g.name, err)
+			continue
+		}
+		g.writeSample(b, "", gauge.labels, "", "", value)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
@@ -0,0 +1,99 @@
+package telemetry
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestWriteText(t *testing.T) {
+	registry := NewRegistry()
+
+	requests := registry.NewCounterVec("test_requests_total", "Requests.\nPer route.", "route")
+	requests.Inc("/b")
+	requests.Add(2, `/a"quoted"`)
+
+	durations := registry.NewHistogramVec("test_duration_seconds", "Durations.", []float64{0.1, 1}, "route")
+	durations.Observe(0.05, "/a")
+	durations.Observe(0.1, "/a")
+	durations.Observe(3, "/a")
+
+	queue := registry.NewGaugeFuncVec("test_queue_depth", "Queue depth.", "queue")
+	queue.Set(func() (float64, error) { return 4, nil }, "ok")
+	queue.Set(func() (float64, error) { return 0, errors.New("unavailable") }, "broken")
+
+	var b bytes.Buffer
+	registry.WriteText(&b)
+
+	expected := `# HELP test_duration_seconds Durations.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{route="/a",le="0.1"} 2
+test_duration_seconds_bucket{route="/a",le="1"} 2
+test_duration_seconds_bucket{route="/a",le="+Inf"} 3
+test_duration_seconds_sum{route="/a"} 3.15
+test_duration_seconds_count{route="/a"} 3
+# HELP test_queue_depth Queue depth.
+# TYPE test_queue_depth gauge
+test_queue_depth{queue="ok"} 4
+# HELP test_requests_total Requests.\nPer route.
+# TYPE test_requests_total counter
+test_requests_total{route="/a\"quoted\""} 2
+test_requests_total{route="/b"} 1
+`
+	if b.String() != expected {
+		t.Errorf("unexpected exposition:\n%s\nwant:\n%s", b.String(), expected)
+	}
+}
+
+func TestHandlerToken(t *testing.T) {
+	registry := NewRegistry()
+	registry.NewCounterVec("test_total", "Test.").Inc()
+
+	scrape := func(handler http.Handler, authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/metrics", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := scrape(registry.Handler(""), ""); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "test_total 1") {
+		t.Errorf("open endpoint: got %d %q", rr.Code, rr.Body.String())
+	}
+
+	protected := registry.Handler("secret")
+	if rr := scrape(protected, ""); rr.Code != http.StatusUnauthorized {
+		t.Errorf("missing token: got %d want %d", rr.Code, http.StatusUnauthorized)
+	}
+	if rr := scrape(protected, "Bearer wrong"); rr.Code != http.StatusUnauthorized {
+		t.Errorf("wrong token: got %d want %d", rr.Code, http.StatusUnauthorized)
+	}
+	if rr := scrape(protected, "Bearer secret"); rr.Code != http.StatusOK {
+		t.Errorf("valid token: got %d want %d", rr.Code, http.StatusOK)
+	}
+}
+
+func TestMiddlewareUsesRoutePattern(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Get("/api/sites/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	for _, path := range []string{"/api/sites/1", "/api/sites/2"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	var b bytes.Buffer
+	Default.WriteText(&b)
+	if !strings.Contains(b.String(), `egide_http_requests_total{method="GET",route="/api/sites/{id}",status="404"} 2`) {
+		t.Errorf("requests not counted per route:\n%s", b.String())
+	}
+}
@@ -0,0 +1,16 @@
+CREATE TABLE monitors (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    site_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    url TEXT NOT NULL,
+    enabled BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (site_id) REFERENCES sites(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_monitors_site_id ON monitors(site_id);
+
+ALTER TABLE health_checks ADD COLUMN monitor_id INTEGER REFERENCES monitors(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_health_checks_monitor_id ON health_checks(monitor_id, timestamp);
@@ -0,0 +1,12 @@
+CREATE TABLE threats (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    site_id INTEGER NOT NULL,
+    nature INTEGER NOT NULL,
+    status INTEGER NOT NULL,
+    sources TEXT NOT NULL,
+    time TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (site_id) REFERENCES sites(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_threats_site_id_time ON threats(site_id, time);
@@ -0,0 +1,17 @@
+CREATE TABLE maintenance_windows (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    site_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    recurrence TEXT NOT NULL CHECK(recurrence IN ('none', 'daily', 'weekly')),
+    starts_at TIMESTAMP NOT NULL,
+    duration_minutes INTEGER NOT NULL,
+    weekdays TEXT NOT NULL DEFAULT '',
+    time_zone TEXT NOT NULL DEFAULT 'UTC',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (site_id) REFERENCES sites(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_maintenance_windows_site_id ON maintenance_windows(site_id);
+
+ALTER TABLE health_checks ADD COLUMN maintenance BOOLEAN NOT NULL DEFAULT FALSE;
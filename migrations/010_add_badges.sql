@@ -0,0 +1,6 @@
+CREATE TABLE badge_settings (
+    site_id INTEGER PRIMARY KEY,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (site_id) REFERENCES sites(id) ON DELETE CASCADE
+);
@@ -0,0 +1,20 @@
+CREATE TABLE health_check_rollups (
+    monitor_id INTEGER NOT NULL,
+    resolution TEXT NOT NULL CHECK (resolution IN ('hour', 'day')),
+    bucket_start TIMESTAMP NOT NULL,
+    total INTEGER NOT NULL,
+    successes INTEGER NOT NULL,
+    maintenance INTEGER NOT NULL,
+    latency_count INTEGER NOT NULL,
+    min_ms INTEGER NOT NULL,
+    avg_ms REAL NOT NULL,
+    max_ms INTEGER NOT NULL,
+    p50_ms INTEGER NOT NULL,
+    p95_ms INTEGER NOT NULL,
+    p99_ms INTEGER NOT NULL,
+    histogram TEXT NOT NULL,
+    PRIMARY KEY (monitor_id, resolution, bucket_start),
+    FOREIGN KEY (monitor_id) REFERENCES monitors(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_health_check_rollups_bucket ON health_check_rollups(resolution, bucket_start);
@@ -0,0 +1,14 @@
+CREATE TABLE traffic_counters (
+    site_id INTEGER NOT NULL,
+    node TEXT NOT NULL,
+    period_start TIMESTAMP NOT NULL,
+    requests INTEGER NOT NULL DEFAULT 0,
+    blocked INTEGER NOT NULL DEFAULT 0,
+    challenged INTEGER NOT NULL DEFAULT 0,
+    cached INTEGER NOT NULL DEFAULT 0,
+    bytes INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (site_id, node, period_start),
+    FOREIGN KEY (site_id) REFERENCES sites(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_traffic_counters_period_start ON traffic_counters(period_start);
@@ -0,0 +1,12 @@
+CREATE TABLE analytics_top_values (
+    site_id INTEGER NOT NULL,
+    dimension TEXT NOT NULL,
+    bucket_start TIMESTAMP NOT NULL,
+    value TEXT NOT NULL,
+    count INTEGER NOT NULL,
+    error INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (site_id, dimension, bucket_start, value),
+    FOREIGN KEY (site_id) REFERENCES sites(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_analytics_top_values_bucket ON analytics_top_values(bucket_start);
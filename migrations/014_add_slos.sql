@@ -0,0 +1,26 @@
+CREATE TABLE slos (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    site_id INTEGER NOT NULL,
+    monitor_id INTEGER,
+    name TEXT NOT NULL,
+    target REAL NOT NULL,
+    latency_threshold_ms INTEGER,
+    window_days INTEGER NOT NULL DEFAULT 30,
+    alert_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    burn_rate_threshold REAL NOT NULL DEFAULT 14.4,
+    burning_since TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (site_id) REFERENCES sites(id) ON DELETE CASCADE,
+    FOREIGN KEY (monitor_id) REFERENCES monitors(id) ON DELETE CASCADE
+);
+
+CREATE TABLE slo_channels (
+    slo_id INTEGER NOT NULL,
+    channel_id INTEGER NOT NULL,
+    PRIMARY KEY (slo_id, channel_id),
+    FOREIGN KEY (slo_id) REFERENCES slos(id) ON DELETE CASCADE,
+    FOREIGN KEY (channel_id) REFERENCES notification_channels(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_slos_site_id ON slos(site_id);
@@ -0,0 +1,16 @@
+CREATE TABLE tls_certificates (
+    monitor_id INTEGER PRIMARY KEY,
+    subject TEXT NOT NULL,
+    issuer TEXT NOT NULL,
+    sans TEXT NOT NULL DEFAULT '',
+    serial_number TEXT NOT NULL,
+    fingerprint TEXT NOT NULL,
+    not_before TIMESTAMP NOT NULL,
+    not_after TIMESTAMP NOT NULL,
+    chain TEXT NOT NULL DEFAULT '[]',
+    warned_days INTEGER,
+    checked_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (monitor_id) REFERENCES monitors(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_tls_certificates_not_after ON tls_certificates(not_after);
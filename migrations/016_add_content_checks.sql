@@ -0,0 +1,26 @@
+CREATE TABLE content_checks (
+    monitor_id INTEGER PRIMARY KEY,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    ignore_patterns TEXT NOT NULL DEFAULT '',
+    baseline_hash TEXT NOT NULL DEFAULT '',
+    baseline_body TEXT NOT NULL DEFAULT '',
+    baseline_at TIMESTAMP,
+    last_hash TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (monitor_id) REFERENCES monitors(id) ON DELETE CASCADE
+);
+
+CREATE TABLE content_changes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    monitor_id INTEGER NOT NULL,
+    site_id INTEGER NOT NULL,
+    baseline_hash TEXT NOT NULL,
+    hash TEXT NOT NULL,
+    summary TEXT NOT NULL,
+    threat_id INTEGER,
+    detected_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (monitor_id) REFERENCES monitors(id) ON DELETE CASCADE,
+    FOREIGN KEY (site_id) REFERENCES sites(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_content_changes_site_id ON content_changes(site_id, detected_at);
@@ -0,0 +1,21 @@
+CREATE TABLE user_two_factor (
+    user_id INTEGER PRIMARY KEY,
+    secret BLOB NOT NULL,
+    enabled_at TIMESTAMP,
+    last_counter INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE recovery_codes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_recovery_codes_user_id ON recovery_codes(user_id);
+
+-- Last two-factor verification of a session, allowing sensitive actions for a while
+ALTER TABLE sessions ADD COLUMN two_factor_verified_at TIMESTAMP;
@@ -0,0 +1,30 @@
+CREATE TABLE organizations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    require_two_factor BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE organization_members (
+    organization_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    role TEXT NOT NULL CHECK(role IN ('owner', 'admin', 'editor', 'viewer')),
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (organization_id, user_id),
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_organization_members_user_id ON organization_members(user_id);
+
+-- Sites owned by an organization rather than by the user who created them.
+-- Kept apart from sites, which is rebuilt by update_sites_add_verified.sql
+CREATE TABLE organization_sites (
+    site_id INTEGER PRIMARY KEY,
+    organization_id INTEGER NOT NULL,
+    FOREIGN KEY (site_id) REFERENCES sites(id) ON DELETE CASCADE,
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_organization_sites_organization_id ON organization_sites(organization_id);
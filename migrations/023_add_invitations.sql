@@ -0,0 +1,18 @@
+CREATE TABLE organization_invitations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    organization_id INTEGER NOT NULL,
+    email TEXT NOT NULL,
+    role TEXT NOT NULL CHECK(role IN ('owner', 'admin', 'editor', 'viewer')),
+    token_hash TEXT NOT NULL UNIQUE,
+    invited_by INTEGER NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    sent_at TIMESTAMP NOT NULL,
+    accepted_by INTEGER,
+    accepted_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE,
+    FOREIGN KEY (invited_by) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (accepted_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_organization_invitations_organization_id ON organization_invitations(organization_id);
@@ -0,0 +1,14 @@
+CREATE TABLE audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    actor_id INTEGER,
+    action TEXT NOT NULL,
+    site_id INTEGER,
+    organization_id INTEGER,
+    details TEXT NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- Entries outlive the sites and organizations they are about
+CREATE INDEX idx_audit_log_site_id ON audit_log(site_id, created_at);
+CREATE INDEX idx_audit_log_organization_id ON audit_log(organization_id, created_at);
@@ -0,0 +1,22 @@
+CREATE TABLE site_transfers (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    site_id INTEGER NOT NULL,
+    from_user_id INTEGER NOT NULL,
+    from_organization_id INTEGER,
+    to_user_id INTEGER,
+    to_organization_id INTEGER,
+    status TEXT NOT NULL CHECK(status IN ('pending', 'accepted', 'declined', 'cancelled')),
+    initiated_by INTEGER NOT NULL,
+    completed_by INTEGER,
+    created_at TIMESTAMP NOT NULL,
+    completed_at TIMESTAMP,
+    CHECK((to_user_id IS NULL) != (to_organization_id IS NULL)),
+    FOREIGN KEY (site_id) REFERENCES sites(id) ON DELETE CASCADE,
+    FOREIGN KEY (to_user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (to_organization_id) REFERENCES organizations(id) ON DELETE CASCADE
+);
+
+-- A site has at most one pending transfer
+CREATE UNIQUE INDEX idx_site_transfers_pending ON site_transfers(site_id) WHERE status = 'pending';
+CREATE INDEX idx_site_transfers_to_user_id ON site_transfers(to_user_id, status);
+CREATE INDEX idx_site_transfers_to_organization_id ON site_transfers(to_organization_id, status);
@@ -0,0 +1,4 @@
+-- Failed two-factor attempts of a user, locking verification out for a
+-- while once too many codes were wrong
+ALTER TABLE user_two_factor ADD COLUMN failed_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE user_two_factor ADD COLUMN locked_until TIMESTAMP;
@@ -0,0 +1,9 @@
+-- Custom domains of status pages only go live once a DNS TXT record proves
+-- that the owner of the page controls them. Until then, they are pending.
+ALTER TABLE status_pages ADD COLUMN pending_domain TEXT;
+ALTER TABLE status_pages ADD COLUMN domain_token TEXT;
+
+-- Domains set before verification existed have to be verified too
+UPDATE status_pages
+SET pending_domain = custom_domain, domain_token = lower(hex(randomblob(16))), custom_domain = NULL
+WHERE custom_domain IS NOT NULL;
@@ -0,0 +1,2 @@
+-- Why the chain presented to the last check isn't trusted, NULL when it is
+ALTER TABLE tls_certificates ADD COLUMN verification_error TEXT;
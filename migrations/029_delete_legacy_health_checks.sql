@@ -0,0 +1,4 @@
+-- Checks of the single URL monitored before per-site monitors existed. That
+-- URL belongs to no site, so the checks can't be attached to a monitor: they
+-- are never rolled up and no endpoint serves them anymore.
+DELETE FROM health_checks WHERE monitor_id IS NULL;
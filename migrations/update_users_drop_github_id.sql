@@ -0,0 +1,18 @@
+-- Move GitHub accounts to user_identities and drop users.github_id. Once
+-- applied, selecting github_id fails and the whole migration is rolled back.
+INSERT OR IGNORE INTO user_identities (user_id, provider, subject, username, email, created_at)
+SELECT id, 'github', github_id, username, COALESCE(email, ''), created_at FROM users;
+
+CREATE TABLE users_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username TEXT NOT NULL,
+    email TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO users_new
+SELECT id, username, email, created_at, updated_at FROM users;
+
+DROP TABLE users;
+ALTER TABLE users_new RENAME TO users;